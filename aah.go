@@ -8,20 +8,25 @@
 package aah
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
 	"html/template"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"path"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"aahframe.work/ahttp"
 	"aahframe.work/ainsp"
@@ -37,6 +42,7 @@ import (
 	"aahframe.work/router"
 	"aahframe.work/security"
 	"aahframe.work/security/acrypto"
+	"aahframe.work/security/cookie"
 	"aahframe.work/security/session"
 	"aahframe.work/valpar"
 	"aahframe.work/vfs"
@@ -76,6 +82,8 @@ func newApp() *Application {
 			VirtualBaseDir: "/app",
 		},
 		cacheMgr: cache.NewManager(),
+		clock:    realClock{},
+		workers:  &workers{},
 	}
 	aahApp.cli.Commands = make([]console.Command, 0)
 
@@ -107,25 +115,47 @@ type Application struct {
 	settings       *settings.Settings
 	cli            *console.Application
 	cfg            *config.Config
+	cfgMu          sync.RWMutex
 	vfs            *vfs.VFS
 	tlsCfg         *tls.Config
 	he             *HTTPEngine
 	wse            *ws.Engine
 	server         *http.Server
+	listener       net.Listener
+	baseCancel     context.CancelFunc
 	redirectServer *http.Server
+	redirectLn     net.Listener
+	listenerSrvs   []*listenerServer
 	router         *router.Router
 	eventStore     *EventStore
 	bindMgr        *bindManager
 	i18n           i18n.I18ner
+	i18nWatcher    bool
 	securityMgr    *security.Manager
+	cookieOpts     *cookie.Options
 	viewMgr        *viewManager
 	staticMgr      *staticManager
 	errorMgr       *errorManager
 	cacheMgr       *cache.Manager
+	respCache      *responseCache
+	sigVerifier    *signatureVerifier
+	warmup         *warmup
+	state          int32
+	shutdownMu     sync.Mutex
+	shutdownHooks  []*shutdownHook
+	workers        *workers
+	serverSetups   []func(*http.Server)
+	connTracker    *connTracker
+	serverErrLog   *serverErrorLog
 	sc             chan os.Signal
+	clockMu        sync.RWMutex
+	clock          Clock
+	tracer         TracerProvider
+	logMu          sync.RWMutex
 	logger         log.Loggerer
 	accessLog      *accessLogger
 	dumpLog        *dumpLogger
+	auditLog       *auditLogger
 	diagnosis      *diagnosis.Diagnosis
 }
 
@@ -151,6 +181,72 @@ func (a *Application) InitForCLI(importPath string) error {
 	return nil
 }
 
+// NewAppForTest method is for purpose of aah test server, refer to package
+// `aahframe.work/aahtest`. IT IS NOT FOR AAH USER.
+//
+// It creates new aah application instance of given import path and
+// initializes it with given config overrides, overrides are applied once on
+// the active env profile before the app initialization. Active env profile
+// could be set via key `env.active`. Supported override value types are
+// string, bool, int, int64 and float64.
+func NewAppForTest(importPath string, overrides map[string]interface{}) (*Application, error) {
+	a := newApp()
+	a.SetBuildInfo(&BuildInfo{
+		BinaryName: filepath.Base(importPath),
+		Timestamp:  time.Now().Format(time.RFC3339),
+		Version:    "1.0.0",
+	})
+
+	if err := a.VFS().AddMount(a.VirtualBaseDir(), importPath); err != nil {
+		return nil, err
+	}
+
+	a.settings.ImportPath = importPath
+	if err := a.initPath(); err != nil {
+		return nil, err
+	}
+	if err := a.initConfig(); err != nil {
+		return nil, err
+	}
+	if p, found := overrides["env.active"]; found {
+		a.Config().SetString("env.active", fmt.Sprint(p))
+	}
+
+	p := a.Config().StringDefault("env.active", settings.DefaultEnvProfile)
+	if err := a.Config().SetProfile(settings.ProfilePrefix + strings.TrimPrefix(p, settings.ProfilePrefix)); err != nil {
+		return nil, err
+	}
+	for k, v := range overrides {
+		if err := setConfigValue(a.Config(), k, v); err != nil {
+			return nil, err
+		}
+	}
+	if err := a.initApp(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// ServeForTest method is for purpose of aah test server, refer to package
+// `aahframe.work/aahtest`. IT IS NOT FOR AAH USER.
+//
+// It prepares given server to serve the application same as `Serve`, i.e.
+// request contexts are derived from the base context which is cancelled on
+// shutdown drain, publishes `OnStart` event and starts background workers.
+// Server is started by the caller.
+func (a *Application) ServeForTest(srv *http.Server) {
+	baseCtx, baseCancel := context.WithCancel(context.Background())
+	srv.BaseContext = func(net.Listener) context.Context { return baseCtx }
+	a.Lock()
+	a.baseCancel = baseCancel
+	a.server = srv
+	a.Unlock()
+
+	a.EventStore().sortAndPublishSync(&Event{Name: EventOnStart})
+	a.startWorkers()
+}
+
 // Name method returns aah application name from app config `name` otherwise
 // app name of the base directory.
 func (a *Application) Name() string {
@@ -268,20 +364,33 @@ func (a *Application) IsEnvProfile(envProfile string) bool {
 }
 
 // EnvProfiles method returns all available environment profile names from aah
-// application.
+// application sorted by name. Key `env.active` and profile `default` are not
+// included.
 func (a *Application) EnvProfiles() []string {
 	var profiles []string
 
 	for _, v := range a.Config().KeysByPath("env") {
-		if v == "default" {
+		if v == "default" || v == "active" {
 			continue
 		}
 		profiles = append(profiles, v)
 	}
 
+	sort.Strings(profiles)
 	return profiles
 }
 
+// EnvProfileKeys method returns the config keys defined by given environment
+// profile, i.e. keys of section `env.<profile>` in dot notation without the
+// profile prefix, sorted by name. For e.g.: `log.level`, `server.port`.
+//
+// It returns nil if profile is not defined.
+func (a *Application) EnvProfileKeys(envProfile string) []string {
+	keys := a.collectConfigKeys("env."+envProfile, "", nil)
+	sort.Strings(keys)
+	return keys
+}
+
 // IsSSLEnabled method returns true if aah application is enabled with SSL
 // otherwise false.
 func (a *Application) IsSSLEnabled() bool {
@@ -299,7 +408,7 @@ func (a *Application) IsLetsEncryptEnabled() bool {
 //
 // Value of `server.websocket.enable` from `aah.conf`.
 func (a *Application) IsWebSocketEnabled() bool {
-	return a.cfg.BoolDefault("server.websocket.enable", false)
+	return a.Config().BoolDefault("server.websocket.enable", false)
 }
 
 // NewChildLogger method create a child logger from aah application default logger.
@@ -415,6 +524,49 @@ func (a *Application) AddTemplateFunc(funcs template.FuncMap) {
 	view.AddTemplateFunc(funcs)
 }
 
+// SetViewEngine method sets the given view engine as an application view
+// engine, it takes precedence over config `view.engine`. It has to be set
+// before the application initialize, i.e. `aah.Init`.
+func (a *Application) SetViewEngine(engine view.Enginer) {
+	if engine == nil {
+		return
+	}
+	if a.viewMgr == nil {
+		a.viewMgr = &viewManager{a: a}
+	}
+	if a.viewMgr.userEngine {
+		a.Log().Warnf("Changing view engine from: '%T' to '%T'", a.viewMgr.engine, engine)
+	}
+	a.viewMgr.engine = engine
+	a.viewMgr.engineName = fmt.Sprintf("%T", engine)
+	a.viewMgr.userEngine = true
+}
+
+// RenderTemplate method renders the given page template (relative to
+// `views/pages`) with default layout and returns the result as string.
+// It is useful to render outside of request cycle, for e.g.: email body.
+//
+//	body, err := aah.App().RenderTemplate("emails/welcome.html", aah.Data{"Name": "Jeeva"})
+func (a *Application) RenderTemplate(name string, data Data) (string, error) {
+	if a.viewMgr == nil || a.viewMgr.engine == nil {
+		return "", errors.New("aah: view engine is not initialized")
+	}
+	layout := ""
+	if a.viewMgr.defaultLayoutEnabled {
+		layout = a.viewMgr.defaultTmplLayout
+	}
+	return a.viewMgr.renderTemplate(layout, name, data)
+}
+
+// RenderTemplateWithLayout method renders the given page template with given
+// layout and returns the result as string. Empty layout value means no layout.
+func (a *Application) RenderTemplateWithLayout(layout, name string, data Data) (string, error) {
+	if a.viewMgr == nil || a.viewMgr.engine == nil {
+		return "", errors.New("aah: view engine is not initialized")
+	}
+	return a.viewMgr.renderTemplate(layout, name, data)
+}
+
 // AddViewEngine method adds the given name and view engine to view store.
 func (a *Application) AddViewEngine(name string, engine view.Enginer) error {
 	return view.AddEngine(name, engine)
@@ -512,6 +664,19 @@ func (a *Application) Run(args []string) error {
 // app Unexported methods
 //______________________________________________________________________________
 
+// collectConfigKeys method collects leaf keys of given config path
+// recursively, empty section is not collected.
+func (a *Application) collectConfigKeys(path, prefix string, keys []string) []string {
+	for _, k := range a.Config().KeysByPath(path) {
+		if sub := a.Config().KeysByPath(path + "." + k); len(sub) > 0 {
+			keys = a.collectConfigKeys(path+"."+k, prefix+k+".", keys)
+			continue
+		}
+		keys = append(keys, prefix+k)
+	}
+	return keys
+}
+
 func (a *Application) logsDir() string {
 	return filepath.Join(a.BaseDir(), "logs")
 }
@@ -593,6 +758,10 @@ func (a *Application) initApp() error {
 	if err = a.initLog(); err != nil {
 		return err
 	}
+	if rd := a.Config().StringDefault("render.default", ""); len(rd) > 0 && len(a.settings.DefaultContentType) == 0 {
+		a.Log().Warnf("'render.default' value '%s' is not a displayable content type, falling back "+
+			"to application type based default", rd)
+	}
 	if err = a.initI18n(); err != nil {
 		return err
 	}
@@ -614,6 +783,19 @@ func (a *Application) initApp() error {
 	if err = a.initError(); err != nil {
 		return err
 	}
+	if err = a.initResponseCache(); err != nil {
+		return err
+	}
+	if err = a.initSignatureVerifier(); err != nil {
+		return err
+	}
+	if err = a.initWarmup(); err != nil {
+		return err
+	}
+	if err = a.initServerErrorLog(); err != nil {
+		return err
+	}
+	a.he.initConcurrencyLimit()
 	if a.settings.AccessLogEnabled {
 		if err = a.initAccessLog(); err != nil {
 			return err
@@ -624,6 +806,9 @@ func (a *Application) initApp() error {
 			return err
 		}
 	}
+	if err = a.initAuditLog(); err != nil {
+		return err
+	}
 	if a.IsWebSocketEnabled() {
 		if a.wse, err = ws.New(a); err != nil {
 			return err
@@ -672,26 +857,50 @@ func (a *Application) aahRecover() {
 //______________________________________________________________________________
 
 // Config method returns aah application configuration instance.
+//
+// It is safe for concurrent use, on hot-reload reloaded configuration is
+// swapped atomically after it is fully loaded. So reader gets either old or
+// new configuration never the partially loaded one. Obtain it once and use it,
+// for consistent values within the unit of work.
 func (a *Application) Config() *config.Config {
+	a.cfgMu.RLock()
+	defer a.cfgMu.RUnlock()
 	return a.cfg
 }
 
 func (a *Application) initConfig() error {
+	cfg, err := a.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	a.setConfig(cfg)
+	return nil
+}
+
+func (a *Application) loadConfig() (*config.Config, error) {
 	cfg, err := config.LoadFile(path.Join(a.VirtualBaseDir(), "config", "aah.conf"))
 	if err != nil {
-		return fmt.Errorf("aah.conf: %s", err)
+		return nil, fmt.Errorf("aah.conf: %s", err)
 	}
+	return cfg, nil
+}
 
+func (a *Application) setConfig(cfg *config.Config) {
+	a.cfgMu.Lock()
+	defer a.cfgMu.Unlock()
 	a.cfg = cfg
-	return nil
 }
 
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Log Definitions
 //______________________________________________________________________________
 
-// Log method returns app logger instance.
+// Log method returns app logger instance. Logger is replaced on the config
+// hot-reload, obtain it via this method instead of holding the reference.
 func (a *Application) Log() log.Loggerer {
+	a.logMu.RLock()
+	defer a.logMu.RUnlock()
 	return a.logger
 }
 
@@ -728,17 +937,26 @@ func (a *Application) initLog() error {
 		"insname": a.InstanceName(),
 	})
 
-	a.logger = al
+	a.setLogger(al)
 	log.SetDefaultLogger(al)
 	return nil
 }
 
+func (a *Application) setLogger(l log.Loggerer) {
+	a.logMu.Lock()
+	defer a.logMu.Unlock()
+	a.logger = l
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // i18n Definitions
 //______________________________________________________________________________
 
 const keyLocale = "Locale"
 
+// i18nHotReloadInterval is the poll interval of message files changes.
+var i18nHotReloadInterval = time.Second
+
 // RegisterI18n method is used to register the i18n message store
 // into aah appplication the implements interface `i18n.I18ner`.
 func (a *Application) RegisterI18n(ms i18n.I18ner) {
@@ -774,15 +992,78 @@ func (a *Application) initI18n() error {
 		return err
 	}
 	a.RegisterI18n(ai18n)
+
+	// Hot-reload of messages is guarded, so production message store stays
+	// cached.
+	if a.Config().BoolDefault("i18n.hot_reload", a.IsEnvProfile(settings.DefaultEnvProfile)) &&
+		!a.IsEnvProfile("prod") && !a.IsPackaged() {
+		a.watchI18n()
+	}
 	return nil
 }
 
+// watchI18n method registers the background worker, which polls the message
+// files for changes and reloads the application i18n message store. Polling
+// is used instead of file system notifications, since it works for replaced
+// files and mounted directories alike, parsing is done only on change.
+func (a *Application) watchI18n() {
+	a.Lock()
+	if a.i18nWatcher {
+		a.Unlock()
+		return
+	}
+	a.i18nWatcher = true
+	a.Unlock()
+
+	a.Log().Info("I18n messages hot-reload enabled")
+	a.Go("i18n-hot-reload", func(ctx context.Context) {
+		ticker := time.NewTicker(i18nHotReloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.reloadI18nIfChanged()
+			}
+		}
+	})
+}
+
+// reloadI18nIfChanged method reloads the application i18n message store if
+// message files are changed. Custom message store is reloaded if it
+// implements `Changed` and `Reload` methods.
+func (a *Application) reloadI18nIfChanged() {
+	ms, ok := a.I18n().(interface {
+		Changed() bool
+		Reload() error
+	})
+	if !ok || !ms.Changed() {
+		return
+	}
+	if err := ms.Reload(); err != nil {
+		a.Log().Errorf("Unable to reload i18n messages: %v", err)
+		return
+	}
+	a.Log().Info("I18n messages reloaded")
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // App - Engines
 //______________________________________________________________________________
 
 // ServeHTTP method implementation of http.Handler interface.
 func (a *Application) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Request reached the handler, refer to `connTracker.track`
+	connHandled(r)
+
+	// Lightweight handlers bypass the request lifecycle, refer to
+	// `HTTPEngine.DirectHandler`
+	if h, found := a.he.directs[r.URL.Path]; found {
+		a.he.serveDirect(h, w, r)
+		return
+	}
+
 	defer a.aahRecover()
 	if a.settings.Redirect {
 		if a.he.doRedirect(w, r) {
@@ -790,6 +1071,11 @@ func (a *Application) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Application mounted under path prefix `server.base_path`
+	if bp := a.Router().BasePath(); len(bp) > 0 {
+		r = stripBasePath(r, bp)
+	}
+
 	if h := r.Header[ahttp.HeaderUpgrade]; len(h) > 0 {
 		if h[0] == "websocket" || h[0] == "Websocket" {
 			a.wse.Handle(w, r)
@@ -800,6 +1086,27 @@ func (a *Application) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	a.he.Handle(w, r)
 }
 
+// stripBasePath method returns the shallow copy of request with given base
+// path removed from URL path. If the request path does not have base path,
+// for e.g. already stripped by `http.StripPrefix`, request returned as-is.
+func stripBasePath(r *http.Request, basePath string) *http.Request {
+	p := r.URL.Path
+	if !strings.HasPrefix(p, basePath) || (len(p) > len(basePath) && p[len(basePath)] != '/') {
+		return r
+	}
+
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = p[len(basePath):]
+	if len(r2.URL.Path) == 0 {
+		r2.URL.Path = "/"
+	}
+	r2.URL.RawPath = ""
+	return r2
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // HotReload Definitions for Prod profile
 //______________________________________________________________________________
@@ -830,19 +1137,24 @@ func (a *Application) performHotReload() {
 	a.Log().Info("Application hot-reload and reinitialization starts ...")
 	var err error
 
-	if err = a.initConfig(); err != nil {
+	cfg, err := a.loadConfig()
+	if err != nil {
 		a.Log().Errorf("Unable to reload aah.conf: %v", err)
 		return
 	}
-	a.Log().Info("Configuration files reload succeeded")
-
-	// Set activeProfile into reloaded configuration
-	a.Config().SetString("env.active", activeProfile)
 
-	if err = a.settings.Refresh(a.Config()); err != nil {
-		a.Log().Errorf("Unable to reinitialize aah application settings: %v", err)
+	// Set activeProfile into reloaded configuration and refresh the copy of
+	// settings, it applies the env profile and validates the values. So
+	// that current configuration and settings remains as-is on failure.
+	cfg.SetString("env.active", activeProfile)
+	ns := *a.settings
+	if err = ns.Refresh(cfg); err != nil {
+		a.Log().Errorf("Unable to reinitialize aah application settings, continuing with current configuration: %v", err)
 		return
 	}
+	a.setConfig(cfg)
+	*a.settings = ns
+	a.Log().Info("Configuration files reload succeeded")
 	a.Log().Info("Configuration values reinitialize succeeded")
 
 	if err = a.initLog(); err != nil {
@@ -895,6 +1207,11 @@ func (a *Application) performHotReload() {
 		a.Log().Info("Server dump logging reinitialize succeeded")
 	}
 
+	if err = a.initAuditLog(); err != nil {
+		a.Log().Errorf("Unable to reinitialize application audit log: %v", err)
+		return
+	}
+
 	a.Log().Info("Application hot-reload and reinitialization was successful")
 	a.EventStore().PublishSync(&Event{Name: EventOnConfigHotReload})
 }
@@ -912,6 +1229,24 @@ func inferBaseDir(p string) (string, error) {
 	return "", errors.New("aah: config directory not found in parent directories")
 }
 
+func setConfigValue(cfg *config.Config, key string, value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		cfg.SetString(key, v)
+	case bool:
+		cfg.SetBool(key, v)
+	case int:
+		cfg.SetInt(key, v)
+	case int64:
+		cfg.SetInt64(key, v)
+	case float64:
+		cfg.SetFloat64(key, v)
+	default:
+		return fmt.Errorf("aah: unsupported config override value type %T for key '%s'", value, key)
+	}
+	return nil
+}
+
 type aahVFS struct {
 	fs *vfs.VFS
 }
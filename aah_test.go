@@ -5,20 +5,23 @@
 package aah
 
 import (
-	"bytes"
-	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/cookiejar"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -27,7 +30,10 @@ import (
 	"aahframe.work/config"
 	"aahframe.work/console"
 	"aahframe.work/essentials"
+	"aahframe.work/i18n"
+	"aahframe.work/internal/testutil"
 	"aahframe.work/log"
+	"aahframe.work/security/cookie"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -167,6 +173,192 @@ func TestAahApp(t *testing.T) {
 
 }
 
+func TestAppTestServerHelpers(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Test Server Helpers]: %s", ts.URL)
+
+	t.Log("GET request")
+	ts.Get("/get-text.html").
+		AssertStatus(http.StatusOK).
+		AssertHeader(ahttp.HeaderContentType, "text/plain; charset=utf-8")
+
+	t.Log("Cookie jar retains cookies")
+	ts.Get("/hey-cookies").AssertStatus(http.StatusOK)
+	u, _ := url.Parse(ts.URL)
+	var names []string
+	for _, c := range ts.client.Jar.Cookies(u) {
+		names = append(names, c.Name)
+	}
+	assert.Contains(t, names, "test_cookie_1")
+	assert.Contains(t, names, "test_cookie_2")
+
+	t.Log("JSON path assertion")
+	req, _ := http.NewRequest(ahttp.MethodGet, ts.URL+"/trigger-panic", nil)
+	req.Header.Set(ahttp.HeaderAccept, ahttp.ContentTypeJSON.Mime)
+	ts.Do(req).
+		AssertStatus(http.StatusInternalServerError).
+		AssertJSONPath("code", 500).
+		AssertJSONPath("message", "Internal Server Error")
+
+	t.Log("POST JSON request without anti-csrf token")
+	result := ts.PostJSON("/create-record", sampleJSON{FirstName: "My firstname"})
+	result.AssertStatus(http.StatusForbidden)
+	assert.True(t, len(result.BodyString()) > 0)
+}
+
+func TestAppTestServerWithConfig(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"env.active":                           "prod",
+		"server.header":                        "aah-test-server",
+		"request.id.enable":                    false,
+		"server.max_concurrent_requests.limit": 10,
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Test Server With Config]: %s", ts.URL)
+
+	assert.Equal(t, "prod", ts.app.EnvProfile())
+	assert.False(t, ts.app.settings.RequestIDEnabled)
+	assert.Equal(t, 10, ts.app.settings.MaxConcurrentRequests)
+
+	ts.Get("/get-text.html").
+		AssertStatus(http.StatusOK).
+		AssertHeader(ahttp.HeaderServer, "aah-test-server").
+		AssertHeader(ahttp.HeaderXRequestID, "")
+}
+
+func TestAppTestServerMiddlewares(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Test Server Middlewares]: %s", ts.URL)
+
+	ts.WithoutMiddlewares(AntiCSRFMiddleware)
+	assert.Equal(t, 6, len(ts.app.he.mwStack))
+
+	ts.PostJSON("/create-record", sampleJSON{FirstName: "My firstname", Number: 8253645635}).
+		AssertStatus(http.StatusOK).
+		AssertJSONPath("success", true).
+		AssertJSONPath("data.first_name", "My firstname").
+		AssertJSONPath("data.number", 8253645635)
+
+	ts.SetMiddlewares(RouteMiddleware, ActionMiddleware)
+	assert.Equal(t, 2, len(ts.app.he.mwChain))
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+}
+
+func TestAppTestServerCaptureLog(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"log.level": "warn",
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Test Server Capture Log]: %s", ts.URL)
+
+	lr := ts.CaptureLog()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ts.Get(fmt.Sprintf("/not-exists-%d", i)).AssertStatus(http.StatusNotFound)
+		}(i)
+	}
+	wg.Wait()
+
+	ts.AssertLogContains("Route not found")
+	ts.AssertLogContains("Path: /not-exists-9")
+	assert.Equal(t, 10, strings.Count(lr.String(), "Route not found"))
+}
+
+func TestAppClock(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"server.access_log.enable": true,
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [App Clock]: %s", ts.URL)
+
+	assert.Equal(t, realClock{}, ts.app.Clock())
+
+	fixed := time.Date(2018, time.October, 10, 10, 10, 10, 0, time.UTC)
+	tc := NewFakeClock(fixed)
+	ts.app.SetClock(tc)
+	assert.Equal(t, fixed, ts.app.Clock().Now())
+	tc.Advance(time.Minute)
+	assert.Equal(t, fixed.Add(time.Minute), ts.app.Clock().Now())
+
+	var startTime time.Time
+	ts.app.HTTPEngine().OnRequest(func(e *Event) {
+		startTime = e.Data.(*Context).Get(reqStartTimeKey).(time.Time)
+	})
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+	assert.Equal(t, fixed.Add(time.Minute), startTime)
+
+	// security and cookie time source follows the app clock
+	s := ts.app.SessionManager().NewSession()
+	assert.Equal(t, fixed.Add(time.Minute), *s.CreatedTime)
+	opts := *ts.app.cookieOptions()
+	opts.MaxAge = 3600
+	c := cookie.NewWithOptions("value", &opts)
+	assert.Equal(t, fixed.Add(time.Hour+time.Minute), c.Expires)
+
+	// concurrent clock changes while serving requests
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ts.app.SetClock(NewFakeClock(fixed))
+			_ = ts.app.Clock().Now()
+		}()
+	}
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+	wg.Wait()
+
+	ts.app.SetClock(nil)
+	assert.Equal(t, realClock{}, ts.app.Clock())
+}
+
+func TestAppTestServerClose(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Test Server Close]: %s", ts.URL)
+
+	var events []string
+	ts.app.OnPreShutdown(func(e *Event) { events = append(events, e.Name) })
+	ts.app.OnPostShutdown(func(e *Event) { events = append(events, e.Name) })
+
+	workerStopped := make(chan struct{})
+	ts.app.Go("test-worker", func(ctx context.Context) {
+		<-ctx.Done()
+		close(workerStopped)
+	})
+	ts.app.RegisterShutdownHook("test-hook", 1, func(ctx context.Context) error {
+		events = append(events, "shutdown-hook")
+		return nil
+	})
+
+	ts.Close()
+	ts.Close()
+	assert.Equal(t, []string{EventOnPreShutdown, "shutdown-hook", EventOnPostShutdown}, events)
+	assert.Equal(t, AppStateStopped, ts.app.State())
+	select {
+	case <-workerStopped:
+	default:
+		t.Error("background worker is not stopped on test server close")
+	}
+}
+
 func TestAppMisc(t *testing.T) {
 	importPath := filepath.Join(testdataBaseDir(), "webapp1")
 	ts := newTestServer(t, importPath)
@@ -181,7 +373,16 @@ func TestAppMisc(t *testing.T) {
 	assert.False(t, a.IsPackaged())
 	a.SetPackaged(true)
 	assert.True(t, a.IsPackaged())
-	assert.True(t, strings.Contains(strings.Join(a.EnvProfiles(), " "), "prod"))
+	assert.Equal(t, []string{"dev", "prod"}, a.EnvProfiles())
+	a.Config().SetString("env.active", "dev")
+	assert.Equal(t, []string{"dev", "prod"}, a.EnvProfiles())
+	assert.True(t, a.IsEnvProfile("dev"))
+
+	keys := a.EnvProfileKeys("prod")
+	assert.True(t, sort.StringsAreSorted(keys))
+	assert.Contains(t, keys, "log.level")
+	assert.NotContains(t, keys, "log")
+	assert.Nil(t, a.EnvProfileKeys("staging"))
 
 	_ = a.Run([]string{"-v"})
 
@@ -251,7 +452,90 @@ func TestHotAppReload(t *testing.T) {
 
 	t.Logf("Test Server URL [Hot Reload]: %s", ts.URL)
 
+	oldCfg := ts.app.Config()
+	profile := ts.app.EnvProfile()
+	ts.app.Config().SetString("env.active", profile)
+
+	// concurrent readers never see partially loaded configuration
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					assert.Equal(t, profile, ts.app.Config().StringDefault("env.active", ""))
+					// env profile is applied before the swap
+					assert.True(t, ts.app.Config().BoolDefault("render.pretty", false))
+				}
+			}
+		}()
+	}
+
+	ts.app.performHotReload()
+	close(done)
+	wg.Wait()
+
+	assert.False(t, oldCfg == ts.app.Config())
+	assert.Equal(t, profile, ts.app.Config().StringDefault("env.active", ""))
+	assert.True(t, ts.app.Config().BoolDefault("render.pretty", false))
+
+	t.Log("Settings failure retains the current configuration")
+	lr := ts.CaptureLog()
+	curCfg := ts.app.Config()
+	ts.app.settings.EnvProfile = "notexists"
 	ts.app.performHotReload()
+	assert.True(t, curCfg == ts.app.Config())
+	assert.Equal(t, "notexists", ts.app.EnvProfile())
+	assert.True(t, strings.Contains(lr.String(), "continuing with current configuration"))
+}
+
+type testReloadI18n struct {
+	i18n.I18ner
+	changed bool
+	reload  error
+	reloads int
+}
+
+func (ti *testReloadI18n) Changed() bool { return ti.changed }
+
+func (ti *testReloadI18n) Reload() error {
+	ti.reloads++
+	return ti.reload
+}
+
+func TestAppI18nHotReload(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+	assert.True(t, ts.app.i18nWatcher)
+
+	prodTS := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"env.active":      "prod",
+		"i18n.hot_reload": true,
+	})
+	defer prodTS.Close()
+	assert.False(t, prodTS.app.i18nWatcher)
+
+	// custom message store without watcher
+	ms := &testReloadI18n{I18ner: prodTS.app.I18n()}
+	prodTS.app.RegisterI18n(ms)
+	prodTS.app.reloadI18nIfChanged()
+	assert.Equal(t, 0, ms.reloads)
+
+	ms.changed = true
+	prodTS.app.reloadI18nIfChanged()
+	assert.Equal(t, 1, ms.reloads)
+
+	prodTS.CaptureLog()
+	ms.reload = errors.New("invalid message file")
+	prodTS.app.reloadI18nIfChanged()
+	assert.Equal(t, 2, ms.reloads)
+	prodTS.AssertLogContains("Unable to reload i18n messages: invalid message file")
 }
 
 func TestLogInitRelativeFilePath(t *testing.T) {
@@ -343,20 +627,12 @@ func panicTest(a *Application) {
 	panic("test panic")
 }
 
-func fireRequest(t *testing.T, req *http.Request) *testResult {
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		t.Errorf("Request failed %s", err)
-		t.FailNow()
-		return nil
-	}
+func fireRequest(t *testing.T, req *http.Request) *testutil.Result {
+	return testutil.Do(t, http.DefaultClient, req)
+}
 
-	return &testResult{
-		StatusCode: resp.StatusCode,
-		Header:     resp.Header,
-		Body:       responseBody(resp),
-		Raw:        resp,
-	}
+func responseBody(res *http.Response) string {
+	return testutil.ResponseBody(res)
 }
 
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
@@ -364,93 +640,133 @@ func fireRequest(t *testing.T, req *http.Request) *testResult {
 //______________________________________________________________________________
 
 func newTestServer(t *testing.T, importPath string) *testServer {
+	return newTestServerWithConfig(t, importPath, nil)
+}
+
+func newTestServerWithConfig(t *testing.T, importPath string, overrides map[string]interface{}) *testServer {
+	a := newTestAppWithConfig(t, importPath, overrides)
+	jar, _ := cookiejar.New(nil)
 	ts := &testServer{
-		app: newTestApp(t, importPath),
+		t:      t,
+		app:    a,
+		client: &http.Client{Jar: jar},
 	}
 
-	ts.server = httptest.NewServer(ts.app)
-	ts.URL = ts.server.URL
+	// test log is discarded, test could inspect it via `CaptureLog`
+	ts.app.Log().(*log.Logger).SetWriter(ioutil.Discard)
 
 	// Manually do it here here, for aah CLI test no issue `aah test` :)
-	ts.manualInit()
+	manualInit(ts.app)
 
-	ts.DiscordLog()
+	ts.SetMiddlewares(
+		RouteMiddleware,
+		CORSMiddleware,
+		SignatureMiddleware,
+		BindMiddleware,
+		AntiCSRFMiddleware,
+		AuthcAuthzMiddleware,
+		ActionMiddleware,
+	)
+
+	ts.server = httptest.NewUnstartedServer(ts.app)
+	ts.app.ServeForTest(ts.server.Config)
+	ts.server.Start()
+	ts.URL = ts.server.URL
 
 	return ts
 }
 
 func newTestApp(t *testing.T, importPath string) *Application {
-	a := newApp()
-	a.SetBuildInfo(&BuildInfo{
-		BinaryName: filepath.Base(importPath),
-		Timestamp:  time.Now().Format(time.RFC3339),
-		Version:    "1.0.0",
-	})
-
-	err := a.VFS().AddMount(a.VirtualBaseDir(), importPath)
-	assert.Nil(t, err, "not expecting any error")
+	return newTestAppWithConfig(t, importPath, nil)
+}
 
-	a.settings.ImportPath = importPath
-	err = a.initPath()
-	assert.Nil(t, err, "app initPath failure")
-	err = a.initConfig()
-	assert.Nil(t, err, "app initConfig failure")
-	err = a.settings.Refresh(a.Config())
-	assert.Nil(t, err, "app settings failure")
-	err = a.initLog()
-	assert.Nil(t, err, "app log failure")
-	err = a.initApp()
+func newTestAppWithConfig(t *testing.T, importPath string, overrides map[string]interface{}) *Application {
+	a, err := NewAppForTest(importPath, overrides)
 	assert.Nil(t, err, "app init failure")
-
 	return a
 }
 
-type testResult struct {
-	StatusCode int
-	Header     http.Header
-	Body       string
-	Raw        *http.Response
+// testServer is the in-package counterpart of `aahtest.TestServer`, since
+// package `aahtest` imports the package `aah`.
+type testServer struct {
+	URL string
+
+	t           *testing.T
+	app         *Application
+	client      *http.Client
+	server      *httptest.Server
+	closeOnce   sync.Once
+	logRecorder *testutil.LogRecorder
 }
 
-// TestServer provides capabilities to test aah application end-to-end.
-//
-// Note: after sometime I will expose this test server, I'm not fully satisfied with
-// the implementation yet! Because there are short comings in the test server....
-type testServer struct {
-	URL    string
-	app    *Application
-	server *httptest.Server
+func (ts *testServer) Get(path string) *testutil.Result {
+	ts.t.Helper()
+	return testutil.Get(ts.t, ts.client, ts.URL+path)
 }
 
-func (ts *testServer) Close() {
-	ts.server.Close()
+func (ts *testServer) PostJSON(path string, body interface{}) *testutil.Result {
+	ts.t.Helper()
+	return testutil.PostJSON(ts.t, ts.client, ts.URL+path, body)
 }
 
-func (ts *testServer) DiscordLog() {
-	ts.app.Log().(*log.Logger).SetWriter(ioutil.Discard)
+func (ts *testServer) Do(req *http.Request) *testutil.Result {
+	ts.t.Helper()
+	return testutil.Do(ts.t, ts.client, req)
+}
+
+func (ts *testServer) SetMiddlewares(middlewares ...MiddlewareFunc) {
+	ts.app.he.SetMiddlewares(middlewares...)
+}
+
+func (ts *testServer) WithoutMiddlewares(middlewares ...MiddlewareFunc) {
+	var mws []MiddlewareFunc
+	for _, mw := range ts.app.he.mwStack {
+		skip := false
+		for _, m := range middlewares {
+			if reflect.ValueOf(mw).Pointer() == reflect.ValueOf(m).Pointer() {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			mws = append(mws, mw)
+		}
+	}
+	ts.SetMiddlewares(mws...)
+}
+
+func (ts *testServer) CaptureLog() *testutil.LogRecorder {
+	ts.logRecorder = &testutil.LogRecorder{}
+	ts.app.Log().(*log.Logger).SetWriter(ts.logRecorder)
+	return ts.logRecorder
 }
 
-func (ts *testServer) UndiscordLog() {
-	ts.app.Log().(*log.Logger).SetWriter(os.Stdout)
+func (ts *testServer) AssertLogContains(str string) {
+	ts.t.Helper()
+	if ts.logRecorder == nil {
+		ts.t.Fatal("log is not captured, call 'CaptureLog' first")
+	}
+	if !strings.Contains(ts.logRecorder.String(), str) {
+		ts.t.Errorf("log does not contain '%s'", str)
+	}
+}
+
+func (ts *testServer) Close() {
+	ts.closeOnce.Do(func() {
+		if ts.app.State() != AppStateStopped {
+			ts.app.Shutdown()
+		}
+		ts.server.Close()
+	})
 }
 
 // It a workaround to init required things for application, since test `webapp1`
 // residing in `aahframework.org/aah.v0/testdata/webapp1`.
 //
 // This is not required for actual application residing in $GOPATH :)
-func (ts *testServer) manualInit() {
-	// adding middlewares
-	ts.app.he.Middlewares(
-		RouteMiddleware,
-		CORSMiddleware,
-		BindMiddleware,
-		AntiCSRFMiddleware,
-		AuthcAuthzMiddleware,
-		ActionMiddleware,
-	)
-
+func manualInit(a *Application) {
 	// adding controller
-	ts.app.AddController((*testSiteController)(nil), []*ainsp.Method{
+	a.AddController((*testSiteController)(nil), []*ainsp.Method{
 		{Name: "Index"},
 		{Name: "Text"},
 		{
@@ -483,16 +799,25 @@ func (ts *testServer) manualInit() {
 		{Name: "TriggerPanic"},
 		{Name: "BinaryBytes"},
 		{Name: "SendFile"},
+		{Name: "StreamTrailer"},
 		{Name: "Cookies"},
+		{Name: "Webhook"},
+		{Name: "APIVersion"},
+		{
+			Name: "ActionResult",
+			Parameters: []*ainsp.Parameter{
+				{Name: "mode", Type: reflect.TypeOf((*string)(nil))},
+			},
+		},
 	})
 
 	// reset controller namespace and key
 	cregistry := &ainsp.TargetRegistry{Registry: make(map[string]*ainsp.Target), SearchType: ctxPtrType}
-	for k, v := range ts.app.he.registry.Registry {
+	for k, v := range a.he.registry.Registry {
 		v.Namespace = ""
 		cregistry.Registry[path.Base(k)] = v
 	}
-	ts.app.he.registry = cregistry
+	a.he.registry = cregistry
 }
 
 // Test types
@@ -604,6 +929,26 @@ func (s *testSiteController) BinaryBytes() {
 		Binary([]byte("This is my Binary Bytes"))
 }
 
+func (s *testSiteController) StreamTrailer() {
+	var size int64
+	s.Reply().
+		ContentType(ahttp.ContentTypePlainText.String()).
+		Trailer("x-stream-size", func() string { return strconv.FormatInt(size, 10) }).
+		Trailer("X-Stream-Status", func() string { return "completed" }).
+		FromReader(&countReader{r: strings.NewReader("This is streamed content"), n: &size})
+}
+
+type countReader struct {
+	r io.Reader
+	n *int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	*c.n += int64(n)
+	return n, err
+}
+
 func (s *testSiteController) SendFile() {
 	s.Reply().
 		Header("X-Before-Interceptor", "").
@@ -630,6 +975,39 @@ func (s *testSiteController) Cookies() {
 		}).Text("Hey I'm sending cookies for you :)")
 }
 
+var errTestSentinel = &Error{ErrorCode: "sentinel", Message: "Sentinel error"}
+
+func (s *testSiteController) ActionResult(mode string) (*sampleJSON, error) {
+	switch mode {
+	case "aah-error":
+		return nil, NewError(http.StatusBadRequest, "bad_input", "Invalid input")
+	case "sentinel-error":
+		return nil, errTestSentinel
+	case "error":
+		return nil, errors.New("database is down")
+	case "empty":
+		s.Reply().NoContent()
+		return nil, nil
+	}
+	return &sampleJSON{FirstName: "Jeeva", LastName: "M", Number: 1}, nil
+}
+
+func (s *testSiteController) APIVersion() {
+	s.Reply().JSON(Data{
+		"route":   s.route.Name,
+		"version": s.route.Version,
+	})
+}
+
+func (s *testSiteController) Webhook() {
+	b, err := ioutil.ReadAll(s.Req.Body())
+	if err != nil {
+		s.Reply().BadRequest().Text("unable to read body")
+		return
+	}
+	s.Reply().Text("received: %s", b)
+}
+
 func (s *testSiteController) HandleError(err *Error) bool {
 	s.Log().Infof("we got the callbakc from error handler: %s", err)
 	s.Reply().Header("X-Cntrl-ErrorHandler", "true")
@@ -677,14 +1055,3 @@ func testdataBaseDir() string {
 	}
 	return filepath.Join(wd, "testdata")
 }
-
-func responseBody(res *http.Response) string {
-	body := res.Body
-	defer ess.CloseQuietly(body)
-	if strings.Contains(res.Header.Get(ahttp.HeaderContentEncoding), "gzip") {
-		body, _ = gzip.NewReader(body)
-	}
-	buf := new(bytes.Buffer)
-	io.Copy(buf, body)
-	return buf.String()
-}
@@ -0,0 +1,248 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package aahtest provides the aah test server to test aah application
+// end-to-end, application is initialized and served by `httptest.Server`.
+// It is a separate package, so that `httptest` and `cookiejar` are linked
+// only into the test binary.
+//
+//	ts := aahtest.NewTestServer(t, importPath)
+//	defer ts.Close()
+//
+//	ts.Get("/").AssertStatus(http.StatusOK)
+package aahtest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+
+	"aahframe.work"
+	"aahframe.work/internal/testutil"
+	"aahframe.work/log"
+)
+
+// TestingT interface is the subset of `testing.TB` used by aah test server,
+// so that it could be used with `*testing.T` and `*testing.B`. Assertion
+// failures are reported at the caller via `Helper()`.
+type TestingT = testutil.TestingT
+
+// TestResult struct holds the test server response, body is read and
+// decompressed if it's gzip encoded. Assertion methods are chainable.
+type TestResult = testutil.Result
+
+// LogRecorder struct records the application log written by aah logger, it
+// is safe for concurrent writes.
+type LogRecorder = testutil.LogRecorder
+
+// TestServerOption type to provide configuration options to create aah
+// test server.
+type TestServerOption func(*TestServer)
+
+// WithMiddlewares option func is to set the HTTP engine middleware stack of
+// test server, so that test could choose its own middleware chain. By default
+// test server uses the aah framework middlewares.
+func WithMiddlewares(middlewares ...aah.MiddlewareFunc) TestServerOption {
+	return func(ts *TestServer) {
+		ts.middlewares = middlewares
+	}
+}
+
+// WithoutMiddlewares option func is to remove given middlewares from the HTTP
+// engine middleware stack of test server. For e.g.: to skip anti-csrf check
+// on handler test.
+func WithoutMiddlewares(middlewares ...aah.MiddlewareFunc) TestServerOption {
+	return func(ts *TestServer) {
+		ts.skipMiddlewares = append(ts.skipMiddlewares, middlewares...)
+	}
+}
+
+// WithSetup option func is to customize the application before test server
+// starts, for e.g.: register controllers, event subscribers, workers.
+// Callbacks are invoked in the order of registration.
+//
+//	ts := aahtest.NewTestServer(t, importPath, aahtest.WithSetup(func(a *aah.Application) {
+//	  a.AddController((*controllers.UserController)(nil), userMethods)
+//	}))
+func WithSetup(fn func(*aah.Application)) TestServerOption {
+	return func(ts *TestServer) {
+		if fn != nil {
+			ts.setups = append(ts.setups, fn)
+		}
+	}
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Test Server
+//______________________________________________________________________________
+
+// NewTestServer method creates aah test server for the application of given
+// import path, application is initialized and served by `httptest.Server`.
+// Test server must be closed after the test.
+func NewTestServer(t TestingT, importPath string, opts ...TestServerOption) *TestServer {
+	return NewTestServerWithConfig(t, importPath, nil, opts...)
+}
+
+// NewTestServerWithConfig method creates aah test server with given config
+// overrides, it is applied after config load and before app initialize.
+// Active environment profile could be set via key `env.active`.
+//
+// Supported override value types are string, bool, int, int64 and float64.
+func NewTestServerWithConfig(t TestingT, importPath string, overrides map[string]interface{}, opts ...TestServerOption) *TestServer {
+	a, err := aah.NewAppForTest(importPath, overrides)
+	if err != nil {
+		t.Errorf("aah test server: %v", err)
+		t.FailNow()
+		return nil
+	}
+
+	jar, _ := cookiejar.New(nil)
+	ts := &TestServer{
+		t:      t,
+		app:    a,
+		client: &http.Client{Jar: jar},
+	}
+	for _, opt := range opts {
+		opt(ts)
+	}
+
+	// test log is discarded, test could inspect it via `CaptureLog`
+	ts.app.Log().(*log.Logger).SetWriter(ioutil.Discard)
+
+	for _, fn := range ts.setups {
+		fn(ts.app)
+	}
+	if len(ts.middlewares) > 0 {
+		ts.SetMiddlewares(ts.middlewares...)
+	} else if len(ts.app.HTTPEngine().MiddlewareStack()) == 0 {
+		ts.SetMiddlewares(
+			aah.RouteMiddleware,
+			aah.CORSMiddleware,
+			aah.SignatureMiddleware,
+			aah.BindMiddleware,
+			aah.AntiCSRFMiddleware,
+			aah.AuthcAuthzMiddleware,
+			aah.ActionMiddleware,
+		)
+	}
+	if len(ts.skipMiddlewares) > 0 {
+		ts.WithoutMiddlewares(ts.skipMiddlewares...)
+	}
+
+	ts.server = httptest.NewUnstartedServer(ts.app)
+	ts.app.ServeForTest(ts.server.Config)
+	ts.server.Start()
+	ts.URL = ts.server.URL
+
+	return ts
+}
+
+// TestServer provides capabilities to test aah application end-to-end. HTTP
+// client of test server retains the cookies across requests.
+type TestServer struct {
+	URL string
+
+	t               TestingT
+	app             *aah.Application
+	client          *http.Client
+	server          *httptest.Server
+	middlewares     []aah.MiddlewareFunc
+	skipMiddlewares []aah.MiddlewareFunc
+	setups          []func(*aah.Application)
+	closeOnce       sync.Once
+	logRecorder     *LogRecorder
+}
+
+// App method returns the aah application instance of test server.
+func (ts *TestServer) App() *aah.Application {
+	return ts.app
+}
+
+// Client method returns the HTTP client of test server, it has cookie jar.
+func (ts *TestServer) Client() *http.Client {
+	return ts.client
+}
+
+// Get method sends HTTP GET request to test server for given path.
+func (ts *TestServer) Get(path string) *TestResult {
+	ts.t.Helper()
+	return testutil.Get(ts.t, ts.client, ts.URL+path)
+}
+
+// PostJSON method sends HTTP POST request to test server for given path
+// with JSON payload of given body.
+func (ts *TestServer) PostJSON(path string, body interface{}) *TestResult {
+	ts.t.Helper()
+	return testutil.PostJSON(ts.t, ts.client, ts.URL+path, body)
+}
+
+// Do method sends given HTTP request to test server using client with
+// cookie jar, so cookies are retained across requests.
+func (ts *TestServer) Do(req *http.Request) *TestResult {
+	ts.t.Helper()
+	return testutil.Do(ts.t, ts.client, req)
+}
+
+// SetMiddlewares method replaces the HTTP engine middleware stack with given
+// middlewares. So that test could choose its own middleware chain.
+func (ts *TestServer) SetMiddlewares(middlewares ...aah.MiddlewareFunc) {
+	ts.app.HTTPEngine().SetMiddlewares(middlewares...)
+}
+
+// WithoutMiddlewares method removes given middlewares from HTTP engine
+// middleware stack. For e.g.: to skip anti-csrf check on handler test.
+func (ts *TestServer) WithoutMiddlewares(middlewares ...aah.MiddlewareFunc) {
+	var mws []aah.MiddlewareFunc
+	for _, mw := range ts.app.HTTPEngine().MiddlewareStack() {
+		skip := false
+		for _, m := range middlewares {
+			if reflect.ValueOf(mw).Pointer() == reflect.ValueOf(m).Pointer() {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			mws = append(mws, mw)
+		}
+	}
+	ts.SetMiddlewares(mws...)
+}
+
+// CaptureLog method captures the application log into recorder, so that
+// test could inspect it. Recorder is safe for concurrent writes.
+func (ts *TestServer) CaptureLog() *LogRecorder {
+	ts.logRecorder = &LogRecorder{}
+	ts.app.Log().(*log.Logger).SetWriter(ts.logRecorder)
+	return ts.logRecorder
+}
+
+// AssertLogContains method asserts the captured log contains given string.
+func (ts *TestServer) AssertLogContains(str string) {
+	ts.t.Helper()
+	if ts.logRecorder == nil {
+		ts.t.Errorf("log is not captured, call 'CaptureLog' first")
+		ts.t.FailNow()
+		return
+	}
+	if !strings.Contains(ts.logRecorder.String(), str) {
+		ts.t.Errorf("log does not contain '%s'", str)
+	}
+}
+
+// Close method closes the test server and performs the application shutdown
+// sequence same as `Application.Shutdown`, i.e. publishes shutdown events,
+// drains the in-flight requests, stops background workers and executes
+// shutdown hooks. It is idempotent and safe to call in a defer.
+func (ts *TestServer) Close() {
+	ts.closeOnce.Do(func() {
+		if ts.app.State() != aah.AppStateStopped {
+			ts.app.Shutdown()
+		}
+		ts.server.Close()
+	})
+}
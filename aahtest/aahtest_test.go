@@ -0,0 +1,101 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aahtest
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"aahframe.work"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestServerOptions(t *testing.T) {
+	var setupCalled bool
+	ts := NewTestServer(t, testAppImportPath(),
+		WithSetup(func(a *aah.Application) { setupCalled = true }),
+		WithMiddlewares(aah.RouteMiddleware, replyMiddleware, aah.AntiCSRFMiddleware, aah.ActionMiddleware),
+		WithoutMiddlewares(aah.AntiCSRFMiddleware, aah.ActionMiddleware),
+	)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Test Server Options]: %s", ts.URL)
+
+	assert.True(t, setupCalled)
+	assert.NotNil(t, ts.App())
+	assert.NotNil(t, ts.Client().Jar)
+	assert.Equal(t, []string{"aahframe.work.RouteMiddleware", "aahframe.work/aahtest.replyMiddleware"},
+		ts.App().HTTPEngine().MiddlewareNames())
+
+	ts.Get("/get-text.html").
+		AssertStatus(http.StatusOK).
+		AssertHeader("X-Test-Server", "true").
+		AssertJSONPath("path", "/get-text.html")
+
+	ts.PostJSON("/create-record", map[string]string{"first_name": "aah"}).
+		AssertStatus(http.StatusOK).
+		AssertJSONPath("method", http.MethodPost)
+}
+
+func TestTestServerWithConfig(t *testing.T) {
+	ts := NewTestServerWithConfig(t, testAppImportPath(), map[string]interface{}{
+		"env.active":       "prod",
+		"server.base_path": "/myapp",
+	}, WithMiddlewares(replyMiddleware))
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Test Server With Config]: %s", ts.URL)
+
+	assert.Equal(t, "prod", ts.App().EnvProfile())
+	assert.Equal(t, "/myapp", ts.App().Config().StringDefault("server.base_path", ""))
+
+	lr := ts.CaptureLog()
+	ts.App().Log().Error("test server log")
+	ts.AssertLogContains("test server log")
+	assert.Contains(t, lr.String(), "test server log")
+}
+
+func TestTestServerClose(t *testing.T) {
+	ts := NewTestServer(t, testAppImportPath(), WithMiddlewares(replyMiddleware))
+
+	t.Logf("Test Server URL [Test Server Close]: %s", ts.URL)
+
+	var events []string
+	ts.App().OnPreShutdown(func(e *aah.Event) { events = append(events, e.Name) })
+	ts.App().OnPostShutdown(func(e *aah.Event) { events = append(events, e.Name) })
+
+	workerStopped := make(chan struct{})
+	ts.App().Go("test-worker", func(ctx context.Context) {
+		<-ctx.Done()
+		close(workerStopped)
+	})
+
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+
+	ts.Close()
+	ts.Close()
+	assert.Equal(t, []string{aah.EventOnPreShutdown, aah.EventOnPostShutdown}, events)
+	assert.Equal(t, aah.AppStateStopped, ts.App().State())
+	select {
+	case <-workerStopped:
+	default:
+		t.Error("background worker is not stopped on test server close")
+	}
+}
+
+func replyMiddleware(ctx *aah.Context, m *aah.Middleware) {
+	ctx.Reply().Header("X-Test-Server", "true").JSON(aah.Data{
+		"method": ctx.Req.Method,
+		"path":   ctx.Req.Path,
+	})
+}
+
+func testAppImportPath() string {
+	wd, _ := os.Getwd()
+	return filepath.Join(filepath.Dir(wd), "testdata", "webapp1")
+}
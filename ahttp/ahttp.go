@@ -94,6 +94,18 @@ func WrapGzipWriter(w io.Writer) ResponseWriter {
 	return gr
 }
 
+// IsCommitted method returns true if the status or body of given response is
+// written on the wire. It uses method `Committed() bool` of the writer if it
+// implements, for e.g.: `ahttp.Response`, otherwise the written status.
+func IsCommitted(w ResponseWriter) bool {
+	if c, ok := w.(interface {
+		Committed() bool
+	}); ok {
+		return c.Committed()
+	}
+	return w.Status() > 0
+}
+
 // Scheme method is to identify value of protocol value. It's derived
 // value, Go language doesn't provide directly.
 //
@@ -127,6 +139,16 @@ func Scheme(r *http.Request) string {
 	return SchemeHTTP
 }
 
+// ConnScheme method returns the protocol value of the connection itself,
+// forwarded headers are not considered. Returns `https` if
+// `http.Request.TLS` is not nil otherwise `http`.
+func ConnScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return SchemeHTTPS
+	}
+	return SchemeHTTP
+}
+
 // Host method is to correct Host value from HTTP request.
 func Host(r *http.Request) string {
 	if h := r.Header[HeaderXForwardedHost]; len(h) > 0 {
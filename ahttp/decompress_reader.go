@@ -0,0 +1,97 @@
+// Copyright (c) Jeevanandam M (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package ahttp
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+)
+
+// decompressed bytes below the threshold are not checked against the ratio,
+// small payloads compresses well beyond any sane ratio.
+const decompressRatioThreshold = 1 << 20
+
+// ErrDecompressLimitExceeded returned by decompress reader when decompressed
+// bytes exceeds the max size or ratio of `DecompressLimit`.
+var ErrDecompressLimitExceeded = errors.New("ahttp: decompressed size exceeds the limit")
+
+// DecompressLimit holds the limits of decompressed bytes, it guards against
+// decompression bombs, i.e. tiny compressed payload expands to gigabytes.
+//
+//  - MaxSize is the max decompressed bytes, zero means no limit.
+//
+//  - MaxRatio is the max ratio of decompressed bytes to compressed bytes
+//  read, applied beyond 1mb of decompressed bytes. Zero means no limit.
+type DecompressLimit struct {
+	MaxSize  int64
+	MaxRatio int64
+}
+
+// NewDecompressReader method wraps the decompressor created by `fn` for the
+// compressed reader `r` with given limit. On limit read returns
+// `ErrDecompressLimitExceeded`. Close method closes the decompressor and `r`,
+// if they are `io.Closer`.
+func NewDecompressReader(r io.Reader, limit DecompressLimit, fn func(io.Reader) (io.Reader, error)) (io.ReadCloser, error) {
+	src := &countReader{r: r}
+	dr, err := fn(src)
+	if err != nil {
+		return nil, err
+	}
+	return &decompressReader{r: dr, src: src, limit: limit}, nil
+}
+
+// NewGzipReader method returns the gzip decompress reader for the compressed
+// reader `r` with given limit, for e.g.: request body, gzipped multipart file.
+//
+//	f, _ := fileHeader.Open()
+//	zr, err := ahttp.NewGzipReader(f, ahttp.DecompressLimit{MaxSize: 10 << 20, MaxRatio: 100})
+func NewGzipReader(r io.Reader, limit DecompressLimit) (io.ReadCloser, error) {
+	return NewDecompressReader(r, limit, func(src io.Reader) (io.Reader, error) {
+		return gzip.NewReader(src)
+	})
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Unexported types and methods
+//___________________________________
+
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type decompressReader struct {
+	r     io.Reader
+	src   *countReader
+	limit DecompressLimit
+	n     int64
+}
+
+func (d *decompressReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	d.n += int64(n)
+	if (d.limit.MaxSize > 0 && d.n > d.limit.MaxSize) ||
+		(d.limit.MaxRatio > 0 && d.n > decompressRatioThreshold && d.n > d.src.n*d.limit.MaxRatio) {
+		return 0, ErrDecompressLimitExceeded
+	}
+	return n, err
+}
+
+func (d *decompressReader) Close() error {
+	if c, ok := d.r.(io.Closer); ok {
+		_ = c.Close()
+	}
+	if c, ok := d.src.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) Jeevanandam M (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package ahttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecompressReader(t *testing.T) {
+	gzipBytes := func(s string) []byte {
+		buf := new(bytes.Buffer)
+		zw := gzip.NewWriter(buf)
+		_, _ = zw.Write([]byte(s))
+		_ = zw.Close()
+		return buf.Bytes()
+	}
+	read := func(b []byte, limit DecompressLimit) (string, error) {
+		zr, err := NewGzipReader(bytes.NewReader(b), limit)
+		if err != nil {
+			return "", err
+		}
+		defer zr.Close()
+		rb, err := ioutil.ReadAll(zr)
+		return string(rb), err
+	}
+
+	t.Log("Within limits")
+	s, err := read(gzipBytes("aah framework"), DecompressLimit{MaxSize: 13, MaxRatio: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, "aah framework", s)
+
+	t.Log("Max size")
+	_, err = read(gzipBytes("aah framework"), DecompressLimit{MaxSize: 12})
+	assert.Equal(t, ErrDecompressLimitExceeded, err)
+
+	t.Log("Max ratio")
+	bomb := gzipBytes(strings.Repeat("a", 4<<20))
+	_, err = read(bomb, DecompressLimit{MaxRatio: 100})
+	assert.Equal(t, ErrDecompressLimitExceeded, err)
+
+	s, err = read(bomb, DecompressLimit{})
+	assert.Nil(t, err)
+	assert.Equal(t, 4<<20, len(s))
+
+	t.Log("Malformed gzip")
+	_, err = read([]byte("aah framework"), DecompressLimit{})
+	assert.Equal(t, gzip.ErrHeader, err)
+}
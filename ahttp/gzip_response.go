@@ -58,7 +58,9 @@ func (g *GzipResponse) Header() http.Header {
 
 // Write method writes bytes into Response.
 func (g *GzipResponse) Write(b []byte) (int, error) {
-	g.r.WriteHeader(http.StatusOK)
+	if !g.r.wroteStatus {
+		g.r.WriteHeader(http.StatusOK)
+	}
 	size, err := g.gw.Write(b)
 	g.r.bytesWritten += size
 	return size, err
@@ -69,6 +71,12 @@ func (g *GzipResponse) BytesWritten() int {
 	return g.r.BytesWritten()
 }
 
+// Committed method returns true if the response status or body is written on
+// the wire.
+func (g *GzipResponse) Committed() bool {
+	return g.r.Committed()
+}
+
 // Close method closes the writer if possible.
 func (g *GzipResponse) Close() error {
 	if err := g.gw.Close(); err != nil {
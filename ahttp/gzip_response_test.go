@@ -26,7 +26,9 @@ func TestHTTPGzipWriter(t *testing.T) {
 
 		gw.Header().Set(HeaderVary, HeaderAcceptEncoding)
 		gw.Header().Set(HeaderContentEncoding, "gzip")
+		assert.False(t, IsCommitted(gw))
 		gw.WriteHeader(http.StatusOK)
+		assert.True(t, IsCommitted(gw))
 
 		_, _ = gw.Write([]byte(`aah framework - testing gzip response writer
 
@@ -38,6 +38,7 @@ const (
 	HeaderCacheControl                    = "Cache-Control"
 	HeaderConnection                      = "Connection"
 	HeaderContentDisposition              = "Content-Disposition"
+	HeaderContentLanguage                 = "Content-Language"
 	HeaderContentEncoding                 = "Content-Encoding"
 	HeaderContentLength                   = "Content-Length"
 	HeaderContentType                     = "Content-Type"
@@ -67,11 +68,14 @@ const (
 	HeaderSetCookie                       = "Set-Cookie"
 	HeaderStatus                          = "Status"
 	HeaderStrictTransportSecurity         = "Strict-Transport-Security"
+	HeaderTraceparent                     = "Traceparent"
+	HeaderTrailer                         = "Trailer"
 	HeaderTransferEncoding                = "Transfer-Encoding"
 	HeaderUpgrade                         = "Upgrade"
 	HeaderUserAgent                       = "User-Agent"
 	HeaderVary                            = "Vary"
 	HeaderWWWAuthenticate                 = "Www-Authenticate"
+	HeaderXB3TraceID                      = "X-B3-Traceid"
 	HeaderXContentTypeOptions             = "X-Content-Type-Options"
 	HeaderXDNSPrefetchControl             = "X-Dns-Prefetch-Control"
 	HeaderXCSRFToken                      = "X-Csrf-Token"
@@ -89,6 +93,7 @@ const (
 	HeaderXRealIP                         = "X-Real-Ip"
 	HeaderXRequestedWith                  = "X-Requested-With"
 	HeaderXRequestID                      = "X-Request-Id"
+	HeaderXTraceID                        = "X-Trace-Id"
 	HeaderXXSSProtection                  = "X-Xss-Protection"
 )
 
@@ -138,6 +143,10 @@ func NegotiateContentType(req *http.Request) *ContentType {
 			verparts := strings.Split(subparts[0], "-v")
 			spec.Params["vendor"] = strings.TrimPrefix(verparts[0], vendorTreePrefix)
 			spec.Params["version"] = verparts[1]
+		} else if idx := strings.LastIndex(subparts[0], ".v"); isVendorVersion(subparts[0], idx) {
+			// dot separated version, for e.g.: application/vnd.myapp.v2+json
+			spec.Params["vendor"] = strings.TrimPrefix(subparts[0][:idx], vendorTreePrefix)
+			spec.Params["version"] = subparts[0][idx+2:]
 		} else {
 			spec.Params["vendor"] = strings.TrimPrefix(subparts[0], vendorTreePrefix)
 		}
@@ -325,6 +334,13 @@ func isVendorType(mime string) ([]string, bool) {
 	return parts, strings.HasPrefix(parts[1], vendorTreePrefix)
 }
 
+// isVendorVersion method returns true if the vendor subtype has version
+// segment at given index, i.e. `.v` followed by digit.
+func isVendorVersion(subtype string, idx int) bool {
+	return idx > len(vendorTreePrefix) && idx+2 < len(subtype) &&
+		subtype[idx+2] >= '0' && subtype[idx+2] <= '9'
+}
+
 // parseMediaType method parses a media type value and any optional
 // parameters, per RFC 1521. the values in Content-Type and
 // Content-Disposition headers (RFC 2183).
@@ -132,6 +132,17 @@ func TestHTTPAcceptHeaderVendorType(t *testing.T) {
 	assert.Equal(t, "mycompany.myapp.customer", ctype.Vendor())
 	assert.Equal(t, "", ctype.Version())
 
+	req = createRawHTTPRequest(HeaderAccept, "application/vnd.myapp.v2+json")
+	ctype = NegotiateContentType(req)
+	assert.Equal(t, "application/json", ctype.Mime)
+	assert.Equal(t, "myapp", ctype.Vendor())
+	assert.Equal(t, "2", ctype.Version())
+
+	req = createRawHTTPRequest(HeaderAccept, "application/vnd.myapp.vendor+json")
+	ctype = NegotiateContentType(req)
+	assert.Equal(t, "myapp.vendor", ctype.Vendor())
+	assert.Equal(t, "", ctype.Version())
+
 	req = createRawHTTPRequest(HeaderAccept, "application/vnd.api+json")
 	ctype = NegotiateContentType(req)
 	assert.Equal(t, "application/json", ctype.Mime)
@@ -5,9 +5,13 @@
 package ahttp
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"net/url"
@@ -20,11 +24,20 @@ import (
 
 const (
 	jsonpReqParamKey = "callback"
+	defaultMaxMemory = 32 << 20 // same as Go HTTP server
 	ajaxHeaderValue  = "XMLHttpRequest"
 )
 
 var requestPool = &sync.Pool{New: func() interface{} { return &Request{} }}
 
+// ErrRequestBodyTooLarge returned by method `Request.BodyBytes` when the
+// request body exceeds the configured max body size.
+var ErrRequestBodyTooLarge = errors.New("ahttp: request body too large")
+
+// ErrMultipartPartsExceeded returned by method `Request.ParseMultipartForm`
+// when the multipart request has parts more than configured max parts.
+var ErrMultipartPartsExceeded = errors.New("ahttp: multipart parts exceeded")
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Package methods
 //___________________________________
@@ -84,6 +97,9 @@ type Request struct {
 	contentType       *ContentType
 	acceptContentType *ContentType
 	acceptEncoding    *AcceptSpec
+	body              []byte
+	maxBodySize       int64
+	maxMultipartParts int
 }
 
 // AcceptContentType method returns negotiated value.
@@ -246,14 +262,121 @@ func (r *Request) FormArrayValue(key string) []string {
 // FormFile method returns the first file for the provided form key otherwise
 // returns error. It is caller responsibility to close the file.
 func (r *Request) FormFile(key string) (multipart.File, *multipart.FileHeader, error) {
+	if r.Unwrap().MultipartForm == nil {
+		if err := r.ParseMultipartForm(defaultMaxMemory); err != nil {
+			return nil, nil, err
+		}
+	}
 	return r.Unwrap().FormFile(key)
 }
 
+// ParseMultipartForm method parses the multipart form same as
+// `http.Request.ParseMultipartForm` and enforces the max parts count
+// set via `SetMaxMultipartParts`, beyond it returns `ErrMultipartPartsExceeded`.
+func (r *Request) ParseMultipartForm(maxMemory int64) error {
+	raw := r.Unwrap()
+	if r.maxMultipartParts <= 0 || raw.MultipartForm != nil || raw.Body == nil {
+		return raw.ParseMultipartForm(maxMemory)
+	}
+
+	_, params, err := mime.ParseMediaType(raw.Header.Get(HeaderContentType))
+	if err != nil || len(params["boundary"]) == 0 {
+		return raw.ParseMultipartForm(maxMemory)
+	}
+
+	body := raw.Body
+	plr := &partsLimitReader{r: body, delim: []byte("--" + params["boundary"]), max: r.maxMultipartParts}
+	raw.Body = struct {
+		io.Reader
+		io.Closer
+	}{plr, body}
+	defer func() { raw.Body = body }()
+
+	if err = raw.ParseMultipartForm(maxMemory); plr.exceeded {
+		return ErrMultipartPartsExceeded
+	}
+	return err
+}
+
 // Body method returns the HTTP request body.
 func (r *Request) Body() io.ReadCloser {
 	return r.Unwrap().Body
 }
 
+// BodyBytes method reads the HTTP request body fully and caches it on the
+// request, so that it can be read multiple times in the request lifecycle
+// e.g. signature verification middleware reads the body, then the handler
+// reads the body again via `Body()` or auto parse.
+//
+// Note:
+//
+//  - Entire body is held in memory until the request completes, use it
+//  only when needed. Prefer `Body()` for streaming large request bodies.
+//
+//  - Body is read up to the max body size (`request.max_body_size` or route
+//  `max_body_size`), beyond it returns `ErrRequestBodyTooLarge`.
+//
+//  - Body has to be read via `BodyBytes` before anything else consumes it,
+//  otherwise it returns the remaining unread bytes.
+func (r *Request) BodyBytes() ([]byte, error) {
+	if r.body != nil {
+		return r.body, nil
+	}
+
+	if r.Unwrap().Body == nil || r.Unwrap().Body == http.NoBody {
+		r.body = []byte{}
+		return r.body, nil
+	}
+
+	var rdr io.Reader = r.Unwrap().Body
+	if r.maxBodySize > 0 {
+		rdr = io.LimitReader(rdr, r.maxBodySize+1)
+	}
+
+	b, err := ioutil.ReadAll(rdr)
+	if err != nil {
+		return nil, err
+	}
+	if r.maxBodySize > 0 && int64(len(b)) > r.maxBodySize {
+		return nil, ErrRequestBodyTooLarge
+	}
+
+	r.body = b
+	r.Unwrap().Body = ioutil.NopCloser(bytes.NewReader(b))
+	return r.body, nil
+}
+
+// SetBody method replaces the HTTP request body with given bytes, for e.g.:
+// transcoded body. Subsequently `BodyBytes` and `Body` returns it.
+func (r *Request) SetBody(b []byte) *Request {
+	r.body = b
+	r.Unwrap().Body = ioutil.NopCloser(bytes.NewReader(b))
+	return r
+}
+
+// SetMaxBodySize method sets the max body size in bytes, it is applied by
+// method `BodyBytes`. Value zero means no limit.
+func (r *Request) SetMaxBodySize(size int64) *Request {
+	r.maxBodySize = size
+	return r
+}
+
+// SetMaxMultipartParts method sets the max parts count of multipart request,
+// it is applied by methods `ParseMultipartForm` and `FormFile`. Value zero
+// means no limit.
+func (r *Request) SetMaxMultipartParts(n int) *Request {
+	r.maxMultipartParts = n
+	return r
+}
+
+// SetContext method replaces the context of underlying HTTP request with
+// given one, for e.g.: context carrying the tracing span. It must be derived
+// from the current request context.
+func (r *Request) SetContext(ctx context.Context) *Request {
+	r.raw = r.raw.WithContext(ctx)
+	return r
+}
+
 // Unwrap method returns the underlying *http.Request instance of Go HTTP server,
 // direct interaction with raw object is not encouraged. Use it appropriately.
 func (r *Request) Unwrap() *http.Request {
@@ -296,6 +419,9 @@ func (r *Request) Reset() {
 	r.contentType = nil
 	r.acceptContentType = nil
 	r.acceptEncoding = nil
+	r.body = nil
+	r.maxBodySize = 0
+	r.maxMultipartParts = 0
 }
 
 func (r *Request) cleanupMutlipart() {
@@ -349,3 +475,40 @@ func saveFile(r io.Reader, destFile string) (int64, error) {
 
 	return io.Copy(f, r)
 }
+
+// partsLimitReader counts the multipart boundary delimiters while reading the
+// request body and fails once the parts count exceeds the max.
+type partsLimitReader struct {
+	r        io.Reader
+	delim    []byte
+	max      int
+	count    int
+	tail     []byte
+	buf      []byte
+	exceeded bool
+}
+
+func (pr *partsLimitReader) Read(p []byte) (int, error) {
+	if pr.exceeded {
+		return 0, ErrMultipartPartsExceeded
+	}
+
+	n, err := pr.r.Read(p)
+	if n > 0 {
+		// tail of previous read is prepended to find the delimiter spanning reads
+		pr.buf = append(append(pr.buf[:0], pr.tail...), p[:n]...)
+		pr.count += bytes.Count(pr.buf, pr.delim)
+		if keep := len(pr.delim) - 1; len(pr.buf) > keep {
+			pr.tail = append(pr.tail[:0], pr.buf[len(pr.buf)-keep:]...)
+		} else {
+			pr.tail = append(pr.tail[:0], pr.buf...)
+		}
+
+		// closing delimiter is also counted, so parts are count - 1
+		if pr.count-1 > pr.max {
+			pr.exceeded = true
+			return n, ErrMultipartPartsExceeded
+		}
+	}
+	return n, err
+}
@@ -6,8 +6,10 @@ package ahttp
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"errors"
+	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
@@ -15,6 +17,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"aahframe.work/essentials"
 	"github.com/stretchr/testify/assert"
@@ -206,6 +209,24 @@ func TestRequestSchemeDerived(t *testing.T) {
 
 	req.Header.Set(HeaderXForwardedProto, "http")
 	assert.Equal(t, "http", Scheme(req))
+
+	assert.Equal(t, "https", ConnScheme(req))
+	req.TLS = nil
+	req.Header.Set(HeaderXForwardedProto, "https")
+	assert.Equal(t, "http", ConnScheme(req))
+}
+
+type requestCtxKey struct{}
+
+func TestRequestSetContext(t *testing.T) {
+	req := AcquireRequest(httptest.NewRequest("GET", "http://127.0.0.1:8080/welcome.html", nil))
+	raw := req.Unwrap()
+
+	req.SetContext(context.WithValue(raw.Context(), requestCtxKey{}, "span"))
+	assert.Equal(t, "span", req.Unwrap().Context().Value(requestCtxKey{}))
+	assert.Nil(t, raw.Context().Value(requestCtxKey{}))
+	assert.Equal(t, raw.URL, req.Unwrap().URL)
+	ReleaseRequest(req)
 }
 
 func TestRequestSaveFile(t *testing.T) {
@@ -266,6 +287,91 @@ func TestRequestSaveFileForExistingFile(t *testing.T) {
 	assert.Equal(t, int64(0), size)
 }
 
+func TestRequestBodyBytes(t *testing.T) {
+	r := httptest.NewRequest(MethodPost, "http://localhost:8080/webhook", strings.NewReader(`{"event":"push"}`))
+	req := AcquireRequest(r)
+
+	b, err := req.BodyBytes()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"event":"push"}`, string(b))
+
+	// read again from cache
+	b, err = req.BodyBytes()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"event":"push"}`, string(b))
+
+	// handler reads the body again
+	rb, err := ioutil.ReadAll(req.Body())
+	assert.Nil(t, err)
+	assert.Equal(t, `{"event":"push"}`, string(rb))
+
+	// replaced body
+	req.SetBody([]byte(`{"event":"pull"}`))
+	b, err = req.BodyBytes()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"event":"pull"}`, string(b))
+	rb, err = ioutil.ReadAll(req.Body())
+	assert.Nil(t, err)
+	assert.Equal(t, `{"event":"pull"}`, string(rb))
+
+	ReleaseRequest(req)
+	assert.Nil(t, req.body)
+
+	// max body size
+	r = httptest.NewRequest(MethodPost, "http://localhost:8080/webhook", strings.NewReader(`{"event":"push"}`))
+	req = AcquireRequest(r).SetMaxBodySize(5)
+	b, err = req.BodyBytes()
+	assert.Equal(t, ErrRequestBodyTooLarge, err)
+	assert.Nil(t, b)
+	ReleaseRequest(req)
+
+	// no body
+	r = httptest.NewRequest(MethodGet, "http://localhost:8080/webhook", nil)
+	req = AcquireRequest(r)
+	b, err = req.BodyBytes()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(b))
+	ReleaseRequest(req)
+}
+
+func TestRequestMultipartMaxParts(t *testing.T) {
+	createReq := func(parts int) *http.Request {
+		buf := new(bytes.Buffer)
+		w := multipart.NewWriter(buf)
+		for i := 0; i < parts; i++ {
+			assert.Nil(t, w.WriteField("field", "value"))
+		}
+		ess.CloseQuietly(w)
+		r := httptest.NewRequest(MethodPost, "http://localhost:8080/upload", iotest.OneByteReader(buf))
+		r.Header.Set(HeaderContentType, w.FormDataContentType())
+		return r
+	}
+
+	// within limit, delimiters are read byte by byte
+	req := AcquireRequest(createReq(3)).SetMaxMultipartParts(3)
+	assert.Nil(t, req.ParseMultipartForm(defaultMaxMemory))
+	assert.Equal(t, 3, len(req.Unwrap().MultipartForm.Value["field"]))
+	ReleaseRequest(req)
+
+	// exceeds limit
+	req = AcquireRequest(createReq(4)).SetMaxMultipartParts(3)
+	assert.Equal(t, ErrMultipartPartsExceeded, req.ParseMultipartForm(defaultMaxMemory))
+	ReleaseRequest(req)
+
+	// exceeds limit via FormFile
+	req = AcquireRequest(createReq(10)).SetMaxMultipartParts(3)
+	_, _, err := req.FormFile("field")
+	assert.Equal(t, ErrMultipartPartsExceeded, err)
+	ReleaseRequest(req)
+	assert.Equal(t, 0, req.maxMultipartParts)
+
+	// no limit
+	req = AcquireRequest(createReq(10))
+	assert.Nil(t, req.ParseMultipartForm(defaultMaxMemory))
+	assert.Equal(t, 10, len(req.Unwrap().MultipartForm.Value["field"]))
+	ReleaseRequest(req)
+}
+
 func TestURLParams(t *testing.T) {
 	params := URLParams{
 		{
@@ -11,6 +11,8 @@ import (
 	"net"
 	"net/http"
 	"sync"
+
+	"aahframe.work/log"
 )
 
 var (
@@ -46,11 +48,16 @@ type ResponseWriter interface {
 
 // Response implements multiple interface (CloseNotifier, Flusher,
 // Hijacker) and handy methods for aah framework.
+//
+// Once the response is committed, subsequent `WriteHeader` calls are no-op.
+// Once the write fails, for e.g.: client is gone, subsequent writes are no-op
+// and returns the failed write error.
 type Response struct {
 	w            http.ResponseWriter
 	status       int
 	wroteStatus  bool
 	bytesWritten int
+	writeErr     error
 }
 
 // Status method returns HTTP response status code. If status is not yet written
@@ -61,11 +68,17 @@ func (r *Response) Status() int {
 
 // WriteHeader method writes given status code into Response.
 func (r *Response) WriteHeader(code int) {
-	if code > 0 && !r.wroteStatus {
-		r.status = code
-		r.wroteStatus = true
-		r.w.WriteHeader(code)
+	if code <= 0 {
+		return
+	}
+	if r.wroteStatus {
+		log.Debugf("ahttp: superfluous WriteHeader call with status %d, response already committed with status %d",
+			code, r.status)
+		return
 	}
+	r.status = code
+	r.wroteStatus = true
+	r.w.WriteHeader(code)
 }
 
 // Header method returns response header map.
@@ -75,9 +88,18 @@ func (r *Response) Header() http.Header {
 
 // Write method writes bytes into Response.
 func (r *Response) Write(b []byte) (int, error) {
-	r.WriteHeader(http.StatusOK)
+	if r.writeErr != nil {
+		log.Debugf("ahttp: response write skipped, previous write failed: %v", r.writeErr)
+		return 0, r.writeErr
+	}
+	if !r.wroteStatus {
+		r.WriteHeader(http.StatusOK)
+	}
 	size, err := r.w.Write(b)
 	r.bytesWritten += size
+	if err != nil {
+		r.writeErr = err
+	}
 	return size, err
 }
 
@@ -86,6 +108,12 @@ func (r *Response) BytesWritten() int {
 	return r.bytesWritten
 }
 
+// Committed method returns true if the response status or body is written on
+// the wire. After that response status and headers cannot be modified.
+func (r *Response) Committed() bool {
+	return r.wroteStatus
+}
+
 // Close method closes the writer if possible.
 // TODO for removal
 func (r *Response) Close() error {
@@ -138,6 +166,7 @@ func (r *Response) Reset() {
 	r.status = 0
 	r.bytesWritten = 0
 	r.wroteStatus = false
+	r.writeErr = nil
 }
 
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
@@ -5,6 +5,7 @@
 package ahttp
 
 import (
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -38,8 +39,10 @@ func TestHTTPNoStatusWritten(t *testing.T) {
 		writer := AcquireResponseWriter(w)
 		defer ReleaseResponseWriter(writer)
 
+		assert.False(t, IsCommitted(writer))
 		_, _ = writer.Write([]byte("aah framework no status written"))
 		assert.Equal(t, 31, writer.BytesWritten())
+		assert.True(t, IsCommitted(writer))
 	}
 
 	callAndValidate(t, handler, "aah framework no status written")
@@ -51,7 +54,9 @@ func TestHTTPMultipleStatusWritten(t *testing.T) {
 		defer ReleaseResponseWriter(writer)
 
 		writer.WriteHeader(http.StatusOK)
+		assert.True(t, IsCommitted(writer))
 		writer.WriteHeader(http.StatusAccepted)
+		assert.Equal(t, http.StatusOK, writer.Status())
 
 		_, _ = writer.Write([]byte("aah framework mutiple status written"))
 		assert.Equal(t, 36, writer.BytesWritten())
@@ -60,6 +65,65 @@ func TestHTTPMultipleStatusWritten(t *testing.T) {
 	callAndValidate(t, handler, "aah framework mutiple status written")
 }
 
+func TestHTTPResponseFailingWriter(t *testing.T) {
+	fw := &failingWriter{ResponseRecorder: httptest.NewRecorder(), limit: 10}
+	writer := AcquireResponseWriter(fw)
+	defer ReleaseResponseWriter(writer)
+
+	writer.WriteHeader(http.StatusCreated)
+	n, err := writer.Write([]byte("aah framework response"))
+	assert.Equal(t, 10, n)
+	assert.Equal(t, errClientGone, err)
+
+	t.Log("Writes after failure are no-op")
+	n, err = writer.Write([]byte("more bytes"))
+	assert.Equal(t, 0, n)
+	assert.Equal(t, errClientGone, err)
+	assert.Equal(t, 1, fw.writes)
+	assert.Equal(t, 10, writer.BytesWritten())
+
+	t.Log("Superfluous WriteHeader is no-op")
+	writer.WriteHeader(http.StatusInternalServerError)
+	assert.Equal(t, http.StatusCreated, writer.Status())
+	assert.Equal(t, 1, fw.headers)
+
+	t.Log("Gzip writes after failure are no-op")
+	gw := WrapGzipWriter(writer)
+	_, err = gw.Write([]byte("aah framework gzip response"))
+	assert.Equal(t, errClientGone, err)
+	assert.Equal(t, 1, fw.writes)
+
+	t.Log("Reset clears the write failure")
+	r := writer.(*Response)
+	r.Reset()
+	r.w = httptest.NewRecorder()
+	_, err = r.Write([]byte("aah"))
+	assert.Nil(t, err)
+}
+
+var errClientGone = errors.New("write: broken pipe")
+
+type failingWriter struct {
+	*httptest.ResponseRecorder
+	limit   int
+	writes  int
+	headers int
+}
+
+func (f *failingWriter) WriteHeader(code int) {
+	f.headers++
+	f.ResponseRecorder.WriteHeader(code)
+}
+
+func (f *failingWriter) Write(b []byte) (int, error) {
+	f.writes++
+	if len(b) > f.limit {
+		n, _ := f.ResponseRecorder.Write(b[:f.limit])
+		return n, errClientGone
+	}
+	return f.ResponseRecorder.Write(b)
+}
+
 func TestHTTPHijackCall(t *testing.T) {
 	handler := func(w http.ResponseWriter, r *http.Request) {
 		writer := AcquireResponseWriter(w)
@@ -112,3 +176,20 @@ func callAndValidate(t *testing.T, handler http.HandlerFunc, response string) {
 	bytes, _ := ioutil.ReadAll(resp.Body)
 	assert.Equal(t, response, string(bytes))
 }
+
+type statusOnlyWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusOnlyWriter) WriteHeader(code int)        { w.status = code }
+func (w *statusOnlyWriter) Status() int                 { return w.status }
+func (w *statusOnlyWriter) BytesWritten() int           { return 0 }
+func (w *statusOnlyWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func TestHTTPIsCommittedWithoutCommittedMethod(t *testing.T) {
+	w := &statusOnlyWriter{ResponseWriter: httptest.NewRecorder()}
+	assert.False(t, IsCommitted(w))
+	w.WriteHeader(http.StatusCreated)
+	assert.True(t, IsCommitted(w))
+}
@@ -77,15 +77,31 @@ func isPkgAliasExists(importPaths map[string]string, pkgAlias string) bool {
 	return found
 }
 
+// isSupportedResults method returns true if action method returns nothing,
+// single value or two values with last one error.
+func isSupportedResults(results *ast.FieldList) bool {
+	if results == nil {
+		return true
+	}
+	switch results.NumFields() {
+	case 1:
+		return true
+	case 2:
+		ident, ok := results.List[len(results.List)-1].Type.(*ast.Ident)
+		return ok && ident.Name == "error"
+	}
+	return false
+}
+
 func processMethods(pkg *packageInfo, routeMethods map[string]map[string]uint8, decl ast.Decl, imports map[string]string) {
 	fn, ok := decl.(*ast.FuncDecl)
 
 	// Do not process if these met:
 	// 		1. does not have receiver, it means package function/method
 	// 		2. method is not exported
-	// 		3. method returns result
+	// 		3. method returns unsupported result, refer to `isSupportedResults`
 	if !ok || fn.Recv == nil || !fn.Name.IsExported() ||
-		fn.Type.Results != nil {
+		!isSupportedResults(fn.Type.Results) {
 		return
 	}
 
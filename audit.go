@@ -0,0 +1,288 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"aahframe.work/ahttp"
+	"aahframe.work/config"
+	"aahframe.work/essentials"
+	"aahframe.work/internal/util"
+	"aahframe.work/log"
+)
+
+const (
+	keyAahAuditRecord      = "_aahAuditRecord"
+	defaultAuditRedactMask = "******"
+)
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Package methods
+//______________________________________________________________________________
+
+// AuditMiddleware method writes the audit log entry with request and
+// response body for the routes which has `audit = true` attribute in the
+// `routes.conf`. Fields configured in `server.audit_log.redact` are masked
+// before the entry is written, for e.g.: card number, password.
+//
+// Redaction path is a dot separated field names from the body root, for e.g.:
+// `card.number`. Segment `*` matches any field name and arrays are traversed
+// element-wise, so `items.secret` and `items.*.secret` masks `secret` of every
+// element in `items`. For Form body, path is matched with field name as-is.
+//
+// Only JSON and Form bodies are logged, for other content types just size
+// is logged. JSON body which cannot be parsed is not logged, since it
+// cannot be redacted.
+//
+// Request body is read via `ctx.Req.BodyBytes`, so handler can still parse
+// the payload. Add it before `aah.BindMiddleware` in the middleware stack.
+//
+// Response body is captured while HTTP engine writes the reply and
+// the entry is written after it, so later middlewares and `OnPreReply`
+// event could still modify the reply.
+func AuditMiddleware(ctx *Context, m *Middleware) {
+	if ctx.a.auditLog == nil || ctx.route == nil || !ctx.route.IsAudit {
+		m.Next(ctx)
+		return
+	}
+
+	reqBody, err := ctx.Req.BodyBytes()
+	if err != nil {
+		ctx.Log().Errorf("Unable to read request body for audit log: %v", err)
+	}
+	ctx.Set(keyAahAuditRecord, &auditRecord{reqBody: reqBody, resBuf: acquireBuffer()})
+
+	m.Next(ctx)
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// app Unexported methods
+//______________________________________________________________________________
+
+func (a *Application) initAuditLog() error {
+	if !a.Config().BoolDefault("server.audit_log.enable", false) {
+		if a.auditLog != nil {
+			a.auditLog.logger.Close()
+			a.auditLog = nil
+		}
+		return nil
+	}
+
+	paths, _ := a.Config().StringList("server.audit_log.redact")
+	redact, err := parseRedactPaths(paths)
+	if err != nil {
+		return err
+	}
+
+	// log file configuration
+	cfg := config.NewEmpty()
+	file := a.Config().StringDefault("server.audit_log.file", "")
+
+	cfg.SetString("log.receiver", "file")
+	if ess.IsStrEmpty(file) {
+		cfg.SetString("log.file", filepath.Join(a.logsDir(), a.binaryFilename()+"-audit.log"))
+	} else {
+		abspath, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		cfg.SetString("log.file", abspath)
+	}
+
+	cfg.SetString("log.pattern", "%message")
+
+	alLog, err := log.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	// close the audit log file of previous instance on hot-reload
+	prev := a.auditLog
+	a.auditLog = &auditLogger{
+		a:      a,
+		logger: alLog,
+		redact: redact,
+		mask:   a.Config().StringDefault("server.audit_log.mask", defaultAuditRedactMask),
+	}
+	if prev != nil {
+		prev.logger.Close()
+	}
+	return nil
+}
+
+func parseRedactPaths(paths []string) ([][]string, error) {
+	var redact [][]string
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		segments := strings.Split(p, ".")
+		for _, s := range segments {
+			if len(s) == 0 {
+				return nil, fmt.Errorf("'server.audit_log.redact' unsupported value: %s", p)
+			}
+		}
+		redact = append(redact, segments)
+	}
+	return redact, nil
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Audit logger and its methods
+//______________________________________________________________________________
+
+type auditLogger struct {
+	a      *Application
+	logger *log.Logger
+	redact [][]string
+	mask   string
+}
+
+// auditRecord holds the request body and captured response body of
+// audit route till the reply is written.
+type auditRecord struct {
+	reqBody []byte
+	resBuf  *bytes.Buffer
+}
+
+// LogRecord method writes the audit log entry of the request recorded by
+// `AuditMiddleware`, it is called after the reply is written.
+func (al *auditLogger) LogRecord(ctx *Context) {
+	ar, ok := ctx.Get(keyAahAuditRecord).(*auditRecord)
+	if !ok {
+		return
+	}
+	ctx.Set(keyAahAuditRecord, nil)
+	defer releaseBuffer(ar.resBuf)
+	al.Log(ctx, ar.reqBody, ar.resBuf.Bytes())
+}
+
+type auditEntry struct {
+	Time      string     `json:"time"`
+	RequestID string     `json:"request_id,omitempty"`
+	Route     string     `json:"route"`
+	Method    string     `json:"method"`
+	Path      string     `json:"path"`
+	ClientIP  string     `json:"client_ip"`
+	Status    int        `json:"status"`
+	Request   *auditBody `json:"request"`
+	Response  *auditBody `json:"response"`
+}
+
+type auditBody struct {
+	ContentType string          `json:"content_type,omitempty"`
+	Size        int             `json:"size"`
+	Body        json.RawMessage `json:"body,omitempty"`
+}
+
+func (al *auditLogger) Log(ctx *Context, reqBody, resBody []byte) {
+	entry := &auditEntry{
+		Time:      al.a.Clock().Now().Format(time.RFC3339Nano),
+		RequestID: ctx.Req.Header.Get(al.a.settings.RequestIDHeaderKey),
+		Route:     ctx.route.Name,
+		Method:    ctx.Req.Method,
+		Path:      ctx.Req.Path,
+		ClientIP:  ctx.Req.ClientIP(),
+		Status:    ctx.Res.Status(),
+		Request:   al.body(ctx.Req.ContentType().Mime, reqBody),
+		Response:  al.body(ctx.Reply().ContType, resBody),
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		ctx.Log().Errorf("Unable to write audit log entry: %v", err)
+		return
+	}
+	al.logger.Print(string(b))
+}
+
+func (al *auditLogger) body(ct string, b []byte) *auditBody {
+	ab := &auditBody{ContentType: util.OnlyMIME(ct), Size: len(b)}
+	if len(b) == 0 {
+		return ab
+	}
+
+	switch ab.ContentType {
+	case ahttp.ContentTypeJSON.Mime, ahttp.ContentTypeJSONText.Mime:
+		ab.Body = al.redactJSON(b)
+	case ahttp.ContentTypeForm.Mime:
+		ab.Body = al.redactForm(b)
+	}
+	return ab
+}
+
+func (al *auditLogger) redactJSON(b []byte) json.RawMessage {
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return nil
+	}
+	for _, p := range al.redact {
+		v = redactValue(v, p, al.mask)
+	}
+	rb, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return rb
+}
+
+func (al *auditLogger) redactForm(b []byte) json.RawMessage {
+	values, err := url.ParseQuery(string(b))
+	if err != nil {
+		return nil
+	}
+	for _, p := range al.redact {
+		name := strings.Join(p, ".")
+		for k, v := range values {
+			if name == k || name == "*" {
+				for i := range v {
+					v[i] = al.mask
+				}
+			}
+		}
+	}
+	rb, err := json.Marshal(values)
+	if err != nil {
+		return nil
+	}
+	return rb
+}
+
+// redactValue method replaces the value of given path with mask in the
+// decoded JSON value.
+func redactValue(v interface{}, path []string, mask string) interface{} {
+	if len(path) == 0 {
+		return mask
+	}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if path[0] == "*" {
+			for k, fv := range t {
+				t[k] = redactValue(fv, path[1:], mask)
+			}
+		} else if fv, found := t[path[0]]; found {
+			t[path[0]] = redactValue(fv, path[1:], mask)
+		}
+	case []interface{}:
+		// array elements are traversed with same path, segment `*` is
+		// consumed by the array
+		rest := path
+		if path[0] == "*" {
+			rest = path[1:]
+		}
+		for i, ev := range t {
+			t[i] = redactValue(ev, rest, mask)
+		}
+	}
+	return v
+}
@@ -0,0 +1,134 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"aahframe.work/ahttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditMiddleware(t *testing.T) {
+	auditFile := filepath.Join(t.TempDir(), "webapp1-audit.log")
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"server.audit_log.enable": true,
+		"server.audit_log.file":   auditFile,
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Audit]: %s", ts.URL)
+
+	ts.SetMiddlewares(
+		RouteMiddleware,
+		AuditMiddleware,
+		BindMiddleware,
+		ActionMiddleware,
+	)
+
+	t.Log("Audit route")
+	payload := `{"first_name":"Jeeva","email":"jeeva@example.com","card":{"number":"4111111111111111","cvv":"123"}}`
+	req, _ := http.NewRequest(ahttp.MethodPost, ts.URL+"/create-record-audit", strings.NewReader(payload))
+	req.Header.Set(ahttp.HeaderContentType, ahttp.ContentTypeJSON.String())
+	r := ts.Do(req).AssertStatus(http.StatusOK)
+	assert.True(t, strings.Contains(r.BodyString(), `"first_name":"Jeeva"`), "handler parsed the payload")
+
+	t.Log("Not an audit route")
+	req, _ = http.NewRequest(ahttp.MethodPost, ts.URL+"/create-record", strings.NewReader(payload))
+	req.Header.Set(ahttp.HeaderContentType, ahttp.ContentTypeJSON.String())
+	ts.Do(req).AssertStatus(http.StatusOK)
+
+	b, err := ioutil.ReadFile(auditFile)
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	assert.Equal(t, 1, len(lines))
+	assert.False(t, strings.Contains(lines[0], "4111111111111111"))
+
+	var entry struct {
+		Route    string
+		Method   string
+		Path     string
+		Status   int
+		Request  map[string]interface{}
+		Response map[string]interface{}
+	}
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "create_record_audit", entry.Route)
+	assert.Equal(t, ahttp.MethodPost, entry.Method)
+	assert.Equal(t, "/create-record-audit", entry.Path)
+	assert.Equal(t, http.StatusOK, entry.Status)
+	assert.Equal(t, float64(len(payload)), entry.Request["size"])
+	assert.Equal(t, map[string]interface{}{"number": "******", "cvv": "******"},
+		entry.Request["body"].(map[string]interface{})["card"])
+	assert.Equal(t, "jeeva@example.com", entry.Request["body"].(map[string]interface{})["email"])
+	assert.Equal(t, "application/json", entry.Response["content_type"])
+	assert.Equal(t, "JSON Payload recevied successfully", entry.Response["body"].(map[string]interface{})["message"])
+
+	t.Log("Reply is not committed by audit middleware")
+	ts.SetMiddlewares(
+		RouteMiddleware,
+		func(ctx *Context, m *Middleware) {
+			m.Next(ctx)
+			ctx.Reply().Header("X-Audit-Outer", "true")
+		},
+		AuditMiddleware,
+		BindMiddleware,
+		ActionMiddleware,
+	)
+	req, _ = http.NewRequest(ahttp.MethodPost, ts.URL+"/create-record-audit", strings.NewReader(payload))
+	req.Header.Set(ahttp.HeaderContentType, ahttp.ContentTypeJSON.String())
+	ts.Do(req).AssertStatus(http.StatusOK).AssertHeader("X-Audit-Outer", "true")
+
+	b, err = ioutil.ReadFile(auditFile)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(strings.Split(strings.TrimSpace(string(b)), "\n")))
+
+	t.Log("Reinitialize closes previous audit log file")
+	prev := ts.app.auditLog
+	assert.Nil(t, ts.app.initAuditLog())
+	assert.False(t, prev == ts.app.auditLog)
+	prev.logger.Print("written after close")
+	b, err = ioutil.ReadFile(auditFile)
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(string(b), "written after close"))
+}
+
+func TestAuditRedact(t *testing.T) {
+	paths, err := parseRedactPaths([]string{"card.number", "items.secret", "users.*.password", "*.token", "pin"})
+	assert.Nil(t, err)
+	al := &auditLogger{redact: paths, mask: "***"}
+
+	b := al.redactJSON([]byte(`{
+		"card": {"number": "4111", "expiry": "12/30"},
+		"items": [{"id": 1, "secret": "s1"}, {"id": 2, "secret": "s2"}, "plain"],
+		"users": {"u1": {"password": "p1"}, "u2": {"name": "n2"}},
+		"session": {"token": "t1"},
+		"amount": 10.50
+	}`))
+	assert.Equal(t, `{"amount":10.50,"card":{"expiry":"12/30","number":"***"},`+
+		`"items":[{"id":1,"secret":"***"},{"id":2,"secret":"***"},"plain"],"session":{"token":"***"},`+
+		`"users":{"u1":{"password":"***"},"u2":{"name":"n2"}}}`, string(b))
+
+	b = al.redactJSON([]byte(`[{"pin": "1234"}, {"pin": "5678"}]`))
+	assert.Equal(t, `[{"pin":"***"},{"pin":"***"}]`, string(b))
+
+	assert.Nil(t, al.redactJSON([]byte(`{"card": {"number": "4111"`)))
+
+	b = al.redactForm([]byte("pin=1234&card.number=4111&name=jeeva"))
+	assert.Equal(t, `{"card.number":["***"],"name":["jeeva"],"pin":["***"]}`, string(b))
+
+	ab := al.body(ahttp.ContentTypePlainText.String(), []byte("card number 4111"))
+	assert.Equal(t, 16, ab.Size)
+	assert.Nil(t, ab.Body)
+
+	_, err = parseRedactPaths([]string{"card..number"})
+	assert.Equal(t, "'server.audit_log.redact' unsupported value: card..number", err.Error())
+}
@@ -5,17 +5,22 @@
 package aah
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/url"
 	"reflect"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 
 	"aahframe.work/ahttp"
 	"aahframe.work/essentials"
 	"aahframe.work/valpar"
+	"golang.org/x/text/encoding/htmlindex"
 )
 
 const (
@@ -49,6 +54,7 @@ func BindMiddleware(ctx *Context, m *Middleware) {
 			ctx.Req.QueryValue(ctx.a.bindMgr.keyQueryParamName),
 			ctx.Req.PathValue(ctx.a.bindMgr.keyPathParamName)); len(locale) > 0 {
 			ctx.Req.SetLocale(ahttp.NewLocale(locale))
+			ctx.logger = nil // re-create with overridden locale field
 		}
 	}
 
@@ -56,9 +62,20 @@ func BindMiddleware(ctx *Context, m *Middleware) {
 	if ctx.a.bindMgr.payloadSupported.MatchString(ctx.Req.Method) {
 		ctx.Log().Debugf("Request Content-Type mime: %s", ctx.Req.ContentType())
 
+		// Route consumes, it takes precedence over content negotiation
+		// accepted content types
+		routeConsumes := ctx.route != nil && len(ctx.route.Consumes) > 0
+		if routeConsumes {
+			if !ess.IsSliceContainsString(ctx.route.Consumes, ctx.Req.ContentType().Mime) {
+				ctx.Log().Warnf("Content type '%v' not consumed by route '%s'", ctx.Req.ContentType(), ctx.route.Name)
+				ctx.Reply().UnsupportedMediaType().Error(newError(ErrContentTypeNotAccepted, http.StatusUnsupportedMediaType))
+				return
+			}
+		}
+
 		// Content Negotitaion - Accepted & Offered, refer to GitHub #75
 		if ctx.a.bindMgr.contentNegotiationEnabled {
-			if len(ctx.a.bindMgr.acceptedContentTypes) > 0 &&
+			if !routeConsumes && len(ctx.a.bindMgr.acceptedContentTypes) > 0 &&
 				!ess.IsSliceContainsString(ctx.a.bindMgr.acceptedContentTypes, ctx.Req.ContentType().Mime) {
 				ctx.Log().Warnf("Content type '%v' not accepted by server", ctx.Req.ContentType())
 				ctx.Reply().UnsupportedMediaType().Error(newError(ErrContentTypeNotAccepted, http.StatusUnsupportedMediaType))
@@ -77,6 +94,13 @@ func BindMiddleware(ctx *Context, m *Middleware) {
 		// TODO: integrate the max bytes reader error into aah error handling flow
 		ctx.Req.Unwrap().Body = http.MaxBytesReader(ctx.Res, ctx.Req.Body(), ctx.route.MaxBodySize)
 
+		// Request body decompression `request.decompress.*`
+		if ctx.a.bindMgr.decompressEnabled {
+			if res := decompressBody(ctx); res == flowAbort {
+				return
+			}
+		}
+
 		// Set the tee reader if dump log enabled with request body enabled
 		if ctx.a.settings.DumpLogEnabled && ctx.a.dumpLog.logRequestBody {
 			reqBuf := acquireBuffer()
@@ -84,6 +108,20 @@ func BindMiddleware(ctx *Context, m *Middleware) {
 			ctx.Set(keyAahRequestBodyBuf, reqBuf)
 		}
 
+		// Request body charset transcoding `request.transcode_body.*`
+		if ctx.a.bindMgr.transcodeEnabled && isTextualContentType(ctx.Req.ContentType()) {
+			if res := transcodeBody(ctx); res == flowAbort {
+				return
+			}
+		}
+
+		// Request body must be UTF-8 `request.enforce_utf8`
+		if ctx.a.settings.EnforceUTF8 && isTextualContentType(ctx.Req.ContentType()) {
+			if res := validateUTF8Body(ctx); res == flowAbort {
+				return
+			}
+		}
+
 		// Parse request content by Content-Type
 		if parser, found := ctx.a.bindMgr.requestParsers[ctx.Req.ContentType().Mime]; found {
 			if res := parser(ctx); res == flowAbort {
@@ -110,6 +148,26 @@ func (a *Application) initBind() error {
 		payloadSupported:          regexp.MustCompile(`(POST|PUT|DELETE)`),
 	}
 
+	// Request body charset transcoding
+	bindMgr.transcodeEnabled = cfg.BoolDefault("request.transcode_body.enable", false)
+	bindMgr.transcodeCharset = strings.ToLower(cfg.StringDefault("request.transcode_body.default_charset", "utf-8"))
+	if bindMgr.transcodeEnabled && !isUTF8Charset(bindMgr.transcodeCharset) {
+		if _, err := htmlindex.Get(bindMgr.transcodeCharset); err != nil {
+			return fmt.Errorf("'request.transcode_body.default_charset' unsupported value: %s", bindMgr.transcodeCharset)
+		}
+	}
+
+	// Request body decompression
+	bindMgr.decompressEnabled = cfg.BoolDefault("request.decompress.enable", false)
+	if maxSizeStr := cfg.StringDefault("request.decompress.max_size", ""); len(maxSizeStr) > 0 {
+		maxSize, err := ess.StrToBytes(maxSizeStr)
+		if err != nil {
+			return fmt.Errorf("'request.decompress.max_size' unsupported value: %s", maxSizeStr)
+		}
+		bindMgr.decompressLimit.MaxSize = maxSize
+	}
+	bindMgr.decompressLimit.MaxRatio = int64(cfg.IntDefault("request.decompress.max_ratio", 100))
+
 	// Content Negotitaion, GitHub #75
 	bindMgr.acceptedContentTypes, _ = cfg.StringList("request.content_negotiation.accepted")
 	for idx, v := range bindMgr.acceptedContentTypes {
@@ -166,6 +224,10 @@ type bindManager struct {
 	autobindPriority          []string
 	requestParsers            map[string]requestParser
 	payloadSupported          *regexp.Regexp
+	transcodeEnabled          bool
+	transcodeCharset          string
+	decompressEnabled         bool
+	decompressLimit           ahttp.DecompressLimit
 }
 
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
@@ -173,8 +235,17 @@ type bindManager struct {
 //______________________________________________________________________________
 
 func multipartFormParser(ctx *Context) flowResult {
-	if err := ctx.Req.Unwrap().ParseMultipartForm(ctx.route.MaxBodySize); err != nil {
+	if err := ctx.Req.ParseMultipartForm(ctx.route.MaxBodySize); err != nil {
+		if err == ahttp.ErrMultipartPartsExceeded {
+			ctx.Log().Warnf("Multipart parts count exceeds the limit of %d, Path: %s", ctx.a.settings.MultipartMaxParts, ctx.Req.Path)
+			ctx.Reply().BadRequest().Error(newError(ErrMultipartPartsExceeded, http.StatusBadRequest))
+			return flowAbort
+		}
 		ctx.Log().Errorf("Unable to parse multipart form: %s", err)
+		if isBodyTooLarge(err) {
+			replyBodyTooLarge(ctx)
+			return flowAbort
+		}
 	}
 	return flowCont
 }
@@ -182,10 +253,162 @@ func multipartFormParser(ctx *Context) flowResult {
 func formParser(ctx *Context) flowResult {
 	if err := ctx.Req.Unwrap().ParseForm(); err != nil {
 		ctx.Log().Errorf("Unable to parse form: %s", err)
+		if isBodyTooLarge(err) {
+			replyBodyTooLarge(ctx)
+			return flowAbort
+		}
+	}
+	return flowCont
+}
+
+// validateUTF8Body method rejects the request body with `400 Bad Request`,
+// if it declares non UTF-8 charset or contains invalid UTF-8 bytes.
+func validateUTF8Body(ctx *Context) flowResult {
+	if cs := strings.ToLower(ctx.Req.ContentType().Charset("")); len(cs) > 0 && !isUTF8Charset(cs) {
+		ctx.Log().Warnf("Request body charset '%s' is not UTF-8, Path: %s", cs, ctx.Req.Path)
+		ctx.Reply().BadRequest().Error(newError(ErrInvalidCharset, http.StatusBadRequest))
+		return flowAbort
+	}
+
+	body, ok := readBodyBytes(ctx)
+	if !ok {
+		return flowAbort
+	}
+
+	if !utf8.Valid(body) {
+		ctx.Log().Warnf("Request body contains invalid UTF-8, Path: %s", ctx.Req.Path)
+		ctx.Reply().BadRequest().Error(newError(ErrInvalidCharset, http.StatusBadRequest))
+		return flowAbort
+	}
+	return flowCont
+}
+
+// transcodeBody method transcodes the request body from declared charset or
+// `request.transcode_body.default_charset` to UTF-8. Unsupported charset is
+// rejected with `400 Bad Request`.
+func transcodeBody(ctx *Context) flowResult {
+	ct := ctx.Req.ContentType()
+	cs := strings.ToLower(strings.TrimSpace(ct.Charset(ctx.a.bindMgr.transcodeCharset)))
+	if len(cs) == 0 || isUTF8Charset(cs) {
+		return flowCont
+	}
+
+	enc, err := htmlindex.Get(cs)
+	if err != nil {
+		ctx.Log().Warnf("Request body charset '%s' is not supported, Path: %s", cs, ctx.Req.Path)
+		ctx.Reply().BadRequest().Error(newError(ErrInvalidCharset, http.StatusBadRequest))
+		return flowAbort
+	}
+	if name, _ := htmlindex.Name(enc); name == "utf-8" {
+		return flowCont
+	}
+
+	body, ok := readBodyBytes(ctx)
+	if !ok {
+		return flowAbort
+	}
+	b, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		ctx.Log().Warnf("Unable to transcode request body from charset '%s': %v, Path: %s", cs, err, ctx.Req.Path)
+		ctx.Reply().BadRequest().Error(newError(ErrInvalidCharset, http.StatusBadRequest))
+		return flowAbort
+	}
+	ctx.Req.SetBody(b)
+
+	// body is UTF-8 now, so does the content type
+	params := map[string]string{}
+	for k, v := range ct.Params {
+		params[k] = v
+	}
+	params["charset"] = "utf-8"
+	ctx.Req.Header.Set(ahttp.HeaderContentType, mime.FormatMediaType(ct.Mime, params))
+	ctx.Req.SetContentType(ahttp.ParseContentType(ctx.Req.Unwrap()))
+	return flowCont
+}
+
+// decompressBody method wraps the request body with gzip reader, if request
+// has `Content-Encoding: gzip`. Decompressed body is limited by
+// `request.decompress.max_size` and `request.decompress.max_ratio`, beyond it
+// read returns `ahttp.ErrDecompressLimitExceeded`. Malformed gzip is rejected
+// with `400 Bad Request`.
+func decompressBody(ctx *Context) flowResult {
+	ce := strings.ToLower(strings.TrimSpace(ctx.Req.Header.Get(ahttp.HeaderContentEncoding)))
+	if ce != "gzip" && ce != "x-gzip" {
+		return flowCont
+	}
+
+	limit := ctx.a.bindMgr.decompressLimit
+	if limit.MaxSize == 0 {
+		limit.MaxSize = ctx.route.MaxBodySize
+	}
+
+	r := ctx.Req.Unwrap()
+	zr, err := ahttp.NewGzipReader(r.Body, limit)
+	if err != nil {
+		ctx.Log().Warnf("Unable to decompress request body: %v, Path: %s", err, ctx.Req.Path)
+		ctx.Reply().BadRequest().Error(newError(ErrInvalidContentEncoding, http.StatusBadRequest))
+		return flowAbort
 	}
+
+	r.Body = zr
+	r.ContentLength = -1
+	r.Header.Del(ahttp.HeaderContentEncoding)
+	r.Header.Del(ahttp.HeaderContentLength)
 	return flowCont
 }
 
+// readBodyBytes method reads the request body, on error it replies
+// `413 Request Entity Too Large` or `400 Bad Request` and returns false.
+func readBodyBytes(ctx *Context) ([]byte, bool) {
+	body, err := ctx.Req.BodyBytes()
+	if err != nil {
+		ctx.Log().Errorf("Unable to read request body: %v", err)
+		if isBodyTooLarge(err) {
+			replyBodyTooLarge(ctx)
+		} else {
+			ctx.Reply().BadRequest().Error(newError(ErrInvalidRequestParameter, http.StatusBadRequest))
+		}
+		return nil, false
+	}
+	return body, true
+}
+
+// replyBodyTooLarge method replies `413 Request Entity Too Large`.
+func replyBodyTooLarge(ctx *Context) {
+	ctx.Reply().Status(http.StatusRequestEntityTooLarge).
+		Error(newError(ahttp.ErrRequestBodyTooLarge, http.StatusRequestEntityTooLarge))
+}
+
+// isBodyTooLarge method returns true if the request body read error is due
+// to max body size or decompression limit.
+func isBodyTooLarge(err error) bool {
+	var mbErr *http.MaxBytesError
+	return errors.As(err, &mbErr) || errors.Is(err, ahttp.ErrRequestBodyTooLarge) ||
+		errors.Is(err, ahttp.ErrDecompressLimitExceeded)
+}
+
+func isUTF8Charset(cs string) bool {
+	return cs == "utf-8" || cs == "utf8" || cs == "us-ascii"
+}
+
+// isTextualContentType method returns true if given content type is text
+// based, i.e. `text/*`, JSON, XML, Form or it declares the charset. Multipart
+// is not, since it may have binary parts.
+func isTextualContentType(ct *ahttp.ContentType) bool {
+	if strings.HasPrefix(ct.Mime, "multipart/") {
+		return false
+	}
+	if _, found := ct.Params["charset"]; found {
+		return true
+	}
+	switch ct.Mime {
+	case ahttp.ContentTypeJSON.Mime, ahttp.ContentTypeXML.Mime, ahttp.ContentTypeForm.Mime:
+		return true
+	}
+	return strings.HasPrefix(ct.Mime, "text/") ||
+		strings.HasSuffix(ct.Mime, "+json") || strings.HasSuffix(ct.Mime, "+xml")
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Context - Action Parameters Auto Parse
 //______________________________________________________________________________
@@ -222,6 +445,10 @@ func (ctx *Context) parseParameters() ([]reflect.Value, *Error) {
 				ctx.Log().Errorf("Parsed parameter value is invalid or value parser not found [param: %s, type: %s]",
 					val.Name, val.Type)
 			}
+			if isBodyTooLarge(err) {
+				ctx.Log().Errorf("Unable to read request body: %v", err)
+				return nil, newError(ahttp.ErrRequestBodyTooLarge, http.StatusRequestEntityTooLarge)
+			}
 			return nil, newErrorWithData(ErrInvalidRequestParameter, http.StatusBadRequest, err)
 		}
 
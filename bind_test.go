@@ -5,10 +5,15 @@
 package aah
 
 import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -17,6 +22,7 @@ import (
 	"aahframe.work/ahttp"
 	"aahframe.work/config"
 	"aahframe.work/essentials"
+	"aahframe.work/internal/testutil"
 	"aahframe.work/log"
 	"github.com/stretchr/testify/assert"
 )
@@ -59,6 +65,42 @@ func TestBindParamContentNegotiation(t *testing.T) {
 	assert.Equal(t, http.StatusNotAcceptable, ctx2.Reply().err.Code)
 }
 
+func TestBindRouteConsumesProduces(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Route Consumes and Produces]: %s", ts.URL)
+
+	// consumes
+	ts.PostJSON("/create-record-strict", sampleJSON{FirstName: "My firstname"}).
+		AssertStatus(http.StatusOK).
+		AssertJSONPath("data.first_name", "My firstname")
+
+	req, _ := http.NewRequest(ahttp.MethodPost, ts.URL+"/create-record-strict", strings.NewReader("first_name=jeeva"))
+	req.Header.Set(ahttp.HeaderContentType, ahttp.ContentTypeForm.String())
+	ts.Do(req).AssertStatus(http.StatusUnsupportedMediaType)
+
+	// produces
+	resp := ts.Get("/action-result-xml/value")
+	resp.AssertStatus(http.StatusOK)
+	resp.AssertHeader(ahttp.HeaderContentType, "application/xml; charset=utf-8")
+
+	req, _ = http.NewRequest(ahttp.MethodGet, ts.URL+"/action-result-xml/value", nil)
+	req.Header.Set(ahttp.HeaderAccept, ahttp.ContentTypeJSON.String())
+	ts.Do(req).AssertHeader(ahttp.HeaderContentType, "application/json; charset=utf-8")
+
+	routes := ts.app.Router().RootDomain().Routes()
+	for _, r := range routes {
+		switch r.Name {
+		case "create_record_strict":
+			assert.Equal(t, []string{"application/json"}, r.Consumes)
+		case "action_result_xml":
+			assert.Equal(t, "application/xml", r.Produces)
+		}
+	}
+}
+
 func TestBindAddValueParser(t *testing.T) {
 	app := newApp()
 	err := app.AddValueParser(reflect.TypeOf(time.Time{}), func(key string, typ reflect.Type, params url.Values) (reflect.Value, error) {
@@ -127,3 +169,199 @@ func TestBindParamTemplateFuncs(t *testing.T) {
 	v3 := a.viewMgr.tmplPathParam(viewArgs, "userId")
 	assert.Equal(t, "100001", v3)
 }
+
+func TestBindMultipartMaxParts(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{"request.multipart_max_parts": 5})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Multipart Max Parts]: %s", ts.URL)
+
+	post := func(parts int) *testutil.Result {
+		buf := new(bytes.Buffer)
+		w := multipart.NewWriter(buf)
+		assert.Nil(t, w.WriteField("id", "1000001"))
+		for i := 1; i < parts; i++ {
+			assert.Nil(t, w.WriteField(fmt.Sprintf("field%d", i), "value"))
+		}
+		ess.CloseQuietly(w)
+		req, err := http.NewRequest(ahttp.MethodPost, ts.URL+"/form-submit-no-csrf", buf)
+		assert.Nil(t, err)
+		req.Header.Set(ahttp.HeaderContentType, w.FormDataContentType())
+		return ts.Do(req)
+	}
+
+	t.Log("Multipart parts within limit")
+	post(5).AssertStatus(http.StatusOK).AssertJSONPath("id", 1000001)
+
+	t.Log("Multipart parts exceeds limit")
+	post(500).AssertStatus(http.StatusBadRequest)
+}
+
+func TestBindEnforceUTF8(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{"request.enforce_utf8": true})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Enforce UTF-8]: %s", ts.URL)
+
+	ts.SetMiddlewares(
+		RouteMiddleware,
+		BindMiddleware,
+		ActionMiddleware,
+	)
+
+	post := func(ct, body string) *testutil.Result {
+		req, err := http.NewRequest(ahttp.MethodPost, ts.URL+"/create-record", strings.NewReader(body))
+		assert.Nil(t, err)
+		req.Header.Set(ahttp.HeaderContentType, ct)
+		return ts.Do(req)
+	}
+
+	t.Log("Valid UTF-8 body")
+	post(ahttp.ContentTypeJSON.String(), `{"first_name":"Jéeva"}`).
+		AssertStatus(http.StatusOK).AssertJSONPath("data.first_name", "Jéeva")
+	post(ahttp.ContentTypeJSON.Mime, `{"first_name":"Jeeva"}`).AssertStatus(http.StatusOK)
+
+	t.Log("Non UTF-8 charset")
+	post("application/json; charset=iso-8859-1", `{"first_name":"Jeeva"}`).AssertStatus(http.StatusBadRequest)
+
+	t.Log("Invalid UTF-8 bytes")
+	post(ahttp.ContentTypeJSON.Mime, "{\"first_name\":\"J\xe9eva\"}").AssertStatus(http.StatusBadRequest)
+
+	t.Log("Not a text body")
+	post("application/octet-stream", "\xff\xfe").AssertStatus(http.StatusOK)
+
+	t.Log("Disabled")
+	ts.app.settings.EnforceUTF8 = false
+	post(ahttp.ContentTypeJSON.Mime, "{\"first_name\":\"J\xe9eva\"}").AssertStatus(http.StatusOK)
+}
+
+func TestBindTranscodeBody(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"request.transcode_body.enable":          true,
+		"request.transcode_body.default_charset": "iso-8859-1",
+		"request.enforce_utf8":                   true,
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Transcode Body]: %s", ts.URL)
+
+	ts.SetMiddlewares(
+		RouteMiddleware,
+		BindMiddleware,
+		ActionMiddleware,
+	)
+
+	post := func(ct, body string) *testutil.Result {
+		req, err := http.NewRequest(ahttp.MethodPost, ts.URL+"/create-record", strings.NewReader(body))
+		assert.Nil(t, err)
+		req.Header.Set(ahttp.HeaderContentType, ct)
+		return ts.Do(req)
+	}
+
+	t.Log("Declared charset")
+	post("application/json; charset=iso-8859-1", "{\"first_name\":\"J\xe9eva\"}").
+		AssertStatus(http.StatusOK).AssertJSONPath("data.first_name", "Jéeva")
+	post("application/json; charset=shift_jis", "{\"first_name\":\"\x93\xfa\x96\x7b\"}").
+		AssertStatus(http.StatusOK).AssertJSONPath("data.first_name", "日本")
+
+	t.Log("Default charset")
+	post(ahttp.ContentTypeJSON.Mime, "{\"first_name\":\"J\xe9eva\"}").
+		AssertStatus(http.StatusOK).AssertJSONPath("data.first_name", "Jéeva")
+
+	t.Log("UTF-8 body as-is")
+	post(ahttp.ContentTypeJSON.String(), `{"first_name":"Jéeva"}`).
+		AssertStatus(http.StatusOK).AssertJSONPath("data.first_name", "Jéeva")
+
+	t.Log("Unsupported charset")
+	post("application/json; charset=x-unknown", `{"first_name":"Jeeva"}`).AssertStatus(http.StatusBadRequest)
+
+	t.Log("Invalid default charset")
+	a := newTestApp(t, importPath)
+	a.Config().SetBool("request.transcode_body.enable", true)
+	a.Config().SetString("request.transcode_body.default_charset", "x-unknown")
+	err := a.initBind()
+	assert.Equal(t, "'request.transcode_body.default_charset' unsupported value: x-unknown", err.Error())
+}
+
+func TestBindDecompressBody(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"request.decompress.enable": true,
+		"request.max_body_size":     "1kb",
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Decompress Body]: %s", ts.URL)
+
+	ts.SetMiddlewares(
+		RouteMiddleware,
+		BindMiddleware,
+		ActionMiddleware,
+	)
+
+	post := func(encoding string, body []byte) *testutil.Result {
+		req, err := http.NewRequest(ahttp.MethodPost, ts.URL+"/create-record", bytes.NewReader(body))
+		assert.Nil(t, err)
+		req.Header.Set(ahttp.HeaderContentType, ahttp.ContentTypeJSON.String())
+		req.Header.Set(ahttp.HeaderContentEncoding, encoding)
+		return ts.Do(req)
+	}
+	gzipBytes := func(s string) []byte {
+		buf := new(bytes.Buffer)
+		zw := gzip.NewWriter(buf)
+		_, _ = zw.Write([]byte(s))
+		_ = zw.Close()
+		return buf.Bytes()
+	}
+
+	t.Log("Gzipped body")
+	post("gzip", gzipBytes(`{"first_name":"Jeeva"}`)).
+		AssertStatus(http.StatusOK).AssertJSONPath("data.first_name", "Jeeva")
+
+	t.Log("Malformed gzip body")
+	post("gzip", []byte(`{"first_name":"Jeeva"}`)).AssertStatus(http.StatusBadRequest)
+
+	t.Log("Decompressed body exceeds max body size")
+	body := gzipBytes(`{"first_name":"` + strings.Repeat("a", 2048) + `"}`)
+	assert.True(t, len(body) < 1024)
+	r := post("gzip", body).AssertStatus(http.StatusRequestEntityTooLarge)
+	assert.NotContains(t, r.BodyString(), "aaaa")
+
+	t.Log("Decompressed body exceeds max size")
+	ts.app.bindMgr.decompressLimit.MaxSize = 16
+	post("gzip", gzipBytes(`{"first_name":"Jeeva"}`)).AssertStatus(http.StatusRequestEntityTooLarge)
+	ts.app.bindMgr.decompressLimit.MaxSize = 0
+
+	t.Log("Gzipped form bomb")
+	postForm := func(ct string, body []byte) *testutil.Result {
+		req, err := http.NewRequest(ahttp.MethodPost, ts.URL+"/form-submit-no-csrf", bytes.NewReader(body))
+		assert.Nil(t, err)
+		req.Header.Set(ahttp.HeaderContentType, ct)
+		req.Header.Set(ahttp.HeaderContentEncoding, "gzip")
+		return ts.Do(req)
+	}
+	body = gzipBytes("first_name=" + strings.Repeat("a", 64*1024))
+	assert.True(t, len(body) < 1024)
+	postForm(ahttp.ContentTypeForm.String(), body).AssertStatus(http.StatusRequestEntityTooLarge)
+
+	mbuf := new(bytes.Buffer)
+	mw := multipart.NewWriter(mbuf)
+	_ = mw.WriteField("first_name", strings.Repeat("a", 64*1024))
+	_ = mw.Close()
+	body = gzipBytes(mbuf.String())
+	assert.True(t, len(body) < 1024)
+	postForm(mw.FormDataContentType(), body).AssertStatus(http.StatusRequestEntityTooLarge)
+
+	t.Log("Other encodings as-is")
+	post("identity", []byte(`{"first_name":"Jeeva"}`)).
+		AssertStatus(http.StatusOK).AssertJSONPath("data.first_name", "Jeeva")
+
+	t.Log("Invalid max size")
+	a := newTestApp(t, importPath)
+	a.Config().SetString("request.decompress.max_size", "10xb")
+	err := a.initBind()
+	assert.Equal(t, "'request.decompress.max_size' unsupported value: 10xb", err.Error())
+}
@@ -0,0 +1,98 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock interface is used to abstract the time source of aah framework.
+// Default implementation uses the wall-clock time, it can be replaced via
+// `aah.App().SetClock(...)` for deterministic tests of time-dependent behavior.
+//
+// Clock is used by the framework for request elapsed time, response cache,
+// cookie and session expiry, anti-CSRF and OAuth2 state timestamp and
+// scheduled jobs activation time.
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock implements Clock interface using wall-clock time.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// FakeClock implements Clock interface for the tests, time moves only when
+// it is advanced or set. It is safe for concurrent use.
+//
+//	fc := aah.NewFakeClock(time.Date(2018, time.October, 10, 10, 10, 10, 0, time.UTC))
+//	aah.App().SetClock(fc)
+//	fc.Advance(30 * time.Minute)
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock method creates a fake clock with given start time.
+func NewFakeClock(t time.Time) *FakeClock {
+	return &FakeClock{now: t}
+}
+
+// Now method returns the current time of fake clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance method moves the fake clock forward by given duration.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set method sets the fake clock to given time.
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Application methods
+//______________________________________________________________________________
+
+// Clock method returns the clock instance used by aah framework.
+func (a *Application) Clock() Clock {
+	a.clockMu.RLock()
+	defer a.clockMu.RUnlock()
+	return a.clock
+}
+
+// SetClock method is to set the clock instance into aah application,
+// for e.g.: fake clock in the tests. Nil value resets it to wall-clock.
+// It is safe to call while the application is serving requests.
+func (a *Application) SetClock(c Clock) {
+	if c == nil {
+		c = realClock{}
+	}
+	a.clockMu.Lock()
+	defer a.clockMu.Unlock()
+	a.clock = c
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// app Unexported methods
+//______________________________________________________________________________
+
+// now method returns the current time of application clock, it is used as
+// time source func for the framework libraries.
+func (a *Application) now() time.Time {
+	return a.Clock().Now()
+}
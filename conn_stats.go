@@ -0,0 +1,146 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// ConnStats struct holds the connection counts of aah server tracked via
+// `http.Server.ConnState`. Fields `New`, `Active` and `Idle` are the current
+// counts and `Accepted`, `Hijacked` and `Closed` are the cumulative counts
+// since server start.
+type ConnStats struct {
+	New      int64 `json:"new"`
+	Active   int64 `json:"active"`
+	Idle     int64 `json:"idle"`
+	Accepted int64 `json:"accepted"`
+	Hijacked int64 `json:"hijacked"`
+	Closed   int64 `json:"closed"`
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Application methods
+//______________________________________________________________________________
+
+// ConnStats method returns the snapshot of aah server connection counts, it
+// helps to diagnose connection leaks and keep-alive behavior. Counts are
+// reset on server start.
+func (a *Application) ConnStats() ConnStats {
+	a.RLock()
+	defer a.RUnlock()
+	return a.connTracker.stats()
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// connTracker and its methods
+//______________________________________________________________________________
+
+type connTracker struct {
+	states   sync.Map // net.Conn => http.ConnState
+	pending  sync.Map // net.Conn => struct{}, request read yet to be handled
+	new      int64
+	active   int64
+	idle     int64
+	accepted int64
+	hijacked int64
+	closed   int64
+}
+
+func (ct *connTracker) stats() ConnStats {
+	if ct == nil {
+		return ConnStats{}
+	}
+	return ConnStats{
+		New:      atomic.LoadInt64(&ct.new),
+		Active:   atomic.LoadInt64(&ct.active),
+		Idle:     atomic.LoadInt64(&ct.idle),
+		Accepted: atomic.LoadInt64(&ct.accepted),
+		Hijacked: atomic.LoadInt64(&ct.hijacked),
+		Closed:   atomic.LoadInt64(&ct.closed),
+	}
+}
+
+// track method is the `http.Server.ConnState` hook, it moves the connection
+// count from previous state to given state.
+//
+// It returns true when the connection is closed after the request bytes were
+// read, however request was never handled by aah. It happens when Go HTTP
+// server rejects the request prior to invoking the handler, for e.g.: header
+// exceeds `server.max_header_bytes` (431), malformed request (400) or read
+// timeout.
+func (ct *connTracker) track(c net.Conn, state http.ConnState) bool {
+	if prev, found := ct.states.Load(c); found {
+		if p := ct.current(prev.(http.ConnState)); p != nil {
+			atomic.AddInt64(p, -1)
+		}
+	}
+
+	var unhandled bool
+	switch state {
+	case http.StateNew:
+		atomic.AddInt64(&ct.accepted, 1)
+	case http.StateActive:
+		// HTTP/2 connection multiplexes the requests, it is not tracked
+		if tc, ok := c.(*tls.Conn); !ok || tc.ConnectionState().NegotiatedProtocol != "h2" {
+			ct.pending.Store(c, struct{}{})
+		}
+	case http.StateIdle:
+		ct.pending.Delete(c)
+	case http.StateHijacked:
+		atomic.AddInt64(&ct.hijacked, 1)
+		ct.pending.Delete(c)
+	case http.StateClosed:
+		atomic.AddInt64(&ct.closed, 1)
+		_, unhandled = ct.pending.LoadAndDelete(c)
+	}
+
+	if p := ct.current(state); p != nil {
+		atomic.AddInt64(p, 1)
+		ct.states.Store(c, state)
+	} else {
+		// hijacked and closed connections are no longer tracked by server
+		ct.states.Delete(c)
+	}
+	return unhandled
+}
+
+// connContext method is the `http.Server.ConnContext` hook, it makes the
+// connection available to `connHandled` via request context.
+func (ct *connTracker) connContext(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connCtxKey{}, &trackedConn{ct: ct, c: c})
+}
+
+type connCtxKey struct{}
+
+type trackedConn struct {
+	ct *connTracker
+	c  net.Conn
+}
+
+// connHandled method marks the connection of given request as handled,
+// refer to `connTracker.track`.
+func connHandled(r *http.Request) {
+	if tc, ok := r.Context().Value(connCtxKey{}).(*trackedConn); ok {
+		tc.ct.pending.Delete(tc.c)
+	}
+}
+
+func (ct *connTracker) current(state http.ConnState) *int64 {
+	switch state {
+	case http.StateNew:
+		return &ct.new
+	case http.StateActive:
+		return &ct.active
+	case http.StateIdle:
+		return &ct.idle
+	}
+	return nil
+}
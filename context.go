@@ -5,19 +5,24 @@
 package aah
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/url"
 	"reflect"
 	"strings"
+	"time"
 
 	"aahframe.work/ahttp"
 	"aahframe.work/ainsp"
+	"aahframe.work/aruntime"
+	"aahframe.work/config"
 	"aahframe.work/essentials"
 	"aahframe.work/log"
 	"aahframe.work/router"
 	"aahframe.work/security"
 	"aahframe.work/security/authz"
+	"aahframe.work/security/cookie"
 	"aahframe.work/security/session"
 )
 
@@ -66,6 +71,11 @@ type Context struct {
 	abort      bool
 	decorated  bool
 	logger     log.Loggerer
+	cfg        *config.Config
+	component  string
+	mw         *Middleware
+	trace      *TraceContext
+	start      time.Time
 }
 
 // Reply method gives you control and convenient way to write
@@ -105,6 +115,21 @@ func (ctx *Context) RouteURLNamedArgs(routeName string, args map[string]interfac
 	return ctx.a.Router().CreateRouteURL(ctx.Req.Host, routeName, args)
 }
 
+// NewCookie method returns the cookie for given name and value with the
+// application cookie defaults `security.cookie.*`. Use `Reply().Cookie(...)`
+// to add it into response.
+//
+//	c := ctx.NewCookie("theme", "dark")
+//	c.MaxAge = 86400
+//	ctx.Reply().Cookie(c)
+func (ctx *Context) NewCookie(name, value string) *http.Cookie {
+	opts := *ctx.a.cookieOptions()
+	opts.Name = name
+	c := cookie.NewWithOptions(value, &opts)
+	c.Secure = ctx.isSecureCookie()
+	return c
+}
+
 // Msg method returns the i18n value for given key otherwise empty string returned.
 func (ctx *Context) Msg(key string, args ...interface{}) string {
 	return ctx.Msgl(ctx.Req.Locale(), key, args...)
@@ -217,6 +242,94 @@ func (ctx *Context) SetMethod(method string) {
 	ctx.Req.Method = method
 }
 
+// Elapsed method returns the time elapsed since aah received the request,
+// it is the same measure used by access log. For e.g.: deferred function in
+// the handler could use it after writing the response.
+func (ctx *Context) Elapsed() time.Duration {
+	if ctx.start.IsZero() {
+		return 0
+	}
+	return ctx.a.Clock().Now().Sub(ctx.start)
+}
+
+// BytesWritten method returns the no. of response body bytes written on the
+// wire so far, it is the same measure used by access log. Since aah writes
+// the reply after the controller action, value is `0` within the action
+// unless `ctx.Res` is used directly. For e.g.: billing by response size
+// could be done in `OnPostReply` HTTP engine extension.
+func (ctx *Context) BytesWritten() int {
+	if ctx.Res == nil {
+		return 0
+	}
+	return ctx.Res.BytesWritten()
+}
+
+// Go method runs the given function in a new goroutine with panic recovery,
+// so the background work triggered by the request, for e.g.: sending email,
+// audit entry, cannot crash the aah server. Recovered panic is logged with
+// stacktrace along with request ID and published to `HTTPEngine.OnPanic`
+// callback, same as request panic.
+//
+// Note: It is best-effort, goroutine is not tracked by aah application
+// lifecycle, i.e. shutdown does not wait for it. Use `Application.Go` for
+// long running or lifecycle-managed background work. Context is reused
+// after the request, do not access `ctx` within the function; capture the
+// required values prior to the call.
+//
+//	userID := ctx.Req.PathValue("id")
+//	ctx.Go(func() {
+//	  sendWelcomeEmail(userID)
+//	})
+func (ctx *Context) Go(fn func()) {
+	if fn == nil {
+		return
+	}
+	a, e, logger, uri := ctx.a, ctx.e, ctx.Log(), ctx.Req.URL().RequestURI()
+	var dctx *Context
+	if e != nil && e.onPanicFunc != nil {
+		dctx = ctx.detach()
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Errorf("Recovered from panic in goroutine spawned on %s: %v", uri, r)
+				st := aruntime.NewStacktrace(r, a.Config())
+				buf := acquireBuilder()
+				defer releaseBuilder(buf)
+				st.Print(buf)
+				logger.Error(buf.String())
+				if dctx != nil {
+					e.publishOnPanic(dctx, r, []byte(buf.String()))
+				}
+			}
+		}()
+		fn()
+	}()
+}
+
+// detach method returns the copy of request context, which can be used
+// beyond the request life cycle. Response and reply are not copied.
+func (ctx *Context) detach() *Context {
+	req := *ctx.Req
+	dctx := &Context{
+		a:          ctx.a,
+		Req:        &req,
+		controller: ctx.controller,
+		action:     ctx.action,
+		domain:     ctx.domain,
+		route:      ctx.route,
+		logger:     ctx.logger,
+		trace:      ctx.trace,
+		component:  ctx.component,
+		start:      ctx.start,
+		values:     make(map[string]interface{}, len(ctx.values)),
+	}
+	for k, v := range ctx.values {
+		dctx.values[k] = v
+	}
+	return dctx
+}
+
 // Reset method resets context instance for reuse.
 func (ctx *Context) reset() {
 	ctx.Req = nil
@@ -235,6 +348,11 @@ func (ctx *Context) reset() {
 	ctx.abort = false
 	ctx.decorated = false
 	ctx.logger = nil
+	ctx.cfg = nil
+	ctx.component = ""
+	ctx.mw = nil
+	ctx.trace = nil
+	ctx.start = time.Time{}
 }
 
 // Set method is used to set value for the given key in the current request flow.
@@ -250,14 +368,41 @@ func (ctx *Context) Get(key string) interface{} {
 	return ctx.values[key]
 }
 
-// Log method adds field `Request ID` into current log context and returns
-// the logger.
+// Cfg method returns the application config for current request. Config is
+// captured on first access, so the request reads consistent values even if
+// the config is hot-reloaded in between.
+//
+//	pageSize := ctx.Cfg().IntDefault("app.page_size", 20)
+func (ctx *Context) Cfg() *config.Config {
+	if ctx.cfg == nil {
+		ctx.cfg = ctx.a.Config()
+	}
+	return ctx.cfg
+}
+
+// Context method returns the request context `context.Context`, it carries
+// the request deadline, cancellation and the tracing span if any. Pass it to
+// the downstream calls, for e.g.: DB queries, outbound HTTP requests.
+func (ctx *Context) Context() context.Context {
+	return ctx.Req.Unwrap().Context()
+}
+
+// Log method adds field `Request ID` and trace headers `request.trace_headers`
+// into current log context and returns the logger.
 func (ctx *Context) Log() log.Loggerer {
 	if ctx.logger == nil {
+		fields := log.Fields{}
 		if h := ctx.Req.Header[ctx.a.settings.RequestIDHeaderKey]; len(h) > 0 {
-			ctx.logger = ctx.a.Log().WithFields(log.Fields{
-				"reqid": h[0],
-			})
+			fields["reqid"] = h[0]
+		}
+		if len(ctx.a.settings.TraceHeaders) > 0 {
+			ctx.TraceContext().addLogFields(fields)
+		}
+		if locale := ctx.locale(); len(locale) > 0 {
+			fields["locale"] = locale
+		}
+		if len(fields) > 0 {
+			ctx.logger = ctx.a.Log().WithFields(fields)
 		} else {
 			ctx.logger = ctx.a.Log()
 		}
@@ -269,6 +414,31 @@ func (ctx *Context) Log() log.Loggerer {
 // Context Unexported methods
 //______________________________________________________________________________
 
+// locale method returns the locale served for the request, i.e. request
+// locale resolved against the i18n message store. It returns empty string if
+// i18n is not enabled.
+func (ctx *Context) locale() string {
+	ms := ctx.a.I18n()
+	if ms == nil || ctx.Req == nil {
+		return ""
+	}
+	if r, ok := ms.(interface {
+		Resolve(locale *ahttp.Locale) string
+	}); ok {
+		return r.Resolve(ctx.Req.Locale())
+	}
+
+	// custom message store, request locale is served as-is
+	locale := ctx.Req.Locale()
+	if locale == nil {
+		return ms.DefaultLocale()
+	}
+	if len(locale.Region) > 0 {
+		return locale.Language + "-" + locale.Region
+	}
+	return locale.Language
+}
+
 func (ctx *Context) setRequestID() {
 	h := ctx.Req.Header[ctx.a.settings.RequestIDHeaderKey]
 	if len(h) == 0 {
@@ -315,19 +485,46 @@ func (ctx *Context) setTarget(route *router.Route) error {
 }
 
 func (ctx *Context) detectContentType() string {
+	// as per route 'produces' from routes.conf
+	if ct := ctx.routeProduces(); len(ct) > 0 {
+		return ct
+	}
+
 	// based on HTTP Header 'Accept'
 	acceptContType := ctx.Req.AcceptContentType()
 	if acceptContType.Mime == "" || acceptContType.Mime == "*/*" {
 		// as per 'render.default' from aah.conf
-		return ctx.a.settings.DefaultContentType
+		if ct := ctx.a.settings.DefaultContentType; len(ct) > 0 {
+			return ct
+		}
+
+		// based on application type, so the text response is displayed
+		// instead of download
+		if strings.EqualFold(ctx.a.Type(), "web") {
+			return ahttp.ContentTypeHTML.String()
+		}
+		return ahttp.ContentTypePlainText.String()
 	}
 	return acceptContType.String()
 }
 
+// routeProduces method returns the route 'produces' content type if request
+// does not have HTTP Header 'Accept' or it is '*/*' otherwise empty string.
+func (ctx *Context) routeProduces() string {
+	if ctx.route == nil || len(ctx.route.Produces) == 0 {
+		return ""
+	}
+	if h := ctx.Req.Header[ahttp.HeaderAccept]; len(h) > 0 && len(h[0]) > 0 && h[0] != "*/*" {
+		return ""
+	}
+	return ctx.route.Produces
+}
+
 // writeCookies method writes the user provided cookies and session cookie; also
 // saves the session data into session store if its stateful.
 func (ctx *Context) writeCookies() {
 	for _, c := range ctx.Reply().cookies {
+		ctx.applyCookieDefaults(c)
 		http.SetCookie(ctx.Res, c)
 	}
 
@@ -340,6 +537,32 @@ func (ctx *Context) writeCookies() {
 	}
 }
 
+// applyCookieDefaults method sets the application cookie defaults
+// `security.cookie.*` on the unset fields of given cookie. Secure flag is
+// only turned on, never off.
+func (ctx *Context) applyCookieDefaults(c *http.Cookie) {
+	opts := ctx.a.cookieOptions()
+	if len(c.Path) == 0 {
+		c.Path = opts.Path
+	}
+	if len(c.Domain) == 0 {
+		c.Domain = opts.Domain
+	}
+	if c.SameSite == 0 && len(opts.SameSite) > 0 {
+		c.SameSite = opts.SameSiteMode()
+	}
+	if !c.Secure {
+		c.Secure = ctx.isSecureCookie()
+	}
+}
+
+// isSecureCookie method reports whether the cookie should be marked as secure
+// for current request.
+func (ctx *Context) isSecureCookie() bool {
+	opts := ctx.a.cookieOptions()
+	return opts.Secure || (opts.SecureAuto && ctx.Req.Scheme == ahttp.SchemeHTTPS)
+}
+
 func (ctx *Context) writeHeaders() {
 	if ctx.a.settings.ServerHeaderEnabled {
 		ctx.Res.Header().Set(ahttp.HeaderServer, ctx.a.settings.ServerHeader)
@@ -371,8 +594,19 @@ func (ctx *Context) writeHeaders() {
 
 		// Apply only if HTTPS (SSL)
 		if ctx.a.IsSSLEnabled() {
-			// Strict-Transport-Security (STS, aka HSTS)
-			ctx.Res.Header().Set(ahttp.HeaderStrictTransportSecurity, secureHeaders.STS)
+			// Strict-Transport-Security (STS, aka HSTS), matched domain could
+			// disable or override it
+			sts := secureHeaders.STS
+			if ctx.domain != nil {
+				if !ctx.domain.STSEnabled {
+					sts = ""
+				} else if len(ctx.domain.STS) > 0 {
+					sts = ctx.domain.STS
+				}
+			}
+			if len(sts) > 0 {
+				ctx.Res.Header().Set(ahttp.HeaderStrictTransportSecurity, sts)
+			}
 
 			// Public-Key-Pins PKP (aka HPKP) and applied only to environment `prod`
 			if ctx.a.IsEnvProfile("prod") && len(secureHeaders.PKP) > 0 {
@@ -6,8 +6,11 @@ package aah
 
 import (
 	"io/ioutil"
+	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"aahframe.work/ahttp"
 	"aahframe.work/config"
@@ -105,3 +108,232 @@ func TestContextSetMethod(t *testing.T) {
 	ctx.SetMethod("nomethod")
 	assert.Equal(t, "GET", ctx.Req.Method)
 }
+
+func TestContextAbsURL(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	a := newTestApp(t, importPath)
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/get-text.html", nil)
+	ctx := newContext(nil, req)
+	ctx.a = a
+	assert.Equal(t, "http://localhost:8080/reset-password?token=abc", ctx.AbsURL("/reset-password?token=abc"))
+	assert.Equal(t, "http://localhost:8080/get-text.html", ctx.AbsURLForRoute("text_get", nil))
+
+	ctx.Req.Scheme = "https"
+	assert.Equal(t, "https://localhost:8080/reset-password", ctx.AbsURL("reset-password"))
+	assert.Equal(t, "https://localhost:8080/get-text.html", ctx.AbsURLForRoute("text_get", nil))
+
+	// off-request, derived from root domain
+	assert.Equal(t, "http://localhost:8080/reset-password", a.AbsURL("/reset-password"))
+	assert.Equal(t, "http://localhost:8080/get-text.html", a.AbsURLForRoute("text_get", nil))
+
+	// configured base URL and base path
+	a = newTestAppWithConfig(t, importPath, map[string]interface{}{
+		"server.base_url":  "https://www.example.com/",
+		"server.base_path": "/myapp",
+	})
+	assert.Equal(t, "https://www.example.com/myapp/reset-password", a.AbsURL("/reset-password"))
+	assert.Equal(t, "https://www.example.com/myapp/get-text.html", a.AbsURLForRoute("text_get", nil))
+
+	ctx = newContext(nil, httptest.NewRequest("GET", "http://localhost:8080/get-text.html", nil))
+	ctx.a = a
+	assert.Equal(t, "http://localhost:8080/myapp/reset-password", ctx.AbsURL("/reset-password"))
+	assert.Equal(t, "http://localhost:8080/myapp/get-text.html", ctx.AbsURLForRoute("text_get", nil))
+
+	ctx.Req = nil
+	assert.Equal(t, "https://www.example.com/myapp/reset-password", ctx.AbsURL("/reset-password"))
+}
+
+func TestContextCookieDefaults(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	a := newTestAppWithConfig(t, importPath, map[string]interface{}{
+		"security.cookie.domain":   "localhost",
+		"security.cookie.samesite": "strict",
+	})
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/get-text.html", nil)
+	ctx := newContext(httptest.NewRecorder(), req)
+	ctx.a = a
+
+	c := ctx.NewCookie("theme", "dark")
+	assert.Equal(t, "theme", c.Name)
+	assert.Equal(t, "/", c.Path)
+	assert.Equal(t, "localhost", c.Domain)
+	assert.True(t, c.HttpOnly)
+	assert.False(t, c.Secure)
+	assert.Equal(t, http.SameSiteStrictMode, c.SameSite)
+
+	// user cookie, unset fields gets the defaults and secure over HTTPS
+	ctx.Req.Scheme = ahttp.SchemeHTTPS
+	uc := &http.Cookie{Name: "lang", Value: "en", Path: "/docs"}
+	ctx.Reply().Cookie(uc)
+	ctx.writeCookies()
+	assert.Equal(t, "/docs", uc.Path)
+	assert.Equal(t, "localhost", uc.Domain)
+	assert.True(t, uc.Secure)
+	assert.False(t, uc.HttpOnly)
+	assert.Equal(t, http.SameSiteStrictMode, uc.SameSite)
+	assert.Contains(t, ctx.Res.Header().Get(ahttp.HeaderSetCookie), "lang=en; Path=/docs; Domain=localhost; Secure; SameSite=Strict")
+}
+
+func TestContextCfg(t *testing.T) {
+	a := newApp()
+	a.cfg = config.NewEmpty()
+	a.cfg.SetInt("app.page_size", 20)
+
+	ctx := newContext(nil, httptest.NewRequest("GET", "http://localhost:8080/users", nil))
+	ctx.a = a
+	assert.Equal(t, 20, ctx.Cfg().IntDefault("app.page_size", 10))
+
+	// config reloaded in between, request reads the same snapshot
+	cfg := config.NewEmpty()
+	cfg.SetInt("app.page_size", 50)
+	a.setConfig(cfg)
+	assert.Equal(t, 20, ctx.Cfg().IntDefault("app.page_size", 10))
+
+	ctx.reset()
+	ctx.a = a
+	assert.Equal(t, 50, ctx.Cfg().IntDefault("app.page_size", 10))
+}
+
+func TestContextElapsedAndBytesWritten(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Context Elapsed]: %s", ts.URL)
+
+	tc := NewFakeClock(time.Date(2018, time.October, 10, 10, 10, 10, 0, time.UTC))
+	ts.app.SetClock(tc)
+	defer ts.app.SetClock(nil)
+
+	var elapsed time.Duration
+	var written int
+	ts.app.HTTPEngine().OnPreReply(func(e *Event) {
+		tc.Advance(250 * time.Millisecond)
+	})
+	ts.app.HTTPEngine().OnPostReply(func(e *Event) {
+		ctx := e.Data.(*Context)
+		elapsed, written = ctx.Elapsed(), ctx.BytesWritten()
+	})
+
+	r := ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+	assert.Equal(t, 250*time.Millisecond, elapsed)
+	assert.Equal(t, len(r.BodyString()), written)
+	assert.True(t, written > 0)
+
+	ctx := &Context{a: ts.app}
+	assert.Equal(t, time.Duration(0), ctx.Elapsed())
+	assert.Equal(t, 0, ctx.BytesWritten())
+
+	ctx.start = tc.Now()
+	ctx.reset()
+	assert.True(t, ctx.start.IsZero())
+}
+
+func TestContextGo(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Context Go]: %s", ts.URL)
+
+	type result struct {
+		path      string
+		recovered interface{}
+	}
+	ch := make(chan result, 1)
+	he := ts.app.HTTPEngine()
+	he.OnPanic(func(ctx *Context, r interface{}, stack []byte) {
+		ch <- result{path: ctx.Req.Path, recovered: r}
+	})
+	defer func() { he.onPanicFunc, he.onPreReplyFunc = nil, nil }()
+
+	done := make(chan struct{})
+	he.OnPreReply(func(e *Event) {
+		ctx := e.Data.(*Context)
+		ctx.Go(nil)
+		ctx.Go(func() { close(done) })
+		ctx.Go(func() { panic("goroutine misbehaving") })
+	})
+
+	lr := ts.CaptureLog()
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("goroutine is not executed")
+	}
+	select {
+	case res := <-ch:
+		assert.Equal(t, "/get-text.html", res.path)
+		assert.Equal(t, "goroutine misbehaving", res.recovered)
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnPanic callback is not invoked")
+	}
+	assert.Contains(t, lr.String(), "Recovered from panic in goroutine spawned on /get-text.html: goroutine misbehaving")
+	assert.Contains(t, lr.String(), "STACKTRACE")
+}
+
+func TestContextDetectContentTypeByAppType(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+
+	testcases := []struct {
+		appType, renderDefault, accept, contentType string
+	}{
+		{appType: "web", renderDefault: "bin", contentType: ahttp.ContentTypeHTML.String()},
+		{appType: "api", renderDefault: "bin", contentType: ahttp.ContentTypePlainText.String()},
+		{appType: "web", renderDefault: "unknown", contentType: ahttp.ContentTypeHTML.String()},
+		{appType: "api", renderDefault: "json", contentType: ahttp.ContentTypeJSON.String()},
+		{appType: "web", renderDefault: "bin", accept: "application/xml", contentType: "application/xml"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.appType+" "+tc.renderDefault, func(t *testing.T) {
+			ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+				"type":           tc.appType,
+				"render.default": tc.renderDefault,
+			})
+			defer ts.Close()
+
+			req := httptest.NewRequest("GET", "http://localhost:8080/users", nil)
+			req.Header.Set(ahttp.HeaderAccept, "*/*")
+			if len(tc.accept) > 0 {
+				req.Header.Set(ahttp.HeaderAccept, tc.accept)
+			}
+			ctx := newContext(nil, req)
+			ctx.a = ts.app
+			assert.Equal(t, tc.contentType, ctx.detectContentType())
+		})
+	}
+}
+
+func TestContextRenderDefaultContentType(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+
+	testcases := []struct {
+		renderDefault, contentType string
+	}{
+		{renderDefault: "html", contentType: ahttp.ContentTypeHTML.String()},
+		{renderDefault: "json", contentType: ahttp.ContentTypeJSON.String()},
+		{renderDefault: "xml", contentType: ahttp.ContentTypeXML.String()},
+		{renderDefault: "text", contentType: ahttp.ContentTypePlainText.String()},
+		{renderDefault: "txt", contentType: ahttp.ContentTypePlainText.String()},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.renderDefault, func(t *testing.T) {
+			a := newTestAppWithConfig(t, importPath, map[string]interface{}{
+				"render.default": tc.renderDefault,
+			})
+			assert.Nil(t, a.settings.Refresh(a.Config()))
+			assert.Equal(t, tc.contentType, a.settings.DefaultContentType)
+
+			req := httptest.NewRequest("GET", "http://localhost:8080/users", nil)
+			req.Header.Set(ahttp.HeaderAccept, "*/*")
+			ctx := newContext(nil, req)
+			ctx.a = a
+			assert.Equal(t, tc.contentType, ctx.detectContentType())
+		})
+	}
+}
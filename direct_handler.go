@@ -0,0 +1,69 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"net/http"
+
+	"aahframe.work/ahttp"
+)
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// HTTPEngine methods
+//______________________________________________________________________________
+
+// DirectHandler method registers the lightweight handler for the given
+// request path, it is meant for ultra-low-overhead internal endpoints, for
+// e.g.: liveness probe, metrics. So the endpoint responds even when the
+// application is misbehaving.
+//
+// Request is served right at the beginning of `Application.ServeHTTP` and
+// it bypasses the aah request lifecycle entirely, i.e. redirects, base path,
+// concurrency limit, warm up, request context, events, middlewares, routing,
+// access log and error handling.
+//
+// Direct handlers are matched prior to `server.redirect` check and
+// `server.base_path` stripping, so that probes respond on any host. Path is
+// matched as-is with request URL path, i.e. for base path `/myapp` register
+// the handler as `/myapp/healthz` to serve it under base path.
+//
+// Panic in the handler is recovered, logged in a single line and replies
+// `500 Internal Server Error` without body, if the response is not already
+// committed by the handler.
+//
+// Note: Direct handlers must be registered prior to aah server start.
+//
+//	aah.App().HTTPEngine().DirectHandler("/healthz", func(w http.ResponseWriter, r *http.Request) {
+//	  w.WriteHeader(http.StatusOK)
+//	})
+func (e *HTTPEngine) DirectHandler(path string, handler http.HandlerFunc) {
+	if handler == nil {
+		delete(e.directs, path)
+		return
+	}
+	if e.directs == nil {
+		e.directs = make(map[string]http.HandlerFunc)
+	}
+	e.directs[path] = handler
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// HTTPEngine Unexported methods
+//______________________________________________________________________________
+
+func (e *HTTPEngine) serveDirect(handler http.HandlerFunc, w http.ResponseWriter, r *http.Request) {
+	rw := ahttp.AcquireResponseWriter(w)
+	defer ahttp.ReleaseResponseWriter(rw)
+	defer func() {
+		if rec := recover(); rec != nil {
+			e.Log().Errorf("Direct handler panic on %s: %v", r.URL.Path, rec)
+			// response is already committed, status cannot be changed
+			if !ahttp.IsCommitted(rw) {
+				rw.WriteHeader(http.StatusInternalServerError)
+			}
+		}
+	}()
+	handler(rw, r)
+}
@@ -0,0 +1,119 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPEngineDirectHandler(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Direct Handler]: %s", ts.URL)
+
+	he := ts.app.HTTPEngine()
+	he.DirectHandler("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	he.DirectHandler("/panicz", func(w http.ResponseWriter, r *http.Request) {
+		panic("direct handler panic")
+	})
+
+	var mwCalled bool
+	ts.SetMiddlewares(func(ctx *Context, m *Middleware) {
+		mwCalled = true
+		panic("application is misbehaving")
+	})
+
+	t.Log("Direct handler bypasses middlewares")
+	r := ts.Get("/healthz").AssertStatus(http.StatusOK)
+	assert.Equal(t, "ok", r.BodyString())
+	assert.False(t, mwCalled)
+
+	t.Log("Direct handler panic")
+	lr := ts.CaptureLog()
+	r = ts.Get("/panicz").AssertStatus(http.StatusInternalServerError)
+	assert.Equal(t, "", r.BodyString())
+	assert.Contains(t, lr.String(), "Direct handler panic on /panicz: direct handler panic")
+	assert.NotContains(t, lr.String(), "STACKTRACE")
+
+	t.Log("Direct handler panic after response is committed")
+	he.DirectHandler("/partialz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("partial"))
+		panic("direct handler panic after commit")
+	})
+	r = ts.Get("/partialz").AssertStatus(http.StatusAccepted)
+	assert.Equal(t, "partial", r.BodyString())
+	assert.Contains(t, lr.String(), "Direct handler panic on /partialz: direct handler panic after commit")
+
+	t.Log("Regular route")
+	ts.Get("/get-text.html").AssertStatus(http.StatusInternalServerError)
+	assert.True(t, mwCalled)
+
+	t.Log("Unregister direct handler")
+	he.DirectHandler("/healthz", nil)
+	_, found := he.directs["/healthz"]
+	assert.False(t, found)
+}
+
+func TestHTTPEngineDirectHandlerOrdering(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"server.base_path":       "/myapp",
+		"server.redirect.enable": true,
+		"server.redirect.to":     "non-www",
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Direct Handler Ordering]: %s", ts.URL)
+
+	he := ts.app.HTTPEngine()
+	he.DirectHandler("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	he.DirectHandler("/myapp/readyz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ready"))
+	})
+
+	httpClient := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	get := func(host, path string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		req.Host = host
+		resp, err := httpClient.Do(req)
+		assert.Nil(t, err)
+		return resp
+	}
+
+	t.Log("Direct handler is matched prior to redirect")
+	resp := get("www.example.com", "/healthz")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ok", responseBody(resp))
+
+	resp = get("www.example.com", "/myapp/get-text.html")
+	assert.Equal(t, http.StatusMovedPermanently, resp.StatusCode)
+	assert.Equal(t, "http://example.com/myapp/get-text.html", resp.Header.Get("Location"))
+
+	t.Log("Direct handler is matched prior to base path stripping")
+	resp = get("example.com", "/myapp/readyz")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ready", responseBody(resp))
+
+	resp = get("example.com", "/myapp/healthz")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = get("example.com", "/myapp/get-text.html")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
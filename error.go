@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"path/filepath"
 	"strings"
 
 	"aahframe.work/ahttp"
@@ -27,6 +28,7 @@ var (
 	ErrContentTypeNotAccepted     = errors.New("aah: content type not accepted")
 	ErrContentTypeNotOffered      = errors.New("aah: content type not offered")
 	ErrHTTPMethodNotAllowed       = errors.New("aah: http method not allowed")
+	ErrAPIVersionNotSupported     = errors.New("aah: api version not supported")
 	ErrNotAuthenticated           = errors.New("aah: not authenticated")
 	ErrAccessDenied               = errors.New("aah: access denied")
 	ErrAuthenticationFailed       = errors.New("aah: authentication failed")
@@ -37,6 +39,13 @@ var (
 	ErrValidation                 = errors.New("aah: validation error")
 	ErrRenderResponse             = errors.New("aah: render response error")
 	ErrWriteResponse              = errors.New("aah: write response error")
+	ErrSignatureMismatch          = errors.New("aah: signature mismatch")
+	ErrHeaderCountExceeded        = errors.New("aah: request header count exceeded")
+	ErrHeaderSizeExceeded         = errors.New("aah: request header size exceeded")
+	ErrMultipartPartsExceeded     = errors.New("aah: request multipart parts exceeded")
+	ErrResponseSizeExceeded       = errors.New("aah: response size exceeded")
+	ErrInvalidCharset             = errors.New("aah: invalid request body charset")
+	ErrInvalidContentEncoding     = errors.New("aah: invalid request body content encoding")
 )
 
 var defaultErrorHTMLTemplate = template.Must(template.New("error_template").Parse(`<!DOCTYPE html>
@@ -118,6 +127,33 @@ func (a *Application) initError() error {
 	return nil
 }
 
+// defaultErrorContentType method returns the error response content type
+// based on application type, used when route `produces`, URL extension and
+// HTTP Header `Accept` does not express one. For e.g.: 404 of `api`
+// application is JSON and `web` application is HTML. It takes precedence
+// over `render.default`, since it's typically configured for the successful
+// responses of the application.
+func (a *Application) defaultErrorContentType(ctx *Context) string {
+	if len(ctx.routeProduces()) > 0 {
+		return ""
+	}
+	if h := ctx.Req.Header[ahttp.HeaderAccept]; len(h) > 0 && len(h[0]) > 0 && h[0] != "*/*" {
+		return ""
+	}
+	switch filepath.Ext(ctx.Req.Path) {
+	case ".html", ".htm", ".json", ".js", ".xml", ".txt":
+		return ""
+	}
+
+	switch strings.ToLower(a.Type()) {
+	case "api":
+		return ahttp.ContentTypeJSON.Mime
+	case "web":
+		return ahttp.ContentTypeHTML.Mime
+	}
+	return ""
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Error Manager
 //______________________________________________________________________________
@@ -164,7 +200,9 @@ func (er *errorManager) Handle(ctx *Context) {
 func (er *errorManager) DefaultHandler(ctx *Context, err *Error) bool {
 	ct := ctx.Reply().ContType
 	if len(ct) == 0 {
-		ct = ctx.detectContentType()
+		if ct = ctx.a.defaultErrorContentType(ctx); len(ct) == 0 {
+			ct = ctx.detectContentType()
+		}
 		if ctx.a.viewMgr == nil && strings.HasPrefix(ct, ahttp.ContentTypeHTML.Mime) {
 			ct = ahttp.ContentTypePlainText.Mime
 		}
@@ -176,10 +214,18 @@ func (er *errorManager) DefaultHandler(ctx *Context, err *Error) bool {
 	ctx.Reply().Status(err.Code)
 
 	// Set it to nil do not expose any app internal info
+	data := err.Data
 	err.Data = nil
 
 	switch ct {
 	case ahttp.ContentTypeJSON.Mime, ahttp.ContentTypeJSONText.Mime:
+		// panic stacktrace is exposed only on 'dev' environment profile
+		if st, found := ctx.Get(panicStacktraceKey).(string); found {
+			err.Data = Data{
+				"panic":      fmt.Sprintf("%v", data),
+				"stacktrace": strings.Split(strings.TrimSpace(st), "\n"),
+			}
+		}
 		ctx.Reply().JSON(err)
 	case ahttp.ContentTypeXML.Mime, ahttp.ContentTypeXMLText.Mime:
 		ctx.Reply().XML(err)
@@ -210,18 +256,67 @@ func (er *errorManager) DefaultHandler(ctx *Context, err *Error) bool {
 //______________________________________________________________________________
 
 // Error structure is used to represent the error information in aah framework.
+//
+// Field `Code` is HTTP status code, `ErrorCode` is machine readable code for
+// the clients and `Details` is client facing additional info. The JSON
+// serialization shape is-
+//
+//	{
+//	  "code": 400,
+//	  "error_code": "bad_input",
+//	  "message": "Invalid email address",
+//	  "details": {"field": "email"}
+//	}
+//
+// For XML response `Details` value has to be XML marshalable. Field `Data`
+// is not exposed to the clients by default error handler.
 type Error struct {
-	Reason  error       `json:"-" xml:"-"`
-	Code    int         `json:"code,omitempty" xml:"code,omitempty"`
-	Message string      `json:"message,omitempty" xml:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty" xml:"data,omitempty"`
+	Reason    error       `json:"-" xml:"-"`
+	Code      int         `json:"code,omitempty" xml:"code,omitempty"`
+	ErrorCode string      `json:"error_code,omitempty" xml:"error_code,omitempty"`
+	Message   string      `json:"message,omitempty" xml:"message,omitempty"`
+	Details   interface{} `json:"details,omitempty" xml:"details,omitempty"`
+	Data      interface{} `json:"data,omitempty" xml:"data,omitempty"`
+}
+
+// NewError method creates the error with given HTTP status code, machine
+// readable error code and user message. If the message is empty then HTTP
+// status text is used.
+//
+//	ctx.Reply().Error(aah.NewError(http.StatusBadRequest, "bad_input", "Invalid email address"))
+func NewError(code int, errorCode, message string) *Error {
+	if len(message) == 0 {
+		message = http.StatusText(code)
+	}
+	return &Error{Code: code, ErrorCode: errorCode, Message: message}
 }
 
 // Error method is to comply error interface.
 func (e *Error) Error() string {
+	if e.Reason == nil && len(e.ErrorCode) > 0 {
+		return fmt.Sprintf("%s, code '%v', message '%s'", e.ErrorCode, e.Code, e.Message)
+	}
 	return fmt.Sprintf("%v, code '%v', message '%s'", e.Reason, e.Code, e.Message)
 }
 
+// Unwrap method returns the underlying reason of the error, it is used by
+// `errors.Is` and `errors.As`.
+func (e *Error) Unwrap() error {
+	return e.Reason
+}
+
+// Wrap method sets the given error as reason of the error.
+func (e *Error) Wrap(err error) *Error {
+	e.Reason = err
+	return e
+}
+
+// WithDetails method sets the client facing details of the error.
+func (e *Error) WithDetails(details interface{}) *Error {
+	e.Details = details
+	return e
+}
+
 func newError(err error, code int) *Error {
 	return &Error{Reason: err, Code: code, Message: http.StatusText(code)}
 }
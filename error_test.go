@@ -0,0 +1,138 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"aahframe.work/ahttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorStructured(t *testing.T) {
+	err := NewError(http.StatusBadRequest, "bad_input", "Invalid email address").
+		WithDetails(map[string]string{"field": "email"})
+	assert.Equal(t, "bad_input, code '400', message 'Invalid email address'", err.Error())
+	assert.Nil(t, err.Unwrap())
+
+	b, _ := json.Marshal(err)
+	assert.Equal(t, `{"code":400,"error_code":"bad_input","message":"Invalid email address","details":{"field":"email"}}`, string(b))
+
+	err = NewError(http.StatusNotFound, "user_not_found", "")
+	assert.Equal(t, "Not Found", err.Message)
+
+	reason := errors.New("sql: no rows in result set")
+	var werr error = err.Wrap(reason)
+	assert.True(t, errors.Is(werr, reason))
+	var aerr *Error
+	assert.True(t, errors.As(werr, &aerr))
+	assert.Equal(t, "user_not_found", aerr.ErrorCode)
+	assert.Equal(t, "sql: no rows in result set, code '404', message 'Not Found'", werr.Error())
+
+	assert.True(t, errors.Is(newError(ErrRouteNotFound, http.StatusNotFound), ErrRouteNotFound))
+}
+
+func TestErrorDefaultHandlerRender(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Error Render]: %s", ts.URL)
+
+	testcases := []struct {
+		accept, contentType, body string
+	}{
+		{
+			accept:      ahttp.ContentTypeJSON.Mime,
+			contentType: ahttp.ContentTypeJSON.String(),
+			body:        `{"code":422,"error_code":"bad_input","message":"Invalid email address","details":{"field":"email"}}`,
+		},
+		{
+			accept:      ahttp.ContentTypeXML.Mime,
+			contentType: ahttp.ContentTypeXML.String(),
+			body:        `<Error><code>422</code><error_code>bad_input</error_code><message>Invalid email address</message><details>email</details></Error>`,
+		},
+		{
+			accept:      ahttp.ContentTypePlainText.Mime,
+			contentType: ahttp.ContentTypePlainText.String(),
+			body:        "422 - Invalid email address",
+		},
+	}
+
+	for _, tc := range testcases {
+		req := httptest.NewRequest(ahttp.MethodGet, ts.URL+"/users", nil)
+		req.Header.Set(ahttp.HeaderAccept, tc.accept)
+		w := httptest.NewRecorder()
+		ctx := newContext(w, req)
+		ctx.a = ts.app
+
+		var details interface{} = map[string]string{"field": "email"}
+		if tc.accept == ahttp.ContentTypeXML.Mime {
+			details = "email"
+		}
+		ctx.Reply().Error(NewError(http.StatusUnprocessableEntity, "bad_input", "Invalid email address").
+			WithDetails(details).Wrap(errors.New("validation failed")))
+		ts.app.he.writeReply(ctx)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, tc.contentType, w.Header().Get(ahttp.HeaderContentType))
+		assert.Contains(t, w.Body.String(), tc.body)
+	}
+}
+
+func TestErrorDefaultContentTypeByAppType(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+
+	// webapp1 configures `render.default = "html"`
+	testcases := []struct {
+		appType, accept, contentType, body string
+	}{
+		{
+			appType:     "api",
+			contentType: ahttp.ContentTypeJSON.String(),
+			body:        `{"code":404,"message":"Not Found"}`,
+		},
+		{
+			appType:     "web",
+			contentType: ahttp.ContentTypeHTML.String(),
+			body:        "404 Not Found",
+		},
+		{
+			appType:     "api",
+			accept:      ahttp.ContentTypePlainText.Mime,
+			contentType: ahttp.ContentTypePlainText.String(),
+			body:        "404 - Not Found",
+		},
+		{
+			appType:     "web",
+			accept:      ahttp.ContentTypeJSON.Mime,
+			contentType: ahttp.ContentTypeJSON.String(),
+			body:        `{"code":404,"message":"Not Found"}`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.appType+" "+tc.accept, func(t *testing.T) {
+			ts := newTestServerWithConfig(t, importPath, map[string]interface{}{"type": tc.appType})
+			defer ts.Close()
+			assert.Equal(t, ahttp.ContentTypeHTML.String(), ts.app.settings.DefaultContentType)
+
+			req, err := http.NewRequest(ahttp.MethodGet, ts.URL+"/not-exists", nil)
+			assert.Nil(t, err)
+			if len(tc.accept) > 0 {
+				req.Header.Set(ahttp.HeaderAccept, tc.accept)
+			}
+			result := ts.Do(req).
+				AssertStatus(http.StatusNotFound).
+				AssertHeader(ahttp.HeaderContentType, tc.contentType)
+			assert.Contains(t, result.BodyString(), tc.body)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// app methods
+//______________________________________________________________________________
+
+// Feature method returns the feature flag of given name, it is configured in
+// the section `features.<name>` of aah.conf. Environment profile overrides the
+// value same as any other config, for e.g.: `env.prod.features.<name>`.
+//
+// Flag values are read from config on every call, so config hot-reload is
+// reflected immediately.
+//
+//	if aah.App().Feature("new_checkout").EnabledFor(userID) {
+//	  ...
+//	}
+func (a *Application) Feature(name string) *Feature {
+	return &Feature{a: a, Name: name}
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Feature
+//______________________________________________________________________________
+
+// Feature struct is config driven feature flag with percentage rollout.
+//
+// Rollout percentage buckets the rollout key, for e.g.: user ID, into
+// `0-99` by FNV-1a hash of `<name>:<key>` modulo 100, key is enabled if its
+// bucket is less than `rollout`. So the same key always gets the same result
+// and increasing the rollout keeps the keys already enabled. Feature name is
+// part of the hash, so each feature is rolled out to different set of keys.
+type Feature struct {
+	Name string
+	a    *Application
+}
+
+// Enabled method returns true if feature is enabled for everyone, i.e.
+// `enabled = true` and `rollout = 100`. Use `EnabledFor` for partial rollout.
+func (f *Feature) Enabled() bool {
+	return f.isOn() && f.Rollout() == 100
+}
+
+// EnabledFor method returns true if feature is enabled for given rollout key,
+// for e.g.: user ID, tenant ID.
+func (f *Feature) EnabledFor(key string) bool {
+	if !f.isOn() {
+		return false
+	}
+	rollout := f.Rollout()
+	switch rollout {
+	case 0:
+		return false
+	case 100:
+		return true
+	}
+	return featureBucket(f.Name, key) < rollout
+}
+
+// Rollout method returns the rollout percentage of feature between `0-100`.
+// Default value is `100`.
+func (f *Feature) Rollout() int {
+	rollout := f.a.Config().IntDefault(f.key("rollout"), 100)
+	switch {
+	case rollout < 0:
+		return 0
+	case rollout > 100:
+		return 100
+	}
+	return rollout
+}
+
+// isOn method returns true if feature `enabled = true`, regardless of rollout
+// percentage.
+func (f *Feature) isOn() bool {
+	return f.a.Config().BoolDefault(f.key("enabled"), false)
+}
+
+func (f *Feature) key(name string) string {
+	return "features." + f.Name + "." + name
+}
+
+func featureBucket(name, key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name + ":" + key))
+	return int(h.Sum32() % 100)
+}
+
+// featureKey method returns rollout key string of given value, for e.g.:
+// user ID from template.
+func featureKey(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,69 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeatureFlag(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"features.new_checkout.enabled": true,
+		"features.new_checkout.rollout": 30,
+		"features.dark_mode.enabled":    true,
+		"features.beta_api.enabled":     false,
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Feature Flag]: %s", ts.URL)
+
+	a := ts.app
+
+	t.Log("Enabled for everyone")
+	dm := a.Feature("dark_mode")
+	assert.Equal(t, 100, dm.Rollout())
+	assert.True(t, dm.Enabled())
+	assert.True(t, dm.EnabledFor("user1"))
+
+	t.Log("Disabled and not configured")
+	assert.False(t, a.Feature("beta_api").Enabled())
+	assert.False(t, a.Feature("beta_api").EnabledFor("user1"))
+	assert.False(t, a.Feature("unknown").Enabled())
+
+	t.Log("Percentage rollout")
+	nc := a.Feature("new_checkout")
+	assert.Equal(t, 30, nc.Rollout())
+	assert.False(t, nc.Enabled())
+	var enabled []string
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("user%d", i)
+		assert.Equal(t, featureBucket("new_checkout", key) < 30, nc.EnabledFor(key))
+		if nc.EnabledFor(key) {
+			enabled = append(enabled, key)
+		}
+	}
+	assert.InDelta(t, 300, len(enabled), 60)
+
+	t.Log("Increasing rollout keeps enabled keys")
+	a.Config().SetInt("features.new_checkout.rollout", 60)
+	for _, key := range enabled {
+		assert.True(t, nc.EnabledFor(key))
+	}
+	a.Config().SetInt("features.new_checkout.rollout", 0)
+	assert.False(t, nc.EnabledFor(enabled[0]))
+	a.Config().SetInt("features.new_checkout.rollout", 150)
+	assert.Equal(t, 100, nc.Rollout())
+
+	t.Log("Template func")
+	assert.True(t, a.viewMgr.tmplFeature("dark_mode"))
+	a.Config().SetInt("features.new_checkout.rollout", 30)
+	assert.False(t, a.viewMgr.tmplFeature("new_checkout"))
+	assert.Equal(t, nc.EnabledFor("42"), a.viewMgr.tmplFeature("new_checkout", 42))
+}
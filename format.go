@@ -0,0 +1,259 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+
+	"aahframe.work/ahttp"
+	"aahframe.work/i18n"
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// Time type wraps the `time.Time` value with application time zone and
+// layout from `format.timezone` and `format.datetime`. It is formatted
+// consistently in JSON, XML and text output regardless of the server's
+// time zone. Create it via `ctx.Time(t)`.
+type Time struct {
+	time.Time
+	layout string
+}
+
+// String method returns the formatted time value.
+func (t Time) String() string {
+	return t.Time.Format(t.layout)
+}
+
+// MarshalJSON method implements the `json.Marshaler` interface.
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// MarshalText method implements the `encoding.TextMarshaler` interface.
+func (t Time) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Application methods
+//______________________________________________________________________________
+
+// TimeZone method returns the application time zone from `format.timezone`,
+// default is UTC.
+func (a *Application) TimeZone() *time.Location {
+	if a.settings.TimeLocation == nil {
+		return time.UTC
+	}
+	return a.settings.TimeLocation
+}
+
+// FormatTime method formats the given time in the application time zone with
+// given layout, if layout is not provided then `format.datetime` is used.
+func (a *Application) FormatTime(t time.Time, layout ...string) string {
+	return a.Time(t, layout...).String()
+}
+
+// Time method returns the given time as `aah.Time` in the application time
+// zone with given layout, if layout is not provided then `format.datetime`
+// is used.
+func (a *Application) Time(t time.Time, layout ...string) Time {
+	l := a.settings.DateTimeLayout
+	if len(layout) > 0 {
+		l = layout[0]
+	}
+	if len(l) == 0 {
+		l = time.RFC3339
+	}
+	return Time{Time: t.In(a.TimeZone()), layout: l}
+}
+
+// FormatNumber method formats the given number as per conventions of given
+// locale, i.e. thousands separator and decimal mark, for e.g.: `1,234.5` for
+// `en`, `1.234,5` for `de`. Locale fallback is same as `FormatDate`.
+func (a *Application) FormatNumber(locale *ahttp.Locale, n interface{}) string {
+	lf := a.localeFormat(locale)
+	return message.NewPrinter(lf.tag).Sprint(number.Decimal(n))
+}
+
+// FormatCurrency method formats the given amount for ISO 4217 currency code
+// as per conventions of given locale, for e.g.: `FormatCurrency(en, 1234.5,
+// "EUR")` returns `€1,234.50` and for `de` returns `1.234,50 €`. Amount is
+// rounded to the standard digits of currency. Locale fallback is same as
+// `FormatDate`.
+//
+// Symbol placement is configured via `format.locale.<locale>.currency`, for
+// e.g.: `"{amount} {symbol}"`.
+func (a *Application) FormatCurrency(locale *ahttp.Locale, amount float64, code string) string {
+	lf := a.localeFormat(locale)
+	p := message.NewPrinter(lf.tag)
+
+	symbol, scale := strings.ToUpper(code), 2
+	if unit, err := currency.ParseISO(code); err == nil {
+		symbol = p.Sprint(currency.Symbol(unit))
+		scale, _ = currency.Standard.Rounding(unit)
+	} else {
+		a.Log().Warnf("format: unknown currency code '%s'", code)
+	}
+
+	value := p.Sprint(number.Decimal(math.Abs(amount),
+		number.MinFractionDigits(scale), number.MaxFractionDigits(scale)))
+	value = strings.NewReplacer("{symbol}", symbol, "{amount}", value).Replace(lf.currency)
+	if amount < 0 {
+		value = "-" + value
+	}
+	return value
+}
+
+// FormatDate method formats the date of given time in the application time
+// zone as per date layout of given locale, for e.g.: `01/02/2006` for
+// `en`, `02.01.2006` for `de`.
+//
+// Formatting conventions of locale are resolved in the order of locale tag
+// hierarchy then the default locale `i18n.default`, for e.g.: `fr-CA`, `fr`,
+// `en`. Locale conventions are overridden via config
+// `format.locale.<locale>`, locale tag separator is underscore.
+//
+//	format {
+//	  locale {
+//	    en_GB {
+//	      date = "02/01/2006"
+//	      currency = "{symbol}{amount}"
+//	    }
+//	  }
+//	}
+//
+// Finally date layout defaults to `format.date`, i.e. `2006-01-02` and
+// currency to `{symbol}{amount}`.
+func (a *Application) FormatDate(locale *ahttp.Locale, t time.Time) string {
+	return t.In(a.TimeZone()).Format(a.localeFormat(locale).date)
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Context methods
+//______________________________________________________________________________
+
+// FormatTime method formats the given time as per `format.timezone` and
+// `format.datetime`. Optionally layout can be provided.
+func (ctx *Context) FormatTime(t time.Time, layout ...string) string {
+	return ctx.a.FormatTime(t, layout...)
+}
+
+// Time method returns the given time as `aah.Time`, use it in the reply
+// data to render the time as per `format.timezone` and `format.datetime`.
+func (ctx *Context) Time(t time.Time, layout ...string) Time {
+	return ctx.a.Time(t, layout...)
+}
+
+// FormatNumber method formats the given number as per conventions of
+// request locale. Refer to `aah.App().FormatNumber`.
+func (ctx *Context) FormatNumber(n interface{}) string {
+	return ctx.a.FormatNumber(ctx.Req.Locale(), n)
+}
+
+// FormatCurrency method formats the given amount for ISO 4217 currency code
+// as per conventions of request locale. Refer to `aah.App().FormatCurrency`.
+func (ctx *Context) FormatCurrency(amount float64, code string) string {
+	return ctx.a.FormatCurrency(ctx.Req.Locale(), amount, code)
+}
+
+// FormatDate method formats the date of given time as per conventions of
+// request locale. Refer to `aah.App().FormatDate`.
+func (ctx *Context) FormatDate(t time.Time) string {
+	return ctx.a.FormatDate(ctx.Req.Locale(), t)
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Unexported types and methods
+//______________________________________________________________________________
+
+const (
+	currencyPrefix      = "{symbol}{amount}"
+	currencyPrefixSpace = "{symbol}\u00a0{amount}"
+	currencySuffix      = "{amount}\u00a0{symbol}"
+)
+
+// localeFormats is built-in formatting conventions of locales, which are not
+// provided by `golang.org/x/text`. Number separators and currency symbols
+// are from `golang.org/x/text`.
+var localeFormats = map[string]localeFormat{
+	"en":    {date: "01/02/2006", currency: currencyPrefix},
+	"en-au": {date: "02/01/2006", currency: currencyPrefix},
+	"en-ca": {date: "2006-01-02", currency: currencyPrefix},
+	"en-gb": {date: "02/01/2006", currency: currencyPrefix},
+	"en-in": {date: "02/01/2006", currency: currencyPrefix},
+	"cs":    {date: "02.01.2006", currency: currencySuffix},
+	"da":    {date: "02.01.2006", currency: currencySuffix},
+	"de":    {date: "02.01.2006", currency: currencySuffix},
+	"de-ch": {date: "02.01.2006", currency: currencyPrefixSpace},
+	"es":    {date: "02/01/2006", currency: currencySuffix},
+	"fi":    {date: "02.01.2006", currency: currencySuffix},
+	"fr":    {date: "02/01/2006", currency: currencySuffix},
+	"fr-ca": {date: "2006-01-02", currency: currencySuffix},
+	"hi":    {date: "02/01/2006", currency: currencyPrefix},
+	"it":    {date: "02/01/2006", currency: currencySuffix},
+	"ja":    {date: "2006/01/02", currency: currencyPrefix},
+	"ko":    {date: "2006. 01. 02.", currency: currencyPrefix},
+	"nb":    {date: "02.01.2006", currency: currencySuffix},
+	"nl":    {date: "02-01-2006", currency: currencyPrefixSpace},
+	"pl":    {date: "02.01.2006", currency: currencySuffix},
+	"pt":    {date: "02/01/2006", currency: currencyPrefixSpace},
+	"pt-pt": {date: "02/01/2006", currency: currencySuffix},
+	"ru":    {date: "02.01.2006", currency: currencySuffix},
+	"sv":    {date: "2006-01-02", currency: currencySuffix},
+	"tr":    {date: "02.01.2006", currency: currencyPrefix},
+	"zh":    {date: "2006/01/02", currency: currencyPrefix},
+}
+
+type localeFormat struct {
+	tag      language.Tag
+	date     string
+	currency string
+}
+
+// localeFormat method resolves the formatting conventions of given locale.
+// Each convention is resolved independently from config and then built-in
+// conventions in the order of locale fallback.
+func (a *Application) localeFormat(locale *ahttp.Locale) localeFormat {
+	var tags []string
+	if locale != nil {
+		tag := locale.Language
+		if len(locale.Region) > 0 {
+			tag += "-" + locale.Region
+		}
+		tags = append(tags, i18n.Hierarchy(tag)...)
+	}
+	tags = append(tags, i18n.Hierarchy(a.Config().StringDefault("i18n.default", "en"))...)
+
+	lf := localeFormat{tag: language.Und}
+	for _, t := range tags {
+		if lf.tag == language.Und {
+			if tag, err := language.Parse(t); err == nil {
+				lf.tag = tag
+			}
+		}
+		key := "format.locale." + strings.Replace(t, "-", "_", -1)
+		builtin := localeFormats[strings.ToLower(t)]
+		if len(lf.date) == 0 {
+			lf.date = a.Config().StringDefault(key+".date", builtin.date)
+		}
+		if len(lf.currency) == 0 {
+			lf.currency = a.Config().StringDefault(key+".currency", builtin.currency)
+		}
+	}
+
+	if len(lf.date) == 0 {
+		lf.date = a.Config().StringDefault("format.date", "2006-01-02")
+	}
+	if len(lf.currency) == 0 {
+		lf.currency = currencyPrefix
+	}
+	return lf
+}
@@ -0,0 +1,128 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"aahframe.work/ahttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatTime(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	a := newTestApp(t, importPath)
+
+	nyc, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+	tm := time.Date(2018, 6, 15, 10, 30, 0, 0, nyc)
+
+	t.Log("Default time zone UTC")
+	assert.Equal(t, time.UTC, a.TimeZone())
+	assert.Equal(t, "2018-06-15T14:30:00Z", a.FormatTime(tm))
+	assert.Equal(t, "2018-06-15", a.FormatTime(tm, "2006-01-02"))
+
+	t.Log("Configured time zone and layout")
+	a.Config().SetString("format.timezone", "Asia/Kolkata")
+	a.Config().SetString("format.datetime", "2006-01-02 15:04:05 MST")
+	assert.Nil(t, a.settings.Refresh(a.Config()))
+	assert.Equal(t, "Asia/Kolkata", a.TimeZone().String())
+	assert.Equal(t, "2018-06-15 20:00:00 IST", a.FormatTime(tm))
+	assert.Equal(t, "2018-06-15 20:00:00 IST", a.viewMgr.tmplFormatTime(tm))
+
+	ctx := &Context{a: a}
+	assert.Equal(t, "2018-06-15 20:00:00 IST", ctx.FormatTime(tm))
+
+	t.Log("Time wrapper JSON and XML")
+	data := struct {
+		XMLName   xml.Name `json:"-" xml:"event"`
+		CreatedAt Time     `json:"created_at" xml:"created_at"`
+	}{CreatedAt: ctx.Time(tm)}
+	b, err := json.Marshal(data)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"created_at":"2018-06-15 20:00:00 IST"}`, string(b))
+	b, err = xml.Marshal(data)
+	assert.Nil(t, err)
+	assert.Equal(t, `<event><created_at>2018-06-15 20:00:00 IST</created_at></event>`, string(b))
+
+	t.Log("Invalid time zone")
+	a.Config().SetString("format.timezone", "Mars/Olympus")
+	err = a.settings.Refresh(a.Config())
+	assert.Equal(t, "'format.timezone' value is not a valid time zone: Mars/Olympus", err.Error())
+}
+
+func TestFormatLocale(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	a := newTestApp(t, importPath)
+	tm := time.Date(2018, 6, 15, 22, 30, 0, 0, time.UTC)
+
+	testcases := []struct {
+		label    string
+		locale   *ahttp.Locale
+		number   string
+		currency string
+		date     string
+	}{
+		{label: "en", locale: ahttp.NewLocale("en"), number: "1,234,567.891", currency: "€1,234.50", date: "06/15/2018"},
+		{label: "en-GB", locale: ahttp.NewLocale("en-GB"), number: "1,234,567.891", currency: "€1,234.50", date: "15/06/2018"},
+		{label: "de", locale: ahttp.NewLocale("de"), number: "1.234.567,891", currency: "1.234,50\u00a0€", date: "15.06.2018"},
+		{label: "de-AT", locale: ahttp.NewLocale("de-AT"), number: "1\u00a0234\u00a0567,891", currency: "1\u00a0234,50\u00a0€", date: "15.06.2018"},
+		{label: "de-LU", locale: ahttp.NewLocale("de-LU"), number: "1.234.567,891", currency: "1.234,50\u00a0€", date: "15.06.2018"},
+		{label: "fr-CA", locale: ahttp.NewLocale("fr-CA"), number: "1\u00a0234\u00a0567,891", currency: "1\u00a0234,50\u00a0€", date: "2018-06-15"},
+		{label: "en-IN", locale: ahttp.NewLocale("en-IN"), number: "12,34,567.891", currency: "€1,234.50", date: "15/06/2018"},
+		{label: "unknown falls back to default", locale: ahttp.NewLocale("xx-YY"), number: "1,234,567.891", currency: "€1,234.50", date: "06/15/2018"},
+		{label: "nil locale", locale: nil, number: "1,234,567.891", currency: "€1,234.50", date: "06/15/2018"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.label, func(t *testing.T) {
+			assert.Equal(t, tc.number, a.FormatNumber(tc.locale, 1234567.891))
+			assert.Equal(t, tc.currency, a.FormatCurrency(tc.locale, 1234.5, "EUR"))
+			assert.Equal(t, tc.date, a.FormatDate(tc.locale, tm))
+		})
+	}
+
+	t.Log("Currency digits, negative amount and unknown currency code")
+	en := ahttp.NewLocale("en-US")
+	assert.Equal(t, "¥1,235", a.FormatCurrency(en, 1234.6, "JPY"))
+	assert.Equal(t, "-$10.25", a.FormatCurrency(en, -10.25, "USD"))
+	assert.Equal(t, "XYZ5.00", a.FormatCurrency(en, 5, "XYZ"))
+
+	t.Log("Configured locale conventions and default")
+	a.Config().SetString("format.locale.en_GB.currency", "{amount} {symbol}")
+	a.Config().SetString("format.locale.de.date", "2.1.2006")
+	assert.Equal(t, "1,234.50 €", a.FormatCurrency(ahttp.NewLocale("en-GB"), 1234.5, "EUR"))
+	assert.Equal(t, "15.6.2018", a.FormatDate(ahttp.NewLocale("de-AT"), tm))
+	assert.Equal(t, "06/15/2018", a.FormatDate(ahttp.NewLocale("sw"), tm))
+	a.Config().SetString("i18n.default", "sw")
+	a.Config().SetString("format.date", "Jan 2, 2006")
+	assert.Equal(t, "Jun 15, 2018", a.FormatDate(ahttp.NewLocale("xx"), tm))
+	assert.Equal(t, "€1,234.50", a.FormatCurrency(ahttp.NewLocale("xx"), 1234.5, "EUR"))
+
+	t.Log("Configured default locale and time zone")
+	a.Config().SetString("i18n.default", "de")
+	a.Config().SetString("format.timezone", "Asia/Kolkata")
+	assert.Nil(t, a.settings.Refresh(a.Config()))
+	assert.Equal(t, "1.234,5", a.FormatNumber(nil, 1234.5))
+	assert.Equal(t, "16.6.2018", a.FormatDate(ahttp.NewLocale("xx"), tm))
+
+	t.Log("Context and template funcs")
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(ahttp.HeaderAcceptLanguage, "fr-FR,fr;q=0.9")
+	ctx := a.HTTPEngine().NewContext(httptest.NewRecorder(), r)
+	assert.Equal(t, "1\u00a0234,5", ctx.FormatNumber(1234.5))
+	assert.Equal(t, "12,00\u00a0$US", ctx.FormatCurrency(12, "USD"))
+	assert.Equal(t, "16/06/2018", ctx.FormatDate(tm))
+
+	viewArgs := map[string]interface{}{keyLocale: ahttp.NewLocale("en-US")}
+	assert.Equal(t, "1,234.5", a.viewMgr.tmplFormatNumber(viewArgs, 1234.5))
+	assert.Equal(t, "$12.00", a.viewMgr.tmplFormatCurrency(viewArgs, 12, "USD"))
+	assert.Equal(t, "06/16/2018", a.viewMgr.tmplFormatDate(viewArgs, tm))
+}
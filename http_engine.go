@@ -5,9 +5,13 @@
 package aah
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,9 +20,11 @@ import (
 	"aahframe.work/aruntime"
 	"aahframe.work/essentials"
 	"aahframe.work/internal/settings"
+	"aahframe.work/internal/util"
 	"aahframe.work/log"
 	"aahframe.work/security"
 	"aahframe.work/security/authc"
+	"golang.org/x/sync/singleflight"
 )
 
 const (
@@ -32,6 +38,8 @@ const (
 	// Standard frame type MTU size is 1500 bytes so 1400 bytes would make sense
 	// to Gzip by default. Read: https://en.wikipedia.org/wiki/Maximum_transmission_unit
 	defaultGzipMinSize = 1400
+
+	panicStacktraceKey = "_appPanicStacktraceKey"
 )
 
 var (
@@ -59,36 +67,137 @@ type HTTPEngine struct {
 	mwStack  []MiddlewareFunc
 	mwChain  []*Middleware
 	registry *ainsp.TargetRegistry
+	reqSlots chan struct{}
+	sfGroup  singleflight.Group
+	directs  map[string]http.HandlerFunc
 
 	// http engine events/extensions
+	onReqParseFunc    RequestParseFunc
 	onRequestFunc     EventCallbackFunc
 	onPreReplyFunc    EventCallbackFunc
 	onHeaderReplyFunc EventCallbackFunc
 	onPostReplyFunc   EventCallbackFunc
 	onPreAuthFunc     EventCallbackFunc
 	onPostAuthFunc    EventCallbackFunc
+	onPanicFunc       PanicCallbackFunc
+
+	panicQueue chan *panicEvent
+	panicOnce  sync.Once
+}
+
+// RequestParseFunc is signature of request parse callback function, refer to
+// `HTTPEngine.OnRequestParse`.
+type RequestParseFunc func(r *ahttp.Request)
+
+// PanicCallbackFunc is signature of panic callback function, refer to
+// `HTTPEngine.OnPanic`.
+type PanicCallbackFunc func(ctx *Context, recovered interface{}, stack []byte)
+
+type panicEvent struct {
+	ctx       *Context
+	recovered interface{}
+	stack     []byte
 }
 
 // Handle method is HTTP handler for aah application.
+//
+// The request context carries the deadline of `server.timeout.write`, so
+// handlers performing long running operations (for e.g.: DB queries) could
+// observe the impending timeout via `ctx.Req.Unwrap().Context().Done()`
+// and abort gracefully. Per handler timeout (shorter than write timeout)
+// could be applied on top of it by deriving a new context from it.
 func (e *HTTPEngine) Handle(w http.ResponseWriter, r *http.Request) {
+	// Concurrency limit of in-flight requests `server.max_concurrent_requests.*`
+	if e.reqSlots != nil {
+		if !e.acquireSlot(r) {
+			e.writeServiceUnavailable(w)
+			return
+		}
+		defer e.releaseSlot()
+	}
+
+	// Application warm up `server.warmup.*`, reply 503 till it is ready
+	if wu := e.a.warmup; wu != nil && e.a.State() == AppStateStarting && !wu.isExcluded(r.URL.Path) {
+		wu.writeUnavailable(w)
+		return
+	}
+
 	ctx := e.ctxPool.Get().(*Context)
 	defer e.releaseContext(ctx)
+	ctx.start = e.a.Clock().Now()
 
-	// Record access log
-	if e.a.settings.AccessLogEnabled {
-		ctx.Set(reqStartTimeKey, time.Now())
-		defer e.a.accessLog.Log(ctx)
+	if wt := e.a.writeTimeout(r); wt > 0 {
+		rctx, cancel := context.WithTimeout(r.Context(), wt)
+		defer cancel()
+		r = r.WithContext(rctx)
+	}
+
+	// Record access log and slow request log
+	if e.a.settings.AccessLogEnabled || e.a.settings.SlowRequestEnabled {
+		ctx.Set(reqStartTimeKey, ctx.start)
+		if e.a.settings.AccessLogEnabled {
+			defer e.a.accessLog.Log(ctx)
+		}
+		if e.a.settings.SlowRequestEnabled {
+			defer e.a.logSlowRequest(ctx)
+		}
 	}
 
 	ctx.Req, ctx.Res = ahttp.AcquireRequest(r), ahttp.AcquireResponseWriter(w)
 
+	// Audit log entry is written after the reply including recovery reply,
+	// refer to `aah.AuditMiddleware`
+	if al := e.a.auditLog; al != nil {
+		defer al.LogRecord(ctx)
+	}
+
+	// Tracing span is ended after the reply including recovery reply,
+	// refer to `aah.TracingMiddleware`
+	if e.a.tracer != nil {
+		defer endTracingSpan(ctx)
+	}
+
+	// Forwarded protocol headers are honored only from trusted proxies
+	// `request.forwarded_proto.*`
+	if !e.isForwardedProtoTrusted(r) {
+		ctx.Req.Scheme = ahttp.ConnScheme(r)
+		r.URL.Scheme = ctx.Req.Scheme
+	}
+
 	// Recovery handling
 	defer e.handleRecovery(ctx)
 
+	// 'OnRequestParse' HTTP engine extension
+	if e.onReqParseFunc != nil {
+		e.onReqParseFunc(ctx.Req)
+	}
+
 	if e.a.settings.RequestIDEnabled {
 		ctx.setRequestID()
 	}
 
+	// Request header count limit `request.max_header_count`
+	if e.a.settings.MaxHeaderCount > 0 && headerCount(r.Header) > e.a.settings.MaxHeaderCount {
+		ctx.Log().Warnf("Request header count exceeds the limit of %d, Path: %s", e.a.settings.MaxHeaderCount, ctx.Req.Path)
+		ctx.Reply().Status(http.StatusRequestHeaderFieldsTooLarge).
+			Error(newError(ErrHeaderCountExceeded, http.StatusRequestHeaderFieldsTooLarge))
+		e.writeReply(ctx)
+		return
+	}
+
+	// Request header size limit `server.max_header_bytes`, Go HTTP server
+	// allows slop of 4096 bytes or more beyond the limit, so configured limit
+	// is enforced strictly here
+	if e.a.settings.MaxHeaderBytesStrict && e.a.settings.HTTPMaxHdrBytes > 0 &&
+		headerSize(r) > e.a.settings.HTTPMaxHdrBytes {
+		ctx.Log().Warnf("Request header size exceeds the limit of %d bytes, Path: %s, Remote: %s",
+			e.a.settings.HTTPMaxHdrBytes, ctx.Req.Path, r.RemoteAddr)
+		ctx.Reply().Status(http.StatusRequestHeaderFieldsTooLarge).
+			Error(newError(ErrHeaderSizeExceeded, http.StatusRequestHeaderFieldsTooLarge))
+		e.writeReply(ctx)
+		return
+	}
+
 	// Load session from request if its `stateful` and subject authentication info.
 	if ctx.a.SessionManager().IsStateful() {
 		ctx.Subject().Session = ctx.a.SessionManager().GetSession(ctx.Req.Unwrap())
@@ -113,18 +222,36 @@ func (e *HTTPEngine) Handle(w http.ResponseWriter, r *http.Request) {
 		e.mwChain[0].Next(ctx)
 	}
 
+	if e.a.settings.SlowRequestEnabled {
+		ctx.Set(replyStartTimeKey, e.a.Clock().Now())
+	}
 	e.writeReply(ctx)
 }
 
 // Log method returns HTTP engine logger.
 func (e *HTTPEngine) Log() log.Loggerer {
-	return e.a.logger
+	return e.a.Log()
 }
 
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // HTTP Engine - Server Extensions
 //______________________________________________________________________________
 
+// OnRequestParse method is to subscribe to aah HTTP engine `OnRequestParse`
+// extension point. `OnRequestParse` called for every incoming HTTP request right
+// after the `ahttp.Request` is parsed, prior to `OnRequest` and middlewares.
+// It is meant for lightweight enrichment of request, for e.g.: derive tenant
+// from host into request header, so that later middlewares can rely on it.
+//
+// Note: Session, subject and route are not yet populated at this point.
+func (e *HTTPEngine) OnRequestParse(rpf RequestParseFunc) {
+	if e.onReqParseFunc != nil {
+		e.Log().Warnf("Changing 'OnRequestParse' server extension from '%s' to '%s'",
+			ess.GetFunctionInfo(e.onReqParseFunc).QualifiedName, ess.GetFunctionInfo(rpf).QualifiedName)
+	}
+	e.onReqParseFunc = rpf
+}
+
 // OnRequest method is to subscribe to aah HTTP engine `OnRequest` extension point.
 // `OnRequest` called for every incoming HTTP request.
 //
@@ -217,6 +344,21 @@ func (e *HTTPEngine) OnPostAuth(sef EventCallbackFunc) {
 	e.onPostAuthFunc = sef
 }
 
+// OnPanic method is to subscribe to aah HTTP engine panic recovery, for e.g.:
+// to ship the event to Sentry, Slack, etc. Callback is invoked asynchronously
+// from the bounded queue `server.panic_notify.queue_size` (default 100), it
+// does not block the response. When the queue is full events are dropped.
+//
+// The `aah.Context` passed to the callback is detached copy of the request
+// context, `ctx.Res` and `ctx.Reply()` are not applicable.
+func (e *HTTPEngine) OnPanic(pcf PanicCallbackFunc) {
+	if e.onPanicFunc != nil {
+		e.Log().Warnf("Changing 'OnPanic' server extension from '%s' to '%s'",
+			ess.GetFunctionInfo(e.onPanicFunc).QualifiedName, ess.GetFunctionInfo(pcf).QualifiedName)
+	}
+	e.onPanicFunc = pcf
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // HTTP Engine - Server Extension Publish
 //______________________________________________________________________________
@@ -263,6 +405,69 @@ func (e *HTTPEngine) publishOnPostAuthEvent(ctx *Context) {
 // Engine Unexported methods
 //______________________________________________________________________________
 
+func (e *HTTPEngine) initConcurrencyLimit() {
+	e.reqSlots = nil
+	if e.a.settings.MaxConcurrentRequests > 0 {
+		e.reqSlots = make(chan struct{}, e.a.settings.MaxConcurrentRequests)
+	}
+}
+
+// acquireSlot method acquires the slot for request processing. On mode
+// `queue` it waits for the free slot until queue timeout or client goes away.
+func (e *HTTPEngine) acquireSlot(r *http.Request) bool {
+	select {
+	case e.reqSlots <- struct{}{}:
+		return true
+	default:
+		if !e.a.settings.ConcurrencyQueue {
+			return false
+		}
+	}
+
+	t := time.NewTimer(e.a.settings.ConcurrencyTimeout)
+	defer t.Stop()
+	select {
+	case e.reqSlots <- struct{}{}:
+		return true
+	case <-t.C:
+	case <-r.Context().Done():
+	}
+	return false
+}
+
+func (e *HTTPEngine) releaseSlot() {
+	<-e.reqSlots
+}
+
+func (e *HTTPEngine) writeServiceUnavailable(w http.ResponseWriter) {
+	// logged at debug level, it could be every request during load spike
+	e.a.Log().Debugf("Max concurrent requests limit (%d) reached, request rejected", e.a.settings.MaxConcurrentRequests)
+	w.Header().Set(ahttp.HeaderRetryAfter, e.a.settings.ConcurrencyRetryAfter)
+	w.Header().Set(ahttp.HeaderContentType, ahttp.ContentTypePlainText.String())
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_, _ = w.Write([]byte("503 Service Unavailable"))
+}
+
+// NewContext method returns the aah context for given response writer and
+// request, it is meant for unit testing of controller action, middleware,
+// etc. without HTTP round trip. Reply of the context is usable with its
+// introspection methods, for e.g.: `Reply().StatusCode()`,
+// `Reply().RenderedBody()`.
+//
+// Note: Context is not processed by aah request lifecycle, i.e. events,
+// middlewares, routing, error handling and it is not written on the wire.
+//
+//	ctx := aah.App().HTTPEngine().NewContext(httptest.NewRecorder(),
+//	  httptest.NewRequest(http.MethodGet, "/users/1", nil))
+//	(&controllers.UserController{Context: ctx}).Show(1)
+//	body, err := ctx.Reply().RenderedBody()
+func (e *HTTPEngine) NewContext(w http.ResponseWriter, r *http.Request) *Context {
+	ctx := e.newContext()
+	ctx.start = e.a.Clock().Now()
+	ctx.Req, ctx.Res = ahttp.AcquireRequest(r), ahttp.AcquireResponseWriter(w)
+	return ctx
+}
+
 func (e *HTTPEngine) newContext() *Context {
 	return &Context{a: e.a, e: e}
 }
@@ -271,7 +476,17 @@ func (e *HTTPEngine) newContext() *Context {
 // Panic gets translated into HTTP Internal Server Error (Status 500).
 func (e *HTTPEngine) handleRecovery(ctx *Context) {
 	if r := recover(); r != nil {
-		ctx.Log().Errorf("Internal Server Error on %s", ctx.Req.URL().RequestURI())
+		mwPanic := ctx.mw != nil && ctx.mw.name == ctx.component
+		if mwPanic {
+			phase := "before action"
+			if ctx.action != nil {
+				phase = "after action"
+			}
+			ctx.Log().Errorf("Internal Server Error on %s, panic occurred in '%s' (middleware %d of %d, %s)",
+				ctx.Req.URL().RequestURI(), ctx.component, ctx.mw.pos, len(e.mwChain), phase)
+		} else {
+			ctx.Log().Errorf("Internal Server Error on %s, panic occurred in '%s'", ctx.Req.URL().RequestURI(), ctx.component)
+		}
 
 		st := aruntime.NewStacktrace(r, e.a.Config())
 		buf := acquireBuilder()
@@ -279,37 +494,113 @@ func (e *HTTPEngine) handleRecovery(ctx *Context) {
 
 		st.Print(buf)
 		ctx.Log().Error(buf.String())
+		if e.a.IsEnvProfile(settings.DefaultEnvProfile) {
+			ctx.Set(panicStacktraceKey, buf.String())
+		}
+		e.publishOnPanic(ctx, r, []byte(buf.String()))
+
+		// response is already committed on the wire, error reply cannot be
+		// written without partial response
+		if ahttp.IsCommitted(ctx.Res) {
+			ctx.Log().Warnf("Response already committed with status %d, unable to reply panic recovery",
+				ctx.Res.Status())
+			return
+		}
 
 		err := ErrPanicRecovery
-		if er, ok := r.(error); ok && er == ErrRenderResponse {
+		if er, ok := r.(error); ok && (er == ErrRenderResponse || er == ErrResponseSizeExceeded) {
 			err = er
 		}
 
+		// Recovery response content type is negotiated same as error handling
+		// flow, i.e. HTTP header 'Accept' or 'render.default'. Middleware panic
+		// of `api` application is always JSON, since the route and action
+		// may not be known to negotiate.
+		ctx.Reply().ContType = ""
+		if mwPanic && strings.EqualFold(e.a.Type(), "api") {
+			ctx.Reply().ContType = ahttp.ContentTypeJSON.String()
+		}
 		ctx.Reply().InternalServerError().Error(newErrorWithData(err, http.StatusInternalServerError, r))
 		e.writeReply(ctx)
 	}
 }
 
+// publishOnPanic method queues the panic event for `OnPanic` callback,
+// queue worker is started on first panic.
+func (e *HTTPEngine) publishOnPanic(ctx *Context, r interface{}, stack []byte) {
+	if e.onPanicFunc == nil {
+		return
+	}
+	e.panicOnce.Do(func() {
+		e.panicQueue = make(chan *panicEvent, e.a.Config().IntDefault("server.panic_notify.queue_size", 100))
+		go e.panicWorker()
+	})
+
+	select {
+	case e.panicQueue <- &panicEvent{ctx: ctx.detach(), recovered: r, stack: stack}:
+	default:
+		ctx.Log().Warnf("Panic notify queue is full, dropping the event of %s", ctx.Req.Path)
+	}
+}
+
+func (e *HTTPEngine) panicWorker() {
+	for pe := range e.panicQueue {
+		e.invokeOnPanic(pe)
+	}
+}
+
+func (e *HTTPEngine) invokeOnPanic(pe *panicEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			e.Log().Errorf("'OnPanic' callback panic: %v", r)
+		}
+	}()
+	e.onPanicFunc(pe.ctx, pe.recovered, pe.stack)
+}
+
 // writeReply method writes the response on the wire based on `Reply` instance.
 func (e *HTTPEngine) writeReply(ctx *Context) {
 	re := ctx.Reply()
+
+	// response is already committed on the wire, for e.g.: written directly
+	// via `ctx.Res`, status and headers cannot be rewritten
+	if ahttp.IsCommitted(ctx.Res) {
+		if re.err != nil && !re.done {
+			ctx.Log().Errorf("Response already committed with status %d, unable to reply error: %v",
+				ctx.Res.Status(), re.err)
+		}
+		return
+	}
+
 	if re.err != nil {
 		e.a.errorMgr.Handle(ctx)
 	}
 
 	// don't go forward, if:
 	// 	- Response already written on the wire, refer to method `Reply().Done()`
-	// 	- Static file route
-	if re.done || ctx.IsStaticRoute() {
+	// 	- Static file route, written by static manager unless it is an error
+	if re.done || (ctx.IsStaticRoute() && re.err == nil) {
 		return
 	}
 
+	// route `default_status`, explicitly set status takes precedence
+	if !re.codeSet && re.err == nil && ctx.route != nil && ctx.route.DefaultStatus > 0 {
+		re.Code = ctx.route.DefaultStatus
+	}
+
 	// 'OnPreReply' HTTP event
 	e.publishOnPreReplyEvent(ctx)
 
 	// HTTP headers
 	ctx.writeHeaders()
 
+	// Content-Language of the served locale, unless set by the handler
+	if e.a.settings.ContentLanguage && len(ctx.Res.Header().Get(ahttp.HeaderContentLanguage)) == 0 {
+		if locale := ctx.locale(); len(locale) > 0 {
+			ctx.Res.Header().Set(ahttp.HeaderContentLanguage, locale)
+		}
+	}
+
 	// Set Cookies
 	ctx.writeCookies()
 
@@ -326,6 +617,11 @@ func (e *HTTPEngine) writeReply(ctx *Context) {
 		}
 	}
 	if len(re.ContType) > 0 {
+		// as per 'render.default_charset' from aah.conf, file and bytes
+		// content are sent as-is
+		if _, ok := re.Rdr.(*binaryRender); !ok {
+			re.ContType = util.AddCharset(re.ContType, e.a.settings.DefaultCharset)
+		}
 		ctx.Res.Header().Set(ahttp.HeaderContentType, re.ContType)
 	}
 
@@ -339,7 +635,13 @@ func (e *HTTPEngine) writeReply(ctx *Context) {
 
 		e.writeOnWire(ctx)
 	} else {
+		// body is not written even if render is set. `Content-Length` of
+		// `304 Not Modified` reflects the selected representation, so
+		// it is retained
 		ctx.Res.Header().Del(ahttp.HeaderContentType)
+		if re.Code != http.StatusNotModified {
+			ctx.Res.Header().Del(ahttp.HeaderContentLength)
+		}
 		ctx.Res.WriteHeader(re.Code)
 	}
 
@@ -365,11 +667,29 @@ func (e *HTTPEngine) writeOnWire(ctx *Context) {
 		return
 	}
 	re.body = acquireBuffer()
-	if err := re.Rdr.Render(re.body); err != nil {
+	if err := renderBody(re, re.body); err != nil {
 		ctx.Log().Error("Response render error: ", err)
+
+		// discard the partially rendered body, nothing is written on the
+		// wire yet so recovery flow replies clean 500
+		releaseBuffer(re.body)
+		re.body = nil
 		panic(ErrRenderResponse)
 	}
 
+	// Response size guard `render.max_response_size`
+	if limit := e.a.settings.MaxResponseSize; limit > 0 && re.err == nil && int64(re.body.Len()) > limit {
+		if e.a.settings.MaxResponseSizeStrict {
+			ctx.Log().Errorf("Response size %d bytes exceeds the limit of %d bytes, Path: %s", re.body.Len(), limit, ctx.Req.Path)
+			releaseBuffer(re.body)
+			re.body = nil
+			panic(ErrResponseSizeExceeded)
+		}
+		if e.a.IsEnvProfile(settings.DefaultEnvProfile) {
+			ctx.Log().Warnf("Response size %d bytes exceeds the limit of %d bytes, Path: %s", re.body.Len(), limit, ctx.Req.Path)
+		}
+	}
+
 	// Check response qualify for Gzip
 	if e.qualifyGzip(ctx) && re.body.Len() > defaultGzipMinSize {
 		ctx.Res = wrapGzipWriter(ctx.Res)
@@ -385,6 +705,11 @@ func (e *HTTPEngine) writeOnWire(ctx *Context) {
 		ctx.Set(keyAahResponseBodyBuf, resBuf)
 	}
 
+	// If audit log enabled for the route, refer to `aah.AuditMiddleware`
+	if ar, ok := ctx.Get(keyAahAuditRecord).(*auditRecord); ok {
+		w = io.MultiWriter(w, ar.resBuf)
+	}
+
 	// currently write error on wire is not propagated to error
 	// since we can't do anything after that.
 	// It could be network error, client is gone, etc.
@@ -409,6 +734,16 @@ func (e *HTTPEngine) writeBinary(ctx *Context) {
 		ctx.Res = wrapGzipWriter(ctx.Res)
 	}
 
+	// Declare trailers, it requires chunked transfer encoding (HTTP/1.1)
+	// or HTTP/2. So it is skipped for HTTP/1.0 request.
+	trailers := len(re.trailers) > 0 && ctx.Req.Unwrap().ProtoAtLeast(1, 1)
+	if trailers {
+		ctx.Res.Header().Del(ahttp.HeaderContentLength)
+		for _, t := range re.trailers {
+			ctx.Res.Header().Add(ahttp.HeaderTrailer, t.key)
+		}
+	}
+
 	ctx.Res.WriteHeader(re.Code)
 
 	// currently write error on wire is not propagated to error
@@ -417,6 +752,13 @@ func (e *HTTPEngine) writeBinary(ctx *Context) {
 	if err := re.Rdr.Render(ctx.Res); err != nil {
 		ctx.Log().Error("Response write error: ", err)
 	}
+
+	// Trailer values are sent after the body
+	if trailers {
+		for _, t := range re.trailers {
+			ctx.Res.Header().Set(t.key, t.value())
+		}
+	}
 }
 
 func (e *HTTPEngine) minifierExists() bool {
@@ -451,19 +793,68 @@ func (e *HTTPEngine) doRedirect(w http.ResponseWriter, r *http.Request) bool {
 	switch redirectTo {
 	case www:
 		if host[:3] != www {
-			http.Redirect(w, r, ahttp.Scheme(r)+"://www."+host+r.URL.RequestURI(), redirectCode)
+			http.Redirect(w, r, e.requestScheme(r)+"://www."+host+r.URL.RequestURI(), redirectCode)
 			return true
 		}
 
 	case nonwww:
 		if host[:3] == www {
-			http.Redirect(w, r, ahttp.Scheme(r)+"://"+host[4:]+r.URL.RequestURI(), redirectCode)
+			http.Redirect(w, r, e.requestScheme(r)+"://"+host[4:]+r.URL.RequestURI(), redirectCode)
+			return true
+		}
+	}
+	return false
+}
+
+// isForwardedProtoTrusted method reports whether the forwarded protocol
+// headers of request could be trusted. If `trusted_proxies` is empty then all
+// upstreams are trusted.
+func (e *HTTPEngine) isForwardedProtoTrusted(r *http.Request) bool {
+	if !e.a.settings.ForwardedProtoEnabled {
+		return false
+	}
+	if len(e.a.settings.ForwardedProtoTrusted) == 0 {
+		return true
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range e.a.settings.ForwardedProtoTrusted {
+		if n.Contains(ip) {
 			return true
 		}
 	}
 	return false
 }
 
+// requestScheme method returns the effective scheme of request, refer to
+// `HTTPEngine.isForwardedProtoTrusted`.
+func (e *HTTPEngine) requestScheme(r *http.Request) string {
+	if e.isForwardedProtoTrusted(r) {
+		return ahttp.Scheme(r)
+	}
+	return ahttp.ConnScheme(r)
+}
+
+// renderBody method renders the reply into given writer, panic occurs
+// during render is returned as error with template details if applicable.
+func renderBody(re *Reply, w io.Writer) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+			if h, ok := re.Rdr.(*htmlRender); ok && h.Template != nil {
+				err = fmt.Errorf("template '%s' (layout '%s'): %v", h.Template.Name(), h.Layout, err)
+			}
+		}
+	}()
+	return re.Rdr.Render(w)
+}
+
 // bodyAllowedForStatus reports whether a given response status code
 // permits a body. See RFC 2616, section 4.4.
 //
@@ -479,3 +870,28 @@ func bodyAllowedForStatus(status int) bool {
 	}
 	return true
 }
+
+// headerCount method returns the count of request header fields, repeated
+// header fields are counted individually.
+func headerCount(hdr http.Header) int {
+	var cnt int
+	for _, v := range hdr {
+		cnt += len(v)
+	}
+	return cnt
+}
+
+// headerSize method returns the approximate size of request line and header
+// fields as it was on the wire, i.e. `Name: value\r\n`.
+func headerSize(r *http.Request) int {
+	size := len(r.Method) + len(r.RequestURI) + len(r.Proto) + 4
+	if len(r.Host) > 0 {
+		size += len(ahttp.HeaderHost) + len(r.Host) + 4
+	}
+	for k, v := range r.Header {
+		for _, hv := range v {
+			size += len(k) + len(hv) + 4
+		}
+	}
+	return size
+}
@@ -5,14 +5,24 @@
 package aah
 
 import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"aahframe.work/ahttp"
 	"aahframe.work/config"
+	"aahframe.work/internal/proxyproto"
+	"aahframe.work/internal/testutil"
+	"aahframe.work/log"
+	"aahframe.work/router"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -116,7 +126,22 @@ func TestHTTPEngineTestRequests(t *testing.T) {
 	assert.Equal(t, "application/json; charset=utf-8", resp.Header.Get(ahttp.HeaderContentType))
 	assert.Equal(t, "true", resp.Header.Get("X-Centrallized-ErrorHandler"))
 	assert.Equal(t, "true", resp.Header.Get("X-Cntrl-ErrorHandler"))
-	assert.True(t, strings.Contains(responseBody(resp), `"message":"Internal Server Error"`))
+	body := responseBody(resp)
+	assert.True(t, strings.Contains(body, `"message":"Internal Server Error"`))
+	assert.True(t, strings.Contains(body, `"panic":"This panic flow test and recovery"`))
+	assert.True(t, strings.Contains(body, `"stacktrace":[`))
+
+	// Panic Flow test JSON prod profile - /trigger-panic
+	t.Log("Panic Flow test JSON prod profile - /trigger-panic")
+	ts.app.settings.EnvProfile = "prod"
+	resp, err = httpClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, 500, resp.StatusCode)
+	assert.Equal(t, "application/json; charset=utf-8", resp.Header.Get(ahttp.HeaderContentType))
+	body = responseBody(resp)
+	assert.True(t, strings.Contains(body, `"message":"Internal Server Error"`))
+	assert.False(t, strings.Contains(body, `"stacktrace"`))
+	ts.app.settings.EnvProfile = "dev"
 
 	// Panic Flow test XML - /trigger-panic
 	t.Log("Panic Flow test XML - /trigger-panic")
@@ -335,3 +360,680 @@ func newContext(w http.ResponseWriter, r *http.Request) *Context {
 
 	return ctx
 }
+
+func TestHTTPEngineRequestDeadline(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Request Deadline]: %s", ts.URL)
+
+	var deadline time.Time
+	var hasDeadline bool
+	ts.app.HTTPEngine().OnRequest(func(e *Event) {
+		ctx := e.Data.(*Context)
+		deadline, hasDeadline = ctx.Req.Unwrap().Context().Deadline()
+	})
+
+	start := time.Now()
+	resp, err := new(http.Client).Get(ts.URL + "/get-text.html")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.True(t, hasDeadline)
+	assert.True(t, deadline.After(start.Add(ts.app.settings.HTTPWriteTimeout-time.Second)))
+}
+
+func TestHTTPEngineConcurrencyLimit(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Concurrency Limit]: %s", ts.URL)
+
+	he := ts.app.HTTPEngine()
+	ts.app.settings.MaxConcurrentRequests = 1
+	ts.app.settings.ConcurrencyRetryAfter = "10"
+	he.initConcurrencyLimit()
+	defer func() { he.reqSlots = nil }()
+
+	// occupy the only available slot
+	he.reqSlots <- struct{}{}
+
+	t.Log("Concurrency limit mode - reject")
+	resp, err := new(http.Client).Get(ts.URL + "/get-text.html")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "10", resp.Header.Get(ahttp.HeaderRetryAfter))
+	assert.Equal(t, "503 Service Unavailable", responseBody(resp))
+
+	t.Log("Concurrency limit mode - queue timeout")
+	ts.app.settings.ConcurrencyQueue = true
+	ts.app.settings.ConcurrencyTimeout = 20 * time.Millisecond
+	resp, err = new(http.Client).Get(ts.URL + "/get-text.html")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	t.Log("Concurrency limit mode - queue")
+	ts.app.settings.ConcurrencyTimeout = 2 * time.Second
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		he.releaseSlot()
+	}()
+	resp, err = new(http.Client).Get(ts.URL + "/get-text.html")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 0, len(he.reqSlots))
+
+	t.Log("Concurrency limit retry_after validation")
+	for _, v := range []string{"abc", "-1", "1.5"} {
+		ts.app.Config().SetString("server.max_concurrent_requests.retry_after", v)
+		err = ts.app.settings.Refresh(ts.app.Config())
+		assert.Equal(t, "'server.max_concurrent_requests.retry_after' unsupported value: "+v, err.Error())
+	}
+	ts.app.Config().SetString("server.max_concurrent_requests.retry_after", "0")
+	assert.Nil(t, ts.app.settings.Refresh(ts.app.Config()))
+}
+
+func TestHTTPEngineBasePath(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Base Path]: %s", ts.URL)
+
+	ts.app.Config().SetString("server.base_path", "/myapp/")
+	assert.Nil(t, ts.app.initRouter())
+	defer func() {
+		ts.app.Config().SetString("server.base_path", "")
+		_ = ts.app.initRouter()
+	}()
+	assert.Equal(t, "/myapp", ts.app.Router().BasePath())
+
+	httpClient := new(http.Client)
+
+	t.Log("Request with base path")
+	resp, err := httpClient.Get(ts.URL + "/myapp/get-text.html")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	t.Log("Request already stripped by parent mux")
+	resp, err = httpClient.Get(ts.URL + "/get-text.html")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	t.Log("Static file with base path")
+	resp, err = httpClient.Get(ts.URL + "/myapp/assets/css/aah.css")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(ts.app.staticMgr.assetURL("css/aah.css"), "/myapp/assets/css/aah.css?v="))
+
+	t.Log("Strip base path")
+	r := httptest.NewRequest(ahttp.MethodGet, "http://localhost:8080/myapp", nil)
+	assert.Equal(t, "/", stripBasePath(r, "/myapp").URL.Path)
+	assert.Equal(t, "/myapp", r.URL.Path)
+	r = httptest.NewRequest(ahttp.MethodGet, "http://localhost:8080/myapplication/path", nil)
+	assert.Equal(t, "/myapplication/path", stripBasePath(r, "/myapp").URL.Path)
+}
+
+func TestHTTPEngineDefaultCharset(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Default Charset]: %s", ts.URL)
+
+	assert.Equal(t, "utf-8", ts.app.settings.DefaultCharset)
+	ts.app.HTTPEngine().OnPreReply(func(e *Event) {
+		ctx := e.Data.(*Context)
+		ctx.Reply().ContType = "text/plain"
+	})
+
+	ts.Get("/get-text.html").AssertHeader(ahttp.HeaderContentType, "text/plain; charset=utf-8")
+
+	ts.app.settings.DefaultCharset = "iso-8859-1"
+	ts.Get("/get-text.html").AssertHeader(ahttp.HeaderContentType, "text/plain; charset=iso-8859-1")
+
+	ts.app.settings.DefaultCharset = ""
+	ts.Get("/get-text.html").AssertHeader(ahttp.HeaderContentType, "text/plain")
+}
+
+func TestHTTPEngineMaxHeaderCount(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"request.max_header_count": 10,
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Max Header Count]: %s", ts.URL)
+
+	assert.Equal(t, 10, ts.app.settings.MaxHeaderCount)
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+
+	req, _ := http.NewRequest(ahttp.MethodGet, ts.URL+"/get-text.html", nil)
+	for i := 0; i < 5; i++ {
+		req.Header.Add("X-Custom", fmt.Sprintf("value-%d", i))
+		req.Header.Add(fmt.Sprintf("X-Custom-%d", i), "value")
+	}
+	ts.Do(req).AssertStatus(http.StatusRequestHeaderFieldsTooLarge)
+
+	assert.Equal(t, 3, headerCount(http.Header{"Accept": {"a", "b"}, "X-Custom": {"c"}}))
+}
+
+func TestHTTPEngineWarmup(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"server.warmup.enable":         true,
+		"server.warmup.retry_after":    "10",
+		"server.warmup.check_interval": "10ms",
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Warmup]: %s", ts.URL)
+
+	assert.False(t, ts.app.IsReady())
+	ts.app.warmup.excludes = []string{"/get-xml", "/static/*"}
+	resp := ts.Get("/get-text.html")
+	resp.AssertStatus(http.StatusServiceUnavailable)
+	resp.AssertHeader(ahttp.HeaderRetryAfter, "10")
+	assert.Equal(t, "503 Service Unavailable", resp.BodyString())
+	ts.Get("/get-xml").AssertStatus(http.StatusOK)
+	assert.True(t, ts.app.warmup.isExcluded("/static/css/aah.css"))
+
+	// readiness checks
+	var ready int32
+	ts.app.AddReadinessCheck("test", func() error {
+		if atomic.LoadInt32(&ready) == 0 {
+			return errors.New("not ready")
+		}
+		return nil
+	})
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, ts.app.IsReady())
+	atomic.StoreInt32(&ready, 1)
+	for i := 0; i < 50 && !ts.app.IsReady(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, ts.app.IsReady())
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+
+	// invalid config
+	ts.app.Config().SetString("server.warmup.check_interval", "1d")
+	assert.Equal(t, "'server.warmup.check_interval' value is not a valid time unit", ts.app.initWarmup().Error())
+	ts.app.Config().SetString("server.warmup.check_interval", "1s")
+	for _, v := range []string{"soon", "-5"} {
+		ts.app.Config().SetString("server.warmup.retry_after", v)
+		assert.Equal(t, "'server.warmup.retry_after' unsupported value: "+v, ts.app.initWarmup().Error())
+	}
+
+	// disabled
+	ts.app.Config().SetBool("server.warmup.enable", false)
+	assert.Nil(t, ts.app.initWarmup())
+	ts.app.MarkReady()
+	assert.True(t, ts.app.IsReady())
+}
+
+func TestHTTPEngineHealth(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"server.warmup.enable":         true,
+		"server.warmup.retry_after":    "10",
+		"server.warmup.check_interval": "1m",
+		"server.health.enable":         true,
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Health]: %s", ts.URL)
+
+	t.Log("Starting")
+	assert.Equal(t, AppStateStarting, ts.app.State())
+	r := ts.Get("/healthz").AssertStatus(http.StatusOK)
+	assert.Equal(t, "starting", r.BodyString())
+	r = ts.Get("/readyz").AssertStatus(http.StatusServiceUnavailable)
+	r.AssertHeader(ahttp.HeaderRetryAfter, "10")
+	assert.Equal(t, "starting", r.BodyString())
+
+	t.Log("Ready")
+	ts.app.MarkReady()
+	assert.True(t, ts.app.IsReady())
+	r = ts.Get("/healthz").AssertStatus(http.StatusOK)
+	assert.Equal(t, "ready", r.BodyString())
+	r = ts.Get("/readyz").AssertStatus(http.StatusOK)
+	r.AssertHeader(ahttp.HeaderCacheControl, "no-cache, no-store")
+	assert.Equal(t, "ready", r.BodyString())
+
+	t.Log("Draining")
+	ts.app.setState(AppStateDraining)
+	assert.False(t, ts.app.IsReady())
+	r = ts.Get("/healthz").AssertStatus(http.StatusOK)
+	assert.Equal(t, "draining", r.BodyString())
+	r = ts.Get("/readyz").AssertStatus(http.StatusServiceUnavailable)
+	r.AssertHeader(ahttp.HeaderRetryAfter, "")
+	assert.Equal(t, "draining", r.BodyString())
+	// in-flight and keep-alive requests are served till the server stops
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+
+	t.Log("Mark ready is ignored once shutdown begins")
+	ts.app.MarkReady()
+	assert.Equal(t, AppStateDraining, ts.app.State())
+
+	assert.Equal(t, "stopped", AppStateStopped.String())
+	assert.Equal(t, "unknown", AppState(10).String())
+}
+
+func TestHTTPEngineOnPanic(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"log.level": "warn",
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [On Panic]: %s", ts.URL)
+
+	type result struct {
+		path      string
+		recovered interface{}
+		stack     string
+	}
+	ch := make(chan result, 1)
+	he := ts.app.HTTPEngine()
+	he.OnPanic(func(ctx *Context, r interface{}, stack []byte) {
+		ch <- result{path: ctx.Req.Path, recovered: r, stack: string(stack)}
+		panic("callback panic is recovered")
+	})
+
+	ts.Get("/trigger-panic").AssertStatus(http.StatusInternalServerError)
+	select {
+	case res := <-ch:
+		assert.Equal(t, "/trigger-panic", res.path)
+		assert.Equal(t, "This panic flow test and recovery", res.recovered)
+		assert.True(t, strings.Contains(res.stack, "TriggerPanic"))
+	case <-time.After(2 * time.Second):
+		t.Error("OnPanic callback is not invoked")
+	}
+
+	t.Log("Queue is full")
+	he.panicQueue = make(chan *panicEvent, 1)
+	he.panicQueue <- &panicEvent{}
+	lr := ts.CaptureLog()
+	ts.Get("/trigger-panic").AssertStatus(http.StatusInternalServerError)
+	assert.True(t, strings.Contains(lr.String(), "Panic notify queue is full, dropping the event of /trigger-panic"))
+}
+
+func TestHTTPEngineBodyNotAllowedStatus(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Body Not Allowed Status]: %s", ts.URL)
+
+	testcases := []struct {
+		status        int
+		contentLength string
+	}{
+		{status: http.StatusSwitchingProtocols},
+		{status: http.StatusNoContent},
+		{status: http.StatusNotModified, contentLength: "24"},
+	}
+
+	for _, tc := range testcases {
+		t.Logf("Status %d", tc.status)
+		w := httptest.NewRecorder()
+		ctx := newContext(w, httptest.NewRequest(ahttp.MethodGet, ts.URL+"/get-text.html", nil))
+		ctx.a = ts.app
+		ctx.Reply().Status(tc.status).
+			Header(ahttp.HeaderContentLength, "24").
+			JSON(Data{"message": "not written"})
+		ts.app.he.writeReply(ctx)
+
+		assert.Equal(t, tc.status, w.Code)
+		assert.Equal(t, "", w.Body.String())
+		assert.Equal(t, "", w.Header().Get(ahttp.HeaderContentType))
+		assert.Equal(t, tc.contentLength, w.Header().Get(ahttp.HeaderContentLength))
+	}
+
+	t.Log("Status 200 body is written")
+	w := httptest.NewRecorder()
+	ctx := newContext(w, httptest.NewRequest(ahttp.MethodGet, ts.URL+"/get-text.html", nil))
+	ctx.a = ts.app
+	ctx.Reply().Ok().JSON(Data{"message": "written"})
+	ts.app.he.writeReply(ctx)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"message":"written"}`, strings.TrimSpace(w.Body.String()))
+	assert.Equal(t, ahttp.ContentTypeJSON.String(), w.Header().Get(ahttp.HeaderContentType))
+}
+
+type testPanicRender struct{}
+
+func (testPanicRender) Render(w io.Writer) error {
+	_, _ = w.Write([]byte("partial body"))
+	panic("render boom")
+}
+
+func TestHTTPEngineRenderPanic(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Render Panic]: %s", ts.URL)
+
+	lr := ts.CaptureLog()
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(ahttp.MethodGet, ts.URL+"/get-text.html", nil)
+	req.Header.Set(ahttp.HeaderAccept, ahttp.ContentTypeJSON.Mime)
+	ctx := newContext(w, req)
+	ctx.a = ts.app
+	ctx.Reply().Ok().Render(testPanicRender{})
+	func() {
+		defer ts.app.he.handleRecovery(ctx)
+		ts.app.he.writeReply(ctx)
+	}()
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.False(t, strings.Contains(w.Body.String(), "partial body"))
+	assert.True(t, strings.Contains(w.Body.String(), "Internal Server Error"))
+	assert.True(t, strings.Contains(lr.String(), "Response render error: panic: render boom"))
+
+	t.Log("Template details of render panic")
+	re := &Reply{Rdr: &htmlRender{
+		Template: template.Must(template.New("index.html").Parse("Hi {{.Name}}")),
+		Layout:   "index.html",
+		ViewArgs: Data{"Name": "aah"},
+	}}
+	err := renderBody(re, nil) // nil writer
+	assert.True(t, strings.HasPrefix(err.Error(), "template 'index.html' (layout 'index.html'): panic: "))
+}
+
+func TestHTTPEngineCommittedResponse(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Committed Response]: %s", ts.URL)
+
+	newCtx := func() (*Context, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		ctx := newContext(w, httptest.NewRequest(ahttp.MethodGet, ts.URL+"/get-text.html", nil))
+		ctx.a = ts.app
+		return ctx, w
+	}
+
+	t.Log("Error reply after response is committed")
+	_ = ts.app.Log().(*log.Logger).SetLevel("warn")
+	lr := ts.CaptureLog()
+	ctx, w := newCtx()
+	ctx.Res.WriteHeader(http.StatusAccepted)
+	_, _ = ctx.Res.Write([]byte("written directly"))
+	ctx.Reply().Error(newError(ErrAccessDenied, http.StatusForbidden))
+	ts.app.he.writeReply(ctx)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "written directly", w.Body.String())
+	assert.True(t, strings.Contains(lr.String(), "Response already committed with status 202, unable to reply error"))
+
+	t.Log("Panic after response is committed")
+	ctx, w = newCtx()
+	func() {
+		defer ts.app.he.handleRecovery(ctx)
+		_, _ = ctx.Res.Write([]byte("partial"))
+		panic("after commit")
+	}()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "partial", w.Body.String())
+	assert.True(t, strings.Contains(lr.String(), "Response already committed with status 200, unable to reply panic recovery"))
+
+	t.Log("Write fails partway, subsequent writes are no-op")
+	fw := &failingResponseWriter{ResponseWriter: httptest.NewRecorder(), limit: 5}
+	ctx = newContext(fw, httptest.NewRequest(ahttp.MethodGet, ts.URL+"/get-text.html", nil))
+	ctx.a = ts.app
+	ctx.Reply().Text("aah framework response")
+	func() {
+		defer ts.app.he.handleRecovery(ctx)
+		ts.app.he.writeReply(ctx)
+		_, _ = ctx.Res.Write([]byte("more"))
+		panic("after failed write")
+	}()
+	assert.Equal(t, 1, fw.headers)
+	assert.Equal(t, 1, fw.writes)
+	assert.True(t, strings.Contains(lr.String(), "write: broken pipe"))
+	assert.True(t, strings.Contains(lr.String(), "Response already committed with status 200, unable to reply panic recovery"))
+}
+
+func TestHTTPEngineContentLanguage(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Content-Language]: %s", ts.URL)
+
+	get := func(path, acceptLang string) *testutil.Result {
+		req, err := http.NewRequest(ahttp.MethodGet, ts.URL+path, nil)
+		assert.Nil(t, err)
+		if len(acceptLang) > 0 {
+			req.Header.Set(ahttp.HeaderAcceptLanguage, acceptLang)
+		}
+		return ts.Do(req)
+	}
+
+	get("/get-text.html", "en-US,en;q=0.8").AssertHeader(ahttp.HeaderContentLanguage, "en-US")
+	get("/get-text.html", "fr-CA").AssertHeader(ahttp.HeaderContentLanguage, "en")
+	get("/get-text.html", "").AssertHeader(ahttp.HeaderContentLanguage, "en")
+
+	t.Log("Locale override via query param")
+	get("/get-text.html?lang=en-US", "fr").AssertHeader(ahttp.HeaderContentLanguage, "en-US")
+
+	t.Log("Log fields")
+	w := httptest.NewRecorder()
+	ctx := ts.app.HTTPEngine().NewContext(w, httptest.NewRequest(ahttp.MethodGet, "/get-text.html", nil))
+	ctx.Req.SetLocale(ahttp.NewLocale("en-US"))
+	assert.Equal(t, "en-US", ctx.Log().(*log.Entry).Fields["locale"])
+
+	t.Log("Set by application")
+	ctx.Reply().Header(ahttp.HeaderContentLanguage, "de").Text("Hallo")
+	ts.app.he.writeReply(ctx)
+	assert.Equal(t, "de", w.Header().Get(ahttp.HeaderContentLanguage))
+	al := &accessLog{ResHdr: w.Header()}
+	assert.Equal(t, "de", al.GetResponseLocale())
+	al.ResHdr = http.Header{}
+	assert.Equal(t, "-", al.GetResponseLocale())
+
+	t.Log("Disabled")
+	ts.app.settings.ContentLanguage = false
+	r := get("/get-text.html", "en-US")
+	assert.Equal(t, "", r.Header.Get(ahttp.HeaderContentLanguage))
+	ts.app.settings.ContentLanguage = true
+}
+
+type failingResponseWriter struct {
+	http.ResponseWriter
+	limit   int
+	writes  int
+	headers int
+}
+
+func (f *failingResponseWriter) WriteHeader(code int) {
+	f.headers++
+	f.ResponseWriter.WriteHeader(code)
+}
+
+func (f *failingResponseWriter) Write(b []byte) (int, error) {
+	f.writes++
+	if len(b) > f.limit {
+		n, _ := f.ResponseWriter.Write(b[:f.limit])
+		return n, errors.New("write: broken pipe")
+	}
+	return f.ResponseWriter.Write(b)
+}
+
+func TestHTTPEngineOnRequestParse(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [OnRequestParse]: %s", ts.URL)
+
+	var events []string
+	he := ts.app.HTTPEngine()
+	he.OnRequestParse(func(r *ahttp.Request) {
+		events = append(events, "OnRequestParse")
+		r.Header.Set("X-Tenant", strings.Split(r.Host, ":")[0])
+	})
+	he.OnRequest(func(e *Event) {
+		ctx := e.Data.(*Context)
+		events = append(events, "OnRequest:"+ctx.Req.Header.Get("X-Tenant"))
+	})
+	defer func() {
+		he.onReqParseFunc = nil
+		he.onRequestFunc = nil
+	}()
+
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+	assert.Equal(t, []string{"OnRequestParse", "OnRequest:127.0.0.1"}, events)
+}
+
+func TestHTTPEngineForwardedProto(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Forwarded Proto]: %s", ts.URL)
+
+	var scheme string
+	he := ts.app.HTTPEngine()
+	he.OnRequest(func(e *Event) {
+		scheme = e.Data.(*Context).Req.Scheme
+	})
+	defer func() {
+		he.onRequestFunc = nil
+		ts.app.settings.ForwardedProtoEnabled = true
+		ts.app.settings.ForwardedProtoTrusted = nil
+	}()
+
+	get := func() string {
+		req, _ := http.NewRequest(ahttp.MethodGet, ts.URL+"/get-text.html", nil)
+		req.Header.Set(ahttp.HeaderXForwardedProto, "https")
+		ts.Do(req).AssertStatus(http.StatusOK)
+		return scheme
+	}
+
+	// all upstreams are trusted by default
+	assert.Equal(t, "https", get())
+
+	// untrusted upstream
+	ts.app.settings.ForwardedProtoTrusted, _ = proxyproto.ParseCIDRs([]string{"10.0.0.0/8"})
+	assert.Equal(t, "http", get())
+
+	// trusted upstream
+	ts.app.settings.ForwardedProtoTrusted, _ = proxyproto.ParseCIDRs([]string{"10.0.0.0/8", "127.0.0.1"})
+	assert.Equal(t, "https", get())
+
+	// disabled
+	ts.app.settings.ForwardedProtoEnabled = false
+	assert.Equal(t, "http", get())
+}
+
+func TestHTTPEngineSlowRequestLog(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"server.slow_request.enable":    true,
+		"server.slow_request.threshold": "1s",
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Slow Request Log]: %s", ts.URL)
+
+	tc := NewFakeClock(time.Date(2018, time.October, 10, 10, 10, 10, 0, time.UTC))
+	ts.app.SetClock(tc)
+	defer ts.app.SetClock(nil)
+
+	var delay time.Duration
+	ts.app.HTTPEngine().OnRequest(func(e *Event) { tc.Advance(delay) })
+	defer func() { ts.app.HTTPEngine().onRequestFunc = nil }()
+
+	lr := ts.CaptureLog()
+	ts.app.Log().(*log.Logger).SetLevel("warn")
+
+	t.Log("Request within threshold")
+	delay = 500 * time.Millisecond
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+	assert.False(t, strings.Contains(lr.String(), "Slow request"))
+
+	t.Log("Request exceeds threshold")
+	delay = 2 * time.Second
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+	ts.AssertLogContains("Slow request, Method: GET, Path: /get-text.html, Route: text_get, Status: 200, " +
+		"Elapsed: 2s, Threshold: 1s, Breakdown: [action: 0s, reply: 0s]")
+
+	t.Log("Route level threshold")
+	route := ts.app.Router().Lookup("localhost").LookupByName("text_get")
+	route.SlowThreshold = 5 * time.Second
+	defer func() { route.SlowThreshold = 0 }()
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+	assert.Equal(t, 1, strings.Count(lr.String(), "Slow request"))
+}
+
+func TestHTTPEngineMaxResponseSize(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"render.max_response_size": "10b",
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Max Response Size]: %s", ts.URL)
+
+	lr := ts.CaptureLog()
+	ts.app.Log().(*log.Logger).SetLevel("warn")
+
+	t.Log("Warning in dev profile")
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+	ts.AssertLogContains("exceeds the limit of 10 bytes, Path: /get-text.html")
+
+	t.Log("Strict mode")
+	ts.app.settings.MaxResponseSizeStrict = true
+	defer func() { ts.app.settings.MaxResponseSizeStrict = false }()
+	result := ts.Get("/get-text.html")
+	result.AssertStatus(http.StatusInternalServerError)
+	assert.False(t, strings.Contains(result.BodyString(), "This is text render response"))
+	assert.Contains(t, lr.String(), "Response size")
+
+	t.Log("Within the limit")
+	ts.app.settings.MaxResponseSize = 1 << 20
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+}
+
+func TestHTTPEngineRouteDefaultStatus(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Route Default Status]: %s", ts.URL)
+
+	ts.SetMiddlewares(
+		RouteMiddleware,
+		BindMiddleware,
+		ActionMiddleware,
+	)
+
+	t.Log("Route default status")
+	req, _ := http.NewRequest(ahttp.MethodPost, ts.URL+"/create-record-created", strings.NewReader(`{"first_name":"Jeeva"}`))
+	req.Header.Set(ahttp.HeaderContentType, ahttp.ContentTypeJSON.String())
+	ts.Do(req).AssertStatus(http.StatusCreated).AssertJSONPath("data.first_name", "Jeeva")
+
+	t.Log("Route without default status")
+	req, _ = http.NewRequest(ahttp.MethodPost, ts.URL+"/create-record", strings.NewReader(`{"first_name":"Jeeva"}`))
+	req.Header.Set(ahttp.HeaderContentType, ahttp.ContentTypeJSON.String())
+	ts.Do(req).AssertStatus(http.StatusOK)
+
+	write := func(fn func(re *Reply)) int {
+		w := httptest.NewRecorder()
+		ctx := newContext(w, httptest.NewRequest(ahttp.MethodPost, "http://localhost:8080/records", nil))
+		ctx.a = ts.app
+		ctx.route = &router.Route{Name: "records", DefaultStatus: http.StatusAccepted}
+		fn(ctx.Reply())
+		ts.app.he.writeReply(ctx)
+		return w.Code
+	}
+
+	t.Log("Explicit status takes precedence")
+	assert.Equal(t, http.StatusAccepted, write(func(re *Reply) { re.Text("queued") }))
+	assert.Equal(t, http.StatusOK, write(func(re *Reply) { re.Ok().Text("done") }))
+	assert.Equal(t, http.StatusFound, write(func(re *Reply) { re.Redirect("/records/1") }))
+}
@@ -27,7 +27,22 @@
 // 		message.nl
 // 		etc.
 //
+// Message files of other formats are supported by file extension, i.e.
+// `messages.<Language-ID>.<extension>`. Built-in formats are gettext PO
+// (`.po`) and JSON (`.json`), refer to `LoadPO` and `LoadJSON`. Custom format
+// is added via option `Format`.
+//
+// 	For Example:
+// 		messages.en-US.po
+// 		messages.fr.json
+//
+// Message lookup falls back as per locale tag hierarchy then the default
+// locale, for e.g.: `fr-CA`, `fr`, `en`. So message files of region could
+// have only the messages which differs from the language, refer to
+// `FallbackChain`.
+//
 // Note: Sub directories is supported, so you can organize message files.
+// Message files of same Language ID are merged irrespective of the format.
 package i18n
 
 import (
@@ -37,6 +52,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"aahframe.work/ahttp"
 	"aahframe.work/config"
@@ -44,6 +60,8 @@ import (
 	"aahframe.work/vfs"
 )
 
+var localeRegex = regexp.MustCompile(`^[a-z]{2}(\-[a-z]{2})?$`)
+
 // I18ner interface is used to implement i18n message store.
 type I18ner interface {
 	Lookup(locale *ahttp.Locale, key string, args ...interface{}) string
@@ -60,9 +78,10 @@ func New(l log.Loggerer, opts ...Option) *I18n {
 	msgStore := &I18n{
 		RWMutex:       sync.RWMutex{},
 		store:         make(map[string]*config.Config),
-		fileExtRegex:  regexp.MustCompile(`messages\.[a-z]{2}(\-[a-zA-Z]{2})?$`),
+		fileExtRegex:  regexp.MustCompile(`messages\.[a-z]{2}(\-[a-zA-Z]{2})?(\.[a-zA-Z]+)?$`),
 		defaultLocale: "en",
 		files:         make([]string, 0),
+		loaders:       map[string]Loader{".json": LoadJSON, ".po": LoadPO},
 		log:           l,
 	}
 	for _, opt := range opts {
@@ -71,6 +90,22 @@ func New(l log.Loggerer, opts ...Option) *I18n {
 	return msgStore
 }
 
+// Hierarchy method returns the given locale tag and its parents, derived by
+// removing the last subtag one by one, for e.g.:
+// 	zh-Hant-TW   => zh-Hant-TW, zh-Hant, zh
+func Hierarchy(tag string) []string {
+	var tags []string
+	for len(tag) > 0 {
+		tags = append(tags, tag)
+		idx := strings.LastIndexByte(tag, '-')
+		if idx == -1 {
+			break
+		}
+		tag = tag[:idx]
+	}
+	return tags
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // I18n options type and methods
 //______________________________________________________________________________
@@ -107,14 +142,8 @@ func Dirs(dirs ...string) Option {
 				i.log.Warnf("i18n: %v not exists or error, let's move on", d)
 				continue
 			}
-			_ = vfs.Walk(i.fs, d, func(fpath string, fi os.FileInfo, _ error) error {
-				if !fi.IsDir() {
-					if i.fileExtRegex.MatchString(fi.Name()) {
-						i.files = append(i.files, fpath)
-					}
-				}
-				return nil
-			})
+			i.sources = append(i.sources, d)
+			i.files = append(i.files, i.scanDir(d)...)
 		}
 	}
 }
@@ -129,6 +158,7 @@ func Files(files ...string) Option {
 				i.log.Warnf("i18n: %v not exists, let's move on", f)
 				continue
 			}
+			i.sources = append(i.sources, f)
 			i.files = append(i.files, f)
 		}
 	}
@@ -145,7 +175,10 @@ type I18n struct {
 	store         map[string]*config.Config
 	defaultLocale string
 	files         []string
+	sources       []string
+	modTimes      map[string]time.Time
 	fileExtRegex  *regexp.Regexp
+	loaders       map[string]Loader
 	fs            vfs.FileSystem
 	log           log.Loggerer
 }
@@ -157,50 +190,52 @@ var _ I18ner = (*I18n)(nil)
 // before its return. If given message key or store doesn't exists for given locale;
 // Lookup method returns empty string.
 // 	Lookup(locale, "i.love.aah.framework", "yes")
-// The sequence and fallback order of message fetch from store is -
-// 	* language and region-id (e.g.: en-US)
-// 	* language (e.g.: en)
+// The sequence and fallback order of message fetch from store is as per
+// `FallbackChain`, for e.g.: `fr-CA`, `fr` then default locale `en`. If
+// message key doesn't exists in any of them, key is returned.
 func (s *I18n) Lookup(locale *ahttp.Locale, key string, args ...interface{}) string {
 	s.RLock()
 	defer s.RUnlock()
-	// assign default locale if nil
-	if locale == nil {
-		locale = ahttp.NewLocale(s.defaultLocale)
-	}
-
-	// Lookup by language and region-id. For eg.: en-us
-	store := s.findStoreByLocale(locale.String())
-	if store == nil {
-		goto langStore
-	}
-	if msg, found := retriveValue(store, key, args...); found {
-		s.log.Tracef("i18n message is retrieved from locale: %v, key: %v", locale, key)
-		return msg
-	}
-
-langStore:
-	// Lookup by language. For eg.: en
-	store = s.findStoreByLocale(locale.Language)
-	if store == nil {
-		goto defaultStore
-	}
-	if msg, found := retriveValue(store, key, args...); found {
-		s.log.Tracef("i18n message is retrieved from locale: %v, key: %v", locale.Language, key)
-		return msg
+	for _, tag := range s.fallbackChain(locale) {
+		store := s.findStoreByLocale(tag)
+		if store == nil {
+			continue
+		}
+		if msg, found := retriveValue(store, key, args...); found {
+			s.log.Tracef("i18n message is retrieved from locale: %v, key: %v", tag, key)
+			return msg
+		}
 	}
+	return key
+}
 
-defaultStore: // fallback to `i18n.default` config value.
-	store = s.findStoreByLocale(s.defaultLocale)
-	if store == nil {
-		goto notExists
-	}
-	if msg, found := retriveValue(store, key, args...); found {
-		s.log.Tracef("i18n message is retrieved from locale: %v, key: %v", s.defaultLocale, key)
-		return msg
+// Resolve method returns the locale of message store which serves the given
+// locale, lookup order is same as `Lookup` i.e. `FallbackChain`. It returns
+// empty string if none of them exists in the message store.
+func (s *I18n) Resolve(locale *ahttp.Locale) string {
+	s.RLock()
+	defer s.RUnlock()
+	for _, tag := range s.fallbackChain(locale) {
+		if s.findStoreByLocale(tag) != nil {
+			return tag
+		}
 	}
+	return ""
+}
 
-notExists:
-	return key
+// FallbackChain method returns the message lookup order of given locale. It
+// is derived from the locale tag hierarchy by removing the last subtag one
+// by one, followed by the default locale and its hierarchy. Duplicates are
+// removed, for e.g.:
+// 	fr-CA        => fr-CA, fr, en
+// 	zh-Hant-TW   => zh-Hant-TW, zh-Hant, zh, en
+// 	en-GB        => en-GB, en (default locale `en`)
+// 	pt-BR        => pt-BR, pt, en-US, en (default locale `en-US`)
+// Default locale is used if given locale is nil.
+func (s *I18n) FallbackChain(locale *ahttp.Locale) []string {
+	s.RLock()
+	defer s.RUnlock()
+	return s.fallbackChain(locale)
 }
 
 // DefaultLocale method returns the i18n store's default locale.
@@ -224,39 +259,193 @@ func (s *I18n) Locales() []string {
 // Load method loads message files into message store.
 // Returns error for any failures.
 func (s *I18n) Init() error {
-	for _, f := range s.files {
-		if err := s.add2Store(f); err != nil {
+	s.RLock()
+	files := s.files
+	s.RUnlock()
+	store, err := s.load(files)
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	for key, msgs := range store {
+		if err = s.addMessages(s.store, key, msgs); err != nil {
 			return err
 		}
 	}
+	s.modTimes = s.fileModTimes(files)
 	return nil
 }
 
+// Reload method rescans the message directories and files supplied via
+// options then reloads the message store. New message store is swapped in
+// once all the files are loaded successfully, so concurrent lookups never
+// observe the partially loaded messages. On error existing message store is
+// retained.
+func (s *I18n) Reload() error {
+	files := s.discoverFiles()
+	store, err := s.load(files)
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.store = store
+	s.files = files
+	s.modTimes = s.fileModTimes(files)
+	return nil
+}
+
+// Changed method returns true if message files are added, removed or
+// modified since the last load, it is used to decide the `Reload`.
+func (s *I18n) Changed() bool {
+	modTimes := s.fileModTimes(s.discoverFiles())
+	s.RLock()
+	defer s.RUnlock()
+	if len(modTimes) != len(s.modTimes) {
+		return true
+	}
+	for f, t := range modTimes {
+		if lt, found := s.modTimes[f]; !found || !lt.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // I18n Unexported methods
 //______________________________________________________________________________
 
-func (s *I18n) add2Store(file string) error {
-	key := strings.ToLower(filepath.Ext(file)[1:])
+// load method loads the given message files into new message store.
+func (s *I18n) load(files []string) (map[string]*config.Config, error) {
+	store := make(map[string]*config.Config)
+	for _, f := range files {
+		key, msgFile, err := s.loadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if msgFile == nil {
+			continue
+		}
+		if err = s.addMessages(store, key, msgFile); err != nil {
+			return nil, fmt.Errorf("i18n: error while adding into message store file: %v, error: %v", f, err)
+		}
+	}
+	return store, nil
+}
+
+// loadFile method parses the message file and returns the locale key and its
+// messages. Messages is nil for unsupported message file format.
+func (s *I18n) loadFile(file string) (string, *config.Config, error) {
+	name := filepath.Base(file)
+	loader := s.loaders[strings.ToLower(filepath.Ext(name))]
+	if loader != nil {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	key := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+	if loader == nil && !localeRegex.MatchString(key) {
+		s.log.Warnf("i18n: unsupported message file format: %v, let's move on", file)
+		return key, nil, nil
+	}
+
 	s.log.Tracef("Adding into i18n message store [%v: %v]", key, file)
-	msgFile, err := config.LoadFile(file)
+	var msgFile *config.Config
+	var err error
+	if loader == nil {
+		msgFile, err = config.LoadFile(file)
+	} else {
+		var b []byte
+		if b, err = vfs.ReadFile(s.fs, file); err == nil {
+			msgFile, err = loader(b)
+		}
+	}
 	if err != nil {
-		return fmt.Errorf("i18n: unable to process message file: %v, error: %v", file, err)
+		return key, nil, fmt.Errorf("i18n: unable to process message file: %v, error: %v", file, err)
 	}
+	return key, msgFile, nil
+}
 
-	// merge messages if key is already exists otherwise add it
-	s.Lock()
-	defer s.Unlock()
-	if ms, exists := s.store[key]; exists {
-		if err = ms.Merge(msgFile); err != nil {
-			return fmt.Errorf("i18n: error while adding into message store file: %v", file)
-		}
-	} else {
-		s.store[key] = msgFile
+// addMessages method merges messages if key is already exists otherwise
+// add it.
+func (s *I18n) addMessages(store map[string]*config.Config, key string, msgs *config.Config) error {
+	if ms, exists := store[key]; exists {
+		return ms.Merge(msgs)
 	}
+	store[key] = msgs
 	return nil
 }
 
+// discoverFiles method returns the message files of directories and files
+// supplied via options, in the order of options.
+func (s *I18n) discoverFiles() []string {
+	s.RLock()
+	sources := s.sources
+	s.RUnlock()
+	files := make([]string, 0)
+	for _, src := range sources {
+		if vfs.IsDir(s.fs, src) {
+			files = append(files, s.scanDir(src)...)
+		} else if vfs.IsExists(s.fs, src) {
+			files = append(files, src)
+		}
+	}
+	return files
+}
+
+func (s *I18n) scanDir(dir string) []string {
+	var files []string
+	_ = vfs.Walk(s.fs, dir, func(fpath string, fi os.FileInfo, err error) error {
+		if err == nil && !fi.IsDir() && s.fileExtRegex.MatchString(fi.Name()) {
+			files = append(files, fpath)
+		}
+		return nil
+	})
+	return files
+}
+
+func (s *I18n) fileModTimes(files []string) map[string]time.Time {
+	modTimes := make(map[string]time.Time, len(files))
+	for _, f := range files {
+		if fi, err := vfs.Stat(s.fs, f); err == nil {
+			modTimes[f] = fi.ModTime()
+		}
+	}
+	return modTimes
+}
+
+func (s *I18n) fallbackChain(locale *ahttp.Locale) []string {
+	var chain []string
+	add := func(tag string) {
+		for _, t := range Hierarchy(tag) {
+			var found bool
+			for _, c := range chain {
+				if strings.EqualFold(c, t) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				chain = append(chain, t)
+			}
+		}
+	}
+
+	if locale != nil {
+		tag := locale.Language
+		if len(tag) == 0 {
+			tag = locale.Raw
+		} else if len(locale.Region) > 0 {
+			tag += "-" + locale.Region
+		}
+		add(tag)
+	}
+	add(s.defaultLocale)
+	return chain
+}
+
 func (s *I18n) findStoreByLocale(locale string) *config.Config {
 	if store, exists := s.store[strings.ToLower(locale)]; exists {
 		return store
@@ -8,7 +8,9 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"aahframe.work/ahttp"
 	"aahframe.work/config"
@@ -162,6 +164,125 @@ func TestMsgRetriveNotFoundLocale(t *testing.T) {
 	assert.Equal(t, "store.not.exists", notFoundStore)
 }
 
+func TestI18nResolve(t *testing.T) {
+	wd, _ := os.Getwd()
+	store := New(logger(), Dirs(filepath.Join(wd, "testdata")))
+	assert.Nil(t, store.Init())
+
+	testcases := []struct {
+		label  string
+		locale *ahttp.Locale
+		result string
+	}{
+		{label: "language and region", locale: ahttp.NewLocale("en-US"), result: "en-US"},
+		{label: "language of region", locale: ahttp.NewLocale("fr-BE"), result: "fr"},
+		{label: "language", locale: ahttp.NewLocale("it"), result: "it"},
+		{label: "default", locale: ahttp.NewLocale("pl-PL"), result: "en"},
+		{label: "nil locale", locale: nil, result: "en"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.label, func(t *testing.T) {
+			assert.Equal(t, tc.result, store.Resolve(tc.locale))
+		})
+	}
+
+	emptyStore := New(logger(), DefaultLocale("de"))
+	assert.Equal(t, "", emptyStore.Resolve(ahttp.NewLocale("en")))
+}
+
+func TestI18nFallbackChain(t *testing.T) {
+	wd, _ := os.Getwd()
+	store := New(logger(), Dirs(filepath.Join(wd, "testdata")))
+	assert.Nil(t, store.Init())
+
+	// fr-CA => fr => en
+	locale := ahttp.NewLocale("fr-CA")
+	assert.Equal(t, "Accueil fr-CA", store.Lookup(locale, "label.home"))
+	assert.Equal(t, "Précédent", store.Lookup(locale, "label.paginate.prev"))
+	assert.Equal(t, "Last", store.Lookup(locale, "label.paginate.last"))
+	assert.Equal(t, "label.not.exists", store.Lookup(locale, "label.not.exists"))
+
+	testcases := []struct {
+		label         string
+		defaultLocale string
+		locale        *ahttp.Locale
+		result        []string
+	}{
+		{label: "language and region", defaultLocale: "en", locale: ahttp.NewLocale("fr-CA"), result: []string{"fr-CA", "fr", "en"}},
+		{label: "language", defaultLocale: "en", locale: ahttp.NewLocale("fr"), result: []string{"fr", "en"}},
+		{label: "script subtag", defaultLocale: "en", locale: ahttp.NewLocale("zh-Hant-TW"), result: []string{"zh-Hant-TW", "zh-Hant", "zh", "en"}},
+		{label: "default language", defaultLocale: "en", locale: ahttp.NewLocale("en-GB"), result: []string{"en-GB", "en"}},
+		{label: "regional default", defaultLocale: "en-US", locale: ahttp.NewLocale("pt-BR"), result: []string{"pt-BR", "pt", "en-US", "en"}},
+		{label: "accept-language quality", defaultLocale: "en", locale: &ahttp.Locale{Raw: "fr-CA;q=0.8", Language: "fr", Region: "CA"}, result: []string{"fr-CA", "fr", "en"}},
+		{label: "nil locale", defaultLocale: "de-AT", locale: nil, result: []string{"de-AT", "de"}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.label, func(t *testing.T) {
+			s := New(logger(), DefaultLocale(tc.defaultLocale))
+			assert.Equal(t, tc.result, s.FallbackChain(tc.locale))
+		})
+	}
+
+	// configured default locale is the ultimate fallback
+	store = New(logger(), DefaultLocale("fr"), Dirs(filepath.Join(wd, "testdata")))
+	assert.Nil(t, store.Init())
+	assert.Equal(t, "Suivant", store.Lookup(ahttp.NewLocale("pl-PL"), "label.paginate.next"))
+	assert.Equal(t, "fr", store.Resolve(ahttp.NewLocale("pl-PL")))
+}
+
+func TestI18nReload(t *testing.T) {
+	dir := t.TempDir()
+	enFile := filepath.Join(dir, "messages.en")
+	assert.Nil(t, ioutil.WriteFile(enFile, []byte("label {\n  home = \"Home\"\n}\n"), 0644))
+
+	store := New(logger(), Dirs(dir))
+	assert.Nil(t, store.Init())
+	assert.False(t, store.Changed())
+	assert.Equal(t, "Home", store.Lookup(ahttp.NewLocale("en"), "label.home"))
+
+	// concurrent lookups during reload
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			msg := store.Lookup(ahttp.NewLocale("en"), "label.home")
+			assert.True(t, msg == "Home" || msg == "Home Page")
+		}
+	}()
+
+	// modified file
+	assert.Nil(t, ioutil.WriteFile(enFile, []byte("label {\n  home = \"Home Page\"\n}\n"), 0644))
+	mtime := time.Now().Add(2 * time.Second)
+	assert.Nil(t, os.Chtimes(enFile, mtime, mtime))
+	assert.True(t, store.Changed())
+	assert.Nil(t, store.Reload())
+	<-done
+	assert.False(t, store.Changed())
+	assert.Equal(t, "Home Page", store.Lookup(ahttp.NewLocale("en"), "label.home"))
+
+	// new file
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "messages.fr.json"), []byte(`{"label.home": "Accueil"}`), 0644))
+	assert.True(t, store.Changed())
+	assert.Nil(t, store.Reload())
+	assert.Equal(t, "Accueil", store.Lookup(ahttp.NewLocale("fr"), "label.home"))
+
+	// invalid file, existing messages are retained
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "messages.fr.json"), []byte(`{"label.home": `), 0644))
+	err := store.Reload()
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "i18n: unable to process message file:"))
+	assert.Equal(t, "Accueil", store.Lookup(ahttp.NewLocale("fr"), "label.home"))
+
+	// removed file
+	assert.Nil(t, os.Remove(filepath.Join(dir, "messages.fr.json")))
+	assert.True(t, store.Changed())
+	assert.Nil(t, store.Reload())
+	assert.Equal(t, "Home Page", store.Lookup(ahttp.NewLocale("fr"), "label.home"))
+	assert.Equal(t, []string{"en"}, store.Locales())
+}
+
 func logger() log.Loggerer {
 	l, _ := log.New(config.NewEmpty())
 	l.SetWriter(ioutil.Discard)
@@ -0,0 +1,235 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package i18n
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"aahframe.work/config"
+)
+
+// Loader func type parses the content of message file format into messages
+// config, for e.g.: gettext `.po`, JSON. Message keys are dot separated
+// path same as native message file, for e.g.: `label.pages.home.title`.
+type Loader func(b []byte) (*config.Config, error)
+
+// Format option func registers the message file loader for given file
+// extension, for e.g.: `.yaml`. It overrides the built-in loader of same
+// extension. Message file name is `messages.<Language-ID>.<extension>`.
+//
+//	i18n.New(logger, i18n.Format(".yaml", yamlLoader), i18n.Dirs(dir))
+func Format(ext string, loader Loader) Option {
+	return func(i *I18n) {
+		i.Lock()
+		defer i.Unlock()
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		i.loaders[strings.ToLower(ext)] = loader
+	}
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Built-in loaders
+//______________________________________________________________________________
+
+// LoadJSON method parses the JSON message file content. Messages could be
+// flat key-value pairs or nested objects, nested keys are joined by dot.
+//
+//	{
+//	  "label.home": "Home",
+//	  "label": {
+//	    "paginate": { "prev": "Previous", "next": "Next" }
+//	  }
+//	}
+func LoadJSON(b []byte) (*config.Config, error) {
+	var values map[string]interface{}
+	if err := json.Unmarshal(b, &values); err != nil {
+		return nil, err
+	}
+
+	msgs := make(map[string]string)
+	if err := flattenJSON("", values, msgs); err != nil {
+		return nil, err
+	}
+	return toConfig(msgs), nil
+}
+
+// LoadPO method parses the gettext PO message file content. Value of
+// `msgctxt` is the message key and `msgstr` is the message, so PO files
+// translated from source text (`msgid`) could be used by adding `msgctxt`.
+// Entry without `msgctxt` uses `msgid` as the key, it is skipped if it is
+// not a valid message key, for e.g.: source text with spaces.
+//
+// Plural forms `msgstr[0]` is used as message. Untranslated entries (empty
+// `msgstr`), fuzzy entries (`#, fuzzy`) and header entry (empty `msgid`) are
+// skipped.
+//
+//	#: app/views/pages/app/index.html
+//	msgctxt "label.home"
+//	msgid "Home"
+//	msgstr "Startseite"
+func LoadPO(b []byte) (*config.Config, error) {
+	msgs := make(map[string]string)
+	e := &poEntry{}
+	var field *string
+	lineNo := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+
+		if line[0] == '#' {
+			if strings.HasPrefix(line, "#,") && strings.Contains(line, "fuzzy") {
+				if e.strSet {
+					e.add(msgs)
+				}
+				e.fuzzy = true
+			}
+			continue
+		}
+
+		// continuation of previous field value
+		if line[0] == '"' {
+			if field == nil {
+				return nil, fmt.Errorf("line %d: unexpected string", lineNo)
+			}
+			v, err := strconv.Unquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNo, err)
+			}
+			*field += v
+			continue
+		}
+
+		idx := strings.IndexByte(line, ' ')
+		if idx == -1 {
+			return nil, fmt.Errorf("line %d: invalid entry '%s'", lineNo, line)
+		}
+		keyword, value := line[:idx], strings.TrimSpace(line[idx+1:])
+		v, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNo, err)
+		}
+
+		switch {
+		case keyword == "msgctxt":
+			if e.strSet {
+				e.add(msgs)
+			}
+			field = &e.ctxt
+		case keyword == "msgid":
+			if e.strSet {
+				e.add(msgs)
+			}
+			field = &e.id
+		case keyword == "msgid_plural":
+			field = &e.plural
+		case keyword == "msgstr" || keyword == "msgstr[0]":
+			e.strSet = true
+			field = &e.str
+		case strings.HasPrefix(keyword, "msgstr["):
+			e.strSet = true
+			field = &e.other // other plural forms are not used
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword '%s'", lineNo, keyword)
+		}
+		*field += v
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	e.add(msgs)
+
+	return toConfig(msgs), nil
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Unexported types and methods
+//______________________________________________________________________________
+
+type poEntry struct {
+	ctxt   string
+	id     string
+	plural string
+	str    string
+	other  string
+	strSet bool
+	fuzzy  bool
+}
+
+// add method adds the entry into messages if applicable and resets the entry.
+func (e *poEntry) add(msgs map[string]string) {
+	key := e.ctxt
+	if len(key) == 0 {
+		key = e.id
+	}
+	if len(e.id) > 0 && len(e.str) > 0 && !e.fuzzy && isMessageKey(key) {
+		msgs[key] = e.str
+	}
+	*e = poEntry{}
+}
+
+// isMessageKey method returns true if given value is dot separated message
+// key without spaces, for e.g.: `label.pages.home.title`.
+func isMessageKey(key string) bool {
+	if strings.ContainsAny(key, " \t\r\n") {
+		return false
+	}
+	for _, s := range strings.Split(key, ".") {
+		if len(s) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func flattenJSON(prefix string, values map[string]interface{}, msgs map[string]string) error {
+	for k, v := range values {
+		key := k
+		if len(prefix) > 0 {
+			key = prefix + "." + k
+		}
+		switch tv := v.(type) {
+		case string:
+			msgs[key] = tv
+		case map[string]interface{}:
+			if err := flattenJSON(key, tv, msgs); err != nil {
+				return err
+			}
+		case float64, bool:
+			msgs[key] = fmt.Sprint(tv)
+		default:
+			return fmt.Errorf("unsupported value for key '%s'", key)
+		}
+	}
+	return nil
+}
+
+// toConfig method creates the messages config, keys are added in sorted
+// order for deterministic result.
+func toConfig(msgs map[string]string) *config.Config {
+	keys := make([]string, 0, len(msgs))
+	for k := range msgs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	cfg := config.NewEmpty()
+	for _, k := range keys {
+		cfg.SetString(k, msgs[k])
+	}
+	return cfg
+}
@@ -0,0 +1,117 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package i18n
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"aahframe.work/ahttp"
+	"aahframe.work/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoaderPO(t *testing.T) {
+	wd, _ := os.Getwd()
+	store := New(logger(), Dirs(filepath.Join(wd, "testdata", "deutsch")))
+	assert.Nil(t, store.Init())
+
+	locale := ahttp.NewLocale("de")
+	assert.Equal(t, "Startseite", store.Lookup(locale, "label.home"))
+	assert.Equal(t, "Benutzer hinzufügen", store.Lookup(locale, "label.add", "Benutzer"))
+	assert.Equal(t, "Weiter", store.Lookup(locale, "label.paginate.next"))
+	assert.Equal(t, "Eintrag", store.Lookup(locale, "label.item"))
+	assert.Equal(t, "label.untranslated", store.Lookup(locale, "label.untranslated"))
+	assert.Equal(t, "label.fuzzy", store.Lookup(locale, "label.fuzzy"))
+	assert.Equal(t, "Hello, %v.", store.Lookup(locale, "Hello, %v."))
+
+	cfg, err := LoadPO([]byte("msgid \"Sign in\"\nmsgstr \"Anmelden\"\n\nmsgid \"label..empty\"\nmsgstr \"Leer\""))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(cfg.Keys()))
+
+	testcases := []struct {
+		label string
+		input string
+		err   string
+	}{
+		{label: "unknown keyword", input: "msgfoo \"a\"", err: "line 1: unknown keyword 'msgfoo'"},
+		{label: "invalid entry", input: "msgid", err: "line 1: invalid entry 'msgid'"},
+		{label: "unquoted value", input: "msgid label", err: "line 1: invalid syntax"},
+		{label: "unexpected string", input: "\"label\"", err: "line 1: unexpected string"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.label, func(t *testing.T) {
+			_, err := LoadPO([]byte(tc.input))
+			assert.Equal(t, tc.err, err.Error())
+		})
+	}
+}
+
+func TestLoaderJSON(t *testing.T) {
+	wd, _ := os.Getwd()
+	store := New(logger(), Dirs(filepath.Join(wd, "testdata", "deutsch")))
+	assert.Nil(t, store.Init())
+	assert.Contains(t, store.Locales(), "de-at")
+
+	locale := ahttp.NewLocale("de-AT")
+	assert.Equal(t, "Startseite Österreich", store.Lookup(locale, "label.home"))
+	assert.Equal(t, "Zurück", store.Lookup(locale, "label.paginate.prev"))
+	assert.Equal(t, "2", store.Lookup(locale, "label.count"))
+
+	// falls back to language `de` from PO file
+	assert.Equal(t, "Weiter", store.Lookup(locale, "label.paginate.next"))
+
+	_, err := LoadJSON([]byte(`{"label": ["a"]}`))
+	assert.Equal(t, "unsupported value for key 'label'", err.Error())
+
+	_, err = LoadJSON([]byte(`{"label": `))
+	assert.NotNil(t, err)
+}
+
+func TestLoaderCustomFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "i18n")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"messages.it.txt": "label.home=Casa",
+		"messages.it.bak": "label.home=Backup",
+	} {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	txtLoader := func(b []byte) (*config.Config, error) {
+		cfg := config.NewEmpty()
+		for _, line := range strings.Split(string(b), "\n") {
+			if parts := strings.SplitN(line, "=", 2); len(parts) == 2 {
+				cfg.SetString(parts[0], parts[1])
+			}
+		}
+		return cfg, nil
+	}
+
+	store := New(logger(), Format("txt", txtLoader), Dirs(dir))
+	assert.Nil(t, store.Init())
+	assert.Equal(t, []string{"it"}, store.Locales())
+	assert.Equal(t, "Casa", store.Lookup(ahttp.NewLocale("it"), "label.home"))
+}
+
+func TestLoaderMergeError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "i18n")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "messages.fr"), []byte("label = \"Étiquette\"\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "messages.fr.json"), []byte(`{"label": {"home": "Accueil"}}`), 0644))
+
+	store := New(logger(), Dirs(dir))
+	err = store.Init()
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "i18n: error while adding into message store file: "), err.Error())
+	assert.True(t, strings.Contains(err.Error(), ", error: "), err.Error())
+}
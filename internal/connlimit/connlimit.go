@@ -0,0 +1,167 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package connlimit implements the listener which limits the no. of
+// concurrent connections per remote IP address at TCP layer. Connection
+// beyond the limit is closed right after accept, before any bytes are read.
+//
+// IPv6 client typically gets the whole prefix (for e.g.: /64) from the ISP,
+// so it could rotate the addresses within the prefix. Hence IPv6 addresses
+// are grouped by the configured prefix length. IPv4-mapped IPv6 addresses
+// are treated as IPv4.
+package connlimit
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultIPv6Prefix is the default prefix length of grouping IPv6 addresses.
+const DefaultIPv6Prefix = 64
+
+// NewListener method returns the connection limit listener for given
+// listener. Connections from `trusted` networks, for e.g.: load balancer,
+// reverse proxy, are not limited.
+func NewListener(l net.Listener, limit int, trusted []*net.IPNet, ipv6Prefix int) *Listener {
+	return &Listener{
+		Listener: l,
+		limit:    limit,
+		trusted:  trusted,
+		ipv6Mask: net.CIDRMask(ipv6Prefix, 128),
+		counts:   make(map[string]int),
+	}
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Listener
+//______________________________________________________________________________
+
+// Listener type wraps the `net.Listener` and limits the concurrent
+// connections per remote IP address.
+type Listener struct {
+	net.Listener
+
+	// OnReject is invoked with remote address of rejected connection.
+	OnReject func(addr net.Addr)
+
+	limit    int
+	trusted  []*net.IPNet
+	ipv6Mask net.IPMask
+	mu       sync.Mutex
+	counts   map[string]int
+	rejected int64
+}
+
+// Accept method waits for and returns the next connection to the listener,
+// connection beyond the limit is closed and next one is awaited.
+func (l *Listener) Accept() (net.Conn, error) {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		key, limited := l.key(c.RemoteAddr())
+		if !limited {
+			return c, nil
+		}
+		if l.acquire(key) {
+			return &Conn{Conn: c, l: l, key: key}, nil
+		}
+
+		atomic.AddInt64(&l.rejected, 1)
+		if l.OnReject != nil {
+			l.OnReject(c.RemoteAddr())
+		}
+		_ = c.Close()
+	}
+}
+
+// File method returns the file descriptor of underlying listener, it is
+// used by graceful restart.
+func (l *Listener) File() (*os.File, error) {
+	lf, ok := l.Listener.(interface {
+		File() (*os.File, error)
+	})
+	if !ok {
+		return nil, errors.New("connlimit: listener does not support file descriptor")
+	}
+	return lf.File()
+}
+
+// Rejected method returns the count of rejected connections.
+func (l *Listener) Rejected() int64 {
+	return atomic.LoadInt64(&l.rejected)
+}
+
+// Count method returns the current connection count of given IP address.
+func (l *Listener) Count(ip net.IP) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.counts[l.ipKey(ip)]
+}
+
+// key method returns the count key of given remote address and true if the
+// connection is subject to the limit. Trusted networks and non TCP
+// addresses, for e.g.: unix socket, are not limited.
+func (l *Listener) key(addr net.Addr) (string, bool) {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return "", false
+	}
+	for _, n := range l.trusted {
+		if n.Contains(tcpAddr.IP) {
+			return "", false
+		}
+	}
+	return l.ipKey(tcpAddr.IP), true
+}
+
+func (l *Listener) ipKey(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.String()
+	}
+	return ip.Mask(l.ipv6Mask).String()
+}
+
+func (l *Listener) acquire(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.counts[key] >= l.limit {
+		return false
+	}
+	l.counts[key]++
+	return true
+}
+
+func (l *Listener) release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.counts[key]--; l.counts[key] <= 0 {
+		delete(l.counts, key)
+	}
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Conn
+//______________________________________________________________________________
+
+// Conn type wraps the `net.Conn` and releases the count of remote IP
+// address on close.
+type Conn struct {
+	net.Conn
+	l    *Listener
+	key  string
+	once sync.Once
+}
+
+// Close method closes the connection and releases the count, it is safe to
+// call multiple times.
+func (c *Conn) Close() error {
+	c.once.Do(func() { c.l.release(c.key) })
+	return c.Conn.Close()
+}
@@ -0,0 +1,114 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package connlimit
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnLimitListener(t *testing.T) {
+	rl, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer rl.Close()
+
+	l := NewListener(rl, 2, nil, DefaultIPv6Prefix)
+	rejected := make(chan net.Addr, 1)
+	l.OnReject = func(addr net.Addr) { rejected <- addr }
+
+	accepted := make(chan net.Conn, 4)
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				close(accepted)
+				return
+			}
+			accepted <- c
+		}
+	}()
+
+	dial := func() net.Conn {
+		c, err := net.Dial("tcp", rl.Addr().String())
+		assert.Nil(t, err)
+		return c
+	}
+
+	c1, c2 := dial(), dial()
+	defer c1.Close()
+	defer c2.Close()
+	sc1, sc2 := <-accepted, <-accepted
+	assert.Equal(t, 2, l.Count(net.ParseIP("127.0.0.1")))
+
+	t.Log("Beyond the limit")
+	c3 := dial()
+	defer c3.Close()
+	_ = c3.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = c3.Read(make([]byte, 1))
+	assert.NotNil(t, err)
+	assert.Equal(t, c3.LocalAddr().String(), (<-rejected).String())
+	assert.Equal(t, int64(1), l.Rejected())
+
+	t.Log("Close releases the count, multiple closes are safe")
+	assert.Nil(t, sc1.Close())
+	_ = sc1.Close()
+	assert.Equal(t, 1, l.Count(net.ParseIP("127.0.0.1")))
+
+	c4 := dial()
+	defer c4.Close()
+	sc4 := <-accepted
+	assert.Equal(t, 2, l.Count(net.ParseIP("127.0.0.1")))
+
+	_ = sc2.Close()
+	_ = sc4.Close()
+	assert.Equal(t, 0, l.Count(net.ParseIP("127.0.0.1")))
+	assert.Empty(t, l.counts)
+
+	f, err := l.File()
+	assert.Nil(t, err)
+	assert.NotNil(t, f)
+	_ = f.Close()
+}
+
+func TestConnLimitTrusted(t *testing.T) {
+	_, trusted, _ := net.ParseCIDR("127.0.0.0/8")
+	l := NewListener(nil, 1, []*net.IPNet{trusted}, DefaultIPv6Prefix)
+
+	_, limited := l.key(&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 4000})
+	assert.False(t, limited)
+
+	_, limited = l.key(&net.UnixAddr{Name: "/tmp/aah.sock", Net: "unix"})
+	assert.False(t, limited)
+
+	key, limited := l.key(&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 4000})
+	assert.True(t, limited)
+	assert.Equal(t, "10.0.0.1", key)
+}
+
+func TestConnLimitIPKey(t *testing.T) {
+	testcases := []struct {
+		label  string
+		prefix int
+		ip     string
+		key    string
+	}{
+		{label: "ipv4", prefix: 64, ip: "192.168.1.10", key: "192.168.1.10"},
+		{label: "ipv4-mapped ipv6", prefix: 64, ip: "::ffff:192.168.1.10", key: "192.168.1.10"},
+		{label: "ipv6 /64", prefix: 64, ip: "2001:db8:1:2:aaaa:bbbb:cccc:dddd", key: "2001:db8:1:2::"},
+		{label: "ipv6 /64 same prefix", prefix: 64, ip: "2001:db8:1:2::1", key: "2001:db8:1:2::"},
+		{label: "ipv6 /48", prefix: 48, ip: "2001:db8:1:2::1", key: "2001:db8:1::"},
+		{label: "ipv6 /128", prefix: 128, ip: "2001:db8:1:2::1", key: "2001:db8:1:2::1"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.label, func(t *testing.T) {
+			l := NewListener(nil, 1, nil, tc.prefix)
+			assert.Equal(t, tc.key, l.ipKey(net.ParseIP(tc.ip)))
+		})
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package cron implements the minimal schedule spec parser used by aah
+// scheduled jobs. Supported specs are-
+//
+//	@every <duration>        for e.g.: @every 30s, @every 1h30m
+//	@hourly, @daily (@midnight), @weekly, @monthly, @yearly (@annually)
+//	<minute> <hour> <day-of-month> <month> <day-of-week>
+//
+// Cron fields supports `*`, values `5`, lists `1,15`, ranges `1-5` and
+// steps `*/10`, `0-30/5`. Day of week `0` and `7` is Sunday. Names of
+// month and day of week are not supported.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule interface returns the next activation time after the given time.
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+var descriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+type bounds struct {
+	name     string
+	min, max int
+}
+
+var fieldBounds = []bounds{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day-of-month", 1, 31},
+	{"month", 1, 12},
+	{"day-of-week", 0, 7},
+}
+
+// Parse method parses the given schedule spec.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if len(spec) == 0 {
+		return nil, errors.New("cron: empty spec")
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(spec[len("@every "):]))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("cron: invalid interval in spec '%s'", spec)
+		}
+		return everySchedule(d), nil
+	}
+
+	expr := spec
+	if spec[0] == '@' {
+		v, found := descriptors[spec]
+		if !found {
+			return nil, fmt.Errorf("cron: unsupported descriptor '%s'", spec)
+		}
+		expr = v
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != len(fieldBounds) {
+		return nil, fmt.Errorf("cron: spec '%s' must have 5 fields", spec)
+	}
+
+	s := &specSchedule{}
+	bits := []*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for i, f := range fields {
+		v, err := parseField(f, fieldBounds[i])
+		if err != nil {
+			return nil, err
+		}
+		*bits[i] = v
+	}
+
+	// day of week 7 is Sunday
+	if s.dow&(1<<7) > 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+
+	return s, nil
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Schedules
+//______________________________________________________________________________
+
+type everySchedule time.Duration
+
+func (e everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+type specSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+// Next method returns the next matching minute after given time. If
+// both day of month and day of week are restricted, either one matches,
+// same as standard cron. Zero time is returned if there is no match
+// within 5 years, for e.g.: `0 0 30 2 *`.
+func (s *specSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *specSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) > 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) > 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Unexported methods
+//______________________________________________________________________________
+
+func parseField(f string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(f, ",") {
+		step := 1
+		if idx := strings.IndexByte(part, '/'); idx != -1 {
+			v, err := strconv.Atoi(part[idx+1:])
+			if err != nil || v <= 0 {
+				return 0, fmt.Errorf("cron: invalid step in %s field '%s'", b.name, f)
+			}
+			step = v
+			part = part[:idx]
+		}
+
+		start, end := b.min, b.max
+		switch {
+		case part == "*":
+		case strings.IndexByte(part, '-') != -1:
+			idx := strings.IndexByte(part, '-')
+			var err1, err2 error
+			start, err1 = strconv.Atoi(part[:idx])
+			end, err2 = strconv.Atoi(part[idx+1:])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("cron: invalid range in %s field '%s'", b.name, f)
+			}
+		default:
+			v, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("cron: invalid value in %s field '%s'", b.name, f)
+			}
+			start = v
+			if step == 1 {
+				end = v
+			}
+		}
+
+		if start < b.min || end > b.max || start > end {
+			return 0, fmt.Errorf("cron: %s field '%s' is out of range [%d-%d]", b.name, f, b.min, b.max)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
@@ -0,0 +1,74 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronNext(t *testing.T) {
+	// Wednesday
+	from := time.Date(2018, time.May, 16, 10, 17, 30, 0, time.UTC)
+
+	testcases := []struct {
+		spec string
+		next time.Time
+	}{
+		{spec: "@every 90s", next: from.Add(90 * time.Second)},
+		{spec: "* * * * *", next: time.Date(2018, time.May, 16, 10, 18, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", next: time.Date(2018, time.May, 16, 10, 30, 0, 0, time.UTC)},
+		{spec: "5,45 10-11 * * *", next: time.Date(2018, time.May, 16, 10, 45, 0, 0, time.UTC)},
+		{spec: "0 3 * * *", next: time.Date(2018, time.May, 17, 3, 0, 0, 0, time.UTC)},
+		{spec: "30 9 * * 1-5", next: time.Date(2018, time.May, 17, 9, 30, 0, 0, time.UTC)},
+		{spec: "0 0 * * 7", next: time.Date(2018, time.May, 20, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 1 * 0", next: time.Date(2018, time.May, 20, 0, 0, 0, 0, time.UTC)},
+		{spec: "@hourly", next: time.Date(2018, time.May, 16, 11, 0, 0, 0, time.UTC)},
+		{spec: "@daily", next: time.Date(2018, time.May, 17, 0, 0, 0, 0, time.UTC)},
+		{spec: "@weekly", next: time.Date(2018, time.May, 20, 0, 0, 0, 0, time.UTC)},
+		{spec: "@monthly", next: time.Date(2018, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "@yearly", next: time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 29 2 *", next: time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 30 2 *", next: time.Time{}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			s, err := Parse(tc.spec)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.next, s.Next(from))
+		})
+	}
+}
+
+func TestCronParseErrors(t *testing.T) {
+	testcases := []struct {
+		spec string
+		err  string
+	}{
+		{spec: "", err: "cron: empty spec"},
+		{spec: "@every", err: "cron: unsupported descriptor '@every'"},
+		{spec: "@every 0s", err: "cron: invalid interval in spec '@every 0s'"},
+		{spec: "@every day", err: "cron: invalid interval in spec '@every day'"},
+		{spec: "@fortnightly", err: "cron: unsupported descriptor '@fortnightly'"},
+		{spec: "* * * *", err: "cron: spec '* * * *' must have 5 fields"},
+		{spec: "60 * * * *", err: "cron: minute field '60' is out of range [0-59]"},
+		{spec: "* 5-2 * * *", err: "cron: hour field '5-2' is out of range [0-23]"},
+		{spec: "* * 0 * *", err: "cron: day-of-month field '0' is out of range [1-31]"},
+		{spec: "* * * jan *", err: "cron: invalid value in month field 'jan'"},
+		{spec: "* * * * 1-x", err: "cron: invalid range in day-of-week field '1-x'"},
+		{spec: "*/0 * * * *", err: "cron: invalid step in minute field '*/0'"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			s, err := Parse(tc.spec)
+			assert.Nil(t, s)
+			assert.Equal(t, tc.err, err.Error())
+		})
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package proxyproto implements the listener which parses PROXY protocol
+// v1 and v2 header sent by upstream proxy (for e.g.: AWS NLB, HAProxy) and
+// populates the connection remote address with actual client address.
+//
+// Spec: https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	v1Prefix       = "PROXY "
+	v1MaxHeaderLen = 107
+)
+
+var v2Signature = []byte("\x0D\x0A\x0D\x0A\x00\x0D\x0A\x51\x55\x49\x54\x0A")
+
+// ErrInvalidHeader returned when PROXY protocol header is malformed.
+var ErrInvalidHeader = errors.New("proxyproto: invalid header")
+
+// NewListener method returns the PROXY protocol listener for given listener.
+// Header is parsed only for connections from trusted upstream networks,
+// if `trusted` is empty then all upstreams are trusted.
+func NewListener(l net.Listener, trusted []*net.IPNet, headerTimeout time.Duration) *Listener {
+	return &Listener{Listener: l, trusted: trusted, headerTimeout: headerTimeout}
+}
+
+// ParseCIDRs method parses the given CIDR values, plain IP address is
+// treated as single host network.
+func ParseCIDRs(values []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, fmt.Errorf("proxyproto: invalid address '%s'", v)
+			}
+			if ip.To4() != nil {
+				v += "/32"
+			} else {
+				v += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("proxyproto: invalid CIDR '%s'", v)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Listener
+//______________________________________________________________________________
+
+// Listener type wraps the `net.Listener` and returns PROXY protocol aware
+// connections.
+type Listener struct {
+	net.Listener
+	trusted       []*net.IPNet
+	headerTimeout time.Duration
+}
+
+// Accept method waits for and returns the next connection to the listener.
+// Header is parsed lazily on first read or remote address call, so that
+// slow upstream does not block the accept loop.
+func (l *Listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{
+		Conn:          c,
+		br:            bufio.NewReader(c),
+		trusted:       l.isTrusted(c.RemoteAddr()),
+		headerTimeout: l.headerTimeout,
+	}, nil
+}
+
+// File method returns the file descriptor of underlying listener, it is
+// used by graceful restart.
+func (l *Listener) File() (*os.File, error) {
+	lf, ok := l.Listener.(interface {
+		File() (*os.File, error)
+	})
+	if !ok {
+		return nil, errors.New("proxyproto: listener does not support file descriptor")
+	}
+	return lf.File()
+}
+
+func (l *Listener) isTrusted(addr net.Addr) bool {
+	if len(l.trusted) == 0 {
+		return true
+	}
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	for _, n := range l.trusted {
+		if n.Contains(tcpAddr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Conn
+//______________________________________________________________________________
+
+// Conn type wraps the `net.Conn` and parses the PROXY protocol header.
+type Conn struct {
+	net.Conn
+	br            *bufio.Reader
+	once          sync.Once
+	trusted       bool
+	headerTimeout time.Duration
+	srcAddr       net.Addr
+	err           error
+}
+
+// Read method reads the data from the connection after the PROXY header.
+func (c *Conn) Read(b []byte) (int, error) {
+	c.once.Do(c.readHeader)
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.br.Read(b)
+}
+
+// RemoteAddr method returns the client address from the PROXY header if
+// present otherwise the connection remote address.
+func (c *Conn) RemoteAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.srcAddr != nil {
+		return c.srcAddr
+	}
+	return c.Conn.RemoteAddr()
+}
+
+func (c *Conn) readHeader() {
+	if !c.trusted {
+		return
+	}
+
+	if c.headerTimeout > 0 {
+		_ = c.Conn.SetReadDeadline(time.Now().Add(c.headerTimeout))
+		defer func() { _ = c.Conn.SetReadDeadline(time.Time{}) }()
+	}
+
+	b, err := c.br.Peek(1)
+	if err != nil {
+		if err != io.EOF {
+			c.err = err
+		}
+		return
+	}
+
+	switch b[0] {
+	case v1Prefix[0]:
+		if b, err = c.br.Peek(len(v1Prefix)); err == nil && string(b) == v1Prefix {
+			c.srcAddr, c.err = parseV1(c.br)
+		}
+	case v2Signature[0]:
+		if b, err = c.br.Peek(len(v2Signature)); err == nil && bytes.Equal(b, v2Signature) {
+			c.srcAddr, c.err = parseV2(c.br)
+		}
+	}
+}
+
+// parseV1 method parses the human-readable header format, for e.g.:
+//
+//	PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n
+func parseV1(br *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) < v1MaxHeaderLen {
+		b, err := br.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, ErrInvalidHeader
+	}
+
+	parts := strings.Fields(string(line))
+	if len(parts) >= 2 && parts[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(parts) != 6 || (parts[1] != "TCP4" && parts[1] != "TCP6") {
+		return nil, ErrInvalidHeader
+	}
+
+	ip := net.ParseIP(parts[2])
+	port, err := strconv.Atoi(parts[4])
+	if ip == nil || err != nil || port < 0 || port > 65535 {
+		return nil, ErrInvalidHeader
+	}
+	return &net.TCPAddr{IP: ip, Port: port}, nil
+}
+
+// parseV2 method parses the binary header format.
+func parseV2(br *bufio.Reader) (net.Addr, error) {
+	hdr := make([]byte, 16)
+	if _, err := io.ReadFull(br, hdr); err != nil {
+		return nil, err
+	}
+	if hdr[12]>>4 != 0x2 {
+		return nil, ErrInvalidHeader
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint16(hdr[14:16]))
+	if _, err := io.ReadFull(br, payload); err != nil {
+		return nil, err
+	}
+
+	// LOCAL command, for e.g.: health check from proxy itself
+	if hdr[12]&0x0F == 0x0 {
+		return nil, nil
+	}
+	if hdr[12]&0x0F != 0x1 {
+		return nil, ErrInvalidHeader
+	}
+
+	switch hdr[13] >> 4 {
+	case 0x1: // AF_INET
+		if len(payload) < 12 {
+			return nil, ErrInvalidHeader
+		}
+		return &net.TCPAddr{
+			IP:   net.IP(payload[0:4]),
+			Port: int(binary.BigEndian.Uint16(payload[8:10])),
+		}, nil
+	case 0x2: // AF_INET6
+		if len(payload) < 36 {
+			return nil, ErrInvalidHeader
+		}
+		return &net.TCPAddr{
+			IP:   net.IP(payload[0:16]),
+			Port: int(binary.BigEndian.Uint16(payload[32:34])),
+		}, nil
+	}
+
+	// AF_UNSPEC or AF_UNIX, keep the connection address
+	return nil, nil
+}
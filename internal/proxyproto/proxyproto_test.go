@@ -0,0 +1,120 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package proxyproto
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxyProtoV1(t *testing.T) {
+	testcases := []struct {
+		label  string
+		header string
+		addr   string
+		err    error
+	}{
+		{label: "tcp4", header: "PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n", addr: "192.168.0.1:56324"},
+		{label: "tcp6", header: "PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n", addr: "[2001:db8::1]:56324"},
+		{label: "unknown", header: "PROXY UNKNOWN\r\n"},
+		{label: "invalid protocol", header: "PROXY UDP4 192.168.0.1 192.168.0.11 56324 443\r\n", err: ErrInvalidHeader},
+		{label: "invalid port", header: "PROXY TCP4 192.168.0.1 192.168.0.11 port 443\r\n", err: ErrInvalidHeader},
+		{label: "no crlf", header: "PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\n", err: ErrInvalidHeader},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.label, func(t *testing.T) {
+			addr, err := parseV1(bufio.NewReader(strings.NewReader(tc.header)))
+			assert.Equal(t, tc.err, err)
+			if len(tc.addr) > 0 {
+				assert.Equal(t, tc.addr, addr.String())
+			} else {
+				assert.Nil(t, addr)
+			}
+		})
+	}
+}
+
+func TestProxyProtoV2(t *testing.T) {
+	hdr := append([]byte{}, v2Signature...)
+	hdr = append(hdr, 0x21, 0x11, 0x00, 0x0C)
+	hdr = append(hdr, 10, 1, 2, 3, 10, 0, 0, 1)
+	port := make([]byte, 2)
+	binary.BigEndian.PutUint16(port, 40001)
+	hdr = append(hdr, port...)
+	hdr = append(hdr, 0x01, 0xBB)
+
+	addr, err := parseV2(bufio.NewReader(strings.NewReader(string(hdr))))
+	assert.Nil(t, err)
+	assert.Equal(t, "10.1.2.3:40001", addr.String())
+
+	// LOCAL command
+	local := append(append([]byte{}, v2Signature...), 0x20, 0x00, 0x00, 0x00)
+	addr, err = parseV2(bufio.NewReader(strings.NewReader(string(local))))
+	assert.Nil(t, err)
+	assert.Nil(t, addr)
+
+	// invalid version
+	invalid := append(append([]byte{}, v2Signature...), 0x11, 0x11, 0x00, 0x00)
+	_, err = parseV2(bufio.NewReader(strings.NewReader(string(invalid))))
+	assert.Equal(t, ErrInvalidHeader, err)
+}
+
+func TestProxyProtoListener(t *testing.T) {
+	testcases := []struct {
+		label   string
+		trusted []string
+		addr    string
+	}{
+		{label: "trusted all", addr: "203.0.113.10"},
+		{label: "trusted proxy", trusted: []string{"127.0.0.0/8"}, addr: "203.0.113.10"},
+		{label: "untrusted proxy", trusted: []string{"10.0.0.1"}, addr: "127.0.0.1"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.label, func(t *testing.T) {
+			trusted, err := ParseCIDRs(tc.trusted)
+			assert.Nil(t, err)
+
+			rl, err := net.Listen("tcp", "127.0.0.1:0")
+			assert.Nil(t, err)
+			l := NewListener(rl, trusted, time.Second)
+			defer l.Close()
+
+			go func() {
+				c, err := net.Dial("tcp", rl.Addr().String())
+				if err != nil {
+					return
+				}
+				_, _ = c.Write([]byte("PROXY TCP4 203.0.113.10 127.0.0.1 51000 8080\r\nhello"))
+				_ = c.Close()
+			}()
+
+			c, err := l.Accept()
+			assert.Nil(t, err)
+			defer c.Close()
+
+			assert.Equal(t, tc.addr, c.RemoteAddr().(*net.TCPAddr).IP.String())
+			b, _ := ioutil.ReadAll(c)
+			if tc.addr == "203.0.113.10" {
+				assert.Equal(t, "hello", string(b))
+			} else {
+				assert.True(t, strings.HasPrefix(string(b), "PROXY TCP4"))
+			}
+		})
+	}
+
+	_, err := ParseCIDRs([]string{"10.0.0.0/33"})
+	assert.Equal(t, "proxyproto: invalid CIDR '10.0.0.0/33'", err.Error())
+	_, err = ParseCIDRs([]string{"localhost"})
+	assert.Equal(t, "proxyproto: invalid address 'localhost'", err.Error())
+}
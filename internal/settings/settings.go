@@ -7,13 +7,19 @@ package settings
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"aahframe.work/ahttp"
 	"aahframe.work/config"
 	"aahframe.work/essentials"
+	"aahframe.work/internal/proxyproto"
 	"aahframe.work/internal/util"
 	"aahframe.work/log"
 	"golang.org/x/crypto/acme/autocert"
@@ -40,30 +46,65 @@ type Settings struct {
 	AccessLogEnabled       bool
 	StaticAccessLogEnabled bool
 	DumpLogEnabled         bool
+	SlowRequestEnabled     bool
 	Initialized            bool
 	HotReload              bool
 	HotReloadEnabled       bool
+	GracefulRestartEnabled bool
 	AuthSchemeExists       bool
 	Redirect               bool
+	ConcurrencyQueue       bool
+	JSONInt64String        bool
+	MaxResponseSizeStrict  bool
+	MaxHeaderBytesStrict   bool
+	ForwardedProtoEnabled  bool
+	EnforceUTF8            bool
 	Pid                    int
 	HTTPMaxHdrBytes        int
+	MaxHeaderCount         int
+	MultipartMaxParts      int
+	MaxConcurrentRequests  int
+	MaxResponseSize        int64
 	ImportPath             string
 	BaseDir                string
 	VirtualBaseDir         string
 	Type                   string
 	EnvProfile             string
+	Network                string
+	BaseURL                string
 	SSLCert                string
 	SSLKey                 string
 	ServerHeader           string
 	RequestIDHeaderKey     string
 	SecureJSONPrefix       string
 	ShutdownGraceTimeStr   string
+	ShutdownHookTimeStr    string
+	ShutdownDrainTimeStr   string
+	WorkersTimeStr         string
+	GracefulRestartTimeStr string
+	SlowRequestTimeStr     string
 	DefaultContentType     string
+	DefaultCharset         string
 	HotReloadSignalStr     string
+	DateTimeLayout         string
+	JSONTimeFormat         string
 	HTTPReadTimeout        time.Duration
 	HTTPWriteTimeout       time.Duration
+	HTTPIdleTimeout        time.Duration
 	ShutdownGraceTimeout   time.Duration
+	ShutdownHookTimeout    time.Duration
+	ShutdownDrainTimeout   time.Duration
+	WorkersTimeout         time.Duration
+	GracefulRestartTimeout time.Duration
+	SlowRequestThreshold   time.Duration
+	ConcurrencyTimeout     time.Duration
+	ConcurrencyRetryAfter  string
 	Autocert               *autocert.Manager
+	TimeLocation           *time.Location
+	ForwardedProtoTrusted  []*net.IPNet
+	TraceHeaders           []string
+	Listeners              []*Listener
+	ContentLanguage        bool
 
 	cfg *config.Config
 }
@@ -80,6 +121,19 @@ func (s *Settings) Refresh(cfg *config.Config) error {
 	s.LetsEncryptEnabled = s.cfg.BoolDefault("server.ssl.lets_encrypt.enable", false)
 	s.Redirect = s.cfg.BoolDefault("server.redirect.enable", false)
 
+	s.Network = s.cfg.StringDefault("server.network", "tcp")
+	if s.Network != "tcp" && s.Network != "tcp4" && s.Network != "tcp6" {
+		return fmt.Errorf("'server.network' unsupported value: %s", s.Network)
+	}
+
+	if s.BaseURL = strings.TrimSuffix(s.cfg.StringDefault("server.base_url", ""), "/"); len(s.BaseURL) > 0 {
+		u, er := url.Parse(s.BaseURL)
+		if er != nil || (u.Scheme != ahttp.SchemeHTTP && u.Scheme != ahttp.SchemeHTTPS) ||
+			len(u.Host) == 0 || len(u.Path) > 0 || len(u.RawQuery) > 0 {
+			return fmt.Errorf("'server.base_url' unsupported value: %s", s.BaseURL)
+		}
+	}
+
 	readTimeout := s.cfg.StringDefault("server.timeout.read", "90s")
 	writeTimeout := s.cfg.StringDefault("server.timeout.write", "90s")
 	if !util.IsValidTimeUnit(readTimeout, "s", "m") || !util.IsValidTimeUnit(writeTimeout, "s", "m") {
@@ -94,12 +148,41 @@ func (s *Settings) Refresh(cfg *config.Config) error {
 		return fmt.Errorf("'server.timeout.write': %s", err)
 	}
 
+	idleTimeout := s.cfg.StringDefault("server.timeout.idle", "0s")
+	if !util.IsValidTimeUnit(idleTimeout, "s", "m") {
+		return errors.New("'server.timeout.idle' value is not a valid time unit")
+	}
+	if s.HTTPIdleTimeout, err = time.ParseDuration(idleTimeout); err != nil {
+		return fmt.Errorf("'server.timeout.idle': %s", err)
+	}
+
+	if err = s.refreshListeners(readTimeout, writeTimeout, idleTimeout); err != nil {
+		return err
+	}
+
 	maxHdrBytesStr := s.cfg.StringDefault("server.max_header_bytes", "1mb")
 	if maxHdrBytes, er := ess.StrToBytes(maxHdrBytesStr); er == nil {
 		s.HTTPMaxHdrBytes = int(maxHdrBytes)
 	} else {
 		return errors.New("'server.max_header_bytes' value is not a valid size unit")
 	}
+	// header size is checked by aah only for the configured limit, default
+	// limit is left to Go HTTP server
+	s.MaxHeaderBytesStrict = s.cfg.IsExists("server.max_header_bytes")
+
+	s.MaxConcurrentRequests = s.cfg.IntDefault("server.max_concurrent_requests.limit", 0)
+	s.ConcurrencyQueue = s.cfg.StringDefault("server.max_concurrent_requests.mode", "reject") == "queue"
+	s.ConcurrencyRetryAfter = s.cfg.StringDefault("server.max_concurrent_requests.retry_after", "5")
+	if v, er := strconv.Atoi(s.ConcurrencyRetryAfter); er != nil || v < 0 {
+		return fmt.Errorf("'server.max_concurrent_requests.retry_after' unsupported value: %s", s.ConcurrencyRetryAfter)
+	}
+	queueTimeout := s.cfg.StringDefault("server.max_concurrent_requests.queue_timeout", "5s")
+	if !util.IsValidTimeUnit(queueTimeout, "ms", "s", "m") {
+		return errors.New("'server.max_concurrent_requests.queue_timeout' value is not a valid time unit")
+	}
+	if s.ConcurrencyTimeout, err = time.ParseDuration(queueTimeout); err != nil {
+		return fmt.Errorf("'server.max_concurrent_requests.queue_timeout': %s", err)
+	}
 
 	s.SSLCert = s.cfg.StringDefault("server.ssl.cert", "")
 	s.SSLKey = s.cfg.StringDefault("server.ssl.key", "")
@@ -125,26 +208,62 @@ func (s *Settings) Refresh(cfg *config.Config) error {
 		s.Autocert.Cache = autocert.DirCache(cacheDir)
 	}
 
+	timezone := s.cfg.StringDefault("format.timezone", "UTC")
+	if s.TimeLocation, err = time.LoadLocation(timezone); err != nil {
+		return fmt.Errorf("'format.timezone' value is not a valid time zone: %s", timezone)
+	}
+	s.DateTimeLayout = s.cfg.StringDefault("format.datetime", time.RFC3339)
+
 	s.Type = s.cfg.StringDefault("type", "")
 	if s.Type != "websocket" {
 		if _, err = ess.StrToBytes(s.cfg.StringDefault("request.max_body_size", "5mb")); err != nil {
 			return errors.New("'request.max_body_size' value is not a valid size unit")
 		}
 
+		s.MaxHeaderCount = s.cfg.IntDefault("request.max_header_count", 0)
+		s.MultipartMaxParts = s.cfg.IntDefault("request.multipart_max_parts", 0)
+		s.EnforceUTF8 = s.cfg.BoolDefault("request.enforce_utf8", false)
+		s.ForwardedProtoEnabled = s.cfg.BoolDefault("request.forwarded_proto.enable", true)
+		trustedProxies, _ := s.cfg.StringList("request.forwarded_proto.trusted_proxies")
+		if s.ForwardedProtoTrusted, err = proxyproto.ParseCIDRs(trustedProxies); err != nil {
+			return fmt.Errorf("'request.forwarded_proto.trusted_proxies' %s", err)
+		}
 		s.ServerHeader = s.cfg.StringDefault("server.header", "")
 		s.ServerHeaderEnabled = !ess.IsStrEmpty(s.ServerHeader)
 		s.RequestIDEnabled = s.cfg.BoolDefault("request.id.enable", true)
 		s.RequestIDHeaderKey = s.cfg.StringDefault("request.id.header", ahttp.HeaderXRequestID)
+		traceHeaders, _ := s.cfg.StringList("request.trace_headers")
+		for i, h := range traceHeaders {
+			traceHeaders[i] = http.CanonicalHeaderKey(strings.TrimSpace(h))
+		}
+		s.TraceHeaders = traceHeaders
+		s.ContentLanguage = s.cfg.BoolDefault("i18n.content_language", true)
 		s.SecureHeadersEnabled = s.cfg.BoolDefault("security.http_header.enable", true)
 		s.GzipEnabled = s.cfg.BoolDefault("render.gzip.enable", true)
 		s.AccessLogEnabled = s.cfg.BoolDefault("server.access_log.enable", false)
 		s.StaticAccessLogEnabled = s.cfg.BoolDefault("server.access_log.static_file", true)
 		s.DumpLogEnabled = s.cfg.BoolDefault("server.dump_log.enable", false)
+		s.DefaultContentType = ""
 		if rd := s.cfg.StringDefault("render.default", ""); len(rd) > 0 {
-			s.DefaultContentType = util.MimeTypeByExtension("some." + rd)
+			// octet-stream makes browsers to download the response instead
+			// of display, it is treated as not configured
+			if ct := util.MimeTypeByExtension("." + rd); !strings.HasPrefix(ct, ahttp.ContentTypeOctetStream.Mime) {
+				s.DefaultContentType = ct
+			}
+		}
+
+		if s.MaxResponseSize, err = ess.StrToBytes(s.cfg.StringDefault("render.max_response_size", "0b")); err != nil {
+			return errors.New("'render.max_response_size' value is not a valid size unit")
 		}
+		s.MaxResponseSizeStrict = s.cfg.BoolDefault("render.max_response_size_strict", false)
 
+		s.DefaultCharset = s.cfg.StringDefault("render.default_charset", "utf-8")
 		s.SecureJSONPrefix = s.cfg.StringDefault("render.secure_json.prefix", DefaultSecureJSONPrefix)
+		s.JSONInt64String = s.cfg.BoolDefault("render.json.int64_as_string", false)
+		s.JSONTimeFormat = strings.ToLower(s.cfg.StringDefault("render.json.time_format", "rfc3339"))
+		if !ess.IsSliceContainsString([]string{"rfc3339", "datetime", "unix", "unix_millis"}, s.JSONTimeFormat) {
+			return fmt.Errorf("'render.json.time_format' unsupported value: %s", s.JSONTimeFormat)
+		}
 
 		ahttp.GzipLevel = s.cfg.IntDefault("render.gzip.level", 4)
 		if !(ahttp.GzipLevel >= 1 && ahttp.GzipLevel <= 9) {
@@ -155,6 +274,14 @@ func (s *Settings) Refresh(cfg *config.Config) error {
 	s.HotReloadEnabled = s.cfg.BoolDefault("runtime.config_hotreload.enable", true)
 	s.HotReloadSignalStr = strings.ToUpper(s.cfg.StringDefault("runtime.config_hotreload.signal", "SIGHUP"))
 
+	s.GracefulRestartEnabled = s.cfg.BoolDefault("server.graceful_restart.enable", false)
+	s.GracefulRestartTimeStr = s.cfg.StringDefault("server.graceful_restart.ready_timeout", "30s")
+	if !util.IsValidTimeUnit(s.GracefulRestartTimeStr, "s", "m") {
+		log.Warn("'server.graceful_restart.ready_timeout' value is not a valid time unit, assigning default value 30s")
+		s.GracefulRestartTimeStr = "30s"
+	}
+	s.GracefulRestartTimeout, _ = time.ParseDuration(s.GracefulRestartTimeStr)
+
 	s.ShutdownGraceTimeStr = s.cfg.StringDefault("server.timeout.grace_shutdown", "60s")
 	if !util.IsValidTimeUnit(s.ShutdownGraceTimeStr, "s", "m") {
 		log.Warn("'server.timeout.grace_shutdown' value is not a valid time unit, assigning default value 60s")
@@ -162,6 +289,35 @@ func (s *Settings) Refresh(cfg *config.Config) error {
 	}
 	s.ShutdownGraceTimeout, _ = time.ParseDuration(s.ShutdownGraceTimeStr)
 
+	s.ShutdownDrainTimeStr = s.cfg.StringDefault("server.timeout.drain", "0s")
+	if !util.IsValidTimeUnit(s.ShutdownDrainTimeStr, "ms", "s", "m") {
+		log.Warn("'server.timeout.drain' value is not a valid time unit, assigning default value 0s")
+		s.ShutdownDrainTimeStr = "0s"
+	}
+	s.ShutdownDrainTimeout, _ = time.ParseDuration(s.ShutdownDrainTimeStr)
+
+	s.ShutdownHookTimeStr = s.cfg.StringDefault("server.timeout.shutdown_hook", "10s")
+	if !util.IsValidTimeUnit(s.ShutdownHookTimeStr, "ms", "s", "m") {
+		log.Warn("'server.timeout.shutdown_hook' value is not a valid time unit, assigning default value 10s")
+		s.ShutdownHookTimeStr = "10s"
+	}
+	s.ShutdownHookTimeout, _ = time.ParseDuration(s.ShutdownHookTimeStr)
+
+	s.WorkersTimeStr = s.cfg.StringDefault("server.timeout.workers", "10s")
+	if !util.IsValidTimeUnit(s.WorkersTimeStr, "ms", "s", "m") {
+		log.Warn("'server.timeout.workers' value is not a valid time unit, assigning default value 10s")
+		s.WorkersTimeStr = "10s"
+	}
+	s.WorkersTimeout, _ = time.ParseDuration(s.WorkersTimeStr)
+
+	s.SlowRequestEnabled = s.cfg.BoolDefault("server.slow_request.enable", false)
+	s.SlowRequestTimeStr = s.cfg.StringDefault("server.slow_request.threshold", "1s")
+	if !util.IsValidTimeUnit(s.SlowRequestTimeStr, "ms", "s", "m") {
+		log.Warn("'server.slow_request.threshold' value is not a valid time unit, assigning default value 1s")
+		s.SlowRequestTimeStr = "1s"
+	}
+	s.SlowRequestThreshold, _ = time.ParseDuration(s.SlowRequestTimeStr)
+
 	return nil
 }
 
@@ -187,6 +343,48 @@ func (s *Settings) setEnvProfile(p string) error {
 	return nil
 }
 
+// refreshListeners method parses the additional server listeners from
+// `server.listeners.<name>` of config and active env profile, timeouts fall
+// back to the given global values of `server.timeout.*`.
+func (s *Settings) refreshListeners(readTimeout, writeTimeout, idleTimeout string) error {
+	s.Listeners = nil
+	keys := s.cfg.KeysByPath("server.listeners")
+	for _, k := range s.cfg.KeysByPath(ProfilePrefix + s.EnvProfile + ".server.listeners") {
+		if !ess.IsSliceContainsString(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, name := range keys {
+		keyPrefix := "server.listeners." + name
+		port := s.cfg.StringDefault(keyPrefix+".port", "")
+		if len(port) == 0 {
+			return fmt.Errorf("'%s.port' is required value", keyPrefix)
+		}
+		address := s.cfg.StringDefault(keyPrefix+".address", s.cfg.StringDefault("server.address", ""))
+		l := &Listener{Name: name, Address: net.JoinHostPort(strings.Trim(address, "[]"), port)}
+		for _, t := range []struct {
+			key   string
+			value string
+			d     *time.Duration
+		}{
+			{"read", readTimeout, &l.ReadTimeout},
+			{"write", writeTimeout, &l.WriteTimeout},
+			{"idle", idleTimeout, &l.IdleTimeout},
+		} {
+			key := keyPrefix + ".timeout." + t.key
+			v := s.cfg.StringDefault(key, t.value)
+			d, err := time.ParseDuration(v)
+			if err != nil || !util.IsValidTimeUnit(v, "s", "m") {
+				return fmt.Errorf("'%s' value is not a valid time unit", key)
+			}
+			*t.d = d
+		}
+		s.Listeners = append(s.Listeners, l)
+	}
+	return nil
+}
+
 func (s *Settings) checkSSLConfigValues() error {
 	if s.SSLEnabled {
 		if !s.LetsEncryptEnabled && (ess.IsStrEmpty(s.SSLCert) || ess.IsStrEmpty(s.SSLKey)) {
@@ -207,3 +405,13 @@ func (s *Settings) checkSSLConfigValues() error {
 	}
 	return nil
 }
+
+// Listener represents the additional server listener settings of
+// `server.listeners.<name>`.
+type Listener struct {
+	Name         string
+	Address      string
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+}
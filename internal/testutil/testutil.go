@@ -0,0 +1,226 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package testutil implements the HTTP request and response assertion
+// helpers shared by aah test server `aahframe.work/aahtest` and the aah
+// framework tests. It is kept out of package `aah`, so that `httptest`
+// and friends are not linked into the application binary.
+package testutil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"aahframe.work/ahttp"
+	"aahframe.work/essentials"
+)
+
+// TestingT interface is the subset of `testing.TB` used by aah test server,
+// so that it could be used with `*testing.T` and `*testing.B`. Assertion
+// failures are reported at the caller via `Helper()`.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+	FailNow()
+	Helper()
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Package methods
+//______________________________________________________________________________
+
+// Get method sends HTTP GET request to given URL using given client.
+func Get(t TestingT, client *http.Client, url string) *Result {
+	t.Helper()
+	req, err := http.NewRequest(ahttp.MethodGet, url, nil)
+	if err != nil {
+		t.Errorf("Unable to create request: %v", err)
+		t.FailNow()
+		return nil
+	}
+	return Do(t, client, req)
+}
+
+// PostJSON method sends HTTP POST request to given URL using given client
+// with JSON payload of given body.
+func PostJSON(t TestingT, client *http.Client, url string, body interface{}) *Result {
+	t.Helper()
+	b, err := json.Marshal(body)
+	if err != nil {
+		t.Errorf("Unable to marshal JSON payload: %v", err)
+		t.FailNow()
+		return nil
+	}
+	req, err := http.NewRequest(ahttp.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		t.Errorf("Unable to create request: %v", err)
+		t.FailNow()
+		return nil
+	}
+	req.Header.Set(ahttp.HeaderContentType, ahttp.ContentTypeJSON.String())
+	return Do(t, client, req)
+}
+
+// Do method sends given HTTP request using given client.
+func Do(t TestingT, client *http.Client, req *http.Request) *Result {
+	t.Helper()
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Errorf("Request failed %s", err)
+		t.FailNow()
+		return nil
+	}
+	return NewResult(t, resp)
+}
+
+// NewResult method returns the test result of given response, body is read
+// and decompressed if it's gzip encoded.
+func NewResult(t TestingT, resp *http.Response) *Result {
+	return &Result{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       ResponseBody(resp),
+		Raw:        resp,
+		t:          t,
+	}
+}
+
+// ResponseBody method reads and closes the body of given response, it is
+// decompressed if it's gzip encoded.
+func ResponseBody(res *http.Response) string {
+	body := res.Body
+	defer ess.CloseQuietly(body)
+	if strings.Contains(res.Header.Get(ahttp.HeaderContentEncoding), "gzip") {
+		if gr, err := gzip.NewReader(body); err == nil {
+			body = gr
+		}
+	}
+	buf := new(bytes.Buffer)
+	_, _ = io.Copy(buf, body)
+	return buf.String()
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Result
+//______________________________________________________________________________
+
+// Result struct holds the test server response, body is read and
+// decompressed if it's gzip encoded.
+type Result struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+	Raw        *http.Response
+
+	t TestingT
+}
+
+// AssertStatus method asserts the response HTTP status code.
+func (tr *Result) AssertStatus(code int) *Result {
+	tr.t.Helper()
+	if tr.StatusCode != code {
+		tr.t.Errorf("HTTP status code: expected %d, actual %d", code, tr.StatusCode)
+	}
+	return tr
+}
+
+// AssertHeader method asserts the response HTTP header value.
+func (tr *Result) AssertHeader(key, value string) *Result {
+	tr.t.Helper()
+	if actual := tr.Header.Get(key); actual != value {
+		tr.t.Errorf("HTTP header '%s': expected '%s', actual '%s'", key, value, actual)
+	}
+	return tr
+}
+
+// AssertJSONPath method asserts the value of JSON response body for given
+// dot separated path. For e.g.: "data.email", "items.0.name"
+func (tr *Result) AssertJSONPath(jpath string, expected interface{}) *Result {
+	tr.t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(tr.Body), &v); err != nil {
+		tr.t.Errorf("response body is not a JSON: %v", err)
+		tr.t.FailNow()
+		return tr
+	}
+	for _, p := range strings.Split(jpath, ".") {
+		switch vv := v.(type) {
+		case map[string]interface{}:
+			v = vv[p]
+		case []interface{}:
+			idx, err := strconv.Atoi(p)
+			if err != nil || idx < 0 || idx >= len(vv) {
+				tr.t.Errorf("JSON path not found: %s", jpath)
+				tr.t.FailNow()
+				return tr
+			}
+			v = vv[idx]
+		default:
+			tr.t.Errorf("JSON path not found: %s", jpath)
+			tr.t.FailNow()
+			return tr
+		}
+	}
+	if !equalValues(expected, v) {
+		tr.t.Errorf("JSON path '%s': expected '%v', actual '%v'", jpath, expected, v)
+	}
+	return tr
+}
+
+// BodyString method returns the response body.
+func (tr *Result) BodyString() string {
+	return tr.Body
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Log Recorder
+//______________________________________________________________________________
+
+// LogRecorder struct records the application log written by aah logger, it
+// is safe for concurrent writes.
+type LogRecorder struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write method writes given bytes into recorder.
+func (lr *LogRecorder) Write(p []byte) (int, error) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	return lr.buf.Write(p)
+}
+
+// String method returns the recorded log.
+func (lr *LogRecorder) String() string {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	return lr.buf.String()
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Unexported methods
+//______________________________________________________________________________
+
+// equalValues reports whether given values are equal, expected value is
+// converted to the type of actual value if possible. Since JSON numbers are
+// decoded as float64.
+func equalValues(expected, actual interface{}) bool {
+	if reflect.DeepEqual(expected, actual) {
+		return true
+	}
+	if expected == nil || actual == nil {
+		return false
+	}
+	ev, at := reflect.ValueOf(expected), reflect.TypeOf(actual)
+	if !ev.Type().ConvertibleTo(at) {
+		return false
+	}
+	return reflect.DeepEqual(ev.Convert(at).Interface(), actual)
+}
@@ -0,0 +1,78 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package testutil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultAssertions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"email":"user@example.com","count":2},"items":[{"name":"aah"}]}`))
+	}))
+	defer srv.Close()
+
+	ft := &fakeT{}
+	Get(ft, http.DefaultClient, srv.URL).
+		AssertStatus(http.StatusOK).
+		AssertHeader("Content-Type", "application/json").
+		AssertJSONPath("data.email", "user@example.com").
+		AssertJSONPath("data.count", 2).
+		AssertJSONPath("items.0.name", "aah")
+	assert.Nil(t, ft.errs)
+	assert.True(t, ft.helpers > 0)
+
+	PostJSON(ft, http.DefaultClient, srv.URL, map[string]string{"name": "aah"}).
+		AssertStatus(http.StatusCreated).
+		AssertJSONPath("data.count", 3)
+	assert.Equal(t, []string{
+		"HTTP status code: expected 201, actual 200",
+		"JSON path 'data.count': expected '3', actual '2'",
+	}, ft.errs)
+	assert.False(t, ft.failed)
+
+	ft = &fakeT{}
+	Get(ft, http.DefaultClient, srv.URL).AssertJSONPath("items.1.name", "aah")
+	assert.Equal(t, []string{"JSON path not found: items.1.name"}, ft.errs)
+	assert.True(t, ft.failed)
+}
+
+func TestResponseBody(t *testing.T) {
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	_, _ = gw.Write([]byte("gzip response body"))
+	_ = gw.Close()
+
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Encoding", "gzip")
+	_, _ = w.Write(buf.Bytes())
+	assert.Equal(t, "gzip response body", ResponseBody(w.Result()))
+
+	lr := &LogRecorder{}
+	_, _ = lr.Write([]byte("log line"))
+	assert.Equal(t, "log line", lr.String())
+}
+
+type fakeT struct {
+	errs    []string
+	failed  bool
+	helpers int
+}
+
+func (ft *fakeT) Errorf(format string, args ...interface{}) {
+	ft.errs = append(ft.errs, fmt.Sprintf(format, args...))
+}
+
+func (ft *fakeT) FailNow() { ft.failed = true }
+
+func (ft *fakeT) Helper() { ft.helpers++ }
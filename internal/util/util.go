@@ -87,6 +87,21 @@ func OnlyMIME(ct string) string {
 	return ct
 }
 
+// AddCharset method appends the given charset to text based content type such
+// as `text/*`, JSON, XML and JavaScript, if charset is not already present.
+// Binary content types are returned as-is.
+func AddCharset(ct, charset string) string {
+	if len(ct) == 0 || len(charset) == 0 || strings.Contains(strings.ToLower(ct), "charset=") {
+		return ct
+	}
+	mime := strings.ToLower(strings.TrimSpace(OnlyMIME(ct)))
+	if strings.HasPrefix(mime, "text/") || strings.HasSuffix(mime, "json") ||
+		strings.HasSuffix(mime, "xml") || strings.HasSuffix(mime, "javascript") {
+		return ct + "; charset=" + charset
+	}
+	return ct
+}
+
 // AddQueryString method to add the given query string key value pair appropriately
 // to the given URL string.
 func AddQueryString(u, k, v string) string {
@@ -30,3 +30,26 @@ func TestMimeTypeByExtension(t *testing.T) {
 		assert.Equal(t, tc.output, MimeTypeByExtension(tc.input))
 	}
 }
+
+func TestAddCharset(t *testing.T) {
+	testcases := []struct {
+		input  string
+		output string
+	}{
+		{},
+		{input: "text/html", output: "text/html; charset=utf-8"},
+		{input: "text/csv", output: "text/csv; charset=utf-8"},
+		{input: "application/json", output: "application/json; charset=utf-8"},
+		{input: "application/problem+json", output: "application/problem+json; charset=utf-8"},
+		{input: "application/xml", output: "application/xml; charset=utf-8"},
+		{input: "application/javascript", output: "application/javascript; charset=utf-8"},
+		{input: "text/plain; charset=iso-8859-1", output: "text/plain; charset=iso-8859-1"},
+		{input: "application/octet-stream", output: "application/octet-stream"},
+		{input: "image/png", output: "image/png"},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, tc.output, AddCharset(tc.input, "utf-8"))
+	}
+	assert.Equal(t, "text/html", AddCharset("text/html", ""))
+}
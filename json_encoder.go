@@ -0,0 +1,303 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSON time formats supported by config `render.json.time_format`.
+const (
+	jsonTimeRFC3339    = "rfc3339"
+	jsonTimeDateTime   = "datetime"
+	jsonTimeUnix       = "unix"
+	jsonTimeUnixMillis = "unix_millis"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// app Unexported methods
+//______________________________________________________________________________
+
+// jsonEncoder method returns the JSON encoder if `render.json.*` options
+// are configured otherwise nil, it means stdlib behavior.
+func (a *Application) jsonEncoder() *jsonEncoder {
+	if (len(a.settings.JSONTimeFormat) == 0 || a.settings.JSONTimeFormat == jsonTimeRFC3339) &&
+		!a.settings.JSONInt64String {
+		return nil
+	}
+	return &jsonEncoder{a: a, timeFormat: a.settings.JSONTimeFormat, int64String: a.settings.JSONInt64String}
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// jsonEncoder and its methods
+//______________________________________________________________________________
+
+// jsonEncoder normalizes the given data as per `render.json.*` options
+// before marshaling it with `encoding/json`. It honors the `json` struct tag
+// name, `omitempty`, `string` and `-` options. Values implementing
+// `json.Marshaler` or `encoding.TextMarshaler` are marshaled as-is, except
+// `time.Time`.
+type jsonEncoder struct {
+	a           *Application
+	timeFormat  string
+	int64String bool
+}
+
+// data method returns the normalized data, if encoder is nil then data is
+// returned as-is.
+func (je *jsonEncoder) data(v interface{}) interface{} {
+	if je == nil {
+		return v
+	}
+	return je.value(reflect.ValueOf(v))
+}
+
+func (je *jsonEncoder) value(rv reflect.Value) interface{} {
+	if !rv.IsValid() {
+		return nil
+	}
+
+	if rv.Type() == timeType {
+		return je.time(rv.Interface().(time.Time))
+	}
+	if rv.Type().Implements(jsonMarshalerType) || rv.Type().Implements(textMarshalerType) {
+		return rv.Interface()
+	}
+	if rv.Kind() != reflect.Ptr && rv.CanAddr() {
+		if pt := reflect.PtrTo(rv.Type()); pt.Implements(jsonMarshalerType) || pt.Implements(textMarshalerType) {
+			return rv.Addr().Interface()
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return je.value(rv.Elem())
+	case reflect.Struct:
+		return je.object(rv)
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil
+		}
+		m := make(map[string]interface{}, rv.Len())
+		for _, k := range rv.MapKeys() {
+			m[mapKey(k)] = je.value(rv.MapIndex(k))
+		}
+		return m
+	case reflect.Slice:
+		if rv.IsNil() {
+			return nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return rv.Interface()
+		}
+		fallthrough
+	case reflect.Array:
+		l := make([]interface{}, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			l[i] = je.value(rv.Index(i))
+		}
+		return l
+	case reflect.Int64:
+		if je.int64String {
+			return strconv.FormatInt(rv.Int(), 10)
+		}
+	case reflect.Uint64:
+		if je.int64String {
+			return strconv.FormatUint(rv.Uint(), 10)
+		}
+	}
+
+	return rv.Interface()
+}
+
+func (je *jsonEncoder) time(t time.Time) interface{} {
+	switch je.timeFormat {
+	case jsonTimeDateTime:
+		return je.a.FormatTime(t)
+	case jsonTimeUnix:
+		return t.Unix()
+	case jsonTimeUnixMillis:
+		return t.UnixNano() / int64(time.Millisecond)
+	}
+	return t
+}
+
+func (je *jsonEncoder) object(rv reflect.Value) jsonObject {
+	var obj jsonObject
+	names := make(map[string]int)
+	je.fields(rv, 0, &obj, names)
+
+	// exclude fields with same name at same depth, same as `encoding/json`
+	result := obj[:0]
+	for _, f := range obj {
+		if !f.conflict {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+func (je *jsonEncoder) fields(rv reflect.Value, depth int, obj *jsonObject, names map[string]int) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseJSONTag(tag)
+		fv := rv.Field(i)
+
+		if sf.Anonymous && len(name) == 0 {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && ft != timeType {
+				if fv.Kind() == reflect.Ptr {
+					if fv.IsNil() {
+						continue
+					}
+					fv = fv.Elem()
+				}
+				je.fields(fv, depth+1, obj, names)
+				continue
+			}
+		}
+		if len(sf.PkgPath) > 0 { // unexported
+			continue
+		}
+		if len(name) == 0 {
+			name = sf.Name
+		}
+		if strings.Contains(opts, "omitempty") && isEmptyValue(fv) {
+			continue
+		}
+
+		var v interface{}
+		if strings.Contains(opts, "string") && fv.Kind() == reflect.String {
+			b, _ := json.Marshal(fv.String())
+			v = string(b)
+		} else if strings.Contains(opts, "string") && isQuotableKind(fv.Kind()) {
+			v = fmt.Sprint(fv.Interface())
+		} else {
+			v = je.value(fv)
+		}
+
+		if idx, found := names[name]; found {
+			switch {
+			case (*obj)[idx].depth > depth:
+				(*obj)[idx] = &jsonField{name: name, value: v, depth: depth}
+			case (*obj)[idx].depth == depth:
+				(*obj)[idx].conflict = true
+			}
+			continue
+		}
+		names[name] = len(*obj)
+		*obj = append(*obj, &jsonField{name: name, value: v, depth: depth})
+	}
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// jsonObject and its methods
+//______________________________________________________________________________
+
+type jsonField struct {
+	name     string
+	value    interface{}
+	depth    int
+	conflict bool
+}
+
+// jsonObject preserves the struct field order while marshaling.
+type jsonObject []*jsonField
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	buf := acquireBuffer()
+	defer releaseBuffer(buf)
+
+	_ = buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			_ = buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(f.name)
+		_, _ = buf.Write(k)
+		_ = buf.WriteByte(':')
+		v, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		_, _ = buf.Write(v)
+	}
+	_ = buf.WriteByte('}')
+	return append([]byte(nil), buf.Bytes()...), nil
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Unexported methods
+//______________________________________________________________________________
+
+func parseJSONTag(tag string) (string, string) {
+	if idx := strings.IndexByte(tag, ','); idx != -1 {
+		return tag[:idx], tag[idx+1:]
+	}
+	return tag, ""
+}
+
+func mapKey(k reflect.Value) string {
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		b, _ := tm.MarshalText()
+		return string(b)
+	}
+	return fmt.Sprint(k.Interface())
+}
+
+func isQuotableKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// isEmptyValue method taken from `encoding/json` package.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type jsonBase struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type jsonSample struct {
+	jsonBase
+	Name     string            `json:"name"`
+	Count    int               `json:"count"`
+	Size     uint64            `json:"size,omitempty"`
+	Secret   string            `json:"-"`
+	Code     int64             `json:"code,string"`
+	Updated  *time.Time        `json:"updated,omitempty"`
+	Tags     []int64           `json:"tags"`
+	Attrs    map[string]int64  `json:"attrs"`
+	Raw      json.RawMessage   `json:"raw"`
+	Stamp    Time              `json:"stamp"`
+	Note     interface{}       `json:"note"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	internal string
+}
+
+func TestJSONEncoder(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	a := newTestApp(t, importPath)
+
+	tm := time.Date(2018, 6, 15, 10, 30, 0, 0, time.UTC)
+	sample := &jsonSample{
+		jsonBase: jsonBase{ID: 9007199254740993, CreatedAt: tm},
+		Name:     "aah",
+		Count:    2,
+		Secret:   "secret",
+		Code:     12,
+		Tags:     []int64{9007199254740993},
+		Attrs:    map[string]int64{"a": 1},
+		Raw:      json.RawMessage(`{"k":"v"}`),
+		Stamp:    a.Time(tm),
+		Note:     tm,
+		internal: "internal",
+	}
+
+	t.Log("Default stdlib behavior")
+	assert.Nil(t, a.jsonEncoder())
+	stdlib, _ := json.Marshal(sample)
+	assert.Equal(t, string(stdlib), encodeJSON(t, a.jsonEncoder(), sample))
+
+	t.Log("Normalized data is same as stdlib for default options")
+	je := &jsonEncoder{a: a, timeFormat: jsonTimeRFC3339}
+	assert.Equal(t, string(stdlib), encodeJSON(t, je, sample))
+
+	t.Log("int64 as string and unix millis")
+	a.Config().SetBool("render.json.int64_as_string", true)
+	a.Config().SetString("render.json.time_format", "unix_millis")
+	assert.Nil(t, a.settings.Refresh(a.Config()))
+	result := encodeJSON(t, a.jsonEncoder(), sample)
+	assert.Equal(t, `{"id":"9007199254740993","created_at":1529058600000,"name":"aah","count":2,`+
+		`"code":"12","tags":["9007199254740993"],"attrs":{"a":"1"},"raw":{"k":"v"},`+
+		`"stamp":"2018-06-15T10:30:00Z","note":1529058600000}`, result)
+
+	t.Log("datetime and unix")
+	a.Config().SetString("format.timezone", "Asia/Kolkata")
+	a.Config().SetString("render.json.time_format", "datetime")
+	assert.Nil(t, a.settings.Refresh(a.Config()))
+	assert.True(t, strings.Contains(encodeJSON(t, a.jsonEncoder(), sample), `"created_at":"2018-06-15T16:00:00+05:30"`))
+
+	a.Config().SetString("render.json.time_format", "unix")
+	assert.Nil(t, a.settings.Refresh(a.Config()))
+	assert.True(t, strings.Contains(encodeJSON(t, a.jsonEncoder(), sample), `"created_at":1529058600`))
+
+	t.Log("Reply JSON render")
+	ctx := &Context{a: a}
+	re := newReply(ctx).JSON(Data{"id": int64(10)})
+	buf := new(bytes.Buffer)
+	assert.Nil(t, re.Rdr.Render(buf))
+	assert.Equal(t, `{"id":"10"}`, strings.TrimSpace(buf.String()))
+
+	t.Log("Invalid time format")
+	a.Config().SetString("render.json.time_format", "iso")
+	err := a.settings.Refresh(a.Config())
+	assert.Equal(t, "'render.json.time_format' unsupported value: iso", err.Error())
+}
+
+func encodeJSON(t *testing.T, je *jsonEncoder, v interface{}) string {
+	b, err := json.Marshal(je.data(v))
+	assert.Nil(t, err)
+	return string(b)
+}
@@ -39,6 +39,7 @@ const (
 	fmtFlagResponseSize
 	fmtFlagResponseHeader
 	fmtFlagResponseTime
+	fmtFlagResponseLocale
 	fmtFlagCustom
 )
 
@@ -56,11 +57,14 @@ var (
 		"ressize":   fmtFlagResponseSize,
 		"reshdr":    fmtFlagResponseHeader,
 		"restime":   fmtFlagResponseTime,
+		"reslocale": fmtFlagResponseLocale,
 		"custom":    fmtFlagCustom,
 	}
 
 	defaultAccessLogPattern = "%clientip %custom:- %reqtime %reqmethod %requrl %reqproto %resstatus %ressize %restime %reqhdr:referer"
 	reqStartTimeKey         = "_appReqStartTimeKey"
+	replyStartTimeKey       = "_appReplyStartTimeKey"
+	actionElapsedKey        = "_appActionElapsedKey"
 )
 
 func (a *Application) initAccessLog() error {
@@ -127,7 +131,7 @@ func (aal *accessLogger) Log(ctx *Context) {
 
 	// All the bytes have been written on the wire
 	// so calculate elapsed time
-	al.ElapsedDuration = time.Since(al.StartTime)
+	al.ElapsedDuration = aal.a.Clock().Now().Sub(al.StartTime)
 
 	req := *ctx.Req
 	al.Request = &req
@@ -180,6 +184,8 @@ func (aal *accessLogger) accessLogFormatter(al *accessLog) string {
 			buf.WriteString(al.GetResponseHdr(part.Format))
 		case fmtFlagResponseTime:
 			buf.WriteString(fmt.Sprintf("%.4f", al.ElapsedDuration.Seconds()*1e3))
+		case fmtFlagResponseLocale:
+			buf.WriteString(al.GetResponseLocale())
 		case fmtFlagCustom:
 			buf.WriteString(part.Format)
 		}
@@ -229,6 +235,15 @@ func (al *accessLog) GetResponseHdr(hdrKey string) string {
 	return `"` + strings.Join(hdrValues, ", ") + `"`
 }
 
+// GetResponseLocale method returns the served locale of response, i.e. value
+// of `Content-Language` header.
+func (al *accessLog) GetResponseLocale() string {
+	if locale := al.ResHdr.Get(ahttp.HeaderContentLanguage); len(locale) > 0 {
+		return locale
+	}
+	return "-"
+}
+
 func (al *accessLog) GetQueryString() string {
 	queryStr := al.Request.URL().Query().Encode()
 	if len(queryStr) == 0 {
@@ -369,3 +384,97 @@ func sortHeaderKeys(hdrs http.Header) []string {
 	sort.Strings(keys)
 	return keys
 }
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Server Error Logger Definitions
+//______________________________________________________________________________
+
+const tlsHandshakeErrorPrefix = "http: TLS handshake error"
+
+var serverErrorLogLevels = []string{"error", "warn", "info", "debug", "trace", "off"}
+
+func (a *Application) initServerErrorLog() error {
+	keyPrefix := "server.error_log"
+	el := &serverErrorLog{
+		a:            a,
+		level:        strings.ToLower(a.Config().StringDefault(keyPrefix+".level", "error")),
+		handshakeLvl: strings.ToLower(a.Config().StringDefault(keyPrefix+".tls_handshake_level", "debug")),
+	}
+	if !ess.IsSliceContainsString(serverErrorLogLevels, el.level) {
+		return fmt.Errorf("'%s.level' unsupported value: %s", keyPrefix, el.level)
+	}
+	if !ess.IsSliceContainsString(serverErrorLogLevels, el.handshakeLvl) {
+		return fmt.Errorf("'%s.tls_handshake_level' unsupported value: %s", keyPrefix, el.handshakeLvl)
+	}
+	a.serverErrLog = el
+	return nil
+}
+
+// serverErrorLog routes the Go HTTP server errors into aah logger, mapped
+// to `http.Server.ErrorLog`. TLS handshake errors are logged at its own level
+// since scanners produce lot of them.
+type serverErrorLog struct {
+	a            *Application
+	level        string
+	handshakeLvl string
+}
+
+func (el *serverErrorLog) Write(p []byte) (int, error) {
+	msg := strings.TrimSpace(string(p))
+	level := el.level
+	if strings.HasPrefix(msg, tlsHandshakeErrorPrefix) {
+		level = el.handshakeLvl
+	}
+
+	switch level {
+	case "error":
+		el.a.Log().Error(msg)
+	case "warn":
+		el.a.Log().Warn(msg)
+	case "info":
+		el.a.Log().Info(msg)
+	case "debug":
+		el.a.Log().Debug(msg)
+	case "trace":
+		el.a.Log().Trace(msg)
+	}
+	return len(p), nil
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Slow Request Logger Definitions
+//______________________________________________________________________________
+
+// logSlowRequest method logs the request at warn level, if its elapsed time
+// exceeds the route `slow_threshold` otherwise `server.slow_request.threshold`.
+// Timing breakdown of controller action and reply write is added if available.
+func (a *Application) logSlowRequest(ctx *Context) {
+	start, found := ctx.Get(reqStartTimeKey).(time.Time)
+	if !found {
+		return
+	}
+	end := a.Clock().Now()
+	elapsed := end.Sub(start)
+
+	threshold, routeName := a.settings.SlowRequestThreshold, "-"
+	if ctx.route != nil {
+		routeName = ctx.route.Name
+		if ctx.route.SlowThreshold > 0 {
+			threshold = ctx.route.SlowThreshold
+		}
+	}
+	if elapsed < threshold {
+		return
+	}
+
+	var breakdown []string
+	if d, found := ctx.Get(actionElapsedKey).(time.Duration); found {
+		breakdown = append(breakdown, "action: "+d.String())
+	}
+	if t, found := ctx.Get(replyStartTimeKey).(time.Time); found {
+		breakdown = append(breakdown, "reply: "+end.Sub(t).String())
+	}
+
+	ctx.Log().Warnf("Slow request, Method: %s, Path: %s, Route: %s, Status: %d, Elapsed: %s, Threshold: %s, Breakdown: [%s]",
+		ctx.Req.Method, ctx.Req.Path, routeName, ctx.Res.Status(), elapsed, threshold, strings.Join(breakdown, ", "))
+}
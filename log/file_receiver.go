@@ -121,6 +121,10 @@ func (f *FileReceiver) Log(entry *Entry) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
+	if f.isClosed {
+		return
+	}
+
 	if f.isRotate() {
 		_ = f.rotateFile()
 
@@ -150,6 +154,13 @@ func (f *FileReceiver) Writer() io.Writer {
 	return f.out
 }
 
+// Close method closes the log file, entries logged afterwards are discarded.
+func (f *FileReceiver) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.close()
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // FileReceiver Unexported methods
 //___________________________________
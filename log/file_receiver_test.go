@@ -7,6 +7,7 @@ package log
 import (
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"aahframe.work/config"
@@ -176,3 +177,26 @@ func testFileLogger(t *testing.T, cfgStr string, loop int) {
 	assert.NotNil(t, logger.ToGoLogger())
 	logger.SetWriter(ioutil.Discard)
 }
+
+func TestFileLoggerClose(t *testing.T) {
+	defer cleaupFiles("*.log")
+	configStr := `
+  log {
+    receiver = "file"
+    file = "close-aah-filename.log"
+    pattern = "%message"
+  }
+  `
+	cfg, _ := config.ParseString(configStr)
+	logger, err := New(cfg)
+	assert.Nil(t, err)
+
+	logger.Info("before close")
+	logger.Close()
+	logger.Info("after close")
+	logger.Close()
+
+	b, err := ioutil.ReadFile("close-aah-filename.log")
+	assert.Nil(t, err)
+	assert.Equal(t, "before close", strings.TrimSpace(string(b)))
+}
@@ -25,6 +25,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"aahframe.work/config"
 )
@@ -69,7 +70,7 @@ type (
 	Logger struct {
 		cfg      *config.Config
 		m        *sync.RWMutex
-		level    level
+		level    uint32 // atomic, refer to `lvl`
 		receiver Receiver
 		ctx      Fields
 		hooks    map[string]HookFunc
@@ -213,7 +214,7 @@ func (l *Logger) AddHook(name string, hook HookFunc) error {
 
 // Level method returns currently enabled logging level.
 func (l *Logger) Level() string {
-	return levelToLevelName[l.level]
+	return levelToLevelName[l.lvl()]
 }
 
 // SetLevel method sets the given logging level for the logger.
@@ -225,7 +226,7 @@ func (l *Logger) SetLevel(level string) error {
 	if levelFlag == LevelUnknown {
 		return fmt.Errorf("log: unknown log level '%s'", level)
 	}
-	l.level = levelFlag
+	atomic.StoreUint32(&l.level, uint32(levelFlag))
 	return nil
 }
 
@@ -259,6 +260,18 @@ func (l *Logger) SetWriter(w io.Writer) {
 	l.receiver.SetWriter(w)
 }
 
+// Close method closes the log receiver if it supports, for e.g.: file
+// receiver closes the log file.
+func (l *Logger) Close() {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if c, ok := l.receiver.(interface {
+		Close()
+	}); ok {
+		c.Close()
+	}
+}
+
 // ToGoLogger method wraps the current log writer into Go Logger instance.
 func (l *Logger) ToGoLogger() *slog.Logger {
 	return slog.New(l.receiver.Writer(), "", slog.LstdFlags)
@@ -270,7 +283,7 @@ func (l *Logger) ToGoLogger() *slog.Logger {
 
 // Error logs message as `ERROR`. Arguments handled in the mananer of `fmt.Print`.
 func (l *Logger) Error(v ...interface{}) {
-	if l.level >= LevelError {
+	if l.lvl() >= LevelError {
 		e := acquireEntry(l)
 		e.Error(v...)
 		releaseEntry(e)
@@ -279,7 +292,7 @@ func (l *Logger) Error(v ...interface{}) {
 
 // Errorf logs message as `ERROR`. Arguments handled in the mananer of `fmt.Printf`.
 func (l *Logger) Errorf(format string, v ...interface{}) {
-	if l.level >= LevelError {
+	if l.lvl() >= LevelError {
 		e := acquireEntry(l)
 		e.Errorf(format, v...)
 		releaseEntry(e)
@@ -288,7 +301,7 @@ func (l *Logger) Errorf(format string, v ...interface{}) {
 
 // Warn logs message as `WARN`. Arguments handled in the mananer of `fmt.Print`.
 func (l *Logger) Warn(v ...interface{}) {
-	if l.level >= LevelWarn {
+	if l.lvl() >= LevelWarn {
 		e := acquireEntry(l)
 		e.Warn(v...)
 		releaseEntry(e)
@@ -297,7 +310,7 @@ func (l *Logger) Warn(v ...interface{}) {
 
 // Warnf logs message as `WARN`. Arguments handled in the mananer of `fmt.Printf`.
 func (l *Logger) Warnf(format string, v ...interface{}) {
-	if l.level >= LevelWarn {
+	if l.lvl() >= LevelWarn {
 		e := acquireEntry(l)
 		e.Warnf(format, v...)
 		releaseEntry(e)
@@ -306,7 +319,7 @@ func (l *Logger) Warnf(format string, v ...interface{}) {
 
 // Info logs message as `INFO`. Arguments handled in the mananer of `fmt.Print`.
 func (l *Logger) Info(v ...interface{}) {
-	if l.level >= LevelInfo {
+	if l.lvl() >= LevelInfo {
 		e := acquireEntry(l)
 		e.Info(v...)
 		releaseEntry(e)
@@ -315,7 +328,7 @@ func (l *Logger) Info(v ...interface{}) {
 
 // Infof logs message as `INFO`. Arguments handled in the mananer of `fmt.Printf`.
 func (l *Logger) Infof(format string, v ...interface{}) {
-	if l.level >= LevelInfo {
+	if l.lvl() >= LevelInfo {
 		e := acquireEntry(l)
 		e.Infof(format, v...)
 		releaseEntry(e)
@@ -324,7 +337,7 @@ func (l *Logger) Infof(format string, v ...interface{}) {
 
 // Debug logs message as `DEBUG`. Arguments handled in the mananer of `fmt.Print`.
 func (l *Logger) Debug(v ...interface{}) {
-	if l.level >= LevelDebug {
+	if l.lvl() >= LevelDebug {
 		e := acquireEntry(l)
 		e.Debug(v...)
 		releaseEntry(e)
@@ -333,7 +346,7 @@ func (l *Logger) Debug(v ...interface{}) {
 
 // Debugf logs message as `DEBUG`. Arguments handled in the mananer of `fmt.Printf`.
 func (l *Logger) Debugf(format string, v ...interface{}) {
-	if l.level >= LevelDebug {
+	if l.lvl() >= LevelDebug {
 		e := acquireEntry(l)
 		e.Debugf(format, v...)
 		releaseEntry(e)
@@ -342,7 +355,7 @@ func (l *Logger) Debugf(format string, v ...interface{}) {
 
 // Trace logs message as `TRACE`. Arguments handled in the mananer of `fmt.Print`.
 func (l *Logger) Trace(v ...interface{}) {
-	if l.level >= LevelTrace {
+	if l.lvl() >= LevelTrace {
 		e := acquireEntry(l)
 		e.Trace(v...)
 		releaseEntry(e)
@@ -351,7 +364,7 @@ func (l *Logger) Trace(v ...interface{}) {
 
 // Tracef logs message as `TRACE`. Arguments handled in the mananer of `fmt.Printf`.
 func (l *Logger) Tracef(format string, v ...interface{}) {
-	if l.level >= LevelTrace {
+	if l.lvl() >= LevelTrace {
 		e := acquireEntry(l)
 		e.Tracef(format, v...)
 		releaseEntry(e)
@@ -450,43 +463,49 @@ func (l *Logger) Panicln(v ...interface{}) {
 
 // IsLevelInfo method returns true if log level is INFO otherwise false.
 func (l *Logger) IsLevelInfo() bool {
-	return l.level == LevelInfo
+	return l.lvl() == LevelInfo
 }
 
 // IsLevelError method returns true if log level is ERROR otherwise false.
 func (l *Logger) IsLevelError() bool {
-	return l.level == LevelError
+	return l.lvl() == LevelError
 }
 
 // IsLevelWarn method returns true if log level is WARN otherwise false.
 func (l *Logger) IsLevelWarn() bool {
-	return l.level == LevelWarn
+	return l.lvl() == LevelWarn
 }
 
 // IsLevelDebug method returns true if log level is DEBUG otherwise false.
 func (l *Logger) IsLevelDebug() bool {
-	return l.level == LevelDebug
+	return l.lvl() == LevelDebug
 }
 
 // IsLevelTrace method returns true if log level is TRACE otherwise false.
 func (l *Logger) IsLevelTrace() bool {
-	return l.level == LevelTrace
+	return l.lvl() == LevelTrace
 }
 
 // IsLevelFatal method returns true if log level is FATAL otherwise false.
 func (l *Logger) IsLevelFatal() bool {
-	return l.level == LevelFatal
+	return l.lvl() == LevelFatal
 }
 
 // IsLevelPanic method returns true if log level is PANIC otherwise false.
 func (l *Logger) IsLevelPanic() bool {
-	return l.level == LevelPanic
+	return l.lvl() == LevelPanic
 }
 
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Unexported methods
 //___________________________________
 
+// lvl method returns the logging level, level could be changed while other
+// goroutines are logging.
+func (l *Logger) lvl() level {
+	return level(atomic.LoadUint32(&l.level))
+}
+
 func (l *Logger) output(e *Entry) {
 	if l.receiver.IsCallerInfo() {
 		e.File, e.Line = fetchCallerInfo()
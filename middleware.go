@@ -5,10 +5,15 @@
 package aah
 
 import (
+	"fmt"
 	"net/http"
 	"reflect"
+	"strings"
+	"time"
 
+	"aahframe.work/ahttp"
 	"aahframe.work/essentials"
+	"aahframe.work/internal/util"
 	"aahframe.work/log"
 )
 
@@ -57,12 +62,79 @@ func ToMiddleware(handler interface{}) MiddlewareFunc {
 	}
 }
 
+// RecoverableMiddleware method wraps the given middleware to recover the
+// panic occurs within it, it logs the panic and continues the middleware
+// chain instead of failing the request. Panic occurs in the further chain
+// after `m.Next(ctx)` call is not recovered by it, aah panic recovery
+// handles the same. Route attribute `skip_middlewares` uses the key of
+// wrapped middleware, for e.g.: `metrics`.
+//
+//    aah.AppHTTPEngine().Middlewares(
+//      ...
+//      aah.RecoverableMiddleware(MetricsMiddleware),
+//      ...
+//    )
+func RecoverableMiddleware(mw MiddlewareFunc) MiddlewareFunc {
+	name := middlewareName(mw)
+	key := middlewareKey(name)
+	return func(ctx *Context, m *Middleware) {
+		if isMiddlewareSkipped(ctx, key) {
+			m.Next(ctx)
+			return
+		}
+
+		ctx.component = name
+		var nextCalled bool
+		proxy := &Middleware{name: name, next: func(ctx *Context, _ *Middleware) {
+			nextCalled = true
+			m.Next(ctx)
+		}}
+
+		defer func() {
+			if nextCalled {
+				return
+			}
+			if r := recover(); r != nil {
+				ctx.Log().Errorf("Panic recovered in middleware '%s', continuing the request: %v", name, r)
+				proxy.Next(ctx)
+			}
+		}()
+
+		mw(ctx, proxy)
+	}
+}
+
+// NamedMiddleware method wraps the given middleware with explicit name, it is
+// used by the route attribute `skip_middlewares` and panic report. Key cannot
+// be derived from the closure middleware, so name it.
+//
+//    aah.AppHTTPEngine().Middlewares(
+//      ...
+//      aah.NamedMiddleware("MetricsMiddleware", newMetricsMiddleware(cfg)),
+//      ...
+//    )
+func NamedMiddleware(name string, mw MiddlewareFunc) MiddlewareFunc {
+	key := middlewareKey(name)
+	return func(ctx *Context, m *Middleware) {
+		if isMiddlewareSkipped(ctx, key) {
+			m.Next(ctx)
+			return
+		}
+
+		ctx.component = name
+		mw(ctx, m)
+	}
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Middleware methods
 //______________________________________________________________________________
 
 // Middleware struct is to implement aah framework middleware chain.
 type Middleware struct {
+	name    string
+	key     string
+	pos     int
 	next    MiddlewareFunc
 	further *Middleware
 }
@@ -75,7 +147,18 @@ func (mw *Middleware) Next(ctx *Context) {
 	}
 
 	if mw.next != nil {
+		// skipped by the route attribute `skip_middlewares`
+		if isMiddlewareSkipped(ctx, mw.key) {
+			mw.further.Next(ctx)
+			return
+		}
+
+		// current component and middleware of request flow, it is reported
+		// on panic
+		prev, prevMw := ctx.component, ctx.mw
+		ctx.component, ctx.mw = mw.name, mw
 		mw.next(ctx, mw.further)
+		ctx.component, ctx.mw = prev, prevMw
 	}
 }
 
@@ -90,13 +173,73 @@ func (e *HTTPEngine) Middlewares(middlewares ...MiddlewareFunc) {
 	e.invalidateMwChain()
 }
 
+// SetMiddlewares method replaces the middleware stack with given
+// middlewares, for e.g.: test could choose its own middleware chain.
+func (e *HTTPEngine) SetMiddlewares(middlewares ...MiddlewareFunc) {
+	e.mwStack = nil
+	e.Middlewares(middlewares...)
+}
+
+// MiddlewareStack method returns the copy of registered middlewares in the
+// order of execution.
+func (e *HTTPEngine) MiddlewareStack() []MiddlewareFunc {
+	return append([]MiddlewareFunc(nil), e.mwStack...)
+}
+
+// MiddlewareNames method returns the qualified names of registered middlewares
+// in the order of execution, for e.g.: to assert the order in the test.
+func (e *HTTPEngine) MiddlewareNames() []string {
+	names := make([]string, 0, len(e.mwStack))
+	for _, mw := range e.mwStack {
+		names = append(names, middlewareName(mw))
+	}
+	return names
+}
+
+// validateMiddlewares method returns the warnings for aah framework
+// middlewares registered out of its recommended order.
+func (e *HTTPEngine) validateMiddlewares() []string {
+	pos := make(map[string]int)
+	for idx, name := range e.MiddlewareNames() {
+		if _, found := pos[name]; !found {
+			pos[name] = idx
+		}
+	}
+
+	route, routeFound := pos[middlewareName(RouteMiddleware)]
+	bind, bindFound := pos[middlewareName(BindMiddleware)]
+	var warnings []string
+	if routeFound && route != 0 {
+		warnings = append(warnings, "'RouteMiddleware' is recommended to be the first middleware")
+	}
+	if cors, found := pos[middlewareName(CORSMiddleware)]; found && (!routeFound || cors != route+1) {
+		warnings = append(warnings, "'CORSMiddleware' is recommended to be next to 'RouteMiddleware'")
+	}
+	for _, mw := range []struct {
+		name string
+		fn   MiddlewareFunc
+	}{
+		{"AntiCSRFMiddleware", AntiCSRFMiddleware},
+		{"AuthcAuthzMiddleware", AuthcAuthzMiddleware},
+	} {
+		if idx, found := pos[middlewareName(mw.fn)]; found && bindFound && idx < bind {
+			warnings = append(warnings, fmt.Sprintf("'%s' runs before 'BindMiddleware', request data is not bound yet", mw.name))
+		}
+	}
+	if action, found := pos[middlewareName(ActionMiddleware)]; found && action != len(e.mwStack)-1 {
+		warnings = append(warnings, "'ActionMiddleware' is recommended to be the last middleware")
+	}
+	return warnings
+}
+
 func (e *HTTPEngine) invalidateMwChain() {
 	e.mwChain = nil
 	cnt := len(e.mwStack)
 	e.mwChain = make([]*Middleware, cnt)
 
 	for idx := 0; idx < cnt; idx++ {
-		e.mwChain[idx] = &Middleware{next: e.mwStack[idx]}
+		name := middlewareName(e.mwStack[idx])
+		e.mwChain[idx] = &Middleware{name: name, key: middlewareKey(name), pos: idx + 1, next: e.mwStack[idx]}
 	}
 
 	for idx := cnt - 1; idx > 0; idx-- {
@@ -126,12 +269,26 @@ type finallyInterceptor interface {
 //	- Executes Interceptors (Before, Before<ActionName>, After, After<ActionName>,
 //				Panic, Panic<ActionName>, Finally, Finally<ActionName>)
 // 	- Invokes Controller Action
+//
+// Supported action signatures are-
+//
+//	func (c *UserController) Create(...)
+//	func (c *UserController) Create(...) error
+//	func (c *UserController) Create(...) *models.User
+//	func (c *UserController) Create(...) (*models.User, error)
+//
+// Non-nil returned error is mapped via error handler flow, `*aah.Error` value
+// is used as-is otherwise it becomes `500 Internal Server Error`. Non-nil
+// returned value is rendered as per negotiated content type (JSON, XML or
+// Text; JSON is default), if action did not set the reply render. Value
+// implements `aah.Render` is rendered as-is.
 func ActionMiddleware(ctx *Context, m *Middleware) {
 	if err := ctx.setTarget(ctx.route); err == errTargetNotFound {
 		// No controller or action found for the route
 		ctx.Reply().NotFound().Error(newError(ErrControllerOrActionNotFound, http.StatusNotFound))
 		return
 	}
+	ctx.component = ctx.controller.FqName + "." + ctx.action.Name
 
 	// Finally action and method. Always executed if present
 	defer func() {
@@ -191,7 +348,14 @@ func ActionMiddleware(ctx *Context, m *Middleware) {
 		}
 
 		ctx.Log().Debugf("Calling action: %s.%s", ctx.controller.FqName, ctx.action.Name)
-		ctx.actionrv.Call(actionArgs)
+		var actionStart time.Time
+		if ctx.a.settings.SlowRequestEnabled {
+			actionStart = ctx.a.Clock().Now()
+		}
+		handleActionResult(ctx, ctx.actionrv.Call(actionArgs))
+		if !actionStart.IsZero() {
+			ctx.Set(actionElapsedKey, ctx.a.Clock().Now().Sub(actionStart))
+		}
 	}
 
 	// After action method
@@ -210,3 +374,113 @@ func ActionMiddleware(ctx *Context, m *Middleware) {
 		}
 	}
 }
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Unexported methods
+//______________________________________________________________________________
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func middlewareName(mw MiddlewareFunc) string {
+	return ess.GetFunctionInfo(mw).QualifiedName
+}
+
+// middlewareKey method returns the key of middleware used by the route
+// attribute `skip_middlewares`, it is lower case of function name without
+// suffix `Middleware`. For e.g.: `AntiCSRFMiddleware` => `anticsrf`.
+// `RouteMiddleware` and `ActionMiddleware` cannot be skipped. Closure
+// middleware has no key, refer to `NamedMiddleware`.
+func middlewareKey(name string) string {
+	name = name[strings.LastIndexByte(name, '.')+1:]
+	switch name {
+	case "RouteMiddleware", "ActionMiddleware":
+		return ""
+	}
+	if isClosureName(name) {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSuffix(name, "Middleware"))
+}
+
+// isClosureName method returns true if the function name is generated by Go
+// for the closure, for e.g.: `func1` or `1` for nested one.
+func isClosureName(name string) bool {
+	name = strings.TrimPrefix(name, "func")
+	if len(name) == 0 {
+		return false
+	}
+	for _, r := range name {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isMiddlewareSkipped method returns true if the middleware key is skipped by
+// the route attribute `skip_middlewares` of current request.
+func isMiddlewareSkipped(ctx *Context, key string) bool {
+	return len(key) > 0 && ctx.route != nil && ctx.route.IsMiddlewareSkipped(key)
+}
+
+// handleActionResult method processes the values returned by action, refer
+// to `ActionMiddleware` for supported signatures.
+func handleActionResult(ctx *Context, results []reflect.Value) {
+	if len(results) == 0 {
+		return
+	}
+
+	// last result is error
+	last := results[len(results)-1]
+	if last.Type() == errorType {
+		results = results[:len(results)-1]
+		if !last.IsNil() {
+			err := last.Interface().(error)
+			if e, ok := err.(*Error); ok {
+				// returned error could be shared value, for e.g.: package
+				// level error, so its copy is used for the reply
+				ce := *e
+				if ce.Code == 0 {
+					ce.Code = http.StatusInternalServerError
+				}
+				ctx.Reply().Status(ce.Code).Error(&ce)
+			} else {
+				ctx.Reply().InternalServerError().Error(newError(err, http.StatusInternalServerError))
+			}
+			return
+		}
+	}
+
+	if len(results) == 0 || isNilValue(results[0]) || ctx.Reply().Rdr != nil {
+		return
+	}
+
+	v := results[0].Interface()
+	if rdr, ok := v.(Render); ok {
+		ctx.Reply().Render(rdr)
+		return
+	}
+
+	ct := ctx.Reply().ContType
+	if len(ct) == 0 {
+		if ct = ctx.routeProduces(); len(ct) == 0 {
+			ct = ctx.Req.AcceptContentType().Mime
+		}
+	}
+	switch util.OnlyMIME(ct) {
+	case ahttp.ContentTypeXML.Mime, ahttp.ContentTypeXMLText.Mime:
+		ctx.Reply().XML(v)
+	case ahttp.ContentTypePlainText.Mime:
+		ctx.Reply().Text("%v", v)
+	default:
+		ctx.Reply().JSON(v)
+	}
+}
+
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
@@ -8,6 +8,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -77,3 +80,215 @@ func thirdPartyMiddleware3(w http.ResponseWriter, r *http.Request) {
 func invaildHandlerType(e *Event) {
 	fmt.Println("This is invaild handler type")
 }
+
+func TestMiddlewareActionResult(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Action Result]: %s", ts.URL)
+
+	t.Log("Returned value is rendered as JSON by default")
+	ts.Get("/action-result/value").
+		AssertStatus(http.StatusOK).
+		AssertHeader(ahttp.HeaderContentType, ahttp.ContentTypeJSON.String()).
+		AssertJSONPath("first_name", "Jeeva").
+		AssertJSONPath("number", 1)
+
+	t.Log("Returned value is rendered as per Accept header")
+	req, _ := http.NewRequest(ahttp.MethodGet, ts.URL+"/action-result/value", nil)
+	req.Header.Set(ahttp.HeaderAccept, ahttp.ContentTypeXML.Mime)
+	r := ts.Do(req).AssertStatus(http.StatusOK).
+		AssertHeader(ahttp.HeaderContentType, ahttp.ContentTypeXML.String())
+	assert.True(t, strings.Contains(r.BodyString(), "<FirstName>Jeeva</FirstName>"))
+
+	t.Log("Returned aah error")
+	req, _ = http.NewRequest(ahttp.MethodGet, ts.URL+"/action-result/aah-error", nil)
+	req.Header.Set(ahttp.HeaderAccept, ahttp.ContentTypeJSON.Mime)
+	ts.Do(req).AssertStatus(http.StatusBadRequest).
+		AssertHeader("X-Cntrl-ErrorHandler", "true").
+		AssertJSONPath("error_code", "bad_input").
+		AssertJSONPath("message", "Invalid input")
+
+	t.Log("Returned shared aah error is not modified")
+	req, _ = http.NewRequest(ahttp.MethodGet, ts.URL+"/action-result/sentinel-error", nil)
+	req.Header.Set(ahttp.HeaderAccept, ahttp.ContentTypeJSON.Mime)
+	ts.Do(req).AssertStatus(http.StatusInternalServerError).
+		AssertJSONPath("error_code", "sentinel")
+	assert.Equal(t, 0, errTestSentinel.Code)
+
+	t.Log("Returned error")
+	req, _ = http.NewRequest(ahttp.MethodGet, ts.URL+"/action-result/error", nil)
+	req.Header.Set(ahttp.HeaderAccept, ahttp.ContentTypeJSON.Mime)
+	ts.Do(req).AssertStatus(http.StatusInternalServerError).
+		AssertJSONPath("message", "Internal Server Error")
+
+	t.Log("Returned nil value keeps the reply composed by action")
+	r = ts.Get("/action-result/empty").AssertStatus(http.StatusNoContent)
+	assert.Equal(t, "", r.BodyString())
+}
+
+func TestMiddlewarePanicRecovery(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Middleware Panic Recovery]: %s", ts.URL)
+
+	defaultMws := ts.app.he.mwStack
+	withMiddleware := func(mw MiddlewareFunc) []MiddlewareFunc {
+		mws := append([]MiddlewareFunc{}, defaultMws[:len(defaultMws)-1]...)
+		return append(mws, mw, defaultMws[len(defaultMws)-1])
+	}
+
+	t.Log("Panic in action")
+	lr := ts.CaptureLog()
+	ts.Get("/trigger-panic").AssertStatus(http.StatusInternalServerError)
+	assert.True(t, strings.Contains(lr.String(), "panic occurred in 'aahframe.work/testSiteController.TriggerPanic'"))
+
+	t.Log("Panic in middleware")
+	ts.SetMiddlewares(withMiddleware(testPanicMiddleware)...)
+	lr = ts.CaptureLog()
+	ts.Get("/get-text.html").AssertStatus(http.StatusInternalServerError)
+	assert.True(t, strings.Contains(lr.String(), "panic occurred in 'aahframe.work.testPanicMiddleware'"))
+	assert.True(t, strings.Contains(lr.String(), fmt.Sprintf("(middleware %d of %d, before action)",
+		len(defaultMws), len(defaultMws)+1)))
+
+	t.Log("Panic in middleware after action")
+	ts.SetMiddlewares(withMiddleware(func(ctx *Context, m *Middleware) {
+		m.Next(ctx)
+		panic("after action panic")
+	})...)
+	lr = ts.CaptureLog()
+	ts.Get("/get-text.html").AssertStatus(http.StatusInternalServerError)
+	assert.True(t, strings.Contains(lr.String(), "after action)"))
+
+	t.Log("Panic in middleware of api application is JSON")
+	ts.SetMiddlewares(withMiddleware(testPanicMiddleware)...)
+	ts.app.Config().SetString("type", "api")
+	req, _ := http.NewRequest(ahttp.MethodGet, ts.URL+"/get-text.html", nil)
+	req.Header.Set(ahttp.HeaderAccept, ahttp.ContentTypePlainText.Mime)
+	ts.Do(req).AssertStatus(http.StatusInternalServerError).
+		AssertHeader(ahttp.HeaderContentType, ahttp.ContentTypeJSON.String()).
+		AssertJSONPath("message", "Internal Server Error")
+
+	t.Log("Panic in action of api application is negotiated")
+	ts.SetMiddlewares(defaultMws...)
+	req, _ = http.NewRequest(ahttp.MethodGet, ts.URL+"/trigger-panic", nil)
+	req.Header.Set(ahttp.HeaderAccept, ahttp.ContentTypePlainText.Mime)
+	ts.Do(req).AssertStatus(http.StatusInternalServerError).
+		AssertHeader(ahttp.HeaderContentType, ahttp.ContentTypePlainText.String())
+	ts.app.Config().SetString("type", "web")
+
+	t.Log("Panic in recoverable middleware")
+	ts.SetMiddlewares(withMiddleware(RecoverableMiddleware(testPanicMiddleware))...)
+	lr = ts.CaptureLog()
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+	assert.True(t, strings.Contains(lr.String(),
+		"Panic recovered in middleware 'aahframe.work.testPanicMiddleware', continuing the request: middleware panic"))
+
+	t.Log("Panic in further chain is not recovered by recoverable middleware")
+	ts.SetMiddlewares(withMiddleware(RecoverableMiddleware(func(ctx *Context, m *Middleware) {
+		m.Next(ctx)
+	}))...)
+	ts.Get("/trigger-panic").AssertStatus(http.StatusInternalServerError)
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+}
+
+func testPanicMiddleware(ctx *Context, m *Middleware) {
+	panic("middleware panic")
+}
+
+func TestMiddlewareNamesAndValidation(t *testing.T) {
+	a := newApp()
+	e := a.he
+
+	e.Middlewares(
+		RouteMiddleware,
+		CORSMiddleware,
+		BindMiddleware,
+		AntiCSRFMiddleware,
+		AuthcAuthzMiddleware,
+		ActionMiddleware,
+	)
+	assert.Equal(t, []string{
+		"aahframe.work.RouteMiddleware",
+		"aahframe.work.CORSMiddleware",
+		"aahframe.work.BindMiddleware",
+		"aahframe.work.AntiCSRFMiddleware",
+		"aahframe.work.AuthcAuthzMiddleware",
+		"aahframe.work.ActionMiddleware",
+	}, e.MiddlewareNames())
+	assert.Nil(t, e.validateMiddlewares())
+
+	e.mwStack = nil
+	e.Middlewares(
+		CORSMiddleware,
+		RouteMiddleware,
+		AuthcAuthzMiddleware,
+		BindMiddleware,
+		ActionMiddleware,
+		testPanicMiddleware,
+	)
+	assert.Equal(t, []string{
+		"'RouteMiddleware' is recommended to be the first middleware",
+		"'CORSMiddleware' is recommended to be next to 'RouteMiddleware'",
+		"'AuthcAuthzMiddleware' runs before 'BindMiddleware', request data is not bound yet",
+		"'ActionMiddleware' is recommended to be the last middleware",
+	}, e.validateMiddlewares())
+}
+
+func TestMiddlewareSkipByRoute(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Middleware Skip By Route]: %s", ts.URL)
+
+	assert.Equal(t, "anticsrf", middlewareKey("aahframe.work.AntiCSRFMiddleware"))
+	assert.Equal(t, "metrics", middlewareKey("example.com/app/middleware.MetricsMiddleware"))
+	assert.Equal(t, "", middlewareKey("aahframe.work.RouteMiddleware"))
+	assert.Equal(t, "", middlewareKey("aahframe.work.ActionMiddleware"))
+	assert.Equal(t, "", middlewareKey("aahframe.work.RecoverableMiddleware.func1"))
+	assert.Equal(t, "", middlewareKey("example.com/app/middleware.NewMetrics.func1.1"))
+	assert.Equal(t, "function", middlewareKey("example.com/app/middleware.FunctionMiddleware"))
+
+	form := url.Values{}
+	form.Add("id", "1000001")
+	form.Add("email", "welcome@welcome.com")
+	post := func(p string) *http.Response {
+		req, err := http.NewRequest(ahttp.MethodPost, ts.URL+p, strings.NewReader(form.Encode()))
+		assert.Nil(t, err)
+		req.Header.Set(ahttp.HeaderContentType, ahttp.ContentTypeForm.String())
+		resp, err := ts.client.Do(req)
+		assert.Nil(t, err)
+		return resp
+	}
+
+	t.Log("Anti-CSRF check on route")
+	assert.Equal(t, http.StatusForbidden, post("/form-submit").StatusCode)
+
+	t.Log("Anti-CSRF middleware skipped by route")
+	assert.Equal(t, http.StatusOK, post("/form-submit-no-csrf").StatusCode)
+
+	t.Log("Recoverable middleware is skipped by the key of wrapped middleware")
+	var mws []MiddlewareFunc
+	csrfIdx := -1
+	for idx, mw := range ts.app.he.mwStack {
+		if reflect.ValueOf(mw).Pointer() == reflect.ValueOf(AntiCSRFMiddleware).Pointer() {
+			mw, csrfIdx = RecoverableMiddleware(AntiCSRFMiddleware), idx
+		}
+		mws = append(mws, mw)
+	}
+	ts.SetMiddlewares(mws...)
+	assert.Equal(t, http.StatusForbidden, post("/form-submit").StatusCode)
+	assert.Equal(t, http.StatusOK, post("/form-submit-no-csrf").StatusCode)
+
+	t.Log("Closure middleware is skipped by explicit name")
+	mws[csrfIdx] = NamedMiddleware("AntiCSRFMiddleware", func(ctx *Context, m *Middleware) {
+		AntiCSRFMiddleware(ctx, m)
+	})
+	ts.SetMiddlewares(mws...)
+	assert.Equal(t, http.StatusForbidden, post("/form-submit").StatusCode)
+	assert.Equal(t, http.StatusOK, post("/form-submit-no-csrf").StatusCode)
+}
@@ -31,14 +31,22 @@ type Reply struct {
 
 	redirect bool
 	done     bool
+	codeSet  bool
 	gzip     bool
 	path     string
 	ctx      *Context
 	body     *bytes.Buffer
 	cookies  []*http.Cookie
+	trailers []*trailer
 	err      *Error
 }
 
+// trailer holds the declared HTTP trailer field and its value func.
+type trailer struct {
+	key   string
+	value func() string
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Reply - HTTP Status Code
 //______________________________________________________________________________
@@ -48,9 +56,16 @@ type Reply struct {
 // HTTP Status Codes reference: http://www.restapitutorial.com/httpCodecodes.html
 func (r *Reply) Status(code int) *Reply {
 	r.Code = code
+	r.codeSet = true
 	return r
 }
 
+// IsStatusSet method returns true if HTTP status code is set via method
+// `Reply().Status` or its convenient methods otherwise false.
+func (r *Reply) IsStatusSet() bool {
+	return r.codeSet
+}
+
 // Ok method sets the HTTP Code as 200 RFC 7231, 6.3.1.
 func (r *Reply) Ok() *Reply {
 	return r.Status(http.StatusOK)
@@ -157,7 +172,7 @@ func (r *Reply) ContentType(contentType string) *Reply {
 // and it sets HTTP 'Content-Type' as 'application/json; charset=utf-8'.
 func (r *Reply) JSON(data interface{}) *Reply {
 	r.ContentType(ahttp.ContentTypeJSON.String())
-	r.Render(&jsonRender{Data: data})
+	r.Render(&jsonRender{Data: data, enc: r.jsonEncoder()})
 	return r
 }
 
@@ -167,7 +182,7 @@ func (r *Reply) JSON(data interface{}) *Reply {
 // See config `render.secure_json.prefix`.
 func (r *Reply) JSONSecure(data interface{}) *Reply {
 	r.ContentType(ahttp.ContentTypeJSON.String())
-	r.Render(&secureJSONRender{Data: data, Prefix: r.ctx.a.settings.SecureJSONPrefix})
+	r.Render(&secureJSONRender{Data: data, Prefix: r.ctx.a.settings.SecureJSONPrefix, enc: r.jsonEncoder()})
 	return r
 }
 
@@ -175,7 +190,7 @@ func (r *Reply) JSONSecure(data interface{}) *Reply {
 // and it sets HTTP 'Content-Type' as 'application/javascript; charset=utf-8'.
 func (r *Reply) JSONP(data interface{}, callback string) *Reply {
 	r.ContentType(ahttp.ContentTypeJavascript.String())
-	r.Render(&jsonpRender{Data: data, Callback: callback})
+	r.Render(&jsonpRender{Data: data, Callback: callback, enc: r.jsonEncoder()})
 	return r
 }
 
@@ -341,6 +356,24 @@ func (r *Reply) Header(key, value string) *Reply {
 	return r
 }
 
+// Headers method sets the given headers and values for the response, same
+// as calling `Reply().Header` for each entry. So empty value deletes the
+// header.
+//
+//	ctx.Reply().
+//	  Headers(map[string]string{
+//	    "X-RateLimit-Limit":     "100",
+//	    "X-RateLimit-Remaining": "99",
+//	  }).
+//	  Header("X-Request-Source", "api").
+//	  JSON(data)
+func (r *Reply) Headers(hdrs map[string]string) *Reply {
+	for k, v := range hdrs {
+		r.Header(k, v)
+	}
+	return r
+}
+
 // HeaderAppend method appends the given header and value for the response.
 //
 // Note: It just appends to it. It does not overwrite existing header.
@@ -363,6 +396,28 @@ func (r *Reply) Done() *Reply {
 	return r
 }
 
+// Trailer method declares the HTTP trailer field for the streaming reply
+// i.e. `Reply().FromReader`, `Reply().Binary` and `Reply().File`. The trailer
+// name is sent in the `Trailer` header and given func is invoked after the
+// body is written on the wire to obtain its value, for e.g.: checksum of
+// streamed content, processing status.
+//
+//	hash := sha256.New()
+//	ctx.Reply().
+//		Trailer("X-Checksum", func() string { return hex.EncodeToString(hash.Sum(nil)) }).
+//		FromReader(io.TeeReader(reader, hash))
+//
+// Note: Trailers are sent by Go HTTP server for HTTP/1.1 chunked response
+// and HTTP/2. It is skipped for HTTP/1.0 request and non-streaming reply,
+// since the underlying writer cannot send it.
+func (r *Reply) Trailer(key string, value func() string) *Reply {
+	if len(key) == 0 || value == nil {
+		return r
+	}
+	r.trailers = append(r.trailers, &trailer{key: http.CanonicalHeaderKey(key), value: value})
+	return r
+}
+
 // Cookie method adds the give HTTP cookie into response.
 func (r *Reply) Cookie(cookie *http.Cookie) *Reply {
 	if r.cookies == nil {
@@ -400,6 +455,68 @@ func (r *Reply) Body() *bytes.Buffer {
 	return r.body
 }
 
+// StatusCode method returns the HTTP status code of the reply. Default
+// value is `200 OK`.
+func (r *Reply) StatusCode() int {
+	return r.Code
+}
+
+// ContentTypeValue method returns the Content-Type of the reply, it is
+// empty if not set yet and detected by framework while writing the reply.
+func (r *Reply) ContentTypeValue() string {
+	return r.ContType
+}
+
+// RedirectURL method returns the redirect URL if the reply is redirect
+// otherwise empty string.
+func (r *Reply) RedirectURL() string {
+	if !r.redirect {
+		return ""
+	}
+	return r.path
+}
+
+// RenderedBody method returns the response body bytes of the reply. If the
+// reply is not yet written, it renders the body without writing it on the
+// wire, it is handy for unit testing with `HTTPEngine().NewContext`.
+//
+// Note: HTML reply needs resolved view template, so it is returned only
+// after the reply is written. Reader of `Reply().FromReader` is consumed
+// by the render.
+func (r *Reply) RenderedBody() ([]byte, error) {
+	if r.body != nil {
+		return r.body.Bytes(), nil
+	}
+	if r.Rdr == nil {
+		return nil, nil
+	}
+	buf := new(bytes.Buffer)
+	if err := renderBody(r, buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Reset method resets the reply to its initial state, i.e. status code,
+// content type, render, redirect, cookies, trailers and error.
+//
+// Note: Response headers set via `Reply().Header` are not reset, since
+// those are set on `aah.Context.Res` directly.
+func (r *Reply) Reset() *Reply {
+	releaseBuffer(r.body)
+	*r = *newReply(r.ctx)
+	return r
+}
+
+// jsonEncoder method returns the JSON encoder as per `render.json.*`,
+// nil means stdlib behavior.
+func (r *Reply) jsonEncoder() *jsonEncoder {
+	if r.ctx == nil || r.ctx.a == nil {
+		return nil
+	}
+	return r.ctx.a.jsonEncoder()
+}
+
 func (r *Reply) isHTML() bool {
 	return ahttp.ContentTypeHTML.IsEqual(r.ContType)
 }
@@ -490,11 +607,12 @@ func (t *textRender) Render(w io.Writer) (err error) {
 // jsonRender renders the response JSON content.
 type jsonRender struct {
 	Data interface{}
+	enc  *jsonEncoder
 }
 
 // Render method writes JSON into HTTP response.
 func (j *jsonRender) Render(w io.Writer) error {
-	return json.NewEncoder(w).Encode(j.Data)
+	return json.NewEncoder(w).Encode(j.enc.data(j.Data))
 }
 
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
@@ -505,11 +623,12 @@ func (j *jsonRender) Render(w io.Writer) error {
 type jsonpRender struct {
 	Callback string
 	Data     interface{}
+	enc      *jsonEncoder
 }
 
 // Render method writes JSONP into HTTP response.
 func (j *jsonpRender) Render(w io.Writer) error {
-	jsonBytes, err := json.Marshal(j.Data)
+	jsonBytes, err := json.Marshal(j.enc.data(j.Data))
 	if err != nil {
 		return err
 	}
@@ -530,13 +649,14 @@ func (j *jsonpRender) Render(w io.Writer) error {
 type secureJSONRender struct {
 	Prefix string
 	Data   interface{}
+	enc    *jsonEncoder
 }
 
 func (s *secureJSONRender) Render(w io.Writer) error {
 	if _, err := w.Write([]byte(s.Prefix)); err != nil {
 		return err
 	}
-	return json.NewEncoder(w).Encode(s.Data)
+	return json.NewEncoder(w).Encode(s.enc.data(s.Data))
 }
 
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
@@ -9,12 +9,16 @@ import (
 	"fmt"
 	"html/template"
 	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"aahframe.work/ahttp"
 	"aahframe.work/essentials"
 	"github.com/stretchr/testify/assert"
 )
@@ -126,6 +130,32 @@ func TestReplyHTML(t *testing.T) {
 	assert.True(t, ess.IsStrEmpty(htmlf.Layout))
 	assert.Equal(t, "Filename1.html", htmlf.Filename)
 }
+func TestReplyHeaders(t *testing.T) {
+	req := httptest.NewRequest(ahttp.MethodGet, "http://localhost:8080/headers", nil)
+	w := httptest.NewRecorder()
+	ctx := newContext(w, req)
+	ctx.Res.Header().Set("X-Removed", "value")
+
+	re := ctx.Reply().
+		Header("X-Request-Source", "api").
+		Headers(map[string]string{
+			"X-RateLimit-Limit":     "100",
+			"X-RateLimit-Remaining": "99",
+			"X-Removed":             "",
+			ahttp.HeaderContentType: ahttp.ContentTypeJSON.String(),
+		}).
+		HeaderAppend("X-Request-Source", "web").
+		Text("chained")
+
+	assert.Equal(t, ctx.Reply(), re)
+	hdr := ctx.Res.Header()
+	assert.Equal(t, []string{"api", "web"}, hdr["X-Request-Source"])
+	assert.Equal(t, "100", hdr.Get("X-RateLimit-Limit"))
+	assert.Equal(t, "99", hdr.Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "", hdr.Get("X-Removed"))
+	assert.Equal(t, ahttp.ContentTypeJSON.String(), re.ContType)
+}
+
 func TestReplyDone(t *testing.T) {
 	re1 := newReply(nil)
 
@@ -134,6 +164,82 @@ func TestReplyDone(t *testing.T) {
 	assert.True(t, re1.done)
 }
 
+func TestReplyIntrospection(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	a := newTestApp(t, importPath)
+
+	w := httptest.NewRecorder()
+	ctx := a.HTTPEngine().NewContext(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	assert.Equal(t, "/users/1", ctx.Req.Path)
+
+	re := ctx.Reply()
+	assert.Equal(t, http.StatusOK, re.StatusCode())
+	assert.Equal(t, "", re.ContentTypeValue())
+	body, err := re.RenderedBody()
+	assert.Nil(t, err)
+	assert.Nil(t, body)
+
+	re.Created().Header("X-User-Id", "1").JSON(Data{"id": 1})
+	assert.Equal(t, http.StatusCreated, re.StatusCode())
+	assert.Equal(t, ahttp.ContentTypeJSON.String(), re.ContentTypeValue())
+	assert.Equal(t, "1", ctx.Res.Header().Get("X-User-Id"))
+	body, err = re.RenderedBody()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"id":1}`+"\n", string(body))
+	assert.Equal(t, 0, w.Body.Len())
+
+	t.Log("Redirect")
+	assert.Equal(t, "", re.RedirectURL())
+	re.Redirect("/login")
+	assert.Equal(t, "/login", re.RedirectURL())
+	assert.Equal(t, http.StatusFound, re.StatusCode())
+
+	t.Log("Reset")
+	re.Reset()
+	assert.Equal(t, http.StatusOK, re.StatusCode())
+	assert.False(t, re.IsStatusSet())
+	assert.Equal(t, "", re.ContentTypeValue())
+	assert.Equal(t, "", re.RedirectURL())
+	assert.Nil(t, re.Rdr)
+	assert.True(t, re.gzip)
+
+	t.Log("HTML view is not resolved")
+	_, err = re.HTML(nil).RenderedBody()
+	assert.Equal(t, "template is nil", err.Error())
+}
+
+func TestReplyTrailer(t *testing.T) {
+	re := newReply(nil)
+	re.Trailer("", func() string { return "" }).Trailer("x-checksum", nil)
+	assert.Equal(t, 0, len(re.trailers))
+
+	re.Trailer("x-checksum", func() string { return "abc" })
+	assert.Equal(t, 1, len(re.trailers))
+	assert.Equal(t, "X-Checksum", re.trailers[0].key)
+
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Reply Trailer]: %s", ts.URL)
+
+	resp, err := http.Get(ts.URL + "/stream-trailer")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "This is streamed content", responseBody(resp))
+	assert.Equal(t, "24", resp.Trailer.Get("X-Stream-Size"))
+	assert.Equal(t, "completed", resp.Trailer.Get("X-Stream-Status"))
+
+	t.Log("HTTP/1.0 request, trailers are skipped")
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	assert.Nil(t, err)
+	defer conn.Close()
+	_, _ = fmt.Fprint(conn, "GET /stream-trailer HTTP/1.0\r\nHost: localhost:8080\r\n\r\n")
+	raw, _ := ioutil.ReadAll(conn)
+	assert.True(t, strings.HasSuffix(string(raw), "This is streamed content"))
+	assert.False(t, strings.Contains(string(raw), "Trailer:"))
+}
+
 // customRender implements the interface `aah.Render`.
 type customRender struct {
 	// ... your fields goes here
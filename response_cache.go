@@ -0,0 +1,566 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"aahframe.work/ahttp"
+	"aahframe.work/cache"
+	"golang.org/x/sync/singleflight"
+)
+
+// CachedResponse struct holds the rendered response of the route, which is
+// stored into response cache store.
+type CachedResponse struct {
+	Path      string
+	Status    int
+	Header    http.Header
+	Body      []byte
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Application methods
+//______________________________________________________________________________
+
+// SetResponseCacheStore method is to set the cache store for `CacheMiddleware`,
+// for e.g.: cache created via `aah.App().CacheManager()`. By default in-memory
+// store is used.
+func (a *Application) SetResponseCacheStore(store cache.Cache) {
+	if store == nil {
+		return
+	}
+	if a.respCache == nil {
+		a.respCache = newResponseCache(a)
+	}
+	a.respCache.store = store
+}
+
+// SetResponseCacheKeyFunc method is to set the func, which returns the
+// request identity to be part of cache key, for e.g.: user ID, tenant ID.
+// Responses of authenticated routes are cached only if key func is set or
+// `cache.response.vary_headers` has `Authorization` or `Cookie` header,
+// otherwise response of one user would be served to another user.
+//
+//	aah.App().SetResponseCacheKeyFunc(func(ctx *aah.Context) string {
+//	  return ctx.Subject().PrimaryPrincipal().Value
+//	})
+func (a *Application) SetResponseCacheKeyFunc(fn func(ctx *Context) string) {
+	if a.respCache == nil {
+		a.respCache = newResponseCache(a)
+	}
+	a.respCache.keyFunc = fn
+}
+
+// InvalidateCache method deletes the cached responses of request paths,
+// which matches the given pattern. Pattern syntax is same as `path.Match`,
+// for e.g.: `/products/*`. It returns the count of deleted entries.
+//
+// Note: Only entries cached by this application instance are tracked for
+// invalidation.
+func (a *Application) InvalidateCache(pattern string) int {
+	if a.respCache == nil {
+		return 0
+	}
+	return a.respCache.invalidate(pattern)
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Package methods
+//______________________________________________________________________________
+
+// CacheMiddleware method caches the rendered response of the route, which
+// has `cache_ttl` attribute in the `routes.conf`. Cache key is composed from
+// HTTP method, host, path, query string and `cache.response.vary_headers`
+// values. On cache hit it writes the cached response and skips the action.
+//
+// Concurrent cache miss requests of same key are coalesced, only one of them
+// executes the action and others are served from the cache.
+//
+// Authenticated route is not cached unless the cache key has the request
+// identity, refer to `aah.App().SetResponseCacheKeyFunc`.
+//
+// Add it after `aah.AuthcAuthzMiddleware` in the middleware stack.
+func CacheMiddleware(ctx *Context, m *Middleware) {
+	rc := ctx.a.respCache
+	if rc == nil || ctx.route == nil || ctx.route.CacheTTL <= 0 || !rc.isShareable(ctx) {
+		m.Next(ctx)
+		return
+	}
+
+	reqCacheCtrl := strings.ToLower(ctx.Req.Header.Get(ahttp.HeaderCacheControl))
+	if strings.Contains(reqCacheCtrl, "no-store") {
+		m.Next(ctx)
+		return
+	}
+
+	key := rc.key(ctx)
+	if !strings.Contains(reqCacheCtrl, "no-cache") {
+		if cr := rc.get(key); cr != nil {
+			rc.write(ctx, cr)
+			return
+		}
+	}
+
+	// concurrent cache miss requests are coalesced
+	v, executed := doFlight(&rc.group, key, func() interface{} {
+		cr := rc.capture(ctx, m)
+		if cr == nil {
+			return nil
+		}
+		if cr.Status != http.StatusOK || !isResponseCacheable(cr.Header) {
+			rc.write(ctx, cr)
+			return nil
+		}
+		cr.ExpiresAt = cr.CreatedAt.Add(ctx.route.CacheTTL)
+		rc.put(key, cr, ctx.route.CacheTTL)
+		rc.write(ctx, cr)
+		return cr
+	})
+	if executed {
+		return
+	}
+	if cr, ok := v.(*CachedResponse); ok {
+		rc.write(ctx, cr)
+		return
+	}
+	m.Next(ctx)
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// app Unexported methods
+//______________________________________________________________________________
+
+func (a *Application) initResponseCache() error {
+	if a.respCache == nil {
+		a.respCache = newResponseCache(a)
+	}
+	if vary, found := a.Config().StringList("cache.response.vary_headers"); found {
+		a.respCache.varyHdrs = vary
+	} else {
+		a.respCache.varyHdrs = []string{ahttp.HeaderAccept, ahttp.HeaderAcceptLanguage}
+	}
+
+	maxEntries := a.Config().IntDefault("cache.response.max_entries", defaultResponseCacheMaxEntries)
+	if maxEntries <= 0 {
+		return fmt.Errorf("'cache.response.max_entries' unsupported value: %d", maxEntries)
+	}
+	a.respCache.setMaxEntries(maxEntries)
+	return nil
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// responseCache and its methods
+//______________________________________________________________________________
+
+const defaultResponseCacheMaxEntries = 10000
+
+func newResponseCache(a *Application) *responseCache {
+	return &responseCache{
+		a:          a,
+		store:      newMemoryResponseStore(defaultResponseCacheMaxEntries, a.now),
+		keys:       make(map[string]*list.Element),
+		keyOrder:   list.New(),
+		maxEntries: defaultResponseCacheMaxEntries,
+	}
+}
+
+type responseCache struct {
+	sync.Mutex
+	a          *Application
+	store      cache.Cache
+	varyHdrs   []string
+	keyFunc    func(ctx *Context) string
+	keys       map[string]*list.Element
+	keyOrder   *list.List
+	maxEntries int
+	group      singleflight.Group
+}
+
+// cacheKeyEntry is the tracked cache key and its request path for
+// invalidation.
+type cacheKeyEntry struct {
+	key  string
+	path string
+}
+
+func (rc *responseCache) setMaxEntries(n int) {
+	rc.Lock()
+	rc.maxEntries = n
+	rc.Unlock()
+	if ms, ok := rc.store.(*memoryResponseStore); ok {
+		ms.setMaxEntries(n)
+	}
+}
+
+// isShareable method returns true if the response of request could be
+// shared with other requests of same cache key. Response of authenticated
+// route is shareable only if cache key has the request identity.
+func (rc *responseCache) isShareable(ctx *Context) bool {
+	if !ctx.a.settings.AuthSchemeExists || ctx.route.Auth == "anonymous" || rc.keyFunc != nil {
+		return true
+	}
+	for _, h := range rc.varyHdrs {
+		if strings.EqualFold(h, ahttp.HeaderAuthorization) || strings.EqualFold(h, ahttp.HeaderCookie) {
+			return true
+		}
+	}
+	ctx.Log().Debugf("responsecache: authenticated route '%s' is not cached, cache key has no request identity", ctx.route.Name)
+	return false
+}
+
+func (rc *responseCache) key(ctx *Context) string {
+	buf := acquireBuilder()
+	defer releaseBuilder(buf)
+	buf.WriteString(ctx.Req.Method)
+	buf.WriteByte(' ')
+	buf.WriteString(ctx.Req.Host)
+	buf.WriteString(ctx.Req.Path)
+	if q := ctx.Req.URL().RawQuery; len(q) > 0 {
+		buf.WriteByte('?')
+		buf.WriteString(q)
+	}
+	for _, h := range rc.varyHdrs {
+		buf.WriteByte('|')
+		buf.WriteString(ctx.Req.Header.Get(h))
+	}
+	if rc.keyFunc != nil {
+		buf.WriteByte('|')
+		buf.WriteString(rc.keyFunc(ctx))
+	}
+	return buf.String()
+}
+
+func (rc *responseCache) get(key string) *CachedResponse {
+	cr, ok := rc.store.Get(key).(*CachedResponse)
+	if !ok {
+		return nil
+	}
+	if !rc.a.Clock().Now().Before(cr.ExpiresAt) {
+		rc.delete(key)
+		return nil
+	}
+	return cr
+}
+
+func (rc *responseCache) put(key string, cr *CachedResponse, ttl time.Duration) {
+	_ = rc.store.Delete(key)
+	if err := rc.store.Put(key, cr, ttl); err != nil {
+		rc.a.Log().Errorf("responsecache: unable to store '%s': %v", key, err)
+		return
+	}
+
+	// tracked keys are bounded, oldest key is evicted along with its entry
+	var evicted []string
+	rc.Lock()
+	if e, found := rc.keys[key]; found {
+		rc.keyOrder.Remove(e)
+	}
+	rc.keys[key] = rc.keyOrder.PushFront(&cacheKeyEntry{key: key, path: cr.Path})
+	for rc.keyOrder.Len() > rc.maxEntries {
+		e := rc.keyOrder.Back()
+		ke := rc.keyOrder.Remove(e).(*cacheKeyEntry)
+		delete(rc.keys, ke.key)
+		evicted = append(evicted, ke.key)
+	}
+	rc.Unlock()
+	for _, k := range evicted {
+		_ = rc.store.Delete(k)
+	}
+}
+
+func (rc *responseCache) delete(key string) {
+	_ = rc.store.Delete(key)
+	rc.Lock()
+	if e, found := rc.keys[key]; found {
+		rc.keyOrder.Remove(e)
+		delete(rc.keys, key)
+	}
+	rc.Unlock()
+}
+
+func (rc *responseCache) invalidate(pattern string) int {
+	var keys []string
+	rc.Lock()
+	for k, e := range rc.keys {
+		p := e.Value.(*cacheKeyEntry).path
+		if matched, _ := path.Match(pattern, p); matched || pattern == p {
+			keys = append(keys, k)
+		}
+	}
+	rc.Unlock()
+	for _, k := range keys {
+		rc.delete(k)
+	}
+	return len(keys)
+}
+
+// capture method executes the rest of the middleware chain and renders the
+// reply into buffer, caller writes it on the wire via `write`. It returns nil
+// if the reply is not rendered by it, for e.g.: aborted, already done or
+// error.
+func (rc *responseCache) capture(ctx *Context, m *Middleware) *CachedResponse {
+	res := ctx.Res
+	cw := &cacheResponseWriter{ResponseWriter: res}
+	ctx.Res = cw
+	defer func() { ctx.Res = res }()
+
+	// response body is captured as-is, Gzip applied on write
+	re := ctx.Reply()
+	gzip := re.gzip
+	re.DisableGzip()
+	m.Next(ctx)
+
+	if ctx.abort || re.done || re.err != nil {
+		re.gzip = gzip
+		return nil
+	}
+	rc.a.he.writeReply(ctx)
+	re.gzip = gzip
+
+	hdr := make(http.Header)
+	for k, v := range cw.Header() {
+		if k == rc.a.settings.RequestIDHeaderKey {
+			continue
+		}
+		hdr[k] = append([]string{}, v...)
+	}
+
+	return &CachedResponse{
+		Path:      ctx.Req.Path,
+		Status:    cw.Status(),
+		Header:    hdr,
+		Body:      cw.body.Bytes(),
+		CreatedAt: rc.a.Clock().Now(),
+	}
+}
+
+// write method writes the cached response on the wire. Header `Age` is
+// written for the cache store entry.
+func (rc *responseCache) write(ctx *Context, cr *CachedResponse) {
+	re := ctx.Reply()
+	for k, v := range cr.Header {
+		ctx.Res.Header()[k] = v
+	}
+	re.ContType = cr.Header.Get(ahttp.HeaderContentType)
+	ctx.writeHeaders()
+	if !cr.ExpiresAt.IsZero() {
+		ctx.Res.Header().Set(ahttp.HeaderAge,
+			strconv.Itoa(int(rc.a.Clock().Now().Sub(cr.CreatedAt).Seconds())))
+	}
+
+	if rc.a.settings.GzipEnabled && ctx.Req.IsGzipAccepted && re.gzip &&
+		len(cr.Body) > defaultGzipMinSize {
+		ctx.Res = wrapGzipWriter(ctx.Res)
+	}
+
+	ctx.Res.WriteHeader(cr.Status)
+	if ctx.Req.Method != ahttp.MethodHead {
+		if _, err := ctx.Res.Write(cr.Body); err != nil {
+			ctx.Log().Error(err)
+		}
+	}
+	re.Done()
+}
+
+func isResponseCacheable(hdr http.Header) bool {
+	if len(hdr.Get(ahttp.HeaderSetCookie)) > 0 {
+		return false
+	}
+	cc := strings.ToLower(hdr.Get(ahttp.HeaderCacheControl))
+	return !(strings.Contains(cc, "no-store") || strings.Contains(cc, "no-cache") ||
+		strings.Contains(cc, "private"))
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// cacheResponseWriter
+//______________________________________________________________________________
+
+// cacheResponseWriter captures the response status and body into buffer
+// without writing on the wire, header is of underlying response writer.
+type cacheResponseWriter struct {
+	ahttp.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *cacheResponseWriter) WriteHeader(code int) {
+	if w.status == 0 && code > 0 {
+		w.status = code
+	}
+}
+
+func (w *cacheResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+func (w *cacheResponseWriter) Status() int {
+	return w.status
+}
+
+func (w *cacheResponseWriter) BytesWritten() int {
+	return w.body.Len()
+}
+
+func (w *cacheResponseWriter) Committed() bool {
+	return w.status > 0
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// memoryResponseStore
+//______________________________________________________________________________
+
+var _ cache.Cache = (*memoryResponseStore)(nil)
+
+// memoryResponseStore is default in-memory store of response cache, entries
+// expire as per TTL and least recently used entry is evicted beyond the max
+// entries.
+type memoryResponseStore struct {
+	sync.Mutex
+	maxEntries int
+	now        func() time.Time
+	entries    map[string]*list.Element
+	lru        *list.List
+}
+
+type memoryResponseEntry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+func newMemoryResponseStore(maxEntries int, now func() time.Time) *memoryResponseStore {
+	return &memoryResponseStore{
+		maxEntries: maxEntries,
+		now:        now,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+func (s *memoryResponseStore) Name() string {
+	return "aah-response-cache"
+}
+
+func (s *memoryResponseStore) Get(k string) interface{} {
+	s.Lock()
+	defer s.Unlock()
+	if e := s.get(k); e != nil {
+		return e.value
+	}
+	return nil
+}
+
+func (s *memoryResponseStore) GetOrPut(k string, v interface{}, d time.Duration) (interface{}, error) {
+	s.Lock()
+	defer s.Unlock()
+	if e := s.get(k); e != nil {
+		return e.value, nil
+	}
+	s.put(k, v, d)
+	return v, nil
+}
+
+func (s *memoryResponseStore) Put(k string, v interface{}, d time.Duration) error {
+	s.Lock()
+	defer s.Unlock()
+	if e := s.get(k); e != nil {
+		return cache.ErrEntryExists
+	}
+	s.put(k, v, d)
+	return nil
+}
+
+func (s *memoryResponseStore) Delete(k string) error {
+	s.Lock()
+	if e, found := s.entries[k]; found {
+		s.remove(e)
+	}
+	s.Unlock()
+	return nil
+}
+
+func (s *memoryResponseStore) Exists(k string) bool {
+	s.Lock()
+	defer s.Unlock()
+	return s.get(k) != nil
+}
+
+func (s *memoryResponseStore) Flush() error {
+	s.Lock()
+	s.entries = make(map[string]*list.Element)
+	s.lru.Init()
+	s.Unlock()
+	return nil
+}
+
+func (s *memoryResponseStore) setMaxEntries(n int) {
+	s.Lock()
+	s.maxEntries = n
+	s.evict()
+	s.Unlock()
+}
+
+// get method returns the unexpired entry and marks it as recently used,
+// expired entry is removed.
+func (s *memoryResponseStore) get(k string) *memoryResponseEntry {
+	e, found := s.entries[k]
+	if !found {
+		return nil
+	}
+	me := e.Value.(*memoryResponseEntry)
+	if !me.expiresAt.IsZero() && !s.now().Before(me.expiresAt) {
+		s.remove(e)
+		return nil
+	}
+	s.lru.MoveToFront(e)
+	return me
+}
+
+func (s *memoryResponseStore) put(k string, v interface{}, d time.Duration) {
+	me := &memoryResponseEntry{key: k, value: v}
+	if d > 0 {
+		me.expiresAt = s.now().Add(d)
+	}
+	s.entries[k] = s.lru.PushFront(me)
+	s.evict()
+}
+
+// evict method removes the entries beyond the max entries, expired entries
+// are removed first then least recently used entries.
+func (s *memoryResponseStore) evict() {
+	if s.maxEntries <= 0 || s.lru.Len() <= s.maxEntries {
+		return
+	}
+	now := s.now()
+	for e := s.lru.Back(); e != nil; {
+		prev := e.Prev()
+		if me := e.Value.(*memoryResponseEntry); !me.expiresAt.IsZero() && !now.Before(me.expiresAt) {
+			s.remove(e)
+		}
+		e = prev
+	}
+	for s.lru.Len() > s.maxEntries {
+		s.remove(s.lru.Back())
+	}
+}
+
+func (s *memoryResponseStore) remove(e *list.Element) {
+	s.lru.Remove(e)
+	delete(s.entries, e.Value.(*memoryResponseEntry).key)
+}
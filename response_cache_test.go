@@ -0,0 +1,216 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"aahframe.work/ahttp"
+	"aahframe.work/cache"
+	"aahframe.work/router"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseCacheMiddleware(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"security.session.mode": "stateless",
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Response Cache]: %s", ts.URL)
+
+	ts.SetMiddlewares(
+		RouteMiddleware,
+		CORSMiddleware,
+		BindMiddleware,
+		AntiCSRFMiddleware,
+		AuthcAuthzMiddleware,
+		CacheMiddleware,
+		ActionMiddleware,
+	)
+
+	clock := NewFakeClock(time.Now())
+	ts.app.SetClock(clock)
+
+	var cnt int32
+	ts.app.HTTPEngine().OnPreReply(func(e *Event) {
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&cnt, 1)
+	})
+
+	t.Log("Cache miss and hit")
+	body := ts.Get("/cached-text.html").AssertStatus(200).BodyString()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&cnt))
+	clock.Advance(30 * time.Second)
+	r := ts.Get("/cached-text.html").AssertStatus(200).AssertHeader(ahttp.HeaderAge, "30")
+	assert.Equal(t, body, r.BodyString())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&cnt))
+
+	t.Log("Request Cache-Control no-cache refreshes the entry")
+	req, _ := http.NewRequest(ahttp.MethodGet, ts.URL+"/cached-text.html", nil)
+	req.Header.Set(ahttp.HeaderCacheControl, "no-cache")
+	ts.Do(req).AssertStatus(200)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&cnt))
+	ts.Get("/cached-text.html").AssertHeader(ahttp.HeaderAge, "0")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&cnt))
+
+	t.Log("Route without cache_ttl")
+	ts.Get("/get-text.html").AssertStatus(200)
+	ts.Get("/get-text.html").AssertStatus(200)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&cnt))
+
+	t.Log("Invalidate cache")
+	assert.Equal(t, 0, ts.app.InvalidateCache("/get-*"))
+	assert.Equal(t, 1, ts.app.InvalidateCache("/cached-*"))
+	ts.Get("/cached-text.html").AssertStatus(200)
+	assert.Equal(t, int32(5), atomic.LoadInt32(&cnt))
+
+	t.Log("Cache entry expiry")
+	clock.Advance(2 * time.Minute)
+	ts.Get("/cached-text.html").AssertStatus(200)
+	assert.Equal(t, int32(6), atomic.LoadInt32(&cnt))
+
+	t.Log("Concurrent cache miss executes action once")
+	ts.app.InvalidateCache("/cached-text.html")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := new(http.Client).Get(ts.URL + "/cached-text.html")
+			assert.Nil(t, err)
+			assert.Equal(t, 200, resp.StatusCode)
+			assert.Equal(t, body, responseBody(resp))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(7), atomic.LoadInt32(&cnt))
+}
+
+func TestResponseCacheable(t *testing.T) {
+	assert.True(t, isResponseCacheable(http.Header{}))
+	assert.True(t, isResponseCacheable(http.Header{ahttp.HeaderCacheControl: []string{"public, max-age=60"}}))
+	assert.False(t, isResponseCacheable(http.Header{ahttp.HeaderCacheControl: []string{"private"}}))
+	assert.False(t, isResponseCacheable(http.Header{ahttp.HeaderCacheControl: []string{"no-store"}}))
+	assert.False(t, isResponseCacheable(http.Header{ahttp.HeaderSetCookie: []string{"name=value"}}))
+}
+
+func TestResponseCacheGzipLeader(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"security.session.mode": "stateless",
+	})
+	defer ts.Close()
+
+	ts.SetMiddlewares(
+		RouteMiddleware,
+		BindMiddleware,
+		AntiCSRFMiddleware,
+		AuthcAuthzMiddleware,
+		CacheMiddleware,
+		ActionMiddleware,
+	)
+
+	largeText := strings.Repeat("aah framework ", 200)
+	ts.app.HTTPEngine().OnPreReply(func(e *Event) {
+		e.Data.(*Context).Reply().Text(largeText)
+	})
+
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+	for _, label := range []string{"cache miss", "cache hit"} {
+		t.Log(label)
+		req, _ := http.NewRequest(ahttp.MethodGet, ts.URL+"/cached-text.html", nil)
+		req.Header.Set(ahttp.HeaderAcceptEncoding, "gzip")
+		resp, err := client.Do(req)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "gzip", resp.Header.Get(ahttp.HeaderContentEncoding))
+		assert.Equal(t, largeText, responseBody(resp))
+	}
+
+	t.Log("Cached entry is stored uncompressed")
+	key := ts.app.respCache.keyOrder.Front().Value.(*cacheKeyEntry).key
+	cr := ts.app.respCache.get(key)
+	assert.Equal(t, largeText, string(cr.Body))
+	assert.Equal(t, "", cr.Header.Get(ahttp.HeaderContentEncoding))
+}
+
+func TestResponseCacheAuthenticatedRoute(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	a := newTestApp(t, importPath)
+	a.settings.AuthSchemeExists = true
+	rc := a.respCache
+
+	ctx := a.HTTPEngine().NewContext(httptest.NewRecorder(), httptest.NewRequest(ahttp.MethodGet, "/", nil))
+	ctx.route = &router.Route{Name: "anonymous_route", Auth: "anonymous"}
+	assert.True(t, rc.isShareable(ctx))
+
+	ctx.route = &router.Route{Name: "form_auth_route", Auth: "form_auth"}
+	assert.False(t, rc.isShareable(ctx))
+
+	rc.varyHdrs = []string{ahttp.HeaderAccept, ahttp.HeaderAuthorization}
+	assert.True(t, rc.isShareable(ctx))
+
+	rc.varyHdrs = []string{ahttp.HeaderAccept}
+	keyWithoutIdentity := rc.key(ctx)
+	a.SetResponseCacheKeyFunc(func(ctx *Context) string { return "user1" })
+	assert.True(t, rc.isShareable(ctx))
+	assert.Equal(t, keyWithoutIdentity+"|user1", rc.key(ctx))
+}
+
+func TestResponseCacheBounds(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	a := newTestApp(t, importPath)
+	clock := NewFakeClock(time.Now())
+	a.SetClock(clock)
+
+	t.Log("Memory store TTL expiry and LRU eviction")
+	ms := newMemoryResponseStore(2, clock.Now)
+	assert.Nil(t, ms.Put("k1", "v1", time.Minute))
+	assert.Nil(t, ms.Put("k2", "v2", time.Hour))
+	assert.Equal(t, cache.ErrEntryExists, ms.Put("k1", "v1", time.Minute))
+	assert.Equal(t, "v1", ms.Get("k1")) // k1 is recently used
+	assert.Nil(t, ms.Put("k3", "v3", time.Hour))
+	assert.Nil(t, ms.Get("k2"))
+	assert.Equal(t, "v1", ms.Get("k1"))
+	clock.Advance(2 * time.Minute)
+	assert.False(t, ms.Exists("k1"))
+	assert.Equal(t, "v3", ms.Get("k3"))
+
+	assert.Nil(t, ms.Put("k4", "v4", time.Hour))
+	clock.Advance(30 * time.Minute)
+	v, err := ms.GetOrPut("k5", "v5", time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, "v5", v)
+	assert.Equal(t, 2, ms.lru.Len())
+	assert.Nil(t, ms.Flush())
+	assert.Equal(t, 0, ms.lru.Len())
+
+	t.Log("Tracked cache keys are bounded")
+	a.Config().SetInt("cache.response.max_entries", 3)
+	assert.Nil(t, a.initResponseCache())
+	rc := a.respCache
+	for i := 0; i < 10; i++ {
+		cr := &CachedResponse{Path: "/products", CreatedAt: clock.Now(), ExpiresAt: clock.Now().Add(time.Minute)}
+		rc.put("GET /products?x="+string(rune('a'+i)), cr, time.Minute)
+	}
+	assert.Equal(t, 3, len(rc.keys))
+	assert.Equal(t, 3, rc.store.(*memoryResponseStore).lru.Len())
+	assert.NotNil(t, rc.get("GET /products?x=j"))
+	assert.Nil(t, rc.get("GET /products?x=a"))
+	assert.Equal(t, 3, a.InvalidateCache("/products"))
+	assert.Equal(t, 0, len(rc.keys))
+
+	a.Config().SetInt("cache.response.max_entries", 0)
+	assert.Equal(t, "'cache.response.max_entries' unsupported value: 0", a.initResponseCache().Error())
+}
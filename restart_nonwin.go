@@ -0,0 +1,258 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// +build !windows
+
+package aah
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Environment variable names used to pass the file descriptors to the child
+// process on graceful restart, i.e. server listener, HTTP redirect server
+// listener, additional server listeners and readiness pipe to notify the
+// parent process.
+const (
+	envInheritListenerFD  = "AAH_INHERIT_LISTENER_FD"
+	envInheritRedirectFD  = "AAH_INHERIT_REDIRECT_FD"
+	envInheritListenersFD = "AAH_INHERIT_LISTENERS_FD"
+	envRestartReadyFD     = "AAH_RESTART_READY_FD"
+)
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Graceful Restart Definitions
+//______________________________________________________________________________
+
+// listenForGracefulRestart method listens for the signal `SIGUSR2` and
+// performs zero-downtime restart if `server.graceful_restart.enable` is true.
+//
+// It fork-exec's the new process (application binary) with inherited
+// listeners and waits for the new process to be ready within
+// `server.graceful_restart.ready_timeout`, then current process drains the
+// in-flight requests and exits. On failure current process continues to serve.
+func (a *Application) listenForGracefulRestart() {
+	if !a.settings.GracefulRestartEnabled {
+		return
+	}
+	if a.settings.HotReloadEnabled && a.settings.HotReloadSignalStr == "SIGUSR2" {
+		a.Log().Warn("Signal SIGUSR2 is used by graceful restart, choose different signal for config hot-reload")
+	}
+
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, syscall.SIGUSR2)
+	for {
+		<-sc
+		a.Log().Warn("Graceful restart signal (SIGUSR2) received")
+		p, ready, err := a.forkExec()
+		if err != nil {
+			a.Log().Errorf("Unable to perform graceful restart: %v", err)
+			continue
+		}
+		a.Log().Infof("New process started with PID %d, waiting for it to be ready", p.Pid)
+		if err = waitForReady(ready, a.settings.GracefulRestartTimeout); err != nil {
+			a.Log().Errorf("New process (PID %d) is not ready within %s, continuing with current process: %v",
+				p.Pid, a.settings.GracefulRestartTimeStr, err)
+			_ = p.Kill()
+			go func() { _, _ = p.Wait() }()
+			continue
+		}
+		a.Log().Infof("New process (PID %d) is ready, shutting down current process", p.Pid)
+
+		// Unix socket file is in use by the new process
+		if ul, ok := a.listener.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+
+		// Triggers the aah server shutdown flow, refer to `aah run` command
+		if err = syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+			a.Log().Error(err)
+		}
+		return
+	}
+}
+
+// forkExec method starts new process of the application binary with same
+// arguments and environment, listener file descriptors are passed on to it.
+// It returns the new process and read end of the readiness pipe.
+func (a *Application) forkExec() (*os.Process, *os.File, error) {
+	if a.listener == nil {
+		return nil, nil, errors.New("listener not available")
+	}
+	f, err := listenerFile(a.listener)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	rr, rw, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rw.Close() }()
+
+	// ExtraFiles entry i becomes file descriptor 3+i in the child process
+	files := []*os.File{f, rw}
+	env := append(os.Environ(), envInheritListenerFD+"=3", envRestartReadyFD+"=4")
+
+	a.RLock()
+	rl := a.redirectLn
+	a.RUnlock()
+	if rl != nil {
+		rf, err := listenerFile(rl)
+		if err != nil {
+			_ = rr.Close()
+			return nil, nil, fmt.Errorf("redirect server: %v", err)
+		}
+		defer func() { _ = rf.Close() }()
+		files = append(files, rf)
+		env = append(env, envInheritRedirectFD+"="+strconv.Itoa(2+len(files)))
+	}
+
+	a.RLock()
+	lss := a.listenerSrvs
+	a.RUnlock()
+	var named []string
+	for _, ls := range lss {
+		lf, err := listenerFile(ls.l)
+		if err != nil {
+			_ = rr.Close()
+			return nil, nil, fmt.Errorf("listener '%s': %v", ls.name, err)
+		}
+		defer func(f *os.File) { _ = f.Close() }(lf)
+		files = append(files, lf)
+		named = append(named, ls.name+"="+strconv.Itoa(2+len(files)))
+	}
+	if len(named) > 0 {
+		env = append(env, envInheritListenersFD+"="+strings.Join(named, ","))
+	}
+
+	cmd := exec.Command(os.Args[0], os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = env
+	if err = cmd.Start(); err != nil {
+		_ = rr.Close()
+		return nil, nil, err
+	}
+	return cmd.Process, rr, nil
+}
+
+// notifyRestartReady method notifies the parent process via readiness pipe
+// once the application is ready to serve the requests, i.e. after warm up.
+// It is no-op if the process is not started by graceful restart.
+func (a *Application) notifyRestartReady() {
+	v := os.Getenv(envRestartReadyFD)
+	if len(v) == 0 {
+		return
+	}
+	_ = os.Unsetenv(envRestartReadyFD)
+
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		a.Log().Errorf("Unable to notify parent process: %v", err)
+		return
+	}
+	f := os.NewFile(uintptr(fd), "aah-restart-ready")
+	defer func() { _ = f.Close() }()
+	for !a.IsReady() {
+		if a.State() > AppStateReady {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if _, err = f.Write([]byte{1}); err != nil {
+		a.Log().Errorf("Unable to notify parent process: %v", err)
+	}
+}
+
+// waitForReady method waits for the readiness notification from the child
+// process within given timeout. Read end is closed on return.
+func waitForReady(r *os.File, timeout time.Duration) error {
+	defer func() { _ = r.Close() }()
+	_ = r.SetReadDeadline(time.Now().Add(timeout))
+	if _, err := r.Read(make([]byte, 1)); err != nil {
+		if err == io.EOF {
+			return errors.New("process exited before it is ready")
+		}
+		return err
+	}
+	return nil
+}
+
+// inheritedListener method returns the listener from file descriptor passed
+// on by parent process. If not exists then it returns nil.
+func inheritedListener() (net.Listener, error) {
+	return inheritedListenerByEnv(envInheritListenerFD)
+}
+
+// inheritedRedirectListener method returns the HTTP redirect server listener
+// from file descriptor passed on by parent process. If not exists then it
+// returns nil.
+func inheritedRedirectListener() (net.Listener, error) {
+	return inheritedListenerByEnv(envInheritRedirectFD)
+}
+
+// inheritedNamedListeners method returns the additional server listeners from
+// file descriptors passed on by parent process, keyed by listener name.
+func inheritedNamedListeners() (map[string]net.Listener, error) {
+	v := os.Getenv(envInheritListenersFD)
+	if len(v) == 0 {
+		return nil, nil
+	}
+	_ = os.Unsetenv(envInheritListenersFD)
+
+	listeners := make(map[string]net.Listener)
+	for _, p := range strings.Split(v, ",") {
+		idx := strings.LastIndexByte(p, '=')
+		fd, err := strconv.Atoi(p[idx+1:])
+		if idx <= 0 || err != nil {
+			return listeners, fmt.Errorf("invalid inherited listener: %s", p)
+		}
+		f := os.NewFile(uintptr(fd), "aah-listener-"+p[:idx])
+		l, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			return listeners, fmt.Errorf("listener '%s': %v", p[:idx], err)
+		}
+		listeners[p[:idx]] = l
+	}
+	return listeners, nil
+}
+
+func inheritedListenerByEnv(name string) (net.Listener, error) {
+	v := os.Getenv(name)
+	if len(v) == 0 {
+		return nil, nil
+	}
+	_ = os.Unsetenv(name)
+
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, err
+	}
+	f := os.NewFile(uintptr(fd), "aah-listener")
+	defer func() { _ = f.Close() }()
+	return net.FileListener(f)
+}
+
+func listenerFile(l net.Listener) (*os.File, error) {
+	lf, ok := l.(interface {
+		File() (*os.File, error)
+	})
+	if !ok {
+		return nil, errors.New("listener does not support file descriptor")
+	}
+	return lf.File()
+}
@@ -0,0 +1,141 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// +build !windows
+
+package aah
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerInheritedListener(t *testing.T) {
+	l, err := inheritedListener()
+	assert.Nil(t, err)
+	assert.Nil(t, l)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer ln.Close()
+
+	f, err := ln.(*net.TCPListener).File()
+	assert.Nil(t, err)
+
+	os.Setenv(envInheritListenerFD, strconv.Itoa(int(f.Fd())))
+	l, err = inheritedListener()
+	assert.Nil(t, err)
+	assert.NotNil(t, l)
+	assert.Equal(t, ln.Addr().String(), l.Addr().String())
+	assert.Equal(t, "", os.Getenv(envInheritListenerFD))
+	_ = l.Close()
+
+	os.Setenv(envInheritListenerFD, "notanumber")
+	_, err = inheritedListener()
+	assert.NotNil(t, err)
+}
+
+func TestServerInheritedUnixListener(t *testing.T) {
+	sockFile := filepath.Join(t.TempDir(), "aah.sock")
+	ln, err := net.Listen("unix", sockFile)
+	assert.Nil(t, err)
+	defer ln.Close()
+
+	f, err := ln.(*net.UnixListener).File()
+	assert.Nil(t, err)
+
+	a := newApp()
+	os.Setenv(envInheritListenerFD, strconv.Itoa(int(f.Fd())))
+	l, err := a.listen("unix", sockFile)
+	assert.Nil(t, err)
+	defer l.Close()
+	assert.Equal(t, sockFile, l.Addr().String())
+
+	// socket file is bound to inherited listener, it must not be removed
+	_, err = os.Stat(sockFile)
+	assert.Nil(t, err)
+	conn, err := net.Dial("unix", sockFile)
+	assert.Nil(t, err)
+	_ = conn.Close()
+}
+
+func TestServerInheritedRedirectListener(t *testing.T) {
+	l, err := inheritedRedirectListener()
+	assert.Nil(t, err)
+	assert.Nil(t, l)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer ln.Close()
+
+	f, err := listenerFile(ln)
+	assert.Nil(t, err)
+
+	os.Setenv(envInheritRedirectFD, strconv.Itoa(int(f.Fd())))
+	l, err = inheritedRedirectListener()
+	assert.Nil(t, err)
+	assert.NotNil(t, l)
+	assert.Equal(t, ln.Addr().String(), l.Addr().String())
+	assert.Equal(t, "", os.Getenv(envInheritRedirectFD))
+	_ = l.Close()
+}
+
+func TestServerInheritedNamedListeners(t *testing.T) {
+	ls, err := inheritedNamedListeners()
+	assert.Nil(t, err)
+	assert.Nil(t, ls)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer ln.Close()
+
+	f, err := listenerFile(ln)
+	assert.Nil(t, err)
+
+	os.Setenv(envInheritListenersFD, "admin="+strconv.Itoa(int(f.Fd())))
+	ls, err = inheritedNamedListeners()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(ls))
+	assert.Equal(t, ln.Addr().String(), ls["admin"].Addr().String())
+	assert.Equal(t, "", os.Getenv(envInheritListenersFD))
+	_ = ls["admin"].Close()
+
+	os.Setenv(envInheritListenersFD, "admin")
+	_, err = inheritedNamedListeners()
+	assert.Equal(t, "invalid inherited listener: admin", err.Error())
+}
+
+func TestServerRestartReadiness(t *testing.T) {
+	t.Log("Child process notifies once ready")
+	r, w, err := os.Pipe()
+	assert.Nil(t, err)
+	fd, err := syscall.Dup(int(w.Fd()))
+	assert.Nil(t, err)
+	_ = w.Close()
+	a := newApp()
+	a.setState(AppStateReady)
+	os.Setenv(envRestartReadyFD, strconv.Itoa(fd))
+	a.notifyRestartReady()
+	assert.Equal(t, "", os.Getenv(envRestartReadyFD))
+	assert.Nil(t, waitForReady(r, time.Second))
+
+	t.Log("Child process exits before ready")
+	r, w, err = os.Pipe()
+	assert.Nil(t, err)
+	_ = w.Close()
+	assert.Equal(t, "process exited before it is ready", waitForReady(r, time.Second).Error())
+
+	t.Log("Child process is not ready within timeout")
+	r, w, err = os.Pipe()
+	assert.Nil(t, err)
+	defer w.Close()
+	assert.NotNil(t, waitForReady(r, 50*time.Millisecond))
+}
@@ -0,0 +1,31 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package aah
+
+import "net"
+
+// listenForGracefulRestart method is not applicable to Windows OS, since it
+// does not support signal `SIGUSR2`.
+func (a *Application) listenForGracefulRestart() {
+	if a.settings.GracefulRestartEnabled {
+		a.Log().Warn("OS Windows does not support graceful restart (SIGUSR2)")
+	}
+}
+
+func (a *Application) notifyRestartReady() {}
+
+func inheritedListener() (net.Listener, error) {
+	return nil, nil
+}
+
+func inheritedRedirectListener() (net.Listener, error) {
+	return nil, nil
+}
+
+func inheritedNamedListeners() (map[string]net.Listener, error) {
+	return nil, nil
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"path"
 	"strings"
 
@@ -156,7 +157,9 @@ func (a *Application) initRouter() error {
 // handleRoute method handle route processing for the incoming request.
 // It does-
 //  - finding domain
+//  - cleaning request path
 //  - finding route
+//  - redirect fixed path
 //  - handling static route
 //  - handling redirect trailing slash
 //  - auto options
@@ -171,7 +174,15 @@ func handleRoute(ctx *Context) flowResult {
 	ctx.domain = ctx.a.Router().Lookup(ctx.Req.Host)
 	if ctx.domain == nil {
 		ctx.Log().Warnf("Domain not found, Host: %s, Path: %s", ctx.Req.Host, ctx.Req.Path)
-		ctx.Reply().NotFound().Error(newError(ErrDomainNotFound, http.StatusNotFound))
+		if ctx.a.Router().IsStrictHost() {
+			ctx.Reply().BadRequest().Error(newError(ErrDomainNotFound, http.StatusBadRequest))
+		} else {
+			ctx.Reply().NotFound().Error(newError(ErrDomainNotFound, http.StatusNotFound))
+		}
+		return flowAbort
+	}
+
+	if ctx.domain.CleanPath && handleCleanPath(ctx) == flowAbort {
 		return flowAbort
 	}
 
@@ -185,8 +196,26 @@ func handleRoute(ctx *Context) flowResult {
 		ctx.Reply().NotFound().Error(newError(ErrRouteNotFound, http.StatusNotFound))
 		return flowAbort
 	}
+
+	if ctx.domain.RedirectFixedPath && !ctx.domain.CaseSensitive &&
+		handleFixedPath(ctx, route) == flowAbort {
+		return flowAbort
+	}
+
+	// Selecting the API version of the route
+	if len(route.Versions()) > 0 {
+		version := requestAPIVersion(ctx)
+		if route = ctx.domain.RouteVersion(route, version); route == nil {
+			ctx.Log().Warnf("API version not supported, Version: %s, Host: %s, Path: %s", version, ctx.Req.Host, ctx.Req.Path)
+			ctx.Reply().NotAcceptable().Error(newError(ErrAPIVersionNotSupported, http.StatusNotAcceptable))
+			return flowAbort
+		}
+	}
+
 	ctx.route = route
 	ctx.Req.URLParams = urlParams
+	ctx.Req.SetMaxBodySize(route.MaxBodySize)
+	ctx.Req.SetMaxMultipartParts(ctx.a.settings.MultipartMaxParts)
 
 	// Serving static file
 	if ctx.route.IsStatic {
@@ -210,6 +239,74 @@ func handleRoute(ctx *Context) flowResult {
 	return flowCont
 }
 
+// requestAPIVersion method returns the API version requested by the client,
+// vendor type version or `version` parameter of `Accept` header takes
+// precedence over domain `api_version.header`.
+func requestAPIVersion(ctx *Context) string {
+	if v := ctx.Req.AcceptContentType().Version(); len(v) > 0 {
+		return v
+	}
+	if len(ctx.domain.APIVersionHeader) > 0 {
+		return strings.TrimSpace(ctx.Req.Header.Get(ctx.domain.APIVersionHeader))
+	}
+	return ""
+}
+
+// handleCleanPath method normalizes the request path, either redirects to
+// the canonical path or continues the routing with it.
+func handleCleanPath(ctx *Context) flowResult {
+	escapedPath := ctx.Req.URL().EscapedPath()
+	cleanPath := router.CleanPath(escapedPath)
+	if cleanPath == escapedPath {
+		return flowCont
+	}
+
+	p, err := url.PathUnescape(cleanPath)
+	if err != nil {
+		return flowCont
+	}
+
+	if ctx.domain.CleanPathRedirect {
+		redirectToPath(ctx, cleanPath)
+		ctx.Log().Debugf("CleanPath: %d, %s ==> %s", ctx.Reply().Code, escapedPath, ctx.Reply().path)
+		return flowAbort
+	}
+
+	ctx.Log().Debugf("CleanPath: %s ==> %s", escapedPath, cleanPath)
+	ctx.Req.URL().Path, ctx.Req.URL().RawPath = p, cleanPath
+	ctx.Req.Path = p
+	return flowCont
+}
+
+// handleFixedPath method redirects to the canonical path of the route if
+// request path case differs from it.
+func handleFixedPath(ctx *Context, route *router.Route) flowResult {
+	escapedPath := ctx.Req.URL().EscapedPath()
+	fixedPath := route.CanonicalPath(escapedPath)
+	if fixedPath == escapedPath {
+		return flowCont
+	}
+
+	redirectToPath(ctx, fixedPath)
+	ctx.Log().Debugf("RedirectFixedPath: %d, %s ==> %s", ctx.Reply().Code, escapedPath, ctx.Reply().path)
+	return flowAbort
+}
+
+// redirectToPath method redirects the request to given escaped path with
+// query string, status code is `301` for GET and HEAD otherwise `307`.
+func redirectToPath(ctx *Context, escapedPath string) {
+	code := http.StatusTemporaryRedirect
+	if ctx.Req.Method == ahttp.MethodGet || ctx.Req.Method == ahttp.MethodHead {
+		code = http.StatusMovedPermanently
+	}
+
+	// application mount point path prefix
+	u := *ctx.Req.URL()
+	u.RawPath = ctx.a.Router().BasePath() + escapedPath
+	u.Path, _ = url.PathUnescape(u.RawPath)
+	ctx.Reply().RedirectWithStatus(u.String(), code)
+}
+
 // handleRtsOptionsMna method handles
 // 1) Redirect Trailing Slash
 // 2) Auto Options
@@ -229,10 +326,12 @@ func handleRtsOptionsMna(ctx *Context, rts bool) error {
 				reply.TemporaryRedirect()
 			}
 
+			// application mount point path prefix
+			basePath := ctx.a.Router().BasePath()
 			if len(reqPath) > 1 && reqPath[len(reqPath)-1] == '/' {
-				ctx.Req.URL().Path = reqPath[:len(reqPath)-1]
+				ctx.Req.URL().Path = basePath + reqPath[:len(reqPath)-1]
 			} else {
-				ctx.Req.URL().Path = reqPath + "/"
+				ctx.Req.URL().Path = basePath + reqPath + "/"
 			}
 
 			reply.Redirect(ctx.Req.URL().String())
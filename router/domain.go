@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"sort"
 	"strings"
 
 	"aahframe.work/ahttp"
@@ -18,6 +19,27 @@ import (
 	"aahframe.work/security"
 )
 
+// Static route precedence over application routes, configured via domain
+// `static_precedence` in the routes.conf.
+//
+//   - `specific` static and application routes share the routing tree, most
+//     specific path wins, for e.g.: route `/assets/app.js` shadows static
+//     file of mount `/assets`, however static mount wins over `/*path`.
+//     Overlapping param and wildcard paths are rejected at startup.
+//   - `static` static mount is matched first, application routes are used
+//     only when no static route matches
+//   - `route` application routes are matched first, static mount is
+//     used only when no application route matches
+const (
+	StaticPrecedenceSpecific = "specific"
+	StaticPrecedenceStatic   = "static"
+	StaticPrecedenceRoute    = "route"
+)
+
+// APIVersionLatest value of domain `api_version.default` selects the highest
+// API version of the route when request does not specify the version.
+const APIVersionLatest = "latest"
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Domain
 //___________________________________
@@ -27,17 +49,31 @@ type Domain struct {
 	IsSubDomain           bool
 	MethodNotAllowed      bool
 	RedirectTrailingSlash bool
+	CleanPath             bool
+	CleanPathRedirect     bool
+	CaseSensitive         bool
+	RedirectFixedPath     bool
 	AutoOptions           bool
+	MethodOverride        bool
 	AntiCSRFEnabled       bool
 	CORSEnabled           bool
+	STSEnabled            bool
 	Key                   string
 	Name                  string
 	Host                  string
 	Port                  string
 	DefaultAuth           string
+	STS                   string
+	SSLCert               string
+	SSLKey                string
+	MethodOverrideField   string
+	StaticPrecedence      string
+	APIVersionHeader      string
+	APIVersionDefault     string
 	CORS                  *CORS
 	CatchAllRoute         *Route
 	trees                 map[string]*tree
+	staticTree            *tree
 	routes                map[string]*Route
 }
 
@@ -45,12 +81,24 @@ type Domain struct {
 // redirect trailing slash indicator for given `ahttp.Request` by domain
 // and request URI otherwise returns nil and false.
 func (d *Domain) Lookup(req *http.Request) (*Route, ahttp.URLParams, bool) {
-	// HTTP method override support
-	if req.Method == ahttp.MethodPost {
-		if h := req.Header[ahttp.HeaderXHTTPMethodOverride]; len(h) > 0 {
-			req.Method = h[0]
+	// HTTP method override support, applicable to POST only
+	if d.MethodOverride && req.Method == ahttp.MethodPost {
+		d.overrideMethod(req)
+	}
+
+	p := req.URL.EscapedPath()
+
+	// Static routes are looked up separately, refer to `StaticPrecedence`
+	static := d.staticTree != nil && req.Method == ahttp.MethodGet
+	var staticRts bool
+	if static && d.StaticPrecedence == StaticPrecedenceStatic {
+		route, urlParams, rts := d.staticTree.lookup(p)
+		if route != nil {
+			return route, urlParams, false
 		}
+		staticRts = rts
 	}
+	staticFallback := static && d.StaticPrecedence == StaticPrecedenceRoute
 
 	// get route tree for request method
 	tree, found := d.trees[req.Method]
@@ -61,12 +109,25 @@ func (d *Domain) Lookup(req *http.Request) (*Route, ahttp.URLParams, bool) {
 				tree, found = d.trees[h[0]]
 			}
 		}
-		if !found {
-			return nil, nil, false
+		if !found && !staticFallback {
+			return nil, nil, staticRts
 		}
 	}
 
-	route, urlParams, rts := tree.lookup(req.URL.EscapedPath())
+	var route *Route
+	var urlParams ahttp.URLParams
+	var rts bool
+	if found {
+		route, urlParams, rts = tree.lookup(p)
+	}
+
+	if route == nil && !rts {
+		if staticFallback {
+			route, urlParams, rts = d.staticTree.lookup(p)
+		} else {
+			rts = staticRts
+		}
+	}
 
 	// Catch All
 	if route == nil && !rts && d.CatchAllRoute != nil {
@@ -84,15 +145,43 @@ func (d *Domain) LookupByName(name string) *Route {
 	return nil
 }
 
+// RouteVersion method returns the API version of the route for the requested
+// version. Empty version resolves to domain `api_version.default`, value
+// `latest` means the highest version. It returns the given route as-is for
+// unversioned route and nil when requested version is not registered.
+func (d *Domain) RouteVersion(route *Route, version string) *Route {
+	versions := route.Versions()
+	if len(versions) == 0 {
+		return route
+	}
+	if len(version) == 0 {
+		version = d.APIVersionDefault
+	}
+	if len(version) == 0 || version == APIVersionLatest {
+		return versions[len(versions)-1]
+	}
+	for _, r := range versions {
+		if compareVersion(r.Version, version) == 0 {
+			return r
+		}
+	}
+	return nil
+}
+
 // AddRoute method adds the given route into domain routing tree.
 func (d *Domain) AddRoute(route *Route) error {
 	if ess.IsStrEmpty(route.Method) {
 		return errors.New("router: method value is empty")
 	}
 
-	t := d.trees[route.Method]
-	if t == nil {
-		t = &tree{root: new(node), tralingSlash: d.RedirectTrailingSlash}
+	var t *tree
+	if route.IsStatic && (d.StaticPrecedence == StaticPrecedenceStatic || d.StaticPrecedence == StaticPrecedenceRoute) {
+		if d.staticTree == nil {
+			d.staticTree = &tree{root: new(node), tralingSlash: d.RedirectTrailingSlash, caseSensitive: d.CaseSensitive}
+		}
+		t = d.staticTree
+	} else if t = d.trees[route.Method]; t == nil {
+		t = &tree{root: new(node), tralingSlash: d.RedirectTrailingSlash, caseSensitive: d.CaseSensitive}
 		d.trees[route.Method] = t
 	}
 
@@ -104,6 +193,28 @@ func (d *Domain) AddRoute(route *Route) error {
 	return nil
 }
 
+// Routes method returns all the routes registered in the domain including
+// static and catch all route, sorted by path and method.
+func (d *Domain) Routes() []*Route {
+	var routes []*Route
+	for _, t := range d.trees {
+		routes = t.root.collectRoutes(routes)
+	}
+	if d.staticTree != nil {
+		routes = d.staticTree.root.collectRoutes(routes)
+	}
+	if d.CatchAllRoute != nil {
+		routes = append(routes, d.CatchAllRoute)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path == routes[j].Path {
+			return routes[i].Method < routes[j].Method
+		}
+		return routes[i].Path < routes[j].Path
+	})
+	return routes
+}
+
 // Allowed method returns the value for header `Allow` otherwise empty string.
 func (d *Domain) Allowed(requestMethod, path string) (allowed string) {
 	if path == "*" { // server-wide
@@ -127,6 +238,13 @@ func (d *Domain) Allowed(requestMethod, path string) (allowed string) {
 		}
 	}
 
+	// static routes are served for GET only
+	if d.staticTree != nil && requestMethod != ahttp.MethodGet && !strings.Contains(allowed, ahttp.MethodGet) {
+		if value, _, _ := d.staticTree.lookup(path); value != nil {
+			allowed = suffixCommaValue(allowed, ahttp.MethodGet)
+		}
+	}
+
 	return
 }
 
@@ -173,6 +291,7 @@ func (d *Domain) RouteURLNamedArgs(routeName string, args map[string]interface{}
 
 		reverseURL = path.Join(reverseURL, segment)
 	}
+	reverseURL = canonicalTrailingSlash(route.Path, reverseURL)
 
 	// add remaining params into URL Query parameters, if any
 	if len(args) > 0 {
@@ -240,13 +359,24 @@ func (d *Domain) RouteURL(routeName string, args ...interface{}) string {
 		reverseURL = path.Join(reverseURL, segment)
 	}
 
-	return reverseURL
+	return canonicalTrailingSlash(route.Path, reverseURL)
 }
 
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Domain unexpoted methods
 //___________________________________
 
+// canonicalTrailingSlash method retains the trailing slash of route path in
+// the composed reverse URL, since `path.Join` removes it. So generated URL is
+// the canonical path of route and it does not cause redirect trailing slash.
+func canonicalTrailingSlash(routePath, reverseURL string) string {
+	if len(routePath) > 1 && routePath[len(routePath)-1] == '/' &&
+		reverseURL[len(reverseURL)-1] != '/' {
+		return reverseURL + "/"
+	}
+	return reverseURL
+}
+
 func (d *Domain) inferKey() {
 	if len(d.Port) == 0 {
 		d.Key = strings.ToLower(d.Host)
@@ -275,3 +405,25 @@ func (d *Domain) isAuthConfigured(secMgr *security.Manager) ([]string, bool) {
 
 	return names, len(names) == 0
 }
+
+// overrideMethod method overrides the POST request method by header
+// `X-HTTP-Method-Override` or form field `method_override.form_field`.
+// Only methods PUT, PATCH and DELETE are honored, others are ignored.
+func (d *Domain) overrideMethod(req *http.Request) {
+	var method string
+	if h := req.Header[ahttp.HeaderXHTTPMethodOverride]; len(h) > 0 {
+		method = h[0]
+	} else if len(d.MethodOverrideField) > 0 &&
+		strings.HasPrefix(req.Header.Get(ahttp.HeaderContentType), ahttp.ContentTypeForm.Mime) {
+		// form body is parsed here, prior to route max body size is known,
+		// `http.Request.ParseForm` limits it to 10MB
+		if err := req.ParseForm(); err == nil {
+			method = req.PostForm.Get(d.MethodOverrideField)
+		}
+	}
+
+	switch method = strings.ToUpper(strings.TrimSpace(method)); method {
+	case ahttp.MethodPut, ahttp.MethodPatch, ahttp.MethodDelete:
+		req.Method = method
+	}
+}
@@ -6,7 +6,12 @@ package router
 
 import (
 	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"aahframe.work/config"
 	"aahframe.work/security"
@@ -21,8 +26,14 @@ import (
 type Route struct {
 	IsAntiCSRFCheck bool
 	IsStatic        bool
+	IsSingleFlight  bool
+	IsWebhook       bool
+	IsAudit         bool
 	ListDir         bool
+	DefaultStatus   int
 	MaxBodySize     int64
+	CacheTTL        time.Duration
+	SlowThreshold   time.Duration
 	Name            string
 	Path            string
 	Method          string
@@ -32,10 +43,22 @@ type Route struct {
 	Auth            string
 	Dir             string
 	File            string
+	Summary         string
+	Description     string
+	Produces        string
+	CacheControl    string
+	Version         string
+	SPAFallback     string
+	Tags            []string
+	SPAExcludes     []string
+	Consumes        []string
+	SkipMiddlewares []string
 	CORS            *CORS
 	Constraints     map[string]string
+	Meta            map[string]string
 
 	authorizationInfo *authorizationInfo
+	versions          []*Route
 }
 
 // IsDir method returns true if serving directory otherwise false.
@@ -43,11 +66,63 @@ func (r *Route) IsDir() bool {
 	return len(r.Dir) > 0 && len(r.File) == 0
 }
 
+// IsSPAFallback method returns true if the static directory route serves
+// the `spa_fallback` file for given request path otherwise false. Paths
+// under `spa_exclude` and paths with file extension, for e.g.: missing
+// `/app.js`, are not served with fallback.
+func (r *Route) IsSPAFallback(p string) bool {
+	if len(r.SPAFallback) == 0 || len(path.Ext(p)) > 0 {
+		return false
+	}
+	for _, e := range r.SPAExcludes {
+		if p == e || strings.HasPrefix(p, strings.TrimSuffix(e, "/")+"/") {
+			return false
+		}
+	}
+	return true
+}
+
 // IsFile method returns true if serving single file otherwise false.
 func (r *Route) IsFile() bool {
 	return len(r.File) > 0
 }
 
+// IsMiddlewareSkipped method returns true if given middleware key is listed
+// in the route attribute `skip_middlewares` otherwise false.
+func (r *Route) IsMiddlewareSkipped(key string) bool {
+	for _, k := range r.SkipMiddlewares {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// CanonicalPath method returns the canonical form of given escaped request
+// path matched by the route. Static segments are lower case of route path
+// and path parameter values retain their original case.
+// For e.g.: route `/users/:userId`, request `/Users/JeevaM` => `/users/JeevaM`
+func (r *Route) CanonicalPath(reqPath string) string {
+	rsegs := strings.Split(r.Path, SlashString)
+	psegs := strings.Split(reqPath, SlashString)
+	if len(psegs) < len(rsegs) {
+		return reqPath
+	}
+	for i, seg := range rsegs {
+		if strings.IndexByte(seg, wildByte) >= 0 {
+			break
+		}
+		if len(seg) == 0 || strings.IndexByte(seg, paramByte) >= 0 {
+			continue
+		}
+		lseg := strings.ToLower(seg)
+		if v, err := url.PathUnescape(psegs[i]); err != nil || v != lseg {
+			psegs[i] = url.PathEscape(lseg)
+		}
+	}
+	return strings.Join(psegs, SlashString)
+}
+
 // HasAccess method does authorization check based on configured values at route
 // level.
 // TODO: the appropriate place for this method would be `security` package.
@@ -120,6 +195,19 @@ func (r *Route) HasAccess(subject *security.Subject) (bool, []*authz.Reason) {
 	return rolesResult && permissionResult, reasons
 }
 
+// Versions method returns all the API versions of the route, i.e. routes
+// registered for the same path and method with `version` attribute, sorted
+// by version in ascending order. It returns nil for unversioned route.
+func (r *Route) Versions() []*Route {
+	if len(r.Version) == 0 {
+		return nil
+	}
+	if len(r.versions) == 0 {
+		return []*Route{r}
+	}
+	return r.versions
+}
+
 // String method is Stringer interface.
 func (r *Route) String() string {
 	if r.IsStatic {
@@ -137,6 +225,62 @@ func (r *Route) String() string {
 // Unexported types and methods
 //______________________________________________________________________________
 
+func (r *Route) addVersion(vr *Route) error {
+	if len(r.Version) == 0 || len(vr.Version) == 0 {
+		return fmt.Errorf("same route path '%s' exists on both routes named '%s', '%s' for method '%s'",
+			vr.Path, vr.Name, r.Name, vr.Method)
+	}
+	if len(r.versions) == 0 {
+		r.versions = []*Route{r}
+	}
+	for _, v := range r.versions {
+		if compareVersion(v.Version, vr.Version) == 0 {
+			return fmt.Errorf("same route version '%s' exists on both routes named '%s', '%s' for method '%s' and path '%s'",
+				vr.Version, vr.Name, v.Name, vr.Method, vr.Path)
+		}
+	}
+	r.versions = append(r.versions, vr)
+	sort.SliceStable(r.versions, func(i, j int) bool {
+		return compareVersion(r.versions[i].Version, r.versions[j].Version) < 0
+	})
+	return nil
+}
+
+// compareVersion method compares the API versions segment-wise, numeric
+// segments are compared by its value, for e.g.: `1.10` is greater than `1.9`.
+// Optional `v` prefix is ignored.
+func compareVersion(a, b string) int {
+	as := strings.FieldsFunc(strings.TrimPrefix(strings.ToLower(a), "v"), isVersionSep)
+	bs := strings.FieldsFunc(strings.TrimPrefix(strings.ToLower(b), "v"), isVersionSep)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		ai, aerr := strconv.Atoi(as[i])
+		bi, berr := strconv.Atoi(bs[i])
+		if aerr == nil && berr == nil {
+			if ai != bi {
+				if ai < bi {
+					return -1
+				}
+				return 1
+			}
+			continue
+		}
+		if c := strings.Compare(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+func isVersionSep(r rune) bool {
+	return r == '.' || r == '-'
+}
+
 type parentRouteInfo struct {
 	AntiCSRFCheck     bool
 	CORSEnabled       bool
@@ -145,6 +289,12 @@ type parentRouteInfo struct {
 	Target            string
 	Auth              string
 	MaxBodySizeStr    string
+	Produces          string
+	Version           string
+	SlowThreshold     time.Duration
+	Consumes          []string
+	Tags              []string
+	SkipMiddlewares   []string
 	CORS              *CORS
 	AuthorizationInfo *authorizationInfo
 }
@@ -16,6 +16,7 @@ import (
 	"path"
 	"regexp"
 	"strings"
+	"time"
 
 	"aahframe.work/ahttp"
 	"aahframe.work/config"
@@ -107,11 +108,14 @@ func IsDefaultAction(action string) bool {
 type Router struct {
 	Domains []*Domain
 
-	configPath string
-	rootDomain *Domain
-	app        application
-	config     *config.Config
-	aCfg       *config.Config // kept for backward purpose, to be removed in subsequent release
+	configPath    string
+	basePath      string
+	strictHost    bool
+	rootDomain    *Domain
+	defaultDomain *Domain
+	app           application
+	config        *config.Config
+	aCfg          *config.Config // kept for backward purpose, to be removed in subsequent release
 }
 
 // Load method loads a configuration from given file e.g. `routes.conf` and
@@ -134,9 +138,10 @@ func (r *Router) Load() (err error) {
 	return
 }
 
-// Lookup method returns domain for given host otherwise nil.
+// Lookup method returns domain for given host otherwise default domain
+// if configured. In strict host mode, it returns nil for unknown host.
 func (r *Router) Lookup(host string) *Domain {
-	if len(r.Domains) == 1 {
+	if len(r.Domains) == 1 && !r.strictHost {
 		return r.Domains[0] // only one domain scenario
 	}
 
@@ -150,10 +155,21 @@ func (r *Router) Lookup(host string) *Domain {
 	// for e.g.: router.conf value is `*.sample.com:8080` it matches
 	// {subdomain}.sample.com
 	if idx := strings.IndexByte(host, '.'); idx > 0 {
-		return r.findDomain(wildcardSubdomainPrefix + host[idx+1:])
+		if domain := r.findDomain(wildcardSubdomainPrefix + host[idx+1:]); domain != nil {
+			return domain
+		}
 	}
 
-	return nil
+	if r.strictHost {
+		return nil
+	}
+	return r.defaultDomain
+}
+
+// IsStrictHost method returns true if routes.conf `strict_host` is enabled,
+// request with unknown host is rejected with `400 Bad Request`.
+func (r *Router) IsStrictHost() bool {
+	return r.strictHost
 }
 
 // RootDomain method returns the root domain registered in the routes.conf.
@@ -163,6 +179,12 @@ func (r *Router) RootDomain() *Domain {
 	return r.rootDomain
 }
 
+// BasePath method returns the path prefix of application mount point
+// from config `server.base_path`. For e.g.: /myapp
+func (r *Router) BasePath() string {
+	return r.basePath
+}
+
 // DomainAddresses method returns domain addresses (host:port) from
 // routes configuration.
 func (r *Router) DomainAddresses() []string {
@@ -173,6 +195,16 @@ func (r *Router) DomainAddresses() []string {
 	return addresses
 }
 
+// Routes method returns all the registered routes by domain key, it is
+// useful to build route documentation, for e.g.: OpenAPI spec.
+func (r *Router) Routes() map[string][]*Route {
+	routes := make(map[string][]*Route, len(r.Domains))
+	for _, d := range r.Domains {
+		routes[d.Key] = d.Routes()
+	}
+	return routes
+}
+
 // RegisteredActions method returns all the controller name and it's actions
 // configured in the "routes.conf".
 func (r *Router) RegisteredActions() map[string]map[string]uint8 {
@@ -267,6 +299,7 @@ func (r *Router) lookupRouteURLDomain(host, routeName string) (*Domain, string)
 }
 
 func (r *Router) composeRouteURL(d *Domain, host, routePath, anchor string) string {
+	routePath = r.basePath + routePath
 	switch {
 	case len(r.Domains) == 1 && d.Host == "localhost":
 		routePath = "//" + host + routePath
@@ -284,7 +317,10 @@ func (r *Router) composeRouteURL(d *Domain, host, routePath, anchor string) stri
 
 func inferHost(hosta, hostb string) string {
 	if strings.HasPrefix(hostb, "*.") {
-		return hosta[:strings.IndexByte(hosta, ':')]
+		if i := strings.IndexByte(hosta, ':'); i > 0 {
+			return hosta[:i]
+		}
+		return hosta
 	}
 	return hostb
 }
@@ -295,8 +331,17 @@ func (r *Router) processRoutesConfig() (err error) {
 		return ErrNoDomainRoutesConfigFound
 	}
 
+	// host handling, refer to `strict_host` and `default_domain`
+	r.strictHost = r.config.BoolDefault("strict_host", false)
+	defaultDomainKey := r.config.StringDefault("default_domain", "")
+
 	_ = r.config.SetProfile("domains")
 
+	// application mount point path prefix
+	if bp := strings.Trim(r.appConfig().StringDefault("server.base_path", ""), "/"); len(bp) > 0 {
+		r.basePath = path.Clean("/" + bp)
+	}
+
 	// allocate for no. of domains
 	r.Domains = make([]*Domain, len(domains))
 	r.app.Log().Debugf("Domain count: %d", len(domains))
@@ -328,20 +373,44 @@ func (r *Router) processRoutesConfig() (err error) {
 			IsSubDomain:           domainCfg.BoolDefault("subdomain", false),
 			MethodNotAllowed:      domainCfg.BoolDefault("method_not_allowed", true),
 			RedirectTrailingSlash: domainCfg.BoolDefault("redirect_trailing_slash", true),
+			CleanPath:             domainCfg.BoolDefault("clean_path.enable", false),
+			CleanPathRedirect:     domainCfg.BoolDefault("clean_path.redirect", true),
+			CaseSensitive:         !domainCfg.BoolDefault("case_insensitive.enable", false),
+			RedirectFixedPath:     domainCfg.BoolDefault("case_insensitive.redirect", false),
 			AutoOptions:           domainCfg.BoolDefault("auto_options", true),
+			MethodOverride:        domainCfg.BoolDefault("method_override.enable", true),
+			MethodOverrideField:   strings.TrimSpace(domainCfg.StringDefault("method_override.form_field", "")),
+			StaticPrecedence:      domainCfg.StringDefault("static_precedence", StaticPrecedenceSpecific),
+			APIVersionHeader:      strings.TrimSpace(domainCfg.StringDefault("api_version.header", "X-API-Version")),
+			APIVersionDefault:     strings.TrimSpace(domainCfg.StringDefault("api_version.default", APIVersionLatest)),
 			DefaultAuth:           domainCfg.StringDefault("default_auth", ""),
 			AntiCSRFEnabled:       domainCfg.BoolDefault("anti_csrf_check", true),
 			CORSEnabled:           domainCfg.BoolDefault("cors.enable", false),
+			STSEnabled:            domainCfg.BoolDefault("sts.enable", true),
+			SSLCert:               domainCfg.StringDefault("ssl.cert", ""),
+			SSLKey:                domainCfg.StringDefault("ssl.key", ""),
 			trees:                 make(map[string]*tree),
 			routes:                make(map[string]*Route),
 		}
 
+		switch domain.StaticPrecedence {
+		case StaticPrecedenceSpecific, StaticPrecedenceStatic, StaticPrecedenceRoute:
+		default:
+			err = fmt.Errorf("'%v.static_precedence' unsupported value: %s", key, domain.StaticPrecedence)
+			return
+		}
+
 		// Domain Level CORS configuration
 		if domain.CORSEnabled {
 			baseCORSCfg, _ := domainCfg.GetSubConfig("cors")
 			domain.CORS = processBaseCORSSection(baseCORSCfg)
 		}
 
+		// Domain Level HSTS header value
+		if domain.STSEnabled {
+			domain.STS = r.processDomainSTS(domainCfg)
+		}
+
 		// Catch All route
 		if domainCfg.IsExists("catch_all") {
 			catchAllRoute := &Route{
@@ -411,8 +480,20 @@ func (r *Router) processRoutesConfig() (err error) {
 		for _, t := range domain.trees {
 			t.root.inferwnode()
 		}
+		if domain.staticTree != nil {
+			domain.staticTree.root.inferwnode()
+		}
+
+		if key == defaultDomainKey {
+			r.defaultDomain = domain
+		}
 	} // End of domains
 
+	if len(defaultDomainKey) > 0 && r.defaultDomain == nil {
+		err = fmt.Errorf("'default_domain' value '%s' is not found in 'domains'", defaultDomainKey)
+		return
+	}
+
 	// find out root domain
 	// Note: Assuming of one domain and multiple sub-domains configured
 	// otherwise it will have first non-subdomain reference.
@@ -427,6 +508,34 @@ func (r *Router) processRoutesConfig() (err error) {
 	return
 }
 
+// processDomainSTS method returns the domain level HSTS header value if any of
+// `sts.max_age`, `sts.include_subdomains` or `sts.preload` is configured,
+// unspecified ones fallback to `security.http_header.sts.*` from aah.conf.
+// Otherwise empty string, it means application level value is used.
+func (r *Router) processDomainSTS(domainCfg *config.Config) string {
+	if !domainCfg.IsExists("sts.max_age") && !domainCfg.IsExists("sts.include_subdomains") &&
+		!domainCfg.IsExists("sts.preload") {
+		return ""
+	}
+
+	keyPrefix := "security.http_header.sts."
+	maxAge, err := time.ParseDuration(domainCfg.StringDefault("sts.max_age",
+		r.appConfig().StringDefault(keyPrefix+"max_age", "720h")))
+	if err != nil {
+		r.app.Log().Warn("'sts.max_age' value is not a valid time unit, fallback to 30 days")
+		maxAge = 720 * time.Hour
+	}
+
+	sts := fmt.Sprintf("max-age=%d", int64(maxAge.Seconds()))
+	if domainCfg.BoolDefault("sts.include_subdomains", r.appConfig().BoolDefault(keyPrefix+"include_subdomains", false)) {
+		sts += "; includeSubDomains"
+	}
+	if domainCfg.BoolDefault("sts.preload", r.appConfig().BoolDefault(keyPrefix+"preload", false)) {
+		sts += "; preload"
+	}
+	return sts
+}
+
 func (r *Router) processStaticRoutes(domain *Domain, domainCfg *config.Config) error {
 	staticCfg, found := domainCfg.GetSubConfig("static")
 	if !found {
@@ -580,7 +689,8 @@ func parseSectionRoutes(cfg *config.Config, routeInfo *parentRouteInfo) (routes
 		routeAuth := strings.TrimSpace(cfg.StringDefault(routeName+".auth", routeInfo.Auth))
 
 		// getting route max body size, GitHub go-aah/aah#83
-		routeMaxBodySize, er := ess.StrToBytes(cfg.StringDefault(routeName+".max_body_size", routeInfo.MaxBodySizeStr))
+		routeMaxBodySizeStr := cfg.StringDefault(routeName+".max_body_size", routeInfo.MaxBodySizeStr)
+		routeMaxBodySize, er := ess.StrToBytes(routeMaxBodySizeStr)
 		if er != nil {
 			log.Warnf("'%v.max_body_size' value is not a valid size unit, fallback to global limit", routeName)
 		}
@@ -588,6 +698,76 @@ func parseSectionRoutes(cfg *config.Config, routeInfo *parentRouteInfo) (routes
 			routeMaxBodySize = 0
 		}
 
+		// getting route response cache TTL, applicable to GET and HEAD only
+		var routeCacheTTL time.Duration
+		if ttl, found := cfg.String(routeName + ".cache_ttl"); found {
+			if routeCacheTTL, er = time.ParseDuration(ttl); er != nil {
+				err = fmt.Errorf("'%v.cache_ttl' value is not a valid time unit", routeName)
+				return
+			}
+		}
+
+		// getting route slow request threshold, overrides the
+		// `server.slow_request.threshold` from aah.conf
+		routeSlowThreshold := routeInfo.SlowThreshold
+		if st, found := cfg.String(routeName + ".slow_threshold"); found {
+			if routeSlowThreshold, er = time.ParseDuration(st); er != nil {
+				err = fmt.Errorf("'%v.slow_threshold' value is not a valid time unit", routeName)
+				return
+			}
+		}
+
+		// getting route single flight value, applicable to GET and HEAD only
+		// since non-idempotent requests must not be coalesced
+		routeSingleFlight := cfg.BoolDefault(routeName+".singleflight", false)
+
+		// getting webhook value, request signature is verified by
+		// `aah.SignatureMiddleware`
+		routeWebhook := cfg.BoolDefault(routeName+".webhook", false)
+
+		// getting audit value, request and response body is logged with
+		// redaction by `aah.AuditMiddleware`
+		routeAudit := cfg.BoolDefault(routeName+".audit", false)
+
+		// getting route default response status, applied when the action
+		// does not set the status explicitly
+		routeDefaultStatus := cfg.IntDefault(routeName+".default_status", 0)
+		if routeDefaultStatus != 0 && (routeDefaultStatus < 100 || routeDefaultStatus > 599) {
+			err = fmt.Errorf("'%v.default_status' unsupported value: %d", routeName, routeDefaultStatus)
+			return
+		}
+
+		// getting route documentation attributes, aah does not interpret
+		// these values, exposed via `Router.Routes()`
+		routeTags, found := cfg.StringList(routeName + ".tags")
+		if !found {
+			routeTags = routeInfo.Tags
+		}
+		routeMeta := parseRouteMeta(cfg, routeName)
+
+		// getting route content types, request `Content-Type` is enforced by
+		// `aah.BindMiddleware` and default response content type
+		routeConsumes := routeInfo.Consumes
+		if cfg.IsExists(routeName + ".consumes") {
+			consumes, found := cfg.StringList(routeName + ".consumes")
+			if !found {
+				consumes = []string{cfg.StringDefault(routeName+".consumes", "")}
+			}
+			routeConsumes = parseContentTypes(strings.Join(consumes, ","))
+		}
+		routeProduces := strings.ToLower(strings.TrimSpace(cfg.StringDefault(routeName+".produces", routeInfo.Produces)))
+
+		// getting route API version, child route inherits the parent value
+		// unless it is defined
+		routeVersion := strings.TrimSpace(cfg.StringDefault(routeName+".version", routeInfo.Version))
+
+		// getting middlewares to be skipped for the route, child route
+		// inherits the parent value unless it is defined
+		routeSkipMiddlewares := routeInfo.SkipMiddlewares
+		if skips, found := cfg.StringList(routeName + ".skip_middlewares"); found {
+			routeSkipMiddlewares = parseMiddlewareKeys(skips)
+		}
+
 		// getting Anti-CSRF check value, GitHub go-aah/aah#115
 		routeAntiCSRFCheck := cfg.BoolDefault(routeName+".anti_csrf_check", routeInfo.AntiCSRFCheck)
 
@@ -612,27 +792,56 @@ func parseSectionRoutes(cfg *config.Config, routeInfo *parentRouteInfo) (routes
 			}
 		}
 
-		// 'anti_csrf_check', 'cors' and 'max_body_size' not applicable for WebSocket
+		// 'anti_csrf_check', 'cors', 'max_body_size', 'webhook', 'audit',
+		// 'default_status', 'consumes' and 'produces' not applicable for WebSocket
 		if routeMethod == methodWebSocket {
 			routeAntiCSRFCheck = false
+			routeWebhook = false
+			routeAudit = false
+			routeDefaultStatus = 0
+			routeConsumes = nil
+			routeProduces = ""
 			cors = nil
 			routeMaxBodySize = 0
 		}
 
 		if notToSkip {
 			for _, m := range strings.Split(routeMethod, ",") {
+				m = strings.TrimSpace(m)
+
+				// cache TTL and single flight are checked per HTTP method
+				// for multiple HTTP methods mapping, e.g.: `GET,HEAD,POST`
+				cacheTTL, singleFlight := routeCacheTTL, routeSingleFlight
+				if m != ahttp.MethodGet && m != ahttp.MethodHead {
+					cacheTTL, singleFlight = 0, false
+				}
+
 				routes = append(routes, &Route{
 					Name:              routeName,
 					Path:              actualRoutePath,
-					Method:            strings.TrimSpace(m),
+					Method:            m,
 					Target:            routeTarget,
 					Action:            routeAction,
 					ParentName:        routeInfo.ParentName,
 					Auth:              routeAuth,
 					MaxBodySize:       routeMaxBodySize,
+					DefaultStatus:     routeDefaultStatus,
+					CacheTTL:          cacheTTL,
+					SlowThreshold:     routeSlowThreshold,
+					IsSingleFlight:    singleFlight,
+					IsWebhook:         routeWebhook,
+					IsAudit:           routeAudit,
 					IsAntiCSRFCheck:   routeAntiCSRFCheck,
 					CORS:              cors,
 					Constraints:       routeConstraints,
+					Summary:           cfg.StringDefault(routeName+".summary", ""),
+					Description:       cfg.StringDefault(routeName+".description", ""),
+					Tags:              routeTags,
+					Consumes:          routeConsumes,
+					Produces:          routeProduces,
+					Version:           routeVersion,
+					SkipMiddlewares:   routeSkipMiddlewares,
+					Meta:              routeMeta,
 					authorizationInfo: routeAuthorizationInfo,
 				})
 			}
@@ -645,7 +854,13 @@ func parseSectionRoutes(cfg *config.Config, routeInfo *parentRouteInfo) (routes
 				PrefixPath:        routePath,
 				Target:            routeTarget,
 				Auth:              routeAuth,
-				MaxBodySizeStr:    routeInfo.MaxBodySizeStr,
+				MaxBodySizeStr:    routeMaxBodySizeStr,
+				Produces:          routeProduces,
+				Version:           routeVersion,
+				SlowThreshold:     routeSlowThreshold,
+				Consumes:          routeConsumes,
+				Tags:              routeTags,
+				SkipMiddlewares:   routeSkipMiddlewares,
 				AntiCSRFCheck:     routeAntiCSRFCheck,
 				CORS:              cors,
 				CORSEnabled:       routeInfo.CORSEnabled,
@@ -663,6 +878,59 @@ func parseSectionRoutes(cfg *config.Config, routeInfo *parentRouteInfo) (routes
 	return
 }
 
+func parseContentTypes(v string) []string {
+	var types []string
+	for _, ct := range strings.Split(v, ",") {
+		if ct = strings.ToLower(strings.TrimSpace(ct)); len(ct) > 0 {
+			types = append(types, ct)
+		}
+	}
+	return types
+}
+
+func parseMiddlewareKeys(values []string) []string {
+	var keys []string
+	for _, v := range values {
+		if v = strings.ToLower(strings.TrimSpace(v)); len(v) > 0 {
+			keys = append(keys, v)
+		}
+	}
+	return keys
+}
+
+func parseRouteMeta(cfg *config.Config, routeName string) map[string]string {
+	metaCfg, found := cfg.GetSubConfig(routeName + ".meta")
+	if !found {
+		return nil
+	}
+	meta := make(map[string]string)
+	for _, k := range metaCfg.Keys() {
+		meta[k] = metaCfg.StringDefault(k, "")
+	}
+	return meta
+}
+
+// parseStaticCacheControl method composes the `Cache-Control` header value from
+// static route `cache.*` config, for e.g.: `public, max-age=31536000, immutable`.
+func parseStaticCacheControl(cfg *config.Config, routeName string) (string, error) {
+	keyPrefix := routeName + ".cache."
+	scope := strings.ToLower(cfg.StringDefault(keyPrefix+"scope", "public"))
+	if scope != "public" && scope != "private" {
+		return "", fmt.Errorf("'static.%vscope' unsupported value: %s", keyPrefix, scope)
+	}
+
+	maxAge, err := time.ParseDuration(cfg.StringDefault(keyPrefix+"max_age", "0s"))
+	if err != nil || maxAge < 0 {
+		return "", fmt.Errorf("'static.%vmax_age' value is not a valid time unit", keyPrefix)
+	}
+
+	cc := fmt.Sprintf("%s, max-age=%d", scope, int64(maxAge.Seconds()))
+	if cfg.BoolDefault(keyPrefix+"immutable", false) {
+		cc += ", immutable"
+	}
+	return cc, nil
+}
+
 func parseStaticSection(cfg *config.Config) (routes []*Route, err error) {
 	for _, routeName := range cfg.Keys() {
 		route := &Route{Name: routeName, Method: ahttp.MethodGet, IsStatic: true}
@@ -724,8 +992,29 @@ func parseStaticSection(cfg *config.Config) (routes []*Route, err error) {
 		route.File = routeFile
 		route.ListDir = cfg.BoolDefault(routeName+".list", false)
 
-		// add route if directory found and list dir is enabled
-		if route.ListDir && dirFound {
+		// single-page application fallback file of directory, relative to
+		// the directory
+		if spaFallback, found := cfg.String(routeName + ".spa_fallback"); found {
+			if !dirFound {
+				err = fmt.Errorf("'static.%v.spa_fallback' is applicable only to 'static.%v.dir'", routeName, routeName)
+				return
+			}
+			route.SPAFallback = spaFallback
+			excludes, _ := cfg.StringList(routeName + ".spa_exclude")
+			for _, e := range excludes {
+				route.SPAExcludes = append(route.SPAExcludes, path.Clean(e))
+			}
+		}
+
+		// static route level `Cache-Control`
+		if cfg.IsExists(routeName + ".cache") {
+			if route.CacheControl, err = parseStaticCacheControl(cfg, routeName); err != nil {
+				return
+			}
+		}
+
+		// add route if directory found and list dir or SPA fallback is enabled
+		if (route.ListDir || len(route.SPAFallback) > 0) && dirFound {
 			rt := *route
 			rt.Path = path.Clean(routePath) + "/"
 			routes = append(routes, &rt)
@@ -14,6 +14,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"aahframe.work/ahttp"
 	"aahframe.work/config"
@@ -133,6 +134,37 @@ func TestRouterWildcardSubdomain(t *testing.T) {
 	assert.Equal(t, "//sample.localhost:8080/", result)
 }
 
+func TestRouterHostHandling(t *testing.T) {
+	router, err := createRouter("routes.conf")
+	assert.Nil(t, err)
+	assert.False(t, router.IsStrictHost())
+	assert.Nil(t, router.Lookup("unknown.com:8080"))
+
+	t.Log("Default domain")
+	assert.Nil(t, router.config.Merge(testRoutesConfig(`default_domain = "localhost"`)))
+	assert.Nil(t, router.processRoutesConfig())
+	assert.Equal(t, "localhost", router.Lookup("unknown.com:8080").Host)
+	assert.Equal(t, "localhost", router.Lookup("").Host)
+	assert.Equal(t, "*.localhost", router.Lookup("username1.localhost:8080").Host)
+
+	t.Log("Strict host")
+	assert.Nil(t, router.config.Merge(testRoutesConfig(`strict_host = true`)))
+	assert.Nil(t, router.processRoutesConfig())
+	assert.True(t, router.IsStrictHost())
+	assert.Nil(t, router.Lookup("unknown.com:8080"))
+	assert.Equal(t, "localhost", router.Lookup("localhost:8080").Host)
+
+	router.Domains = router.Domains[:1]
+	assert.Nil(t, router.Lookup("unknown.com:8080"))
+	assert.Equal(t, "localhost", router.Lookup("localhost:8080").Host)
+
+	t.Log("Default domain not exists")
+	router, _ = createRouter("routes.conf")
+	assert.Nil(t, router.config.Merge(testRoutesConfig(`default_domain = "notexists"`)))
+	err = router.processRoutesConfig()
+	assert.Equal(t, "'default_domain' value 'notexists' is not found in 'domains'", err.Error())
+}
+
 func TestRouterStaticLoadConfiguration(t *testing.T) {
 	router, err := createRouter("routes.conf")
 	assert.Nil(t, err, "")
@@ -151,6 +183,7 @@ func TestRouterStaticLoadConfiguration(t *testing.T) {
 	assert.Equal(t, "", route.Dir)
 	assert.False(t, route.IsDir())
 	assert.True(t, route.IsFile())
+	assert.Equal(t, "", route.CacheControl)
 
 	// /static/img/aahframework.png
 	req2 := createHTTPRequest("localhost:8080", "/static/img/aahframework.png")
@@ -165,6 +198,51 @@ func TestRouterStaticLoadConfiguration(t *testing.T) {
 	assert.Equal(t, "", route.File)
 	assert.True(t, route.IsDir())
 	assert.False(t, route.IsFile())
+	assert.Equal(t, "public, max-age=31536000, immutable", route.CacheControl)
+
+	// static route cache config
+	cfg, _ := config.ParseString(`assets {
+		cache {
+			max_age = "1h"
+			scope = "private"
+		}
+	}`)
+	cc, err := parseStaticCacheControl(cfg, "assets")
+	assert.Nil(t, err)
+	assert.Equal(t, "private, max-age=3600", cc)
+
+	cfg.SetString("assets.cache.scope", "shared")
+	_, err = parseStaticCacheControl(cfg, "assets")
+	assert.Equal(t, "'static.assets.cache.scope' unsupported value: shared", err.Error())
+
+	cfg.SetString("assets.cache.scope", "public")
+	cfg.SetString("assets.cache.max_age", "1year")
+	_, err = parseStaticCacheControl(cfg, "assets")
+	assert.Equal(t, "'static.assets.cache.max_age' value is not a valid time unit", err.Error())
+
+	// static route SPA fallback
+	cfg, _ = config.ParseString(`spa {
+		path = "/app"
+		dir = "spa"
+		spa_fallback = "index.html"
+		spa_exclude = ["/app/api/"]
+	}`)
+	routes, err := parseStaticSection(cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(routes))
+	assert.Equal(t, "/app/", routes[0].Path)
+	assert.Equal(t, "/app/*filepath", routes[1].Path)
+	assert.Equal(t, "index.html", routes[1].SPAFallback)
+	assert.Equal(t, []string{"/app/api"}, routes[1].SPAExcludes)
+
+	cfg, _ = config.ParseString(`spa {
+		path = "/index.html"
+		file = "index.html"
+		base_dir = "spa"
+		spa_fallback = "index.html"
+	}`)
+	_, err = parseStaticSection(cfg)
+	assert.Equal(t, "'static.spa.spa_fallback' is applicable only to 'static.spa.dir'", err.Error())
 
 	// static
 	staticDirReq := createHTTPRequest("localhost:8080", "/static")
@@ -183,6 +261,51 @@ func TestRouterStaticLoadConfiguration(t *testing.T) {
 	assert.Nil(t, params)
 }
 
+func TestRouterRoutes(t *testing.T) {
+	router, err := createRouter("routes.conf")
+	assert.Nil(t, err)
+
+	domainRoutes := router.Routes()
+	assert.Equal(t, len(router.Domains), len(domainRoutes))
+
+	routes := domainRoutes["localhost:8080"]
+	assert.True(t, len(routes) > 0)
+	for i := 1; i < len(routes); i++ {
+		assert.True(t, routes[i-1].Path <= routes[i].Path)
+	}
+
+	var showHotels, bookHotels, favicon *Route
+	var bookingMethods []string
+	for _, r := range routes {
+		switch r.Name {
+		case "show_hotels":
+			showHotels = r
+		case "book_hotels":
+			bookHotels = r
+		case "favicon":
+			favicon = r
+		}
+		if r.Path == "/hotels/:id/booking" {
+			bookingMethods = append(bookingMethods, r.Method)
+		}
+	}
+
+	assert.NotNil(t, showHotels)
+	assert.Equal(t, "Show hotel", showHotels.Summary)
+	assert.Equal(t, "Returns the hotel details by given id", showHotels.Description)
+	assert.Equal(t, []string{"hotels", "public"}, showHotels.Tags)
+	assert.Equal(t, []string{"metrics", "authcauthz"}, showHotels.SkipMiddlewares)
+	assert.True(t, showHotels.IsMiddlewareSkipped("authcauthz"))
+	assert.NotNil(t, bookHotels)
+	assert.Equal(t, []string{"metrics"}, bookHotels.SkipMiddlewares)
+	assert.False(t, bookHotels.IsMiddlewareSkipped("authcauthz"))
+	assert.Equal(t, map[string]string{"operation_id": "showHotel"}, showHotels.Meta)
+
+	assert.NotNil(t, favicon)
+	assert.True(t, favicon.IsStatic)
+	assert.Equal(t, []string{"GET", "POST"}, bookingMethods)
+}
+
 func TestRouterErrorLoadConfiguration(t *testing.T) {
 	router, err := createRouter("routes-error.conf")
 	assert.NotNilf(t, err, "expected error loading '%v'", "routes-error.conf")
@@ -197,6 +320,13 @@ func TestRouterErrorHostLoadConfiguration(t *testing.T) {
 	assert.Equal(t, "'localhost.host' key is missing", err.Error())
 }
 
+func TestRouterErrorStaticPrecedenceLoadConfiguration(t *testing.T) {
+	router, err := createRouter("routes-static-precedence-error.conf")
+	assert.NotNilf(t, err, "expected error loading '%v'", "routes-static-precedence-error.conf")
+	assert.Nil(t, router)
+	assert.Equal(t, "'localhost.static_precedence' unsupported value: first", err.Error())
+}
+
 func TestRouterErrorPathLoadConfiguration(t *testing.T) {
 	router, err := createRouter("routes-path-error.conf")
 	assert.NotNilf(t, err, "expected error loading '%v'", "routes-path-error.conf")
@@ -259,6 +389,22 @@ func TestRouterDomainConfig(t *testing.T) {
 	domain := router.Lookup("localhost:8080")
 	assert.NotNil(t, domain)
 
+	assert.True(t, domain.STSEnabled)
+	assert.Equal(t, "max-age=31536000; preload", domain.STS)
+	assert.True(t, domain.CaseSensitive)
+	assert.False(t, domain.RedirectFixedPath)
+	route, _, _ := domain.Lookup(&http.Request{Method: ahttp.MethodGet, URL: &url.URL{Path: "/Hotels"}})
+	assert.Nil(t, route)
+	route, _, _ = domain.Lookup(&http.Request{Method: ahttp.MethodGet, URL: &url.URL{Path: "/hotels"}})
+	assert.NotNil(t, route)
+	assert.Equal(t, "/etc/ssl/localhost.crt", domain.SSLCert)
+	assert.Equal(t, "/etc/ssl/localhost.key", domain.SSLKey)
+
+	subdomain := router.Lookup("username1.localhost:8080")
+	assert.False(t, subdomain.STSEnabled)
+	assert.Equal(t, "", subdomain.STS)
+	assert.Equal(t, "", subdomain.SSLCert)
+
 	domain = router.Lookup("www.aahframework.org")
 	assert.Nil(t, domain)
 }
@@ -372,8 +518,27 @@ func TestRouterDomainRouteURL(t *testing.T) {
 	bookingURL = domain.RouteURL("book_hotels", 12345678, "param1value", "param2value")
 	assert.Equal(t, "", bookingURL)
 
+	// trailing slash of route path is retained
+	err = domain.AddRoute(&Route{
+		Name:   "user_projects",
+		Path:   "/users/:user/projects/",
+		Method: "GET",
+		Target: "User",
+		Action: "Projects",
+	})
+	assert.Nil(t, err)
+	projectsURL := domain.RouteURLNamedArgs("user_projects", map[string]interface{}{
+		"user": "jeeva",
+		"page": 2,
+	})
+	assert.Equal(t, "/users/jeeva/projects/?page=2", projectsURL)
+	projectsURL = domain.RouteURL("user_projects", "jeeva")
+	assert.Equal(t, "/users/jeeva/projects/", projectsURL)
+	result := router.CreateRouteURL("localhost:8080", "user_projects", nil, "jeeva")
+	assert.Equal(t, "//localhost:8080/users/jeeva/projects/", result)
+
 	// Route URLs
-	result := router.CreateRouteURL("localhost:8080", "host", nil)
+	result = router.CreateRouteURL("localhost:8080", "host", nil)
 	assert.Equal(t, "//localhost:8080", result)
 
 	result = router.CreateRouteURL("localhost:8080", "app_index#welcome", nil)
@@ -388,6 +553,16 @@ func TestRouterDomainRouteURL(t *testing.T) {
 
 	result = router.CreateRouteURL("localhost:8080", "book_hotels", nil, 12345678)
 	assert.Equal(t, "//localhost:8080/hotels/12345678/booking", result)
+
+	// Route URLs with base path
+	router.basePath = "/myapp"
+	assert.Equal(t, "/myapp", router.BasePath())
+	result = router.CreateRouteURL("localhost:8080", "host", nil)
+	assert.Equal(t, "//localhost:8080/myapp", result)
+
+	result = router.CreateRouteURL("localhost:8080", "book_hotels", nil, 12345678)
+	assert.Equal(t, "//localhost:8080/myapp/hotels/12345678/booking", result)
+	router.basePath = ""
 }
 
 func TestRouterDomainAddRoute(t *testing.T) {
@@ -627,6 +802,397 @@ func TestRoutePathConstraints(t *testing.T) {
 	}
 }
 
+func TestRouteCacheTTL(t *testing.T) {
+	cfg, err := config.ParseString(`
+	products {
+		path = "/products"
+		controller = "ProductController"
+		cache_ttl = "5m"
+	}
+	create_product {
+		path = "/products"
+		method = "POST"
+		controller = "ProductController"
+		cache_ttl = "5m"
+	}
+	product_info {
+		path = "/products/info"
+		method = "GET, HEAD, POST"
+		controller = "ProductController"
+		action = "Info"
+		cache_ttl = "5m"
+	}`)
+	assert.Nil(t, err)
+	routes, err := parseSectionRoutes(cfg, &parentRouteInfo{AuthorizationInfo: &authorizationInfo{Satisfy: "either"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(routes))
+	for _, r := range routes {
+		if r.Method == ahttp.MethodGet || r.Method == ahttp.MethodHead {
+			assert.Equal(t, 5*time.Minute, r.CacheTTL)
+		} else {
+			assert.Equal(t, time.Duration(0), r.CacheTTL)
+		}
+	}
+
+	cfg, _ = config.ParseString(`
+	products {
+		path = "/products"
+		controller = "ProductController"
+		cache_ttl = "5 minutes"
+	}`)
+	_, err = parseSectionRoutes(cfg, &parentRouteInfo{AuthorizationInfo: &authorizationInfo{Satisfy: "either"}})
+	assert.Equal(t, "'products.cache_ttl' value is not a valid time unit", err.Error())
+}
+
+func TestRouteGroup(t *testing.T) {
+	cfg, err := config.ParseString(`
+	api_v1 {
+		path = "/api/v1"
+		auth = "jwt_auth"
+		max_body_size = "1mb"
+		consumes = "application/json"
+		produces = "application/json"
+		slow_threshold = "250ms"
+		tags = ["v1"]
+		skip_middlewares = ["aah.AuditMiddleware"]
+		routes {
+			list_users {
+				path = "/users"
+				controller = "api/v1/UserController"
+				action = "List"
+				routes {
+					create_user {
+						path = "/"
+						method = "POST"
+					}
+				}
+			}
+			admin {
+				path = "/admin"
+				auth = "form_auth"
+				routes {
+					export_users {
+						path = "/export"
+						controller = "api/v1/AdminController"
+						action = "Export"
+						produces = "text/csv"
+						tags = ["v1", "admin"]
+					}
+				}
+			}
+			health {
+				path = "^/healthz"
+				controller = "HealthController"
+			}
+		}
+	}`)
+	assert.Nil(t, err)
+	routes, err := parseSectionRoutes(cfg, &parentRouteInfo{
+		MaxBodySizeStr:    "5mb",
+		AuthorizationInfo: &authorizationInfo{Satisfy: "either"},
+	})
+	assert.Nil(t, err)
+
+	d := &Domain{trees: make(map[string]*tree), routes: make(map[string]*Route)}
+	for _, r := range routes {
+		assert.Nil(t, d.AddRoute(r))
+	}
+	assert.Nil(t, d.LookupByName("api_v1"), "group without controller is not a route")
+	assert.Nil(t, d.LookupByName("admin"))
+
+	r := d.LookupByName("create_user")
+	assert.Equal(t, "/api/v1/users", r.Path)
+	assert.Equal(t, "api/v1/UserController", r.Target)
+	assert.Equal(t, "Create", r.Action)
+	assert.Equal(t, "jwt_auth", r.Auth)
+	assert.Equal(t, int64(1<<20), r.MaxBodySize)
+	assert.Equal(t, []string{"application/json"}, r.Consumes)
+	assert.Equal(t, "application/json", r.Produces)
+	assert.Equal(t, 250*time.Millisecond, r.SlowThreshold)
+	assert.Equal(t, []string{"v1"}, r.Tags)
+	assert.Equal(t, []string{"aah.auditmiddleware"}, r.SkipMiddlewares)
+	assert.Equal(t, "list_users", r.ParentName)
+
+	t.Log("Nested group, nearest group wins")
+	r = d.LookupByName("export_users")
+	assert.Equal(t, "/api/v1/admin/export", r.Path)
+	assert.Equal(t, "form_auth", r.Auth)
+	assert.Equal(t, "text/csv", r.Produces)
+	assert.Equal(t, []string{"v1", "admin"}, r.Tags)
+	assert.Equal(t, 250*time.Millisecond, r.SlowThreshold)
+	assert.Equal(t, "admin", r.ParentName)
+
+	t.Log("Path without group prefix")
+	assert.Equal(t, "/healthz", d.LookupByName("health").Path)
+	assert.Equal(t, "Index", d.LookupByName("health").Action)
+
+	t.Log("Reverse URL")
+	assert.Equal(t, "/api/v1/admin/export", d.RouteURL("export_users"))
+	assert.Equal(t, "/api/v1/users", d.RouteURL("list_users"))
+}
+
+func TestRouteSlowThreshold(t *testing.T) {
+	cfg, err := config.ParseString(`
+	products {
+		path = "/products"
+		controller = "ProductController"
+		slow_threshold = "250ms"
+	}`)
+	assert.Nil(t, err)
+	routes, err := parseSectionRoutes(cfg, &parentRouteInfo{AuthorizationInfo: &authorizationInfo{Satisfy: "either"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 250*time.Millisecond, routes[0].SlowThreshold)
+
+	cfg.SetString("products.slow_threshold", "quick")
+	_, err = parseSectionRoutes(cfg, &parentRouteInfo{AuthorizationInfo: &authorizationInfo{Satisfy: "either"}})
+	assert.Equal(t, "'products.slow_threshold' value is not a valid time unit", err.Error())
+}
+
+func TestRouteSingleFlight(t *testing.T) {
+	cfg, err := config.ParseString(`
+	products {
+		path = "/products"
+		controller = "ProductController"
+		singleflight = true
+	}
+	create_product {
+		path = "/products"
+		method = "POST"
+		controller = "ProductController"
+		singleflight = true
+	}
+	product_info {
+		path = "/products/info"
+		method = "GET, HEAD, POST"
+		controller = "ProductController"
+		action = "Info"
+		singleflight = true
+	}`)
+	assert.Nil(t, err)
+	routes, err := parseSectionRoutes(cfg, &parentRouteInfo{AuthorizationInfo: &authorizationInfo{Satisfy: "either"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(routes))
+	for _, r := range routes {
+		assert.Equal(t, r.Method == ahttp.MethodGet || r.Method == ahttp.MethodHead, r.IsSingleFlight)
+	}
+}
+
+func TestRouteWebhook(t *testing.T) {
+	cfg, err := config.ParseString(`
+	github_webhook {
+		path = "/webhooks/github"
+		method = "POST"
+		controller = "WebhookController"
+		webhook = true
+	}
+	products {
+		path = "/products"
+		controller = "ProductController"
+	}`)
+	assert.Nil(t, err)
+	routes, err := parseSectionRoutes(cfg, &parentRouteInfo{AuthorizationInfo: &authorizationInfo{Satisfy: "either"}})
+	assert.Nil(t, err)
+	for _, r := range routes {
+		assert.Equal(t, r.Name == "github_webhook", r.IsWebhook)
+	}
+}
+
+func TestRouteAudit(t *testing.T) {
+	cfg, err := config.ParseString(`
+	create_payment {
+		path = "/payments"
+		method = "POST"
+		controller = "PaymentController"
+		audit = true
+	}
+	payments_ws {
+		path = "/payments/ws"
+		method = "WS"
+		controller = "PaymentController"
+		action = "Stream"
+		audit = true
+	}`)
+	assert.Nil(t, err)
+	routes, err := parseSectionRoutes(cfg, &parentRouteInfo{AuthorizationInfo: &authorizationInfo{Satisfy: "either"}})
+	assert.Nil(t, err)
+	for _, r := range routes {
+		assert.Equal(t, r.Name == "create_payment", r.IsAudit)
+	}
+}
+
+func TestRouteDefaultStatus(t *testing.T) {
+	cfg, err := config.ParseString(`
+	create_order {
+		path = "/orders"
+		method = "POST"
+		controller = "OrderController"
+		default_status = 201
+	}
+	orders {
+		path = "/orders"
+		controller = "OrderController"
+	}`)
+	assert.Nil(t, err)
+	routes, err := parseSectionRoutes(cfg, &parentRouteInfo{AuthorizationInfo: &authorizationInfo{Satisfy: "either"}})
+	assert.Nil(t, err)
+	for _, r := range routes {
+		if r.Name == "create_order" {
+			assert.Equal(t, 201, r.DefaultStatus)
+		} else {
+			assert.Equal(t, 0, r.DefaultStatus)
+		}
+	}
+
+	cfg, err = config.ParseString(`
+	create_order {
+		path = "/orders"
+		method = "POST"
+		controller = "OrderController"
+		default_status = 2010
+	}`)
+	assert.Nil(t, err)
+	_, err = parseSectionRoutes(cfg, &parentRouteInfo{AuthorizationInfo: &authorizationInfo{Satisfy: "either"}})
+	assert.Equal(t, "'create_order.default_status' unsupported value: 2010", err.Error())
+}
+
+func TestDomainStaticPrecedence(t *testing.T) {
+	newDomain := func(precedence string, paths ...string) *Domain {
+		d := &Domain{
+			StaticPrecedence:      precedence,
+			RedirectTrailingSlash: true,
+			trees:                 make(map[string]*tree),
+			routes:                make(map[string]*Route),
+		}
+		assert.Nil(t, d.AddRoute(&Route{Name: "public_assets", Path: "/assets/*filepath", Method: ahttp.MethodGet, IsStatic: true}))
+		for _, p := range paths {
+			assert.Nil(t, d.AddRoute(&Route{Name: p, Path: p, Method: ahttp.MethodGet}))
+		}
+		for _, t := range d.trees {
+			t.root.inferwnode()
+		}
+		if d.staticTree != nil {
+			d.staticTree.root.inferwnode()
+		}
+		return d
+	}
+	lookup := func(d *Domain, p string) string {
+		req := createHTTPRequest("localhost:8080", p)
+		req.Method = ahttp.MethodGet
+		if route, _, _ := d.Lookup(req); route != nil {
+			return route.Name
+		}
+		return ""
+	}
+
+	t.Log("Precedence 'specific', most specific path wins")
+	d := newDomain(StaticPrecedenceSpecific, "/assets/app.js", "/users")
+	assert.Nil(t, d.staticTree)
+	assert.Equal(t, "/assets/app.js", lookup(d, "/assets/app.js"))
+	assert.Equal(t, "public_assets", lookup(d, "/assets/logo.png"))
+	assert.Equal(t, "/users", lookup(d, "/users"))
+	err := d.AddRoute(&Route{Name: "asset", Path: "/assets/:name", Method: ahttp.MethodGet})
+	assert.True(t, strings.HasPrefix(err.Error(), "aah/router: parameter based edge already exists"))
+
+	t.Log("Precedence 'static', static mount is matched first")
+	d = newDomain(StaticPrecedenceStatic, "/assets/app.js", "/assets/:name", "/users")
+	assert.Equal(t, "public_assets", lookup(d, "/assets/app.js"))
+	assert.Equal(t, "public_assets", lookup(d, "/assets/logo.png"))
+	assert.Equal(t, "public_assets", lookup(d, "/assets/css/app.css"))
+	assert.Equal(t, "/users", lookup(d, "/users"))
+	assert.Equal(t, "", lookup(d, "/not-exists"))
+
+	t.Log("Precedence 'route', application routes are matched first")
+	d = newDomain(StaticPrecedenceRoute, "/assets/app.js", "/assets/:name", "/users")
+	assert.Equal(t, "/assets/app.js", lookup(d, "/assets/app.js"))
+	assert.Equal(t, "/assets/:name", lookup(d, "/assets/logo.png"))
+	assert.Equal(t, "public_assets", lookup(d, "/assets/css/app.css"))
+	assert.Equal(t, "/users", lookup(d, "/users"))
+	assert.Equal(t, "GET", d.Allowed(ahttp.MethodPost, "/assets/css/app.css"))
+
+	t.Log("Static routes only")
+	d = newDomain(StaticPrecedenceRoute)
+	assert.Equal(t, "public_assets", lookup(d, "/assets/css/app.css"))
+	assert.Equal(t, 1, len(d.Routes()))
+}
+
+func TestDomainMethodOverride(t *testing.T) {
+	d := &Domain{
+		MethodOverride:      true,
+		MethodOverrideField: "_method",
+		trees:               make(map[string]*tree),
+		routes:              make(map[string]*Route),
+	}
+	for _, m := range []string{ahttp.MethodPost, ahttp.MethodPut, ahttp.MethodDelete} {
+		assert.Nil(t, d.AddRoute(&Route{Name: "user_" + m, Path: "/users", Method: m}))
+	}
+	assert.Nil(t, d.AddRoute(&Route{Name: "user_GET", Path: "/users", Method: ahttp.MethodGet}))
+
+	lookup := func(method, override, body string) string {
+		req, _ := http.NewRequest(method, "http://localhost/users", strings.NewReader(body))
+		if len(override) > 0 {
+			req.Header.Set(ahttp.HeaderXHTTPMethodOverride, override)
+		}
+		if len(body) > 0 {
+			req.Header.Set(ahttp.HeaderContentType, ahttp.ContentTypeForm.String())
+		}
+		route, _, _ := d.Lookup(req)
+		return route.Name
+	}
+
+	assert.Equal(t, "user_PUT", lookup(ahttp.MethodPost, "put", ""))
+	assert.Equal(t, "user_DELETE", lookup(ahttp.MethodPost, "", "name=jeeva&_method=DELETE"))
+	assert.Equal(t, "user_DELETE", lookup(ahttp.MethodPost, "DELETE", "_method=PUT"))
+
+	// restricted to POST and methods PUT, PATCH and DELETE
+	assert.Equal(t, "user_GET", lookup(ahttp.MethodGet, "DELETE", ""))
+	assert.Equal(t, "user_POST", lookup(ahttp.MethodPost, "GET", ""))
+	assert.Equal(t, "user_POST", lookup(ahttp.MethodPost, "", "_method=TRACE"))
+
+	d.MethodOverrideField = ""
+	assert.Equal(t, "user_POST", lookup(ahttp.MethodPost, "", "_method=DELETE"))
+
+	d.MethodOverride = false
+	assert.Equal(t, "user_POST", lookup(ahttp.MethodPost, "PUT", ""))
+}
+
+func TestRouterCleanPath(t *testing.T) {
+	testcases := map[string]string{
+		"":                "/",
+		"/":               "/",
+		"//":              "/",
+		"/foo//bar":       "/foo/bar",
+		"/foo/./bar/":     "/foo/bar/",
+		"/foo/../bar":     "/bar",
+		"foo/bar":         "/foo/bar",
+		"/../../foo":      "/foo",
+		"/foo%2F..%2Fbar": "/foo%2F..%2Fbar",
+	}
+	for p, expected := range testcases {
+		assert.Equal(t, expected, CleanPath(p), p)
+	}
+}
+
+func TestRouteCanonicalPath(t *testing.T) {
+	testcases := []struct {
+		route    string
+		reqPath  string
+		expected string
+	}{
+		{"/", "/", "/"},
+		{"/Get-Text.html", "/GET-TEXT.html", "/get-text.html"},
+		{"/users/:userId", "/Users/JeevaM", "/users/JeevaM"},
+		{"/users/:userId/", "/USERS/JeevaM/", "/users/JeevaM/"},
+		{"/files/*filepath", "/Files/Dir/Name.txt", "/files/Dir/Name.txt"},
+		{"/a b/:id", "/A%20B/X%2FY", "/a%20b/X%2FY"},
+		{"/a b/:id", "/a%20b/X", "/a%20b/X"},
+		{"*", "/Any/Path", "/Any/Path"},
+	}
+	for _, tc := range testcases {
+		r := &Route{Path: tc.route}
+		assert.Equal(t, tc.expected, r.CanonicalPath(tc.reqPath), tc.reqPath)
+	}
+}
+
 func TestMiscRouter(t *testing.T) {
 	r, err := NewWithApp(nil, "configPath")
 	assert.NotNil(t, err)
@@ -662,6 +1228,11 @@ func (a *app) Config() *config.Config             { return a.cfg }
 func (a *app) Log() log.Loggerer                  { return a.l }
 func (a *app) SecurityManager() *security.Manager { return a.sec }
 
+func testRoutesConfig(s string) *config.Config {
+	cfg, _ := config.ParseString(s + "\n")
+	return cfg
+}
+
 func createRouter(filename string) (*Router, error) {
 	rfs := new(vfs.VFS)
 	_ = rfs.AddMount("/app/config", testdataBaseDir())
@@ -704,3 +1275,54 @@ func testdataBaseDir() string {
 	}
 	return filepath.Join(wd, ".testdata")
 }
+
+func TestDomainRouteVersion(t *testing.T) {
+	d := &Domain{
+		APIVersionDefault: APIVersionLatest,
+		trees:             make(map[string]*tree),
+		routes:            make(map[string]*Route),
+	}
+	for _, v := range []string{"1.9", "2", "1.10"} {
+		assert.Nil(t, d.AddRoute(&Route{Name: "users_v" + v, Path: "/users/:id", Method: ahttp.MethodGet, Version: v}))
+	}
+	assert.Nil(t, d.AddRoute(&Route{Name: "create_user", Path: "/users/:id", Method: ahttp.MethodPost}))
+	for _, t := range d.trees {
+		t.root.inferwnode()
+	}
+
+	req := createHTTPRequest("localhost:8080", "/users/100")
+	req.Method = ahttp.MethodGet
+	route, params, _ := d.Lookup(req)
+	assert.Equal(t, "100", params.Get("id"))
+
+	var names []string
+	for _, r := range route.Versions() {
+		names = append(names, r.Name)
+	}
+	assert.Equal(t, []string{"users_v1.9", "users_v1.10", "users_v2"}, names)
+	assert.Equal(t, 4, len(d.Routes()))
+
+	assert.Equal(t, "users_v2", d.RouteVersion(route, "").Name)
+	assert.Equal(t, "users_v2", d.RouteVersion(route, APIVersionLatest).Name)
+	assert.Equal(t, "users_v1.10", d.RouteVersion(route, "v1.10").Name)
+	assert.Nil(t, d.RouteVersion(route, "3"))
+
+	d.APIVersionDefault = "1.9"
+	assert.Equal(t, "users_v1.9", d.RouteVersion(route, "").Name)
+
+	t.Log("Unversioned route")
+	create := d.LookupByName("create_user")
+	assert.Nil(t, create.Versions())
+	assert.Equal(t, create, d.RouteVersion(create, "2"))
+
+	t.Log("Duplicate and unversioned routes on same path")
+	err := d.AddRoute(&Route{Name: "users_dup", Path: "/users/:id", Method: ahttp.MethodGet, Version: "v2"})
+	assert.Equal(t, "same route version 'v2' exists on both routes named 'users_dup', 'users_v2' for method 'GET' and path '/users/:id'", err.Error())
+	err = d.AddRoute(&Route{Name: "users", Path: "/users/:id", Method: ahttp.MethodGet})
+	assert.Equal(t, "same route path '/users/:id' exists on both routes named 'users', 'users_v1.9' for method 'GET'", err.Error())
+
+	assert.Equal(t, 0, compareVersion("v2", "2"))
+	assert.Equal(t, -1, compareVersion("2", "2.1"))
+	assert.Equal(t, 1, compareVersion("2018-10-01", "2018-09-30"))
+	assert.Equal(t, 1, compareVersion("2.beta", "2.alpha"))
+}
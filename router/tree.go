@@ -38,13 +38,17 @@ const (
 //______________________________________________________________________________
 
 type tree struct {
-	tralingSlash bool
-	maxParams    uint8
-	root         *node
+	tralingSlash  bool
+	caseSensitive bool
+	maxParams     uint8
+	root          *node
 }
 
 func (t *tree) lookup(p string) (r *Route, params ahttp.URLParams, rts bool) {
-	s, l, sn, pn := strings.ToLower(p), len(p), t.root, t.root
+	s, l, sn, pn := p, len(p), t.root, t.root
+	if !t.caseSensitive {
+		s = strings.ToLower(p)
+	}
 	ll := l
 walk:
 	for {
@@ -62,7 +66,8 @@ walk:
 				i++
 			}
 			if i != max {
-				if pn.wnode != nil {
+				// wildcard sibling of the parent, not applicable to root node
+				if pn != sn && pn.wnode != nil {
 					sn = pn.wnode
 					continue walk
 				}
@@ -134,7 +139,9 @@ walk:
 
 func (t *tree) add(p string, r *Route) error {
 	fp := p
-	p = strings.ToLower(p)
+	if !t.caseSensitive {
+		p = strings.ToLower(p)
+	}
 	var err error
 	maxParams := countParams(p)
 	if maxParams > t.maxParams {
@@ -226,8 +233,8 @@ func (t *tree) insertEdge(typ nodeType, p, arg string, r *Route) error {
 		default:
 			if r != nil {
 				if sn.value != nil {
-					return fmt.Errorf("same route path '%s' exists on both routes named '%s', '%s' for method '%s'",
-						r.Path, r.Name, sn.value.Name, r.Method)
+					// API versions of the route, first one stays on the node
+					return sn.value.addVersion(r)
 				}
 				sn.value = r
 			}
@@ -299,6 +306,20 @@ func (n *node) inferwnode() {
 	}
 }
 
+func (n *node) collectRoutes(routes []*Route) []*Route {
+	if n.value != nil {
+		if len(n.value.versions) > 0 {
+			routes = append(routes, n.value.versions...)
+		} else {
+			routes = append(routes, n.value)
+		}
+	}
+	for _, e := range n.edges {
+		routes = e.collectRoutes(routes)
+	}
+	return routes
+}
+
 func (n *node) findByIdx(i byte) *node {
 	for _, e := range n.edges {
 		if e.idx == i {
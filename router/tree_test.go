@@ -432,6 +432,16 @@ func TestTreeRouteNotFound(t *testing.T) {
 		assert.Nil(t, v)
 		assert.Nil(t, p)
 	}
+
+	// root node with wildcard edge
+	tt = &tree{root: new(node)}
+	assert.Nil(t, tt.add("/assets/*filepath", &Route{Path: "/assets/*filepath"}))
+	tt.root.inferwnode()
+	v, p, _ := tt.lookup("/users")
+	assert.Nil(t, v)
+	assert.Nil(t, p)
+	v, _, _ = tt.lookup("/assets/app.js")
+	assert.NotNil(t, v)
 }
 
 func TestTreeVariousRouteTypes(t *testing.T) {
@@ -499,6 +509,27 @@ func TestTreeEmptyParameterName(t *testing.T) {
 	}
 }
 
+func TestTreeCaseSensitiveLookup(t *testing.T) {
+	tt := &tree{root: new(node), caseSensitive: true}
+	assert.Nil(t, tt.add("/Users/:userId", &Route{Path: "/Users/:userId"}))
+	assert.Nil(t, tt.add("/files/*FilePath", &Route{Path: "/files/*FilePath"}))
+	tt.root.inferwnode()
+
+	v, p, _ := tt.lookup("/Users/JeevaM")
+	assert.NotNil(t, v)
+	assert.Equal(t, "JeevaM", p.Get("userId"))
+
+	v, _, _ = tt.lookup("/users/JeevaM")
+	assert.Nil(t, v)
+
+	v, p, _ = tt.lookup("/files/Dir/Name.txt")
+	assert.NotNil(t, v)
+	assert.Equal(t, "Dir/Name.txt", p.Get("FilePath"))
+
+	v, _, _ = tt.lookup("/FILES/Dir/Name.txt")
+	assert.Nil(t, v)
+}
+
 func TestTreeDoubleParameters(t *testing.T) {
 	routes := []string{
 		"/:foo:bar",
@@ -101,3 +101,18 @@ func addSlashPrefix(v string) string {
 	}
 	return "/" + v
 }
+
+// CleanPath method returns the canonical form of given URL path, it
+// collapses the repeated slashes and resolves the `.` and `..` elements.
+// Trailing slash is preserved. Given path is expected to be escaped path,
+// so that encoded slash `%2F` stays as-is.
+func CleanPath(p string) string {
+	if len(p) == 0 {
+		return "/"
+	}
+	cp := path.Clean(addSlashPrefix(p))
+	if p[len(p)-1] == slashByte && cp != "/" {
+		cp += "/"
+	}
+	return cp
+}
@@ -49,6 +49,65 @@ func TestRouterTemplateFuncs(t *testing.T) {
 	assert.Equal(t, "//localhost:8080", string(url4))
 }
 
+func TestRouterCleanPath(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Clean Path]: %s", ts.URL)
+
+	// disabled by default
+	domain := ts.app.Router().RootDomain()
+	assert.False(t, domain.CleanPath)
+	assert.True(t, domain.CleanPathRedirect)
+	ts.Get("//get-text.html").AssertStatus(http.StatusNotFound)
+
+	domain.CleanPath = true
+	defer func() { domain.CleanPath, domain.CleanPathRedirect = false, true }()
+
+	// redirect
+	ts.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp := ts.Get("/static/..//get-text.html?lang=en")
+	resp.AssertStatus(http.StatusMovedPermanently)
+	resp.AssertHeader(ahttp.HeaderLocation, ts.URL+"/get-text.html?lang=en")
+
+	req, _ := http.NewRequest(ahttp.MethodPost, ts.URL+"/./form-submit", nil)
+	ts.Do(req).AssertStatus(http.StatusTemporaryRedirect)
+
+	// route directly
+	domain.CleanPathRedirect = false
+	ts.Get("//get-text.html").AssertStatus(http.StatusOK)
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+}
+
+func TestRouterRedirectFixedPath(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Redirect Fixed Path]: %s", ts.URL)
+
+	ts.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	// case insensitive is enabled in routes.conf
+	domain := ts.app.Router().RootDomain()
+	assert.False(t, domain.CaseSensitive)
+	assert.False(t, domain.RedirectFixedPath)
+	ts.Get("/GET-Text.html").AssertStatus(http.StatusOK)
+
+	domain.RedirectFixedPath = true
+	defer func() { domain.RedirectFixedPath = false }()
+
+	resp := ts.Get("/GET-Text.html?lang=en")
+	resp.AssertStatus(http.StatusMovedPermanently)
+	resp.AssertHeader(ahttp.HeaderLocation, ts.URL+"/get-text.html?lang=en")
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+}
+
 func TestRouterCORS(t *testing.T) {
 	importPath := filepath.Join(testdataBaseDir(), "webapp1")
 	ts := newTestServer(t, importPath)
@@ -119,3 +178,54 @@ func TestRouterCORS(t *testing.T) {
 	}
 	CORSMiddleware(ctx5, &Middleware{})
 }
+
+func TestRouterAPIVersion(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [API Version]: %s", ts.URL)
+
+	domain := ts.app.Router().RootDomain()
+	assert.Equal(t, "X-API-Version", domain.APIVersionHeader)
+	assert.Equal(t, router.APIVersionLatest, domain.APIVersionDefault)
+
+	testcases := []struct {
+		label, header, value, version string
+	}{
+		{label: "default latest", version: "10"},
+		{label: "accept vendor dot", header: ahttp.HeaderAccept, value: "application/vnd.myapp.v2+json", version: "2"},
+		{label: "accept vendor hyphen", header: ahttp.HeaderAccept, value: "application/vnd.myapp-v1+json", version: "1"},
+		{label: "accept param", header: ahttp.HeaderAccept, value: "application/json; version=2", version: "2"},
+		{label: "version header", header: "X-API-Version", value: "v1", version: "1"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.label, func(t *testing.T) {
+			req, _ := http.NewRequest(ahttp.MethodGet, ts.URL+"/api/users", nil)
+			if len(tc.header) > 0 {
+				req.Header.Set(tc.header, tc.value)
+			}
+			resp := ts.Do(req).AssertStatus(http.StatusOK)
+			assert.Equal(t, `{"route":"api_users_v`+tc.version+`","version":"`+tc.version+`"}`+"\n", resp.BodyString())
+			resp.AssertHeader(ahttp.HeaderContentType, ahttp.ContentTypeJSON.String())
+		})
+	}
+
+	t.Log("Accept header takes precedence")
+	req, _ := http.NewRequest(ahttp.MethodGet, ts.URL+"/api/users", nil)
+	req.Header.Set(ahttp.HeaderAccept, "application/vnd.myapp.v2+json")
+	req.Header.Set("X-API-Version", "1")
+	assert.Contains(t, ts.Do(req).AssertStatus(http.StatusOK).BodyString(), `"version":"2"`)
+
+	t.Log("Unsupported version")
+	req, _ = http.NewRequest(ahttp.MethodGet, ts.URL+"/api/users", nil)
+	req.Header.Set("X-API-Version", "3")
+	ts.Do(req).AssertStatus(http.StatusNotAcceptable)
+
+	t.Log("Explicit default version")
+	domain.APIVersionDefault = "1"
+	defer func() { domain.APIVersionDefault = router.APIVersionLatest }()
+	assert.Contains(t, ts.Get("/api/users").AssertStatus(http.StatusOK).BodyString(), `"version":"1"`)
+
+	assert.Equal(t, "/api/users", domain.RouteURL("api_users_v2"))
+}
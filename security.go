@@ -16,6 +16,7 @@ import (
 	"aahframe.work/security/anticsrf"
 	"aahframe.work/security/authc"
 	"aahframe.work/security/authz"
+	"aahframe.work/security/cookie"
 	"aahframe.work/security/scheme"
 )
 
@@ -44,12 +45,29 @@ func (a *Application) initSecurity() error {
 	if err := asecmgr.Init(a.Config()); err != nil {
 		return err
 	}
+	asecmgr.SetNowFunc(a.now)
+
+	cookieOpts, err := cookie.NewOptions(a.Config(), "")
+	if err != nil {
+		return err
+	}
+	cookieOpts.Now = a.now
 
 	a.securityMgr = asecmgr
+	a.cookieOpts = cookieOpts
 	a.settings.AuthSchemeExists = len(a.securityMgr.AuthSchemes()) > 0
 	return nil
 }
 
+// cookieOptions method returns the application cookie defaults
+// `security.cookie.*`.
+func (a *Application) cookieOptions() *cookie.Options {
+	if a.cookieOpts == nil {
+		return &cookie.Options{Path: "/", HTTPOnly: true, SecureAuto: true, Now: a.now}
+	}
+	return a.cookieOpts
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Authentication and Authorization Middleware
 //______________________________________________________________________________
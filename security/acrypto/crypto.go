@@ -91,6 +91,54 @@ func AESDecrypt(block cipher.Block, value []byte) ([]byte, error) {
 	return nil, ErrUnableToDecrypt
 }
 
+// AESGCM method returns the AES-GCM authenticated encryption instance for
+// given key, either 16, 24, or 32 bytes to select AES-128, AES-192,
+// or AES-256.
+func AESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// AESGCMEncrypt method encrypts and authenticates a given value and
+// additional data with given AES-GCM instance. Additional data is not
+// encrypted, it binds the encrypted value to the context, for e.g.: cookie
+// name.
+func AESGCMEncrypt(aead cipher.AEAD, value, additionalData []byte) []byte {
+	nonce := ess.GenerateSecureRandomKey(aead.NonceSize())
+
+	// nonce + encryptedtext
+	return aead.Seal(nonce, nonce, value, additionalData)
+}
+
+// AESGCMDecrypt method authenticates and decrypts a given value and additional
+// data with the given AES-GCM instance. Tampered value returns an error.
+func AESGCMDecrypt(aead cipher.AEAD, value, additionalData []byte) ([]byte, error) {
+	size := aead.NonceSize()
+	if len(value) <= size {
+		return nil, ErrUnableToDecrypt
+	}
+	b, err := aead.Open(nil, value[:size], value[size:], additionalData)
+	if err != nil {
+		return nil, ErrUnableToDecrypt
+	}
+	return b, nil
+}
+
+// AESGCMDecryptAny method tries the given AES-GCM instances in the order for
+// decryption, it supports the key rotation. First instance is meant to be
+// the active key for `AESGCMEncrypt`.
+func AESGCMDecryptAny(aeads []cipher.AEAD, value, additionalData []byte) ([]byte, error) {
+	for _, aead := range aeads {
+		if b, err := AESGCMDecrypt(aead, value, additionalData); err == nil {
+			return b, nil
+		}
+	}
+	return nil, ErrUnableToDecrypt
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Package Sign/Verify methods
 //___________________________________
@@ -129,6 +177,18 @@ func Verify(key, value, mac []byte, sha string) bool {
 	return hmac.Equal(mac, otherMac)
 }
 
+// VerifyAny method verifies given value and mac against the given keys in the
+// order, it supports the key rotation. First key is meant to be the active
+// key for `Sign`. It returns the index of matched key otherwise -1.
+func VerifyAny(keys [][]byte, value, mac []byte, sha string) int {
+	for i, key := range keys {
+		if Verify(key, value, mac, sha) {
+			return i
+		}
+	}
+	return -1
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Unexported methods
 //___________________________________
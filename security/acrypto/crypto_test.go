@@ -6,6 +6,7 @@ package acrypto
 
 import (
 	"crypto/aes"
+	"crypto/cipher"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -65,3 +66,51 @@ func TestAESEncrptAndDecrypt(t *testing.T) {
 	assert.Nil(t, b)
 	assert.True(t, (ErrUnableToDecrypt == err1))
 }
+
+func TestAESGCMEncryptAndDecrypt(t *testing.T) {
+	aead, err := AESGCM([]byte("467b2d53632646a0a9c6cc0d498a7559"))
+	assert.Nil(t, err)
+
+	text := []byte("This is the text gonna be encrypted and decrypted")
+	encrypted := AESGCMEncrypt(aead, append([]byte{}, text...), []byte("aah_session"))
+	assert.NotEqual(t, text, encrypted[aead.NonceSize():])
+
+	result, err := AESGCMDecrypt(aead, encrypted, []byte("aah_session"))
+	assert.Nil(t, err)
+	assert.Equal(t, text, result)
+
+	// Errors
+	_, err = AESGCMDecrypt(aead, encrypted, []byte("aah_flash"))
+	assert.Equal(t, ErrUnableToDecrypt, err)
+
+	encrypted[len(encrypted)-1] ^= 0x01
+	_, err = AESGCMDecrypt(aead, encrypted, []byte("aah_session"))
+	assert.Equal(t, ErrUnableToDecrypt, err)
+
+	_, err = AESGCMDecrypt(aead, encrypted[:aead.NonceSize()], nil)
+	assert.Equal(t, ErrUnableToDecrypt, err)
+
+	_, err = AESGCM([]byte("467b2d53632646a0a9c6cc0d498a75"))
+	assert.Equal(t, "crypto/aes: invalid key size 30", err.Error())
+}
+
+func TestKeyRotationVerifyAndDecrypt(t *testing.T) {
+	keys := [][]byte{[]byte("new-sign-key"), []byte("old-sign-key")}
+	value := []byte("This is the text gonna be signed and verifed")
+
+	assert.Equal(t, 0, VerifyAny(keys, value, Sign(keys[0], value, "sha-256"), "sha-256"))
+	assert.Equal(t, 1, VerifyAny(keys, value, Sign(keys[1], value, "sha-256"), "sha-256"))
+	assert.Equal(t, -1, VerifyAny(keys, value, Sign([]byte("unknown-key"), value, "sha-256"), "sha-256"))
+	assert.Equal(t, -1, VerifyAny(nil, value, Sign(keys[0], value, "sha-256"), "sha-256"))
+
+	newAEAD, _ := AESGCM([]byte("467b2d53632646a0a9c6cc0d498a7559"))
+	oldAEAD, _ := AESGCM([]byte("467b2d53632646a0a9c6cc0d498a7560"))
+	encrypted := AESGCMEncrypt(oldAEAD, append([]byte{}, value...), nil)
+
+	result, err := AESGCMDecryptAny([]cipher.AEAD{newAEAD, oldAEAD}, encrypted, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, value, result)
+
+	_, err = AESGCMDecryptAny([]cipher.AEAD{newAEAD}, encrypted, nil)
+	assert.Equal(t, ErrUnableToDecrypt, err)
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"aahframe.work/ahttp"
 	"aahframe.work/config"
@@ -63,31 +64,35 @@ func New(cfg *config.Config) (*AntiCSRF, error) {
 
 	// Anit CSRF cookie options
 	c.cookieName = c.cfg.StringDefault(keyPrefix+".prefix", "aah") + "_anti_csrf"
-	opts := &cookie.Options{
-		Name:     c.cookieName,
-		Domain:   c.cfg.StringDefault(keyPrefix+".domain", ""),
-		Path:     c.cfg.StringDefault(keyPrefix+".path", "/"),
-		HTTPOnly: true,
-		// Based on aah server SSL configuration `http.Cookie.Secure` value is set
-		Secure:   c.cfg.BoolDefault("server.ssl.enable", false),
-		SameSite: strings.ToLower(c.cfg.StringDefault(keyPrefix+".samesite", "")),
+	// Based on application cookie defaults `security.cookie.*`
+	opts, err := cookie.NewOptions(c.cfg, keyPrefix)
+	if err != nil {
+		return nil, err
 	}
+	opts.Name = c.cookieName
+	opts.HTTPOnly = true
 
 	// Anti-CSRF cookie TTL, default is 24 hours
-	var err error
 	ttl := c.cfg.StringDefault(keyPrefix+".ttl", "24h")
 	if opts.MaxAge, err = toSeconds(ttl); err != nil {
 		return nil, err
 	}
 
-	if c.cookieMgr, err = cookie.NewManager(opts,
-		c.cfg.StringDefault(keyPrefix+".sign_key", ""),
-		c.cfg.StringDefault(keyPrefix+".enc_key", ""),
-		c.cfg.StringDefault(keyPrefix+".old_sign_key", ""),
-		c.cfg.StringDefault(keyPrefix+".old_enc_key", "")); err != nil {
+	keys, err := cookie.Keys(c.cfg, keyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if c.cookieMgr, err = cookie.NewManager(opts, keys...); err != nil {
 		return nil, err
 	}
 
+	// Authenticated encryption of Anti-CSRF cookie value
+	if keys := cookie.EncryptKeys(c.cfg, keyPrefix); len(keys) > 0 {
+		if err = c.cookieMgr.SetEncryptKeys(keys...); err != nil {
+			return nil, err
+		}
+	}
+
 	return c, nil
 }
 
@@ -189,6 +194,15 @@ func (ac *AntiCSRF) IsTrustedOrigin(ref *url.URL) bool {
 	return found
 }
 
+// SetNowFunc method sets the time source of Anti-CSRF cookie expiry and
+// timestamp, for e.g.: aah application clock. Nil value resets it to
+// `time.Now`.
+func (ac *AntiCSRF) SetNowFunc(fn func() time.Time) {
+	if ac.cookieMgr != nil {
+		ac.cookieMgr.Options.Now = fn
+	}
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // AntiCSRF Unexported methods
 //_________________________________________
@@ -15,6 +15,7 @@ import (
 	"strings"
 	"time"
 
+	"aahframe.work/config"
 	"aahframe.work/essentials"
 	"aahframe.work/security/acrypto"
 )
@@ -27,13 +28,19 @@ var (
 	ErrCookieTimestampIsTooNew  = errors.New("security/cookie: timestamp is too new")
 	ErrCookieTimestampIsExpired = errors.New("security/cookie: timestamp expried")
 	ErrSignVerificationIsFailed = errors.New("security/cookie: sign verification is failed")
+	ErrUnableToDecrypt          = errors.New("security/cookie: unable to decrypt")
+	ErrActiveKeyIsEmpty         = errors.New("security/cookie: active sign and encryption key is empty")
 )
 
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Package methods
 //______________________________________________________________________________
 
-// NewManager method returns the new cookie manager.
+// NewManager method returns the new cookie manager. Keys are the pairs of
+// sign and encryption key, first pair is active for signing & encryption
+// and all the pairs are tried for verification, it allows the key rotation.
+// Empty retired pairs are skipped, however empty active pair along with
+// retired pairs returns `ErrActiveKeyIsEmpty`.
 //
 // Example:
 //
@@ -45,28 +52,21 @@ func NewManager(opts *Options, keys ...string) (*Manager, error) {
 		Options:       opts,
 		maxCookieSize: 4096, // 4kb
 		sha:           "sha-256",
-		key:           new(key),
-		oldKey:        new(key),
 	}
-	if len(keys) >= 2 && !ess.IsStrEmpty(keys[0]) && !ess.IsStrEmpty(keys[1]) {
-		m.key.sign = []byte(keys[0])
-		m.key.enc = []byte(keys[1])
-	}
-	if len(keys) == 4 && !ess.IsStrEmpty(keys[2]) && !ess.IsStrEmpty(keys[3]) {
-		m.oldKey.sign = []byte(keys[2])
-		m.oldKey.enc = []byte(keys[3])
-	}
-
-	var err error
-	if len(m.key.enc) > 0 {
-		if m.key.cipherBlock, err = aes.NewCipher(m.key.enc); err != nil {
-			return nil, err
+	for i := 0; i+1 < len(keys); i += 2 {
+		if ess.IsStrEmpty(keys[i]) || ess.IsStrEmpty(keys[i+1]) {
+			if i == 0 && hasKey(keys[2:]) {
+				return nil, ErrActiveKeyIsEmpty
+			}
+			continue
 		}
-	}
-	if len(m.oldKey.enc) > 0 {
-		if m.oldKey.cipherBlock, err = aes.NewCipher(m.oldKey.enc); err != nil {
+		k := &key{sign: []byte(keys[i]), enc: []byte(keys[i+1])}
+		var err error
+		if k.cipherBlock, err = aes.NewCipher(k.enc); err != nil {
 			return nil, err
 		}
+		m.keys = append(m.keys, k)
+		m.signKeys = append(m.signKeys, k.sign)
 	}
 
 	m.Options.SameSite = strings.ToLower(m.Options.SameSite)
@@ -74,6 +74,91 @@ func NewManager(opts *Options, keys ...string) (*Manager, error) {
 	return m, nil
 }
 
+// Keys method returns the pairs of sign and encryption keys for
+// `NewManager` from config of given key prefix. Lists `sign_keys` and
+// `enc_keys` take precedence over `sign_key`, `enc_key`, `old_sign_key`
+// and `old_enc_key`, both lists should have same length.
+//
+//	sign_keys = ["new-sign-key", "old-sign-key"]
+//	enc_keys = ["new-enc-key", "old-enc-key"]
+func Keys(cfg *config.Config, keyPrefix string) ([]string, error) {
+	signKeys, found := cfg.StringList(keyPrefix + ".sign_keys")
+	if !found {
+		return []string{
+			cfg.StringDefault(keyPrefix+".sign_key", ""),
+			cfg.StringDefault(keyPrefix+".enc_key", ""),
+			cfg.StringDefault(keyPrefix+".old_sign_key", ""),
+			cfg.StringDefault(keyPrefix+".old_enc_key", ""),
+		}, nil
+	}
+
+	encKeys, _ := cfg.StringList(keyPrefix + ".enc_keys")
+	if len(signKeys) != len(encKeys) {
+		return nil, fmt.Errorf("'%s.sign_keys' and '%s.enc_keys' should have same no. of keys", keyPrefix, keyPrefix)
+	}
+	keys := make([]string, 0, 2*len(signKeys))
+	for i := range signKeys {
+		keys = append(keys, signKeys[i], encKeys[i])
+	}
+	return keys, nil
+}
+
+// NewOptions method returns the cookie options composed from the application
+// cookie defaults `security.cookie.*`, values are overridden by the given
+// key prefix if present, for e.g.: `security.session.path`. Option `secure`
+// supports `true`, `false` and `auto`, on `auto` it is derived from
+// `server.ssl.enable`.
+func NewOptions(cfg *config.Config, keyPrefix string) (*Options, error) {
+	opts := &Options{
+		Domain:   cfg.StringDefault(resolveKey(cfg, keyPrefix, "domain"), ""),
+		Path:     cfg.StringDefault(resolveKey(cfg, keyPrefix, "path"), "/"),
+		HTTPOnly: cfg.BoolDefault(resolveKey(cfg, keyPrefix, "http_only"), true),
+	}
+
+	key := resolveKey(cfg, keyPrefix, "secure")
+	switch v, _ := cfg.Get(key); v := v.(type) {
+	case nil:
+		opts.SecureAuto = true
+	case bool:
+		opts.Secure = v
+	case string:
+		if v != "auto" {
+			return nil, fmt.Errorf("'%s' unsupported value: %s", key, v)
+		}
+		opts.SecureAuto = true
+	default:
+		return nil, fmt.Errorf("'%s' unsupported value: %v", key, v)
+	}
+	if opts.SecureAuto {
+		opts.Secure = cfg.BoolDefault("server.ssl.enable", false)
+	}
+
+	key = resolveKey(cfg, keyPrefix, "samesite")
+	opts.SameSite = strings.ToLower(cfg.StringDefault(key, ""))
+	if !ess.IsSliceContainsString([]string{"", "lax", "strict", "none"}, opts.SameSite) {
+		return nil, fmt.Errorf("'%s' unsupported value: %s", key, opts.SameSite)
+	}
+
+	return opts, nil
+}
+
+// EncryptKeys method returns the AES-GCM encryption keys from config key
+// `encrypt_key` of given key prefix if present otherwise from application
+// cookie defaults `security.cookie.encrypt_key`. Value could be a string
+// or list, first key is active for encryption.
+func EncryptKeys(cfg *config.Config, keyPrefix string) []string {
+	key := resolveKey(cfg, keyPrefix, "encrypt_key")
+	if keys, found := cfg.StringList(key); found {
+		return keys
+	}
+	if v, found := cfg.Get(key); found {
+		if k, ok := v.(string); ok && len(k) > 0 {
+			return []string{k}
+		}
+	}
+	return nil
+}
+
 // NewWithOptions method returns http.Cookie with the options set from
 // `session {...}`. It also sets the `Expires` field calculated based on the
 // MaxAge value.
@@ -90,22 +175,13 @@ func NewWithOptions(value string, opts *Options) *http.Cookie {
 
 	if opts.MaxAge > 0 {
 		d := time.Duration(opts.MaxAge) * time.Second
-		cookie.Expires = time.Now().Add(d)
+		cookie.Expires = opts.now().Add(d)
 	} else if opts.MaxAge < 0 {
 		// Set it to the past to expire now.
 		cookie.Expires = time.Unix(1, 0)
 	}
 
-	// SameSite attribute support in the cookie
-	// https://tools.ietf.org/html/draft-ietf-httpbis-cookie-same-site-00
-	switch opts.SameSite {
-	case "lax":
-		cookie.SameSite = http.SameSiteLaxMode
-	case "strict":
-		cookie.SameSite = http.SameSiteStrictMode
-	default:
-		cookie.SameSite = http.SameSiteDefaultMode
-	}
+	cookie.SameSite = opts.SameSiteMode()
 
 	return cookie
 }
@@ -118,8 +194,9 @@ func NewWithOptions(value string, opts *Options) *http.Cookie {
 type Manager struct {
 	Options *Options
 
-	key           *key
-	oldKey        *key
+	keys          []*key
+	signKeys      [][]byte
+	aeads         []cipher.AEAD
 	sha           string
 	maxCookieSize int
 }
@@ -133,6 +210,38 @@ type Options struct {
 	HTTPOnly bool
 	Secure   bool
 	SameSite string
+
+	// SecureAuto is true when option `secure = "auto"`, per request the
+	// cookie could be marked as secure over HTTPS.
+	SecureAuto bool
+
+	// Now is the time source of cookie expiry and value timestamp, for e.g.:
+	// aah application clock. Default is `time.Now`.
+	Now func() time.Time
+}
+
+// SameSiteMode method returns the `http.SameSite` value of option `SameSite`.
+//
+// SameSite attribute support in the cookie
+// https://tools.ietf.org/html/draft-ietf-httpbis-cookie-same-site-00
+func (o *Options) SameSiteMode() http.SameSite {
+	switch o.SameSite {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteDefaultMode
+	}
+}
+
+func (o *Options) now() time.Time {
+	if o.Now == nil {
+		return time.Now()
+	}
+	return o.Now()
 }
 
 type key struct {
@@ -154,28 +263,54 @@ func (m *Manager) Write(w http.ResponseWriter, value string) {
 	}
 }
 
+// SetEncryptKeys method enables the authenticated encryption (AES-GCM) of
+// cookie value with given keys, either 16, 24, or 32 bytes to select AES-128,
+// AES-192, or AES-256. First key is used for encryption and all the keys
+// are tried for decryption, it allows the key rotation. It supersedes the
+// sign and encryption keys of `NewManager`.
+func (m *Manager) SetEncryptKeys(keys ...string) error {
+	aeads := make([]cipher.AEAD, 0, len(keys))
+	for i, k := range keys {
+		aead, err := acrypto.AESGCM([]byte(k))
+		if err != nil {
+			return fmt.Errorf("security/cookie: encrypt key #%d: %s", i+1, err)
+		}
+		aeads = append(aeads, aead)
+	}
+	m.aeads = aeads
+	return nil
+}
+
 // Encode method encodes given value.
 //
-// It performs:
+// If encrypt keys configured, refer to `Manager.SetEncryptKeys`, value
+// is encrypted and authenticated with cookie name, timestamp using AES-GCM
+// then encoded into Base64 string.
+//
+// Otherwise it performs:
 //   1) Encrypts it if encryption key configured
 //   2) Signs the value if sign key configured
 //   3) Encodes value into Base64 string
 //   4) Checks max cookie size i.e 4Kb
 func (m *Manager) Encode(b []byte) (string, error) {
+	if len(m.aeads) > 0 {
+		return m.encodeAEAD(b)
+	}
+
 	// Encrypt it
-	if len(m.key.enc) > 0 {
-		b = acrypto.AESEncrypt(m.key.cipherBlock, b)
+	if len(m.keys) > 0 {
+		b = acrypto.AESEncrypt(m.keys[0].cipherBlock, b)
 	}
 
 	// Encode it
 	b = ess.EncodeToBase64(b)
 
 	// compose value of "name|date|value". Pipe is used while Decode
-	b = []byte(fmt.Sprintf("%s|%d|%s|", m.Options.Name, currentTimestamp(), b))
+	b = []byte(fmt.Sprintf("%s|%d|%s|", m.Options.Name, m.currentTimestamp(), b))
 
 	// Sign it if enabled
-	if len(m.key.sign) > 0 {
-		signed := acrypto.Sign(m.key.sign, b[:len(b)-1], m.sha)
+	if len(m.keys) > 0 {
+		signed := acrypto.Sign(m.keys[0].sign, b[:len(b)-1], m.sha)
 
 		// Append signed value
 		b = append(b, signed...)
@@ -210,6 +345,10 @@ func (m *Manager) Decode(value string) ([]byte, error) {
 		return nil, ErrCookieValueIsTooLarge
 	}
 
+	if len(m.aeads) > 0 {
+		return m.decodeAEAD(value)
+	}
+
 	// Decode base64
 	b, err := ess.DecodeBase64([]byte(value))
 	if err != nil {
@@ -225,34 +364,18 @@ func (m *Manager) Decode(value string) ([]byte, error) {
 	b = append([]byte(m.Options.Name+"|"), b[:len(b)-len(parts[2])-1]...)
 
 	// Verify signed data, if enabled
-	var oldKey bool
-	if len(m.key.sign) > 0 {
-		if !acrypto.Verify(m.key.sign, b, parts[2], m.sha) {
-			if len(m.oldKey.sign) > 0 {
-				oldKey = true
-				if !acrypto.Verify(m.oldKey.sign, b, parts[2], m.sha) {
-					err = ErrSignVerificationIsFailed
-				}
-			} else {
-				err = ErrSignVerificationIsFailed
-			}
+	var k *key
+	if len(m.keys) > 0 {
+		idx := acrypto.VerifyAny(m.signKeys, b, parts[2], m.sha)
+		if idx == -1 {
+			return nil, ErrSignVerificationIsFailed
 		}
-	}
-	if err != nil {
-		return nil, err
+		k = m.keys[idx]
 	}
 
 	// Verify timestamp
-	var t1 int64
-	if t1, err = strconv.ParseInt(string(parts[0]), 10, 64); err != nil {
-		return nil, ErrCookieInvaildTimestamp
-	}
-	t2 := currentTimestamp()
-	if t1 > t2 {
-		return nil, ErrCookieTimestampIsTooNew
-	}
-	if m.Options.MaxAge != 0 && t1 < t2-m.Options.MaxAge {
-		return nil, ErrCookieTimestampIsExpired
+	if err = m.verifyTimestamp(parts[0]); err != nil {
+		return nil, err
 	}
 
 	// Decode
@@ -260,12 +383,8 @@ func (m *Manager) Decode(value string) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	if len(m.key.enc) > 0 { // Decrypt
-		if oldKey {
-			b, err = acrypto.AESDecrypt(m.oldKey.cipherBlock, b)
-		} else {
-			b, err = acrypto.AESDecrypt(m.key.cipherBlock, b)
-		}
+	if k != nil { // Decrypt
+		b, err = acrypto.AESDecrypt(k.cipherBlock, b)
 	}
 
 	return b, err
@@ -275,7 +394,82 @@ func (m *Manager) Decode(value string) ([]byte, error) {
 // Unexported methods
 //___________________________________
 
+// encodeAEAD method encrypts the value of "date|value" with cookie name
+// as additional data, so the value cannot be moved into another cookie.
+func (m *Manager) encodeAEAD(b []byte) (string, error) {
+	b = append([]byte(strconv.FormatInt(m.currentTimestamp(), 10)+"|"), b...)
+	b = ess.EncodeToBase64(acrypto.AESGCMEncrypt(m.aeads[0], b, []byte(m.Options.Name)))
+
+	// Check cookie max size.
+	if len(b) > m.maxCookieSize {
+		return "", ErrCookieValueIsTooLarge
+	}
+
+	return string(b), nil
+}
+
+func (m *Manager) decodeAEAD(value string) ([]byte, error) {
+	b, err := ess.DecodeBase64([]byte(value))
+	if err != nil {
+		return nil, err
+	}
+
+	plain, err := acrypto.AESGCMDecryptAny(m.aeads, b, []byte(m.Options.Name))
+	if err != nil {
+		return nil, ErrUnableToDecrypt
+	}
+
+	// value is "date|value"
+	parts := bytes.SplitN(plain, []byte("|"), 2)
+	if len(parts) != 2 {
+		return nil, ErrCookieValueIsInvalid
+	}
+	if err = m.verifyTimestamp(parts[0]); err != nil {
+		return nil, err
+	}
+
+	return parts[1], nil
+}
+
+func (m *Manager) verifyTimestamp(ts []byte) error {
+	t1, err := strconv.ParseInt(string(ts), 10, 64)
+	if err != nil {
+		return ErrCookieInvaildTimestamp
+	}
+	t2 := m.currentTimestamp()
+	if t1 > t2 {
+		return ErrCookieTimestampIsTooNew
+	}
+	if m.Options.MaxAge != 0 && t1 < t2-m.Options.MaxAge {
+		return ErrCookieTimestampIsExpired
+	}
+	return nil
+}
+
+const defaultKeyPrefix = "security.cookie"
+
+// resolveKey method returns the config key of given key prefix if exists
+// otherwise key of application cookie defaults.
+func resolveKey(cfg *config.Config, keyPrefix, key string) string {
+	if len(keyPrefix) > 0 {
+		if _, found := cfg.Get(keyPrefix + "." + key); found {
+			return keyPrefix + "." + key
+		}
+	}
+	return defaultKeyPrefix + "." + key
+}
+
+// hasKey method returns true if any of the given keys is not empty.
+func hasKey(keys []string) bool {
+	for _, k := range keys {
+		if !ess.IsStrEmpty(k) {
+			return true
+		}
+	}
+	return false
+}
+
 // currentTimestamp method return current UTC time in unix format.
-func currentTimestamp() int64 {
-	return time.Now().UTC().Unix()
+func (m *Manager) currentTimestamp() int64 {
+	return m.Options.now().UTC().Unix()
 }
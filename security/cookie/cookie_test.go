@@ -5,11 +5,15 @@
 package cookie
 
 import (
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"aahframe.work/config"
 	"aahframe.work/essentials"
+	"aahframe.work/security/acrypto"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -29,6 +33,37 @@ func TestCookieNew(t *testing.T) {
 	opts.MaxAge = -1
 	cookie = NewWithOptions("This is my cookie for maxage -1", opts)
 	assert.Equal(t, -1, cookie.MaxAge)
+
+	now := time.Date(2018, time.October, 10, 10, 10, 10, 0, time.UTC)
+	opts.MaxAge = 3600
+	opts.Now = func() time.Time { return now }
+	cookie = NewWithOptions("This is my cookie for custom time source", opts)
+	assert.Equal(t, now.Add(time.Hour), cookie.Expires)
+}
+
+func TestCookieTimeSource(t *testing.T) {
+	now := time.Date(2018, time.October, 10, 10, 10, 10, 0, time.UTC)
+	m, err := NewManager(&Options{
+		Name:   "aah_cookie",
+		MaxAge: 1800,
+		Now:    func() time.Time { return now },
+	}, "eFWLXEewECptbDVXExokRTLONWxrTjfV", "KYqklJsgeclPpZutTeQKNOTWlpksRBwA")
+	assert.Nil(t, err)
+
+	value, err := m.Encode([]byte("cookie value"))
+	assert.Nil(t, err)
+
+	b, err := m.Decode(value)
+	assert.Nil(t, err)
+	assert.Equal(t, "cookie value", string(b))
+
+	now = now.Add(31 * time.Minute)
+	_, err = m.Decode(value)
+	assert.Equal(t, ErrCookieTimestampIsExpired, err)
+
+	now = now.Add(-2 * time.Hour)
+	_, err = m.Decode(value)
+	assert.Equal(t, ErrCookieTimestampIsTooNew, err)
 }
 
 func TestCookieWithNoKeys(t *testing.T) {
@@ -143,3 +178,180 @@ func TestCookieWithKeysRotation(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Equal(t, ErrSignVerificationIsFailed, err)
 }
+
+func TestCookieWithEmptyActiveKey(t *testing.T) {
+	opts := &Options{Name: "aah", MaxAge: 1800}
+
+	cm, err := NewManager(opts, "", "", "eFWLXEewECptbDVXExokRTLONWxrTold", "KYqklJsgeclPpZutTeQKNOTWlpksRold")
+	assert.Nil(t, cm)
+	assert.Equal(t, ErrActiveKeyIsEmpty, err)
+
+	cm, err = NewManager(opts, "eFWLXEewECptbDVXExokRTLONWxrTjfV", "", "eFWLXEewECptbDVXExokRTLONWxrTold", "KYqklJsgeclPpZutTeQKNOTWlpksRold")
+	assert.Nil(t, cm)
+	assert.Equal(t, ErrActiveKeyIsEmpty, err)
+
+	// empty retired pair is skipped
+	cm, err = NewManager(opts, "eFWLXEewECptbDVXExokRTLONWxrTjfV", "KYqklJsgeclPpZutTeQKNOTWlpksRBwA", "", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(cm.keys))
+
+	// no keys configured
+	cm, err = NewManager(opts, "", "", "", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(cm.keys))
+}
+
+func TestCookieNewOptions(t *testing.T) {
+	cfg, _ := config.ParseString(`
+	server {
+	  ssl {
+	    enable = true
+	  }
+	}
+	security {
+	  cookie {
+	    domain = "example.com"
+	    samesite = "Lax"
+	  }
+	  session {
+	    path = "/app"
+	    secure = false
+	  }
+	}`)
+
+	opts, err := NewOptions(cfg, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "example.com", opts.Domain)
+	assert.Equal(t, "/", opts.Path)
+	assert.True(t, opts.HTTPOnly)
+	assert.True(t, opts.Secure)
+	assert.True(t, opts.SecureAuto)
+	assert.Equal(t, "lax", opts.SameSite)
+	assert.Equal(t, http.SameSiteLaxMode, opts.SameSiteMode())
+
+	opts, err = NewOptions(cfg, "security.session")
+	assert.Nil(t, err)
+	assert.Equal(t, "example.com", opts.Domain)
+	assert.Equal(t, "/app", opts.Path)
+	assert.False(t, opts.Secure)
+	assert.False(t, opts.SecureAuto)
+
+	cfg.SetString("security.cookie.secure", "always")
+	_, err = NewOptions(cfg, "")
+	assert.Equal(t, "'security.cookie.secure' unsupported value: always", err.Error())
+
+	cfg.SetString("security.cookie.secure", "auto")
+	cfg.SetString("security.session.samesite", "loose")
+	_, err = NewOptions(cfg, "security.session")
+	assert.Equal(t, "'security.session.samesite' unsupported value: loose", err.Error())
+}
+
+func TestCookieWithEncryptKeys(t *testing.T) {
+	opts := &Options{Name: "aah_session", MaxAge: 3600}
+	oldKey, newKey := "KYqklJsgeclPpZutTeQKNOTWlpksRold", "KYqklJsgeclPpZutTeQKNOTWlpksRnew"
+
+	cmo, _ := NewManager(opts)
+	assert.Nil(t, cmo.SetEncryptKeys(oldKey))
+
+	value := "im the cookie with non-public data"
+	encodeValue, err := cmo.Encode([]byte(value))
+	assert.Nil(t, err)
+	raw, _ := ess.DecodeBase64([]byte(encodeValue))
+	assert.False(t, strings.Contains(string(raw), value))
+
+	r1, err := cmo.Decode(encodeValue)
+	assert.Nil(t, err)
+	assert.Equal(t, value, string(r1))
+
+	// key rotation, newest key is used for encryption
+	cmr, _ := NewManager(opts)
+	assert.Nil(t, cmr.SetEncryptKeys(newKey, oldKey))
+	r2, err := cmr.Decode(encodeValue)
+	assert.Nil(t, err)
+	assert.Equal(t, value, string(r2))
+
+	encodeValue, _ = cmr.Encode([]byte(value))
+	_, err = cmo.Decode(encodeValue)
+	assert.Equal(t, ErrUnableToDecrypt, err)
+
+	// tampered
+	b, _ := ess.DecodeBase64([]byte(encodeValue))
+	b[len(b)-1] ^= 0x01
+	_, err = cmr.Decode(string(ess.EncodeToBase64(b)))
+	assert.Equal(t, ErrUnableToDecrypt, err)
+
+	// cookie name is bound to the value
+	cmf, _ := NewManager(&Options{Name: "aah_flash", MaxAge: 3600})
+	_ = cmf.SetEncryptKeys(newKey)
+	_, err = cmf.Decode(encodeValue)
+	assert.Equal(t, ErrUnableToDecrypt, err)
+
+	// expired
+	aead, _ := acrypto.AESGCM([]byte(newKey))
+	expired := ess.EncodeToBase64(acrypto.AESGCMEncrypt(aead, []byte("1|"+value), []byte("aah_session")))
+	_, err = cmr.Decode(string(expired))
+	assert.Equal(t, ErrCookieTimestampIsExpired, err)
+
+	// invalid key
+	err = cmr.SetEncryptKeys(newKey, "short")
+	assert.Equal(t, "security/cookie: encrypt key #2: crypto/aes: invalid key size 5", err.Error())
+
+	// keys from config
+	cfg, _ := config.ParseString(`
+	security {
+	  cookie {
+	    encrypt_key = "` + newKey + `"
+	  }
+	  anti_csrf {
+	    encrypt_key = ["` + newKey + `", "` + oldKey + `"]
+	  }
+	}`)
+	assert.Equal(t, []string{newKey}, EncryptKeys(cfg, "security.session"))
+	assert.Equal(t, []string{newKey, oldKey}, EncryptKeys(cfg, "security.anti_csrf"))
+	assert.Nil(t, EncryptKeys(config.NewEmpty(), "security.session"))
+}
+
+func TestCookieKeysFromConfig(t *testing.T) {
+	cfg, _ := config.ParseString(`
+	security {
+	  session {
+	    sign_key = "eFWLXEewECptbDVXExokRTLONWxrTjfV"
+	    enc_key = "KYqklJsgeclPpZutTeQKNOTWlpksRBwA"
+	  }
+	  anti_csrf {
+	    sign_keys = ["eFWLXEewECptbDVXExokRTLONWxrTnew", "eFWLXEewECptbDVXExokRTLONWxrTmid", "eFWLXEewECptbDVXExokRTLONWxrTold"]
+	    enc_keys = ["KYqklJsgeclPpZutTeQKNOTWlpksRnew", "KYqklJsgeclPpZutTeQKNOTWlpksRmid", "KYqklJsgeclPpZutTeQKNOTWlpksRold"]
+	  }
+	  webhook {
+	    sign_keys = ["eFWLXEewECptbDVXExokRTLONWxrTnew"]
+	  }
+	}`)
+
+	keys, err := Keys(cfg, "security.session")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"eFWLXEewECptbDVXExokRTLONWxrTjfV", "KYqklJsgeclPpZutTeQKNOTWlpksRBwA", "", ""}, keys)
+
+	keys, err = Keys(cfg, "security.anti_csrf")
+	assert.Nil(t, err)
+	assert.Equal(t, 6, len(keys))
+
+	_, err = Keys(cfg, "security.webhook")
+	assert.Equal(t, "'security.webhook.sign_keys' and 'security.webhook.enc_keys' should have same no. of keys", err.Error())
+
+	// value signed with the oldest key is verified by rotated keys
+	opts := &Options{Name: "aah_anti_csrf", MaxAge: 3600}
+	cmo, _ := NewManager(opts, keys[4:]...)
+	encodeValue, err := cmo.Encode([]byte("im the cookie signed with old key"))
+	assert.Nil(t, err)
+
+	cmr, err := NewManager(opts, keys...)
+	assert.Nil(t, err)
+	r, err := cmr.Decode(encodeValue)
+	assert.Nil(t, err)
+	assert.Equal(t, "im the cookie signed with old key", string(r))
+
+	// retired key
+	cmn, _ := NewManager(opts, keys[:4]...)
+	_, err = cmn.Decode(encodeValue)
+	assert.Equal(t, ErrSignVerificationIsFailed, err)
+}
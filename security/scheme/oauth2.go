@@ -75,6 +75,7 @@ type OAuth2 struct {
 	signSha         string
 	signKey         []byte
 	oauthCfg        *oauth2.Config
+	now             func() time.Time
 }
 
 // Init method initialize the OAuth2 auth scheme during an application start.
@@ -207,12 +208,18 @@ func (o *OAuth2) Principal(keyName string, v ess.Valuer) ([]*authc.Principal, er
 	return o.principalProvider.Principal(keyName, v)
 }
 
+// SetNowFunc method sets the time source of OAuth2 state key expiry, for
+// e.g.: aah application clock. Nil value resets it to `time.Now`.
+func (o *OAuth2) SetNowFunc(fn func() time.Time) {
+	o.now = fn
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // OAuth2 Unexported methods
 //______________________________________________________________________________
 
 func (o *OAuth2) generateStateKey() (string, string) {
-	state := ess.SecureRandomString(32) + ":" + fmt.Sprintf("%v", time.Now().UTC().UnixNano())
+	state := ess.SecureRandomString(32) + ":" + fmt.Sprintf("%v", o.currentTime().UTC().UnixNano())
 	return state, base64.RawURLEncoding.EncodeToString(acrypto.Sign(o.signKey, []byte(state), o.signSha))
 }
 
@@ -228,10 +235,17 @@ func (o *OAuth2) validateStateKey(state, signedState string) bool {
 
 	// check duration, aah state key is only valid for 10 minutes
 	utcNano, _ := strconv.ParseInt(state[33:], 10, 64)
-	min := time.Now().UTC().Sub(time.Unix(0, utcNano).UTC()).Minutes()
+	min := o.currentTime().UTC().Sub(time.Unix(0, utcNano).UTC()).Minutes()
 	return int(min) <= 10
 }
 
+func (o *OAuth2) currentTime() time.Time {
+	if o.now == nil {
+		return time.Now()
+	}
+	return o.now()
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Package Unexported methods
 //______________________________________________________________________________
@@ -182,6 +182,14 @@ func TestOAuth2SignAndVerify(t *testing.T) {
 	tstr := fmt.Sprintf("%v", time.Now().UTC().Truncate(time.Minute*20).UnixNano())
 	result = oauth.validateStateKey(state[:33]+":"+tstr, stateSigned)
 	assert.False(t, result)
+
+	now := time.Date(2018, time.October, 10, 10, 10, 10, 0, time.UTC)
+	oauth.SetNowFunc(func() time.Time { return now })
+	state, stateSigned = oauth.generateStateKey()
+	now = now.Add(10 * time.Minute)
+	assert.True(t, oauth.validateStateKey(state, stateSigned))
+	now = now.Add(time.Minute)
+	assert.False(t, oauth.validateStateKey(state, stateSigned))
 }
 
 func TestOAuth2LifeCycle(t *testing.T) {
@@ -178,6 +178,25 @@ func (m *Manager) AuthSchemes() map[string]scheme.Schemer {
 	return m.authSchemes
 }
 
+// SetNowFunc method sets the time source of session, Anti-CSRF and auth
+// schemes supporting it, for e.g.: aah application clock. Nil value resets
+// it to `time.Now`.
+func (m *Manager) SetNowFunc(fn func() time.Time) {
+	if m.SessionManager != nil {
+		m.SessionManager.SetNowFunc(fn)
+	}
+	if m.AntiCSRF != nil {
+		m.AntiCSRF.SetNowFunc(fn)
+	}
+	for _, s := range m.authSchemes {
+		if ns, ok := s.(interface {
+			SetNowFunc(fn func() time.Time)
+		}); ok {
+			ns.SetNowFunc(fn)
+		}
+	}
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Manager Unexported methods
 //___________________________________
@@ -101,30 +101,32 @@ func NewManager(appCfg *config.Config) (*Manager, error) {
 	m.idLength = m.cfg.IntDefault(keyPrefix+".id_length", 32)
 
 	// Cookie Options
-	opts := &cookie.Options{
-		Name:     m.cfg.StringDefault(keyPrefix+".prefix", "aah") + "_session",
-		Domain:   m.cfg.StringDefault(keyPrefix+".domain", ""),
-		Path:     m.cfg.StringDefault(keyPrefix+".path", "/"),
-		HTTPOnly: m.cfg.BoolDefault(keyPrefix+".http_only", true),
-		// Based on aah server SSL configuration `http.Cookie.Secure` value is set
-		Secure:   m.cfg.BoolDefault("server.ssl.enable", false),
-		SameSite: m.cfg.StringDefault(keyPrefix+".samesite", ""),
+	// Based on application cookie defaults `security.cookie.*`
+	opts, err := cookie.NewOptions(m.cfg, keyPrefix)
+	if err != nil {
+		return nil, err
 	}
+	opts.Name = m.cfg.StringDefault(keyPrefix+".prefix", "aah") + "_session"
 
 	// TTL value
 	if opts.MaxAge, err = toSeconds(m.cfg.StringDefault(keyPrefix+".ttl", "0m")); err != nil {
 		return nil, err
 	}
 
-	m.cookieMgr, err = cookie.NewManager(opts,
-		m.cfg.StringDefault(keyPrefix+".sign_key", ""),
-		m.cfg.StringDefault(keyPrefix+".enc_key", ""),
-		m.cfg.StringDefault(keyPrefix+".old_sign_key", ""),
-		m.cfg.StringDefault(keyPrefix+".old_enc_key", ""),
-	)
+	keys, err := cookie.Keys(m.cfg, keyPrefix)
 	if err != nil {
 		return nil, err
 	}
+	if m.cookieMgr, err = cookie.NewManager(opts, keys...); err != nil {
+		return nil, err
+	}
+
+	// Authenticated encryption of session cookie value
+	if keys := cookie.EncryptKeys(m.cfg, keyPrefix); len(keys) > 0 {
+		if err = m.cookieMgr.SetEncryptKeys(keys...); err != nil {
+			return nil, err
+		}
+	}
 
 	// Cleanup
 	if m.cleanupInterval, err = toSeconds(m.cfg.StringDefault(keyPrefix+".cleanup_interval", "30m")); err != nil {
@@ -166,7 +168,11 @@ func (m *Manager) NewSession() *Session {
 	s := sessionPool.Get().(*Session)
 	s.ID = ess.SecureRandomString(m.idLength)
 	s.IsNew = true
-	t := time.Now()
+	now := m.cookieMgr.Options.Now
+	if now == nil {
+		now = time.Now
+	}
+	t := now()
 	s.CreatedTime = &t
 	return s
 }
@@ -323,6 +329,13 @@ func (m *Manager) IsPath(p string) bool {
 	return strings.HasPrefix(p, m.cookieMgr.Options.Path)
 }
 
+// SetNowFunc method sets the time source of session created time, session
+// cookie expiry and timestamp, for e.g.: aah application clock. Nil value
+// resets it to `time.Now`.
+func (m *Manager) SetNowFunc(fn func() time.Time) {
+	m.cookieMgr.Options.Now = fn
+}
+
 // ReleaseSession method puts session object back to pool.
 func ReleaseSession(s *Session) {
 	if s != nil {
@@ -15,12 +15,26 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"aahframe.work/ahttp"
 	"aahframe.work/essentials"
+	"aahframe.work/internal/connlimit"
+	"aahframe.work/internal/proxyproto"
 	"aahframe.work/internal/settings"
+	"aahframe.work/internal/util"
+	"aahframe.work/log"
 )
 
+const defaultStartupBanner = `
+                 _
+   __ _   __ _  | |__
+  / _` + "`" + ` | / _` + "`" + ` | | '_ \
+ | (_| || (_| | | | | |
+  \__,_| \__,_| |_| |_|  framework
+`
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Application methods
 //______________________________________________________________________________
@@ -33,6 +47,8 @@ func (a *Application) Start() {
 		a.Log().Fatal("aah application is not initialized, call `aah.Init` before the `aah.Start`.")
 	}
 
+	a.printBanner()
+
 	sessionMode := "stateless"
 	if a.SessionManager().IsStateful() {
 		sessionMode = "stateful"
@@ -82,6 +98,15 @@ func (a *Application) Start() {
 	}
 	a.Log().Infof("App Shutdown Grace Timeout: %s", a.settings.ShutdownGraceTimeStr)
 
+	if err := a.validateWebhookRoutes(); err != nil {
+		a.Log().Fatal(err)
+		return
+	}
+
+	for _, w := range a.he.validateMiddlewares() {
+		a.Log().Warnf("Middleware order: %s", w)
+	}
+
 	if a.Log().IsLevelDebug() {
 		a.Log().Debug("Subscribed event callbacks")
 		for _, event := range []string{EventOnInit, EventOnStart, EventOnPreShutdown, EventOnPostShutdown, EventOnConfigHotReload} {
@@ -91,35 +116,103 @@ func (a *Application) Start() {
 		}
 	}
 
+	network, address := a.listenAddress()
+	if network != "unix" && a.diagnosis != nil && a.diagnosis.IsHTTPMode() {
+		a.Log().Infof("aah go diagnosis server running on %s",
+			a.diagnosis.Config.StringDefault("runtime.diagnosis.http.address", ":7070"))
+	}
+
+	l, err := a.listen(network, address)
+	if err != nil {
+		a.Log().Fatal(err)
+		return
+	}
+
+	if network != "unix" {
+		if l, err = a.wrapConnLimit(l); err != nil {
+			a.Log().Fatal(err)
+			return
+		}
+		if l, err = a.wrapProxyProtocol(l); err != nil {
+			a.Log().Fatal(err)
+			return
+		}
+	}
+
+	a.Serve(l)
+}
+
+// Serve method runs the aah go server on the given listener, for e.g.:
+// TLS listener, proxy protocol wrapped listener, test listener, etc.
+// Method `Start` creates the listener based on aah config "server.*" and
+// calls this method.
+//
+// If TLS/SSL is enabled in the config, connections are served with TLS
+// using `server.ssl.*`. Unix socket listener is always served without TLS.
+func (a *Application) Serve(l net.Listener) {
+	defer a.aahRecover()
+
+	if !a.settings.Initialized {
+		a.Log().Fatal("aah application is not initialized, call `aah.Init` before the `aah.Serve`.")
+	}
+
 	// Publish `OnStart` event
 	a.EventStore().sortAndPublishSync(&Event{Name: EventOnStart})
 
+	// Start background workers registered via `Go`
+	a.startWorkers()
+
+	// Go HTTP server errors are routed into aah logger
 	hl := a.Log().ToGoLogger()
-	hl.SetOutput(ioutil.Discard)
+	hl.SetFlags(0)
+	if a.serverErrLog != nil {
+		hl.SetOutput(a.serverErrLog)
+	} else {
+		hl.SetOutput(ioutil.Discard)
+	}
 
-	a.server = &http.Server{
+	// Request contexts are derived from it, cancelled on shutdown drain
+	baseCtx, baseCancel := context.WithCancel(context.Background())
+	a.Lock()
+	a.baseCancel = baseCancel
+	a.Unlock()
+
+	srv := &http.Server{
+		Addr:           l.Addr().String(),
 		Handler:        a,
+		BaseContext:    func(net.Listener) context.Context { return baseCtx },
 		ReadTimeout:    a.settings.HTTPReadTimeout,
 		WriteTimeout:   a.settings.HTTPWriteTimeout,
+		IdleTimeout:    a.settings.HTTPIdleTimeout,
 		MaxHeaderBytes: a.settings.HTTPMaxHdrBytes,
 		ErrorLog:       hl,
 	}
+	a.Lock()
+	a.server = srv
+	a.Unlock()
 
 	a.server.SetKeepAlivesEnabled(a.Config().BoolDefault("server.keep_alive", true))
+	a.setupServer()
 	a.writePID()
+	a.startListeners(baseCtx)
 
 	go a.listenForHotReload()
+	go a.listenForGracefulRestart()
+
+	a.listener = l
+	if a.Config().BoolDefault("server.startup_summary", false) {
+		a.printStartupSummary()
+	}
+
+	// Notifies the parent process once ready, applicable to graceful restart
+	go a.notifyRestartReady()
 
 	// Unix Socket
-	if strings.HasPrefix(a.HTTPAddress(), "unix") {
+	if l.Addr().Network() == "unix" {
+		a.server.Addr = "unix:" + a.server.Addr
 		a.startUnix()
 		return
 	}
-	if a.diagnosis != nil && a.diagnosis.IsHTTPMode() {
-		a.Log().Infof("aah go diagnosis server running on %s",
-			a.diagnosis.Config.StringDefault("runtime.diagnosis.http.address", ":7070"))
-	}
-	a.server.Addr = fmt.Sprintf("%s:%s", a.HTTPAddress(), a.HTTPPort())
 
 	// HTTPS
 	if a.IsSSLEnabled() {
@@ -131,14 +224,46 @@ func (a *Application) Start() {
 	a.startHTTP()
 }
 
+// OnServerSetup method registers the callback to customize the Go HTTP server,
+// it is invoked after aah builds the `http.Server` and before it starts
+// serving. For e.g.: `ConnState`, `TLSNextProto`, `ErrorLog`, `IdleTimeout`.
+// Callbacks are invoked in the order of registration.
+//
+// Fields `Handler` and `Addr` are managed by aah, overriding them is not
+// supported and is reset to aah values.
+//
+//	aah.App().OnServerSetup(func(s *http.Server) {
+//	  s.IdleTimeout = 2 * time.Minute
+//	})
+func (a *Application) OnServerSetup(fn func(*http.Server)) {
+	if fn == nil {
+		return
+	}
+	a.Lock()
+	defer a.Unlock()
+	a.serverSetups = append(a.serverSetups, fn)
+}
+
 // Shutdown method allows aah server to shutdown gracefully with given timeout
 // in seconds. It's invoked on OS signal `SIGINT` and `SIGTERM`.
 //
 // Method performs:
+//    - Marks application state `draining`, readiness replies 503
 //    - Graceful server shutdown with timeout by `server.timeout.grace_shutdown`
+//    - Cancels the in-flight request contexts after `server.timeout.drain`,
+//      if configured, otherwise once the graceful shutdown completes
+//    - Stops background workers registered via `Go`
+//    - Executes shutdown hooks registered via `RegisterShutdownHook`
+//    - Marks application state `stopped`
 //    - Publishes `OnPostShutdown` event
 //    - Exits program with code 0
+//
+// Application state is `draining` prior to `OnPreShutdown` event, so the
+// callback could wait for load balancer to observe the readiness failure
+// before the server stops accepting new connections.
 func (a *Application) Shutdown() {
+	a.setState(AppStateDraining)
+
 	// Publish `OnPreShutdown` event
 	a.EventStore().sortAndPublishSync(&Event{Name: EventOnPreShutdown})
 
@@ -146,12 +271,39 @@ func (a *Application) Shutdown() {
 	defer cancel()
 
 	a.Log().Warn("aah go server graceful shutdown triggered with timeout of ", a.settings.ShutdownGraceTimeStr)
-	if err := a.server.Shutdown(ctx); err != nil && err != http.ErrServerClosed {
+	if a.settings.ShutdownDrainTimeout > 0 {
+		drain := time.AfterFunc(a.settings.ShutdownDrainTimeout, func() {
+			a.Log().Warnf("Shutdown drain timeout of %s elapsed, cancelling the in-flight request contexts", a.settings.ShutdownDrainTimeStr)
+			a.cancelRequests()
+		})
+		defer drain.Stop()
+	}
+	a.RLock()
+	srv, lss := a.server, a.listenerSrvs
+	a.RUnlock()
+	var wg sync.WaitGroup
+	for _, ls := range lss {
+		wg.Add(1)
+		go func(ls *listenerServer) {
+			defer wg.Done()
+			if err := ls.server.Shutdown(ctx); err != nil && err != http.ErrServerClosed {
+				a.Log().Errorf("Listener '%s': %v", ls.name, err)
+			}
+		}(ls)
+	}
+	if err := srv.Shutdown(ctx); err != nil && err != http.ErrServerClosed {
 		a.Log().Error(err)
 	}
+	wg.Wait()
+	a.cancelRequests()
 	a.shutdownRedirectServer()
 	a.Log().Info("aah go server shutdown successfully")
 
+	// Stop background workers and execute shutdown hooks after in-flight
+	// requests are drained
+	a.shutdownWorkersAndHooks()
+	a.setState(AppStateStopped)
+
 	// Publish `OnPostShutdown` event
 	a.EventStore().sortAndPublishSync(&Event{Name: EventOnPostShutdown})
 }
@@ -160,6 +312,57 @@ func (a *Application) Shutdown() {
 // app Unexported methods
 //______________________________________________________________________________
 
+// cancelRequests method cancels the context of in-flight requests, so the
+// long-lived requests (for e.g.: SSE, long-poll) observing the request
+// context could terminate promptly instead of blocking the shutdown.
+func (a *Application) cancelRequests() {
+	a.RLock()
+	cancel := a.baseCancel
+	a.RUnlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (a *Application) setupServer() {
+	a.RLock()
+	fns := a.serverSetups
+	a.RUnlock()
+
+	addr := a.server.Addr
+	for _, fn := range fns {
+		fn(a.server)
+	}
+	if a.server.Handler != http.Handler(a) || a.server.Addr != addr {
+		a.Log().Warn("'Handler' and 'Addr' of server are managed by aah, overridden values are reset")
+		a.server.Handler, a.server.Addr = a, addr
+	}
+
+	// connection counts and rejects are tracked, user provided hooks are chained
+	ct := &connTracker{}
+	a.Lock()
+	a.connTracker = ct
+	a.Unlock()
+	connState := a.server.ConnState
+	a.server.ConnState = func(c net.Conn, state http.ConnState) {
+		if ct.track(c, state) {
+			a.Log().Warnf("Connection closed without handling the request, likely rejected by Go HTTP "+
+				"server (header exceeds 'server.max_header_bytes', malformed request or read timeout), Remote: %s",
+				c.RemoteAddr())
+		}
+		if connState != nil {
+			connState(c, state)
+		}
+	}
+	connContext := a.server.ConnContext
+	a.server.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		if connContext != nil {
+			ctx = connContext(ctx, c)
+		}
+		return ct.connContext(ctx, c)
+	}
+}
+
 func (a *Application) writePID() {
 	// Get the application PID
 	a.settings.Pid = os.Getpid()
@@ -179,20 +382,8 @@ func (a *Application) writePID() {
 }
 
 func (a *Application) startUnix() {
-	sockFile := a.HTTPAddress()[5:]
-	if err := os.Remove(sockFile); !os.IsNotExist(err) {
-		a.Log().Fatal(err)
-	}
-
-	listener, err := net.Listen("unix", sockFile)
-	if err != nil {
-		a.Log().Fatal(err)
-		return
-	}
-
-	a.server.Addr = a.HTTPAddress()
 	a.Log().Infof("aah go server running on %v", a.server.Addr)
-	if err := a.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+	if err := a.server.Serve(a.listener); err != nil && err != http.ErrServerClosed {
 		a.Log().Error(err)
 	}
 }
@@ -208,6 +399,10 @@ func (a *Application) startHTTPS() {
 			a.Log().Info("Adding user provided TLS Config")
 			a.server.TLSConfig = a.tlsCfg
 		}
+		if err := a.setupDomainCertificates(); err != nil {
+			a.Log().Error(err)
+			return
+		}
 		a.Log().Infof("SSLCert: %s, SSLKey: %s", a.settings.SSLCert, a.settings.SSLKey)
 	}
 
@@ -233,18 +428,194 @@ func (a *Application) startHTTPS() {
 	go a.startHTTPRedirect()
 
 	a.printStartupNote()
-	if err := a.server.ListenAndServeTLS(a.settings.SSLCert, a.settings.SSLKey); err != nil && err != http.ErrServerClosed {
+	if err := a.server.ServeTLS(a.listener, a.settings.SSLCert, a.settings.SSLKey); err != nil && err != http.ErrServerClosed {
 		a.Log().Error(err)
 	}
 }
 
+// setupDomainCertificates method adds the SNI based certificate selection for
+// domains having `ssl.cert` and `ssl.key` in routes.conf. Certificate is
+// chosen by TLS handshake server name (before routing) using the domain host,
+// wildcard host `*.sample.com` matches its subdomains. Otherwise it falls back
+// to user provided `GetCertificate` then `server.ssl.cert` and `server.ssl.key`.
+func (a *Application) setupDomainCertificates() error {
+	certs := make(map[string]*tls.Certificate)
+	for _, d := range a.Router().Domains {
+		if len(d.SSLCert) == 0 && len(d.SSLKey) == 0 {
+			continue
+		}
+		cert, err := tls.LoadX509KeyPair(d.SSLCert, d.SSLKey)
+		if err != nil {
+			return fmt.Errorf("domain '%s' ssl cert: %s", d.Key, err)
+		}
+		certs[strings.ToLower(d.Host)] = &cert
+		a.Log().Infof("Domain: %s, SSLCert: %s, SSLKey: %s", d.Key, d.SSLCert, d.SSLKey)
+	}
+	if len(certs) == 0 {
+		return nil
+	}
+
+	if a.server.TLSConfig == nil {
+		a.server.TLSConfig = new(tls.Config)
+	} else {
+		a.server.TLSConfig = a.server.TLSConfig.Clone()
+	}
+	getCert := a.server.TLSConfig.GetCertificate
+	a.server.TLSConfig.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		name := strings.ToLower(hello.ServerName)
+		if cert, found := certs[name]; found {
+			return cert, nil
+		}
+		if idx := strings.IndexByte(name, '.'); idx > 0 {
+			if cert, found := certs["*"+name[idx:]]; found {
+				return cert, nil
+			}
+		}
+		if getCert != nil {
+			return getCert(hello)
+		}
+		return nil, nil
+	}
+	return nil
+}
+
+// startListeners method starts the additional server listeners configured via
+// `server.listeners.<name>`. They serve the same application handler over
+// HTTP with their own read, write and idle timeouts, for e.g.: internal admin
+// listener with longer timeouts for long-poll endpoints.
+func (a *Application) startListeners(baseCtx context.Context) {
+	inherited, err := inheritedNamedListeners()
+	if err != nil {
+		a.Log().Error(err)
+	}
+	for _, ls := range a.settings.Listeners {
+		l := inherited[ls.Name]
+		if l == nil {
+			if l, err = net.Listen(a.settings.Network, ls.Address); err != nil {
+				a.Log().Errorf("Unable to start listener '%s': %v", ls.Name, err)
+				continue
+			}
+		}
+
+		// write timeout of listener is propagated as request context deadline
+		lctx := context.WithValue(baseCtx, listenerWriteTimeoutKey{}, ls.WriteTimeout)
+		srv := &http.Server{
+			Addr:           l.Addr().String(),
+			Handler:        a,
+			BaseContext:    func(net.Listener) context.Context { return lctx },
+			ReadTimeout:    ls.ReadTimeout,
+			WriteTimeout:   ls.WriteTimeout,
+			IdleTimeout:    ls.IdleTimeout,
+			MaxHeaderBytes: a.settings.HTTPMaxHdrBytes,
+			ErrorLog:       a.server.ErrorLog,
+		}
+		srv.SetKeepAlivesEnabled(a.Config().BoolDefault("server.keep_alive", true))
+		a.Lock()
+		a.listenerSrvs = append(a.listenerSrvs, &listenerServer{name: ls.Name, server: srv, l: l})
+		a.Unlock()
+
+		a.Log().Infof("aah go server listener '%s' running on %s", ls.Name, srv.Addr)
+		go func(name string) {
+			if err := srv.Serve(l); err != nil && err != http.ErrServerClosed {
+				a.Log().Errorf("Listener '%s': %v", name, err)
+			}
+		}(ls.Name)
+	}
+}
+
 func (a *Application) startHTTP() {
 	a.printStartupNote()
-	if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if err := a.server.Serve(a.listener); err != nil && err != http.ErrServerClosed {
 		a.Log().Error(err)
 	}
 }
 
+// listen method returns the listener inherited from parent process on
+// graceful restart otherwise creates a new listener for given network
+// and address. Stale unix socket file is removed only for the new listener,
+// since inherited listener is bound to it.
+func (a *Application) listen(network, address string) (net.Listener, error) {
+	l, err := inheritedListener()
+	if err != nil {
+		return nil, err
+	}
+	if l != nil {
+		a.Log().Infof("Using inherited listener from parent process: %s", l.Addr())
+		return l, nil
+	}
+	if network == "unix" {
+		if err = os.Remove(address); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return net.Listen(network, address)
+}
+
+// listenAddress method returns the listener network and address based on
+// `server.network`, `server.address` and `server.port`. For unix socket
+// address, network is `unix` and address is socket file path.
+func (a *Application) listenAddress() (string, string) {
+	address := a.HTTPAddress()
+	if strings.HasPrefix(address, "unix") {
+		return "unix", address[5:]
+	}
+	return a.settings.Network, net.JoinHostPort(strings.Trim(address, "[]"), a.HTTPPort())
+}
+
+// wrapProxyProtocol method wraps the given listener with PROXY protocol
+// listener if `server.proxy_protocol.enable` is true.
+func (a *Application) wrapProxyProtocol(l net.Listener) (net.Listener, error) {
+	keyPrefix := "server.proxy_protocol"
+	if !a.Config().BoolDefault(keyPrefix+".enable", false) {
+		return l, nil
+	}
+
+	trustedProxies, _ := a.Config().StringList(keyPrefix + ".trusted_proxies")
+	trusted, err := proxyproto.ParseCIDRs(trustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("'%s.trusted_proxies' %s", keyPrefix, err)
+	}
+
+	headerTimeoutStr := a.Config().StringDefault(keyPrefix+".header_timeout", "5s")
+	headerTimeout, err := time.ParseDuration(headerTimeoutStr)
+	if err != nil || !util.IsValidTimeUnit(headerTimeoutStr, "ms", "s", "m") {
+		return nil, fmt.Errorf("'%s.header_timeout' value is not a valid time unit", keyPrefix)
+	}
+
+	a.Log().Infof("PROXY protocol enabled, trusted proxies: %s", strings.Join(trustedProxies, ", "))
+	return proxyproto.NewListener(l, trusted, headerTimeout), nil
+}
+
+// wrapConnLimit method wraps the given listener with per IP connection limit
+// listener if `server.max_conns_per_ip.limit` is greater than zero. It wraps
+// the raw listener, so the limit applies to TCP peer address, i.e. PROXY
+// protocol client address is not considered.
+func (a *Application) wrapConnLimit(l net.Listener) (net.Listener, error) {
+	keyPrefix := "server.max_conns_per_ip"
+	limit := a.Config().IntDefault(keyPrefix+".limit", 0)
+	if limit <= 0 {
+		return l, nil
+	}
+
+	trustedProxies, _ := a.Config().StringList(keyPrefix + ".trusted_proxies")
+	trusted, err := proxyproto.ParseCIDRs(trustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("'%s.trusted_proxies' %s", keyPrefix, err)
+	}
+
+	ipv6Prefix := a.Config().IntDefault(keyPrefix+".ipv6_prefix", connlimit.DefaultIPv6Prefix)
+	if ipv6Prefix < 1 || ipv6Prefix > 128 {
+		return nil, fmt.Errorf("'%s.ipv6_prefix' unsupported value: %d", keyPrefix, ipv6Prefix)
+	}
+
+	cl := connlimit.NewListener(l, limit, trusted, ipv6Prefix)
+	cl.OnReject = func(addr net.Addr) {
+		a.Log().Debugf("Connection rejected, max connections per IP (%d) reached, Remote: %s", limit, addr)
+	}
+	a.Log().Infof("Max connections per IP: %d, IPv6 prefix: /%d", limit, ipv6Prefix)
+	return cl, nil
+}
+
 func (a *Application) startHTTPRedirect() {
 	cfg := a.Config()
 	keyPrefix := "server.ssl.redirect_http"
@@ -268,7 +639,7 @@ func (a *Application) startHTTPRedirect() {
 
 	a.Log().Infof("aah go redirect server running on %s:%s", address, fromPort)
 	a.redirectServer = &http.Server{
-		Addr: address + ":" + fromPort,
+		Addr: net.JoinHostPort(strings.Trim(address, "[]"), fromPort),
 		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if r.Method != ahttp.MethodGet && r.Method != ahttp.MethodHead {
 				http.Error(w, "Use HTTPS", http.StatusBadRequest)
@@ -279,7 +650,18 @@ func (a *Application) startHTTPRedirect() {
 		}),
 	}
 
-	if err := a.redirectServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	l, err := inheritedRedirectListener()
+	if err == nil && l == nil {
+		l, err = net.Listen(a.settings.Network, a.redirectServer.Addr)
+	}
+	if err != nil {
+		a.Log().Error(err)
+		return
+	}
+	a.Lock()
+	a.redirectLn = l
+	a.Unlock()
+	if err = a.redirectServer.Serve(l); err != nil && err != http.ErrServerClosed {
 		a.Log().Error(err)
 	}
 }
@@ -297,6 +679,42 @@ func (a *Application) printStartupNote() {
 	a.Log().Infof("aah go server running on %s:%s", a.HTTPAddress(), a.parsePort(port))
 }
 
+// printBanner method logs the startup banner, custom banner text is
+// configured via `server.startup_banner.text`. By default it is disabled on
+// `prod` environment profile.
+func (a *Application) printBanner() {
+	if !a.Config().BoolDefault("server.startup_banner.enable", !a.IsEnvProfile("prod")) {
+		return
+	}
+	banner := a.Config().StringDefault("server.startup_banner.text", defaultStartupBanner)
+	for _, line := range strings.Split(strings.Trim(banner, "\n"), "\n") {
+		a.Log().Info(line)
+	}
+}
+
+// printStartupSummary method logs the one-shot summary of effective server
+// configuration with structured fields.
+func (a *Application) printStartupSummary() {
+	var routes int
+	for _, d := range a.Router().Domains {
+		routes += len(d.Routes())
+	}
+
+	var locales []string
+	if a.I18n() != nil {
+		locales = a.I18n().Locales()
+	}
+
+	a.Log().WithFields(log.Fields{
+		"network":     a.listener.Addr().Network(),
+		"address":     a.listener.Addr().String(),
+		"tls":         a.IsSSLEnabled() && a.listener.Addr().Network() != "unix",
+		"routes":      routes,
+		"locales":     strings.Join(locales, ", "),
+		"middlewares": strings.Join(a.he.MiddlewareNames(), " -> "),
+	}).Info("aah go server startup summary")
+}
+
 func parseHost(address, toPort string) string {
 	host, _, err := net.SplitHostPort(address)
 	if err != nil {
@@ -325,3 +743,21 @@ func inferRedirectMode(redirectTo string) string {
 	}
 	return www + " ==> " + nonwww
 }
+
+// listenerServer holds the Go HTTP server of additional server listener.
+type listenerServer struct {
+	name   string
+	server *http.Server
+	l      net.Listener
+}
+
+type listenerWriteTimeoutKey struct{}
+
+// writeTimeout method returns the write timeout of listener serving the given
+// request, falls back to `server.timeout.write`.
+func (a *Application) writeTimeout(r *http.Request) time.Duration {
+	if d, ok := r.Context().Value(listenerWriteTimeoutKey{}).(time.Duration); ok {
+		return d
+	}
+	return a.settings.HTTPWriteTimeout
+}
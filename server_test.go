@@ -5,13 +5,32 @@
 package aah
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
 	"net/http"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"aahframe.work/ahttp"
 	"aahframe.work/essentials"
+	"aahframe.work/internal/connlimit"
+	"aahframe.work/internal/proxyproto"
+	"aahframe.work/log"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -46,6 +65,270 @@ func TestServerStartUnix(t *testing.T) {
 	time.Sleep(10 * time.Millisecond)
 }
 
+func TestServerServeListener(t *testing.T) {
+	defer ess.DeleteFiles("webapp1.pid")
+
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	t.Logf("Test Server URL [Server Serve Listener]: http://%s", l.Addr())
+
+	go ts.app.Serve(l)
+	defer ts.app.Shutdown()
+
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = http.Get("http://" + l.Addr().String() + "/get-text.html"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, l.Addr().String(), ts.app.server.Addr)
+	assert.True(t, strings.Contains(responseBody(resp), "This is text render response"))
+}
+
+func TestServerIdleTimeout(t *testing.T) {
+	defer ess.DeleteFiles("webapp1.pid")
+
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"server.timeout.idle": "2m",
+	})
+	defer ts.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	go ts.app.Serve(l)
+	defer ts.app.Shutdown()
+
+	for i := 0; i < 50; i++ {
+		if _, err = http.Get("http://" + l.Addr().String() + "/get-text.html"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Nil(t, err)
+	ts.app.RLock()
+	assert.Equal(t, 2*time.Minute, ts.app.server.IdleTimeout)
+	ts.app.RUnlock()
+
+	a := newTestApp(t, importPath)
+	a.Config().SetString("server.timeout.idle", "2h")
+	err = a.settings.Refresh(a.Config())
+	assert.Equal(t, "'server.timeout.idle' value is not a valid time unit", err.Error())
+}
+
+func TestServerListeners(t *testing.T) {
+	defer ess.DeleteFiles("webapp1.pid")
+
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"server.timeout.idle":                  "2m",
+		"server.listeners.admin.address":       "127.0.0.1",
+		"server.listeners.admin.port":          "0",
+		"server.listeners.admin.timeout.write": "5m",
+		"server.listeners.admin.timeout.idle":  "10m",
+	})
+	defer ts.Close()
+
+	t.Log("Listener timeouts fall back to global values")
+	assert.Equal(t, 1, len(ts.app.settings.Listeners))
+	ls := ts.app.settings.Listeners[0]
+	assert.Equal(t, "admin", ls.Name)
+	assert.Equal(t, "127.0.0.1:0", ls.Address)
+	assert.Equal(t, 90*time.Second, ls.ReadTimeout)
+	assert.Equal(t, 5*time.Minute, ls.WriteTimeout)
+	assert.Equal(t, 10*time.Minute, ls.IdleTimeout)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	var deadline time.Time
+	ts.app.HTTPEngine().OnRequest(func(e *Event) {
+		deadline, _ = e.Data.(*Context).Req.Unwrap().Context().Deadline()
+	})
+	defer func() { ts.app.HTTPEngine().onRequestFunc = nil }()
+
+	go ts.app.Serve(l)
+	for i := 0; i < 50; i++ {
+		if _, err = http.Get("http://" + l.Addr().String() + "/get-text.html"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Nil(t, err)
+	assert.True(t, deadline.Before(time.Now().Add(90*time.Second)))
+
+	t.Log("Additional listener serves the application with its own timeouts")
+	ts.app.RLock()
+	assert.Equal(t, 1, len(ts.app.listenerSrvs))
+	admin := ts.app.listenerSrvs[0].server
+	assert.Equal(t, 2*time.Minute, ts.app.server.IdleTimeout)
+	ts.app.RUnlock()
+	assert.Equal(t, 90*time.Second, admin.ReadTimeout)
+	assert.Equal(t, 5*time.Minute, admin.WriteTimeout)
+	assert.Equal(t, 10*time.Minute, admin.IdleTimeout)
+
+	resp, err := http.Get("http://" + admin.Addr + "/get-text.html")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = resp.Body.Close()
+	assert.True(t, deadline.After(time.Now().Add(4*time.Minute)))
+
+	t.Log("Additional listener is shutdown along with the server")
+	ts.app.Shutdown()
+	_, err = http.Get("http://" + admin.Addr + "/get-text.html")
+	assert.NotNil(t, err)
+
+	t.Log("Listener values are validated at init")
+	a := newTestApp(t, importPath)
+	a.Config().SetString("server.listeners.admin.address", "127.0.0.1")
+	err = a.settings.Refresh(a.Config())
+	assert.Equal(t, "'server.listeners.admin.port' is required value", err.Error())
+	a.Config().SetString("server.listeners.admin.port", "8081")
+	a.Config().SetString("server.listeners.admin.timeout.read", "2h")
+	err = a.settings.Refresh(a.Config())
+	assert.Equal(t, "'server.listeners.admin.timeout.read' value is not a valid time unit", err.Error())
+	a.Config().SetString("server.listeners.admin.timeout.read", "30s")
+	assert.Nil(t, a.settings.Refresh(a.Config()))
+	assert.Equal(t, 30*time.Second, a.settings.Listeners[0].ReadTimeout)
+}
+
+func TestServerStartupBannerAndSummary(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	a := newTestApp(t, importPath)
+
+	buf := new(bytes.Buffer)
+	a.Log().(*log.Logger).SetWriter(buf)
+	assert.Nil(t, a.Log().(*log.Logger).SetLevel("info"))
+
+	t.Log("Default banner")
+	a.printBanner()
+	assert.True(t, strings.Contains(buf.String(), "|_| |_|  framework"))
+
+	t.Log("Custom banner")
+	buf.Reset()
+	a.Config().SetString("server.startup_banner.text", "My App\nv1.0.0")
+	a.printBanner()
+	assert.True(t, strings.Contains(buf.String(), "My App"))
+	assert.True(t, strings.Contains(buf.String(), "v1.0.0"))
+
+	t.Log("Banner disabled")
+	buf.Reset()
+	a.Config().SetBool("server.startup_banner.enable", false)
+	a.printBanner()
+	assert.Equal(t, "", buf.String())
+
+	t.Log("Startup summary")
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+	a.listener = l
+	a.HTTPEngine().Middlewares(RouteMiddleware, ActionMiddleware)
+	a.printStartupSummary()
+	summary := buf.String()
+	assert.True(t, strings.Contains(summary, "aah go server startup summary"))
+	assert.True(t, strings.Contains(summary, l.Addr().String()))
+	assert.True(t, strings.Contains(summary, "aahframe.work.RouteMiddleware -> aahframe.work.ActionMiddleware"))
+}
+
+func TestServerProxyProtocol(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	a := newTestApp(t, importPath)
+
+	rl, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer rl.Close()
+
+	l, err := a.wrapProxyProtocol(rl)
+	assert.Nil(t, err)
+	assert.Equal(t, rl, l)
+
+	a.Config().SetBool("server.proxy_protocol.enable", true)
+	l, err = a.wrapProxyProtocol(rl)
+	assert.Nil(t, err)
+	_, ok := l.(*proxyproto.Listener)
+	assert.True(t, ok)
+
+	a.Config().SetString("server.proxy_protocol.header_timeout", "5")
+	_, err = a.wrapProxyProtocol(rl)
+	assert.Equal(t, "'server.proxy_protocol.header_timeout' value is not a valid time unit", err.Error())
+}
+
+func TestServerConnLimit(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	a := newTestApp(t, importPath)
+
+	rl, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer rl.Close()
+
+	l, err := a.wrapConnLimit(rl)
+	assert.Nil(t, err)
+	assert.Equal(t, rl, l)
+
+	a.Config().SetInt("server.max_conns_per_ip.limit", 10)
+	l, err = a.wrapConnLimit(rl)
+	assert.Nil(t, err)
+	_, ok := l.(*connlimit.Listener)
+	assert.True(t, ok)
+
+	a.Config().SetInt("server.max_conns_per_ip.ipv6_prefix", 129)
+	_, err = a.wrapConnLimit(rl)
+	assert.Equal(t, "'server.max_conns_per_ip.ipv6_prefix' unsupported value: 129", err.Error())
+}
+
+func TestServerListenAddress(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	a := newTestApp(t, importPath)
+
+	network, address := a.listenAddress()
+	assert.Equal(t, "tcp", network)
+	assert.Equal(t, ":8080", address)
+
+	a.Config().SetString("server.network", "tcp6")
+	a.Config().SetString("server.address", "::1")
+	assert.Nil(t, a.settings.Refresh(a.Config()))
+	network, address = a.listenAddress()
+	assert.Equal(t, "tcp6", network)
+	assert.Equal(t, "[::1]:8080", address)
+
+	a.Config().SetString("server.address", "unix:/tmp/testserver")
+	network, address = a.listenAddress()
+	assert.Equal(t, "unix", network)
+	assert.Equal(t, "/tmp/testserver", address)
+
+	a.Config().SetString("server.network", "udp")
+	err := a.settings.Refresh(a.Config())
+	assert.Equal(t, "'server.network' unsupported value: udp", err.Error())
+}
+
+func TestServerStartHTTPNetwork(t *testing.T) {
+	defer ess.DeleteFiles("webapp1.pid")
+
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"server.network": "tcp4",
+		"server.address": "127.0.0.1",
+		"server.port":    "0",
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Server Start HTTP Network]: %s", ts.URL)
+
+	go ts.app.Start()
+	defer ts.app.Shutdown()
+
+	time.Sleep(10 * time.Millisecond)
+}
+
 func TestServerHTTPRedirect(t *testing.T) {
 	defer ess.DeleteFiles("webapp1.pid")
 
@@ -85,3 +368,538 @@ func TestServerHTTPRedirect(t *testing.T) {
 	assert.Equal(t, 307, resp.StatusCode)
 	assert.True(t, strings.Contains(responseBody(resp), "Temporary Redirect"))
 }
+
+func TestServerShutdownHooks(t *testing.T) {
+	a := newApp()
+	a.settings.ShutdownHookTimeout = 50 * time.Millisecond
+	a.settings.ShutdownHookTimeStr = "50ms"
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, name)
+	}
+	a.RegisterShutdownHook("workers", 3, func(ctx context.Context) error {
+		record("workers")
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	})
+	a.RegisterShutdownHook("cache", 2, func(ctx context.Context) error {
+		record("cache")
+		return errors.New("flush failed")
+	})
+	a.RegisterShutdownHook("database", 1, func(ctx context.Context) error {
+		record("database")
+		return nil
+	})
+	a.RegisterShutdownHook("metrics", 2, func(ctx context.Context) error {
+		record("metrics")
+		panic("metrics panic")
+	})
+	a.RegisterShutdownHook("nil", 1, nil)
+
+	err := a.runShutdownHooks()
+	mu.Lock()
+	assert.Equal(t, []string{"database", "cache", "metrics", "workers"}, order)
+	mu.Unlock()
+	assert.Equal(t, "shutdown hook(s) failed: cache: flush failed; metrics: panic: metrics panic; "+
+		"workers: timed out after 50ms", err.Error())
+
+	a = newApp()
+	assert.Nil(t, a.runShutdownHooks())
+}
+
+func TestServerShutdownDrain(t *testing.T) {
+	defer ess.DeleteFiles("webapp1.pid")
+
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"server.timeout.drain": "100ms",
+	})
+	defer ts.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	t.Logf("Test Server URL [Shutdown Drain]: http://%s", l.Addr())
+
+	// long-poll request observes the request context
+	entered, done := make(chan struct{}), make(chan struct{})
+	ts.app.HTTPEngine().OnRequest(func(e *Event) {
+		ctx := e.Data.(*Context)
+		if ctx.Req.Header.Get("X-Long-Poll") == "true" {
+			close(entered)
+			<-ctx.Req.Unwrap().Context().Done()
+			close(done)
+		}
+	})
+	defer func() { ts.app.HTTPEngine().onRequestFunc = nil }()
+
+	var preShutdownState AppState
+	ts.app.OnPreShutdown(func(e *Event) { preShutdownState = ts.app.State() })
+
+	go ts.app.Serve(l)
+	for i := 0; i < 50; i++ {
+		if _, err = http.Get("http://" + l.Addr().String() + "/get-text.html"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Nil(t, err)
+
+	go func() {
+		req, _ := http.NewRequest(http.MethodGet, "http://"+l.Addr().String()+"/get-text.html", nil)
+		req.Header.Set("X-Long-Poll", "true")
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+	<-entered
+
+	start := time.Now()
+	ts.app.Shutdown()
+	elapsed := time.Since(start)
+
+	select {
+	case <-done:
+	default:
+		t.Fatal("long-poll request context is not cancelled on shutdown")
+	}
+	assert.True(t, elapsed >= 100*time.Millisecond && elapsed < 5*time.Second, "elapsed %s", elapsed)
+	assert.Equal(t, AppStateDraining, preShutdownState)
+	assert.Equal(t, AppStateStopped, ts.app.State())
+	assert.False(t, ts.app.IsReady())
+}
+
+func TestServerShutdownGracePeriod(t *testing.T) {
+	defer ess.DeleteFiles("webapp1.pid")
+
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"server.timeout.grace_shutdown": "30s",
+	})
+	defer ts.Close()
+	assert.Equal(t, time.Duration(0), ts.app.settings.ShutdownDrainTimeout)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	t.Logf("Test Server URL [Shutdown Grace Period]: http://%s", l.Addr())
+
+	// long request runs beyond 10s, request context is not cancelled within
+	// the grace period
+	entered := make(chan struct{})
+	var cancelled int32
+	ts.app.HTTPEngine().OnRequest(func(e *Event) {
+		ctx := e.Data.(*Context)
+		if ctx.Req.Header.Get("X-Long-Request") == "true" {
+			close(entered)
+			select {
+			case <-ctx.Req.Unwrap().Context().Done():
+				atomic.StoreInt32(&cancelled, 1)
+			case <-time.After(10500 * time.Millisecond):
+			}
+		}
+	})
+	defer func() { ts.app.HTTPEngine().onRequestFunc = nil }()
+
+	go ts.app.Serve(l)
+	for i := 0; i < 50; i++ {
+		if _, err = http.Get("http://" + l.Addr().String() + "/get-text.html"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Nil(t, err)
+
+	status := make(chan int, 1)
+	go func() {
+		req, _ := http.NewRequest(http.MethodGet, "http://"+l.Addr().String()+"/get-text.html", nil)
+		req.Header.Set("X-Long-Request", "true")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			status <- 0
+			return
+		}
+		_ = resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-entered
+
+	ts.app.Shutdown()
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&cancelled))
+	assert.Equal(t, http.StatusOK, <-status)
+	assert.Equal(t, AppStateStopped, ts.app.State())
+}
+
+func TestServerBackgroundWorkers(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Background Workers]: %s", ts.URL)
+
+	started := make(chan struct{})
+	var cancelled int32
+	ts.app.Go("poller", func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		atomic.StoreInt32(&cancelled, 1)
+	})
+	ts.app.Go("panic", func(ctx context.Context) { panic("worker panic") })
+	ts.app.Go("nil", nil)
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("worker is not started")
+	}
+
+	ts.Close()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&cancelled))
+
+	t.Log("Pending workers are started on application start")
+	a := newApp()
+	var ran int32
+	a.Go("pending", func(ctx context.Context) { atomic.StoreInt32(&ran, 1) })
+	assert.Equal(t, 1, len(a.workers.pending))
+	a.startWorkers()
+	a.startWorkers()
+	assert.True(t, a.stopWorkers(time.Second))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ran))
+
+	t.Log("Worker does not return within timeout")
+	a = newApp()
+	a.Go("stuck", func(ctx context.Context) { time.Sleep(200 * time.Millisecond) })
+	a.startWorkers()
+	assert.False(t, a.stopWorkers(10*time.Millisecond))
+	assert.True(t, newApp().stopWorkers(time.Millisecond))
+
+	t.Log("Worker is not started once shutdown has begun")
+	a = newApp()
+	a.startWorkers()
+	assert.True(t, a.stopWorkers(time.Second))
+	var late int32
+	a.Go("late", func(ctx context.Context) { atomic.StoreInt32(&late, 1) })
+	assert.True(t, a.stopWorkers(time.Second))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&late))
+
+	a = newApp()
+	a.Go("pending", func(ctx context.Context) { atomic.StoreInt32(&late, 1) })
+	assert.True(t, a.stopWorkers(time.Second))
+	a.startWorkers()
+	assert.Nil(t, a.workers.ctx)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&late))
+}
+
+func TestServerScheduledJobs(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Scheduled Jobs]: %s", ts.URL)
+
+	err := ts.app.Schedule("invalid", "* * *", func() {})
+	assert.Equal(t, "schedule 'invalid': cron: spec '* * *' must have 5 fields", err.Error())
+	assert.Nil(t, ts.app.Schedule("nil", "@every 1s", nil))
+
+	var runs int32
+	assert.Nil(t, ts.app.Schedule("cleanup", "@every 10ms", func() {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			panic("job panic")
+		}
+	}))
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&runs) < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.True(t, atomic.LoadInt32(&runs) >= 3, "job is not executed after panic")
+
+	ts.Close()
+	stopped := atomic.LoadInt32(&runs)
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, stopped, atomic.LoadInt32(&runs))
+}
+
+func TestServerOnServerSetup(t *testing.T) {
+	defer ess.DeleteFiles("webapp1.pid")
+
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	t.Logf("Test Server URL [On Server Setup]: http://%s", l.Addr())
+
+	var newConns int32
+	ts.app.OnServerSetup(nil)
+	ts.app.OnServerSetup(func(s *http.Server) {
+		s.IdleTimeout = 2 * time.Minute
+		s.ConnState = func(_ net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				atomic.AddInt32(&newConns, 1)
+			}
+		}
+	})
+	ts.app.OnServerSetup(func(s *http.Server) {
+		s.Handler = http.NotFoundHandler()
+		s.Addr = "127.0.0.1:1"
+	})
+
+	lr := ts.CaptureLog()
+	_ = ts.app.Log().(*log.Logger).SetLevel("warn")
+	go ts.app.Serve(l)
+	defer ts.app.Shutdown()
+
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = http.Get("http://" + l.Addr().String() + "/get-text.html"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2*time.Minute, ts.app.server.IdleTimeout)
+	assert.Equal(t, l.Addr().String(), ts.app.server.Addr)
+	assert.True(t, atomic.LoadInt32(&newConns) > 0)
+	assert.True(t, strings.Contains(lr.String(), "'Handler' and 'Addr' of server are managed by aah"))
+}
+
+func TestServerConnStats(t *testing.T) {
+	defer ess.DeleteFiles("webapp1.pid")
+
+	ct := &connTracker{}
+	c1, c2 := &net.TCPConn{}, &net.UDPConn{}
+	ct.track(c1, http.StateNew)
+	ct.track(c2, http.StateNew)
+	ct.track(c1, http.StateActive)
+	ct.track(c2, http.StateActive)
+	ct.track(c1, http.StateIdle)
+	assert.Equal(t, ConnStats{Active: 1, Idle: 1, Accepted: 2}, ct.stats())
+	ct.track(c1, http.StateClosed)
+	ct.track(c2, http.StateHijacked)
+	assert.Equal(t, ConnStats{Accepted: 2, Hijacked: 1, Closed: 1}, ct.stats())
+	assert.Equal(t, ConnStats{}, (*connTracker)(nil).stats())
+
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	t.Logf("Test Server URL [Conn Stats]: http://%s", l.Addr())
+
+	var userHook int32
+	ts.app.OnServerSetup(func(s *http.Server) {
+		s.ConnState = func(net.Conn, http.ConnState) { atomic.AddInt32(&userHook, 1) }
+	})
+	go ts.app.Serve(l)
+	defer ts.app.Shutdown()
+
+	client := &http.Client{Transport: &http.Transport{}}
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = client.Get("http://" + l.Addr().String() + "/get-text.html"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Nil(t, err)
+	_ = responseBody(resp)
+
+	client.Transport.(*http.Transport).CloseIdleConnections()
+	for i := 0; i < 50 && ts.app.ConnStats().Closed == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	stats := ts.app.ConnStats()
+	assert.Equal(t, int64(1), stats.Accepted)
+	assert.Equal(t, int64(1), stats.Closed)
+	assert.Equal(t, int64(0), stats.New+stats.Active+stats.Idle)
+	assert.True(t, atomic.LoadInt32(&userHook) > 0)
+}
+
+func TestServerHeaderSizeLimit(t *testing.T) {
+	defer ess.DeleteFiles("webapp1.pid")
+
+	ct := &connTracker{}
+	c1 := &net.TCPConn{}
+	ct.track(c1, http.StateNew)
+	ct.track(c1, http.StateActive)
+	assert.True(t, ct.track(c1, http.StateClosed))
+	ct.track(c1, http.StateNew)
+	ct.track(c1, http.StateActive)
+	ct.track(c1, http.StateIdle)
+	assert.False(t, ct.track(c1, http.StateClosed))
+
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"server.max_header_bytes": "1kb",
+	})
+	defer ts.Close()
+	assert.True(t, ts.app.settings.MaxHeaderBytesStrict)
+
+	t.Log("Default limit is left to Go HTTP server")
+	a := newTestApp(t, importPath)
+	assert.False(t, a.settings.MaxHeaderBytesStrict)
+	assert.Equal(t, 1<<20, a.settings.HTTPMaxHdrBytes)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	t.Logf("Test Server URL [Header Size Limit]: http://%s", l.Addr())
+
+	_ = ts.app.Log().(*log.Logger).SetLevel("warn")
+	lr := ts.CaptureLog()
+	go ts.app.Serve(l)
+	defer ts.app.Shutdown()
+
+	client := &http.Client{Transport: &http.Transport{}}
+	get := func(hdrSize int) (*http.Response, error) {
+		req, _ := http.NewRequest(ahttp.MethodGet, "http://"+l.Addr().String()+"/get-text.html", nil)
+		req.Header.Set("X-Large", strings.Repeat("a", hdrSize))
+		return client.Do(req)
+	}
+
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = get(10); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = responseBody(resp)
+
+	t.Log("Header size within Go slop, rejected by aah")
+	resp, err = get(2048)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, resp.StatusCode)
+	assert.True(t, strings.Contains(responseBody(resp), "431 Request Header Fields Too Large"))
+	assert.True(t, strings.Contains(lr.String(), "Request header size exceeds the limit of 1024 bytes, Path: /get-text.html, Remote: 127.0.0.1:"))
+
+	t.Log("Header size beyond Go slop, rejected by Go HTTP server")
+
+	resp, err = get(20000)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, resp.StatusCode)
+	_ = responseBody(resp)
+	// Go HTTP server waits for a while prior to closing the connection
+	for i := 0; i < 100 && !strings.Contains(lr.String(), "Connection closed without handling the request"); i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	assert.True(t, strings.Contains(lr.String(), "Connection closed without handling the request, likely rejected by Go HTTP server"))
+	assert.Equal(t, 1, strings.Count(lr.String(), "Connection closed without handling the request"))
+}
+
+func TestServerErrorLog(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"server.error_log.level":               "warn",
+		"server.error_log.tls_handshake_level": "off",
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Server Error Log]: %s", ts.URL)
+
+	_ = ts.app.Log().(*log.Logger).SetLevel("trace")
+	lr := ts.CaptureLog()
+	hl := ts.app.Log().ToGoLogger()
+	hl.SetFlags(0)
+	hl.SetOutput(ts.app.serverErrLog)
+	hl.Printf("http: TLS handshake error from 10.0.0.1:5678: EOF")
+	hl.Printf("http: panic serving 10.0.0.1:5678: boom")
+	assert.False(t, strings.Contains(lr.String(), "TLS handshake error"))
+	assert.True(t, strings.Contains(lr.String(), "WARN  webapp1 http: panic serving 10.0.0.1:5678: boom"))
+
+	ts.app.serverErrLog.handshakeLvl = "debug"
+	hl.Printf("http: TLS handshake error from 10.0.0.1:5678: EOF")
+	assert.True(t, strings.Contains(lr.String(), "DEBUG webapp1 http: TLS handshake error from 10.0.0.1:5678: EOF"))
+
+	ts.app.Config().SetString("server.error_log.level", "verbose")
+	assert.Equal(t, "'server.error_log.level' unsupported value: verbose", ts.app.initServerErrorLog().Error())
+	ts.app.Config().SetString("server.error_log.level", "error")
+	ts.app.Config().SetString("server.error_log.tls_handshake_level", "none")
+	assert.Equal(t, "'server.error_log.tls_handshake_level' unsupported value: none", ts.app.initServerErrorLog().Error())
+}
+
+func TestServerDomainTLSAndSTS(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Domain TLS and STS]: %s", ts.URL)
+
+	domain := ts.app.Router().Domains[0]
+	defer func() {
+		domain.SSLCert, domain.SSLKey = "", ""
+		domain.STSEnabled, domain.STS = true, ""
+	}()
+
+	t.Log("SNI certificate selection by domain host")
+	dir := t.TempDir()
+	domain.SSLCert, domain.SSLKey = createTestCert(t, dir, "localhost")
+	var defaultCert tls.Certificate
+	defer func(s *http.Server) { ts.app.server = s }(ts.app.server)
+	ts.app.server = &http.Server{TLSConfig: &tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return &defaultCert, nil },
+	}}
+	assert.Nil(t, ts.app.setupDomainCertificates())
+
+	cert, err := ts.app.server.TLSConfig.GetCertificate(&tls.ClientHelloInfo{ServerName: "LocalHost"})
+	assert.Nil(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.Nil(t, err)
+	assert.Equal(t, "localhost", leaf.Subject.CommonName)
+
+	cert, err = ts.app.server.TLSConfig.GetCertificate(&tls.ClientHelloInfo{ServerName: "www.example.com"})
+	assert.Nil(t, err)
+	assert.True(t, cert == &defaultCert)
+
+	domain.SSLKey = filepath.Join(dir, "notexists.key")
+	assert.NotNil(t, ts.app.setupDomainCertificates())
+
+	t.Log("HSTS header by domain")
+	ts.app.settings.SSLEnabled = true
+	defer func() { ts.app.settings.SSLEnabled = false }()
+
+	sts := func() string {
+		return ts.Get("/get-text.html").AssertStatus(http.StatusOK).Header.Get(ahttp.HeaderStrictTransportSecurity)
+	}
+	assert.Equal(t, ts.app.SecurityManager().SecureHeaders.STS, sts())
+
+	domain.STS = "max-age=31536000; preload"
+	assert.Equal(t, "max-age=31536000; preload", sts())
+
+	domain.STSEnabled = false
+	assert.Equal(t, "", sts())
+}
+
+func createTestCert(t *testing.T, dir, host string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	certFile, keyFile := filepath.Join(dir, host+".crt"), filepath.Join(dir, host+".key")
+	assert.Nil(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.Nil(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certFile, keyFile
+}
@@ -0,0 +1,108 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ShutdownHookFunc type is used to release the application resources on
+// shutdown, for e.g.: close database connection, flush cache, stop background
+// workers. Given context is cancelled after `server.timeout.shutdown_hook`.
+type ShutdownHookFunc func(ctx context.Context) error
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Application methods
+//______________________________________________________________________________
+
+// RegisterShutdownHook method registers the shutdown hook. Hooks are executed
+// sequentially in the ascending order of priority after aah server stops
+// accepting new requests and in-flight requests are drained, right before
+// the `OnPostShutdown` event. Hooks with same priority are executed in the
+// order of registration.
+//
+// Each hook gets `server.timeout.shutdown_hook` to complete, on timeout aah
+// logs the error and proceeds with next hook. Returned errors are logged
+// together once all the hooks are executed.
+//
+//	aah.App().RegisterShutdownHook("database", 1, func(ctx context.Context) error {
+//	  return db.Close()
+//	})
+func (a *Application) RegisterShutdownHook(name string, priority int, hook ShutdownHookFunc) {
+	if hook == nil {
+		return
+	}
+	a.shutdownMu.Lock()
+	defer a.shutdownMu.Unlock()
+	a.shutdownHooks = append(a.shutdownHooks, &shutdownHook{name: name, priority: priority, hook: hook})
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// app Unexported methods
+//______________________________________________________________________________
+
+type shutdownHook struct {
+	name     string
+	priority int
+	hook     ShutdownHookFunc
+}
+
+// shutdownWorkersAndHooks method stops the background workers then executes
+// the shutdown hooks, since hooks may release the resources used by workers.
+func (a *Application) shutdownWorkersAndHooks() {
+	if !a.stopWorkers(a.settings.WorkersTimeout) {
+		a.Log().Errorf("Background workers did not stop within the timeout of %s", a.settings.WorkersTimeStr)
+	}
+	if err := a.runShutdownHooks(); err != nil {
+		a.Log().Error(err)
+	}
+}
+
+// runShutdownHooks method executes the registered shutdown hooks in the
+// priority order and returns the aggregated error.
+func (a *Application) runShutdownHooks() error {
+	a.shutdownMu.Lock()
+	hooks := append([]*shutdownHook{}, a.shutdownHooks...)
+	a.shutdownMu.Unlock()
+	sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].priority < hooks[j].priority })
+
+	var errs []string
+	for _, h := range hooks {
+		a.Log().Infof("Executing shutdown hook '%s' (priority=%d)", h.name, h.priority)
+		if err := a.runShutdownHook(h); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", h.name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("shutdown hook(s) failed: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (a *Application) runShutdownHook(h *shutdownHook) error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.settings.ShutdownHookTimeout)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				result <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		result <- h.hook(ctx)
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %s", a.settings.ShutdownHookTimeStr)
+	}
+}
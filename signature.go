@@ -0,0 +1,163 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+var signatureHashFuncs = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Package methods
+//______________________________________________________________________________
+
+// SignatureMiddleware method verifies the HMAC signature of webhook requests,
+// for the routes which has `webhook = true` attribute in the `routes.conf`.
+// Signature is computed over the raw request body using the configured secret
+// and algorithm from `security.webhook.*` and compared with the signature
+// header value in constant time. On mismatch it replies `401 Unauthorized`
+// and the action is not invoked.
+//
+// Request body is read via `ctx.Req.BodyBytes`, so handler can still parse
+// the payload.
+//
+// Add it before `aah.BindMiddleware` in the middleware stack. Application
+// fails to start when webhook routes are configured without it.
+func SignatureMiddleware(ctx *Context, m *Middleware) {
+	if ctx.route == nil || !ctx.route.IsWebhook {
+		m.Next(ctx)
+		return
+	}
+
+	sv := ctx.a.sigVerifier
+	if sv == nil {
+		ctx.Log().Errorf("Webhook signature config 'security.webhook' is not configured, Path: %s", ctx.Req.Path)
+		ctx.Reply().Unauthorized().Error(newError(ErrSignatureMismatch, http.StatusUnauthorized))
+		return
+	}
+
+	body, err := ctx.Req.BodyBytes()
+	if err != nil {
+		ctx.Log().Errorf("Unable to read request body for signature verification: %v", err)
+		ctx.Reply().Unauthorized().Error(newError(ErrSignatureMismatch, http.StatusUnauthorized))
+		return
+	}
+
+	if !sv.verify(ctx.Req.Header.Get(sv.header), body) {
+		ctx.Log().Warnf("Webhook signature mismatch, Path: %s", ctx.Req.Path)
+		ctx.Reply().Unauthorized().Error(newError(ErrSignatureMismatch, http.StatusUnauthorized))
+		return
+	}
+
+	m.Next(ctx)
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// app Unexported methods
+//______________________________________________________________________________
+
+func (a *Application) initSignatureVerifier() error {
+	cfg, found := a.Config().GetSubConfig("security.webhook")
+	if !found {
+		return nil
+	}
+
+	secret := cfg.StringDefault("secret", "")
+	if len(secret) == 0 {
+		return errors.New("'security.webhook.secret' value is required")
+	}
+
+	algorithm := strings.ToLower(cfg.StringDefault("algorithm", "sha256"))
+	hashFunc, found := signatureHashFuncs[algorithm]
+	if !found {
+		return fmt.Errorf("'security.webhook.algorithm' unsupported value: %s", algorithm)
+	}
+
+	encoding := strings.ToLower(cfg.StringDefault("encoding", "hex"))
+	if encoding != "hex" && encoding != "base64" {
+		return fmt.Errorf("'security.webhook.encoding' unsupported value: %s", encoding)
+	}
+
+	a.sigVerifier = &signatureVerifier{
+		header:   http.CanonicalHeaderKey(cfg.StringDefault("header", "X-Hub-Signature-256")),
+		prefix:   cfg.StringDefault("prefix", "sha256="),
+		encoding: encoding,
+		secret:   []byte(secret),
+		hashFunc: hashFunc,
+	}
+	return nil
+}
+
+// validateWebhookRoutes method returns an error when webhook routes are
+// configured and `SignatureMiddleware` is not in the middleware stack,
+// otherwise those routes would be served unverified.
+func (a *Application) validateWebhookRoutes() error {
+	for _, name := range a.he.MiddlewareNames() {
+		if name == middlewareName(SignatureMiddleware) {
+			return nil
+		}
+	}
+	for _, d := range a.Router().Domains {
+		for _, r := range d.Routes() {
+			if r.IsWebhook {
+				return fmt.Errorf("webhook route '%s' requires 'SignatureMiddleware' in the middleware stack", r.Name)
+			}
+		}
+	}
+	return nil
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// signatureVerifier and its methods
+//______________________________________________________________________________
+
+type signatureVerifier struct {
+	header   string
+	prefix   string
+	encoding string
+	secret   []byte
+	hashFunc func() hash.Hash
+}
+
+func (sv *signatureVerifier) sign(body []byte) string {
+	return sv.prefix + sv.digest(body)
+}
+
+// verify method compares the signature with computed one in constant time,
+// hex digest is compared case-insensitively.
+func (sv *signatureVerifier) verify(signature string, body []byte) bool {
+	if len(signature) == 0 || !strings.HasPrefix(signature, sv.prefix) {
+		return false
+	}
+	signature = signature[len(sv.prefix):]
+	if sv.encoding == "hex" {
+		signature = strings.ToLower(signature)
+	}
+	return hmac.Equal([]byte(signature), []byte(sv.digest(body)))
+}
+
+func (sv *signatureVerifier) digest(body []byte) string {
+	mac := hmac.New(sv.hashFunc, sv.secret)
+	_, _ = mac.Write(body)
+	if sv.encoding == "base64" {
+		return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
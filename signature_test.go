@@ -0,0 +1,123 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"aahframe.work/ahttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignatureMiddleware(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"security.webhook.secret": "webhook-secret",
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Signature]: %s", ts.URL)
+
+	ts.SetMiddlewares(
+		RouteMiddleware,
+		SignatureMiddleware,
+		BindMiddleware,
+		ActionMiddleware,
+	)
+
+	payload := `{"action":"opened","number":1}`
+	mac := hmac.New(sha256.New, []byte("webhook-secret"))
+	_, _ = mac.Write([]byte(payload))
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	t.Log("Valid signature")
+	req, _ := http.NewRequest(ahttp.MethodPost, ts.URL+"/webhook", strings.NewReader(payload))
+	req.Header.Set(ahttp.HeaderContentType, ahttp.ContentTypeJSON.String())
+	req.Header.Set("X-Hub-Signature-256", signature)
+	r := ts.Do(req).AssertStatus(http.StatusOK)
+	assert.Equal(t, "received: "+payload, r.BodyString())
+
+	t.Log("Valid signature, upper case hex")
+	req, _ = http.NewRequest(ahttp.MethodPost, ts.URL+"/webhook", strings.NewReader(payload))
+	req.Header.Set(ahttp.HeaderContentType, ahttp.ContentTypeJSON.String())
+	req.Header.Set("X-Hub-Signature-256", "sha256="+strings.ToUpper(signature[7:]))
+	ts.Do(req).AssertStatus(http.StatusOK)
+
+	t.Log("Signature without prefix")
+	req, _ = http.NewRequest(ahttp.MethodPost, ts.URL+"/webhook", strings.NewReader(payload))
+	req.Header.Set(ahttp.HeaderContentType, ahttp.ContentTypeJSON.String())
+	req.Header.Set("X-Hub-Signature-256", signature[7:])
+	ts.Do(req).AssertStatus(http.StatusUnauthorized)
+
+	t.Log("Invalid signature")
+	req, _ = http.NewRequest(ahttp.MethodPost, ts.URL+"/webhook", strings.NewReader(payload+" "))
+	req.Header.Set(ahttp.HeaderContentType, ahttp.ContentTypeJSON.String())
+	req.Header.Set("X-Hub-Signature-256", signature)
+	ts.Do(req).AssertStatus(http.StatusUnauthorized)
+
+	t.Log("Missing signature")
+	req, _ = http.NewRequest(ahttp.MethodPost, ts.URL+"/webhook", strings.NewReader(payload))
+	req.Header.Set(ahttp.HeaderContentType, ahttp.ContentTypeJSON.String())
+	ts.Do(req).AssertStatus(http.StatusUnauthorized)
+
+	t.Log("Not a webhook route")
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+}
+
+func TestSignatureVerifierConfig(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	a := newTestApp(t, importPath)
+
+	assert.Nil(t, a.initSignatureVerifier())
+	assert.Nil(t, a.sigVerifier)
+
+	a.Config().SetString("security.webhook.algorithm", "sha512")
+	err := a.initSignatureVerifier()
+	assert.Equal(t, "'security.webhook.secret' value is required", err.Error())
+
+	a.Config().SetString("security.webhook.secret", "webhook-secret")
+	a.Config().SetString("security.webhook.encoding", "base64")
+	a.Config().SetString("security.webhook.header", "x-signature")
+	assert.Nil(t, a.initSignatureVerifier())
+	assert.Equal(t, "X-Signature", a.sigVerifier.header)
+	assert.True(t, a.sigVerifier.verify(a.sigVerifier.sign([]byte("body")), []byte("body")))
+	assert.False(t, a.sigVerifier.verify(a.sigVerifier.sign([]byte("body")), []byte("body1")))
+
+	assert.Equal(t, "sha256=", a.sigVerifier.prefix)
+
+	a.Config().SetString("security.webhook.prefix", "")
+	a.Config().SetString("security.webhook.encoding", "hex")
+	assert.Nil(t, a.initSignatureVerifier())
+	sig := a.sigVerifier.sign([]byte("body"))
+	assert.True(t, a.sigVerifier.verify(strings.ToUpper(sig), []byte("body")))
+
+	a.Config().SetString("security.webhook.algorithm", "md5")
+	err = a.initSignatureVerifier()
+	assert.Equal(t, "'security.webhook.algorithm' unsupported value: md5", err.Error())
+
+	a.Config().SetString("security.webhook.algorithm", "sha1")
+	a.Config().SetString("security.webhook.encoding", "base32")
+	err = a.initSignatureVerifier()
+	assert.Equal(t, "'security.webhook.encoding' unsupported value: base32", err.Error())
+}
+
+func TestSignatureValidateWebhookRoutes(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	a := newTestApp(t, importPath)
+
+	a.HTTPEngine().Middlewares(RouteMiddleware, BindMiddleware, ActionMiddleware)
+	err := a.validateWebhookRoutes()
+	assert.NotNil(t, err)
+	assert.Equal(t, "webhook route 'webhook' requires 'SignatureMiddleware' in the middleware stack", err.Error())
+
+	a.HTTPEngine().Middlewares(SignatureMiddleware)
+	assert.Nil(t, a.validateWebhookRoutes())
+}
@@ -0,0 +1,75 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"aahframe.work/ahttp"
+	"golang.org/x/sync/singleflight"
+)
+
+// SingleFlightMiddleware method coalesces the concurrent identical requests of
+// the route, which has `singleflight = true` attribute in the `routes.conf`.
+// Only one of them executes the action and its response is written to all
+// the callers. Identical request key is same as `aah.CacheMiddleware`, i.e.
+// HTTP method, host, path, query string and `cache.response.vary_headers`
+// values.
+//
+// It is applicable to HTTP methods GET and HEAD only, non-idempotent requests
+// are never coalesced. Response with `Set-Cookie` header is not shared, other
+// callers executes the action on their own. Authenticated route is coalesced
+// only if the request key has the request identity, same as
+// `aah.CacheMiddleware`.
+//
+// Add it after `aah.AuthcAuthzMiddleware` in the middleware stack.
+func SingleFlightMiddleware(ctx *Context, m *Middleware) {
+	rc := ctx.a.respCache
+	if rc == nil || ctx.route == nil || !ctx.route.IsSingleFlight || !rc.isShareable(ctx) {
+		m.Next(ctx)
+		return
+	}
+
+	v, executed := doFlight(&ctx.a.he.sfGroup, rc.key(ctx), func() interface{} {
+		cr := rc.capture(ctx, m)
+		if cr == nil {
+			return nil
+		}
+		rc.write(ctx, cr)
+		if len(cr.Header.Get(ahttp.HeaderSetCookie)) > 0 {
+			return nil
+		}
+		return cr
+	})
+	if executed {
+		return
+	}
+	if cr, ok := v.(*CachedResponse); ok {
+		ctx.Log().Debugf("Single flight response shared: %s", ctx.Req.Path)
+		rc.write(ctx, cr)
+		return
+	}
+	m.Next(ctx)
+}
+
+// doFlight method executes the given fn once for the concurrent callers of
+// same key, it returns true for the caller which executed the fn. Panic in
+// the fn is recovered to finish the flight, so waiting callers are released
+// with nil value, then it is re-panicked on the executed caller.
+func doFlight(g *singleflight.Group, key string, fn func() interface{}) (interface{}, bool) {
+	var executed bool
+	var panicked interface{}
+	v, _, _ := g.Do(key, func() (interface{}, error) {
+		executed = true
+		defer func() {
+			if r := recover(); r != nil {
+				panicked = r
+			}
+		}()
+		return fn(), nil
+	})
+	if panicked != nil {
+		panic(panicked)
+	}
+	return v, executed
+}
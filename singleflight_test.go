@@ -0,0 +1,143 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"net/http"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSingleFlightMiddleware(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"security.session.mode": "stateless",
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Single Flight]: %s", ts.URL)
+
+	ts.SetMiddlewares(
+		RouteMiddleware,
+		CORSMiddleware,
+		BindMiddleware,
+		AntiCSRFMiddleware,
+		AuthcAuthzMiddleware,
+		SingleFlightMiddleware,
+		ActionMiddleware,
+	)
+
+	var cnt int32
+	ts.app.HTTPEngine().OnPreReply(func(e *Event) {
+		time.Sleep(100 * time.Millisecond)
+		atomic.AddInt32(&cnt, 1)
+	})
+
+	body := ts.Get("/singleflight-text.html").AssertStatus(200).BodyString()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&cnt))
+
+	t.Log("Concurrent identical requests")
+	atomic.StoreInt32(&cnt, 0)
+	total := 20
+	var wg sync.WaitGroup
+	for i := 0; i < total; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := new(http.Client).Get(ts.URL + "/singleflight-text.html")
+			assert.Nil(t, err)
+			assert.Equal(t, 200, resp.StatusCode)
+			assert.Equal(t, body, responseBody(resp))
+		}()
+	}
+	wg.Wait()
+	assert.True(t, atomic.LoadInt32(&cnt) < int32(total))
+
+	t.Log("Route without singleflight")
+	atomic.StoreInt32(&cnt, 0)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ts.Get("/get-text.html").AssertStatus(200)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(5), atomic.LoadInt32(&cnt))
+}
+
+func TestSingleFlightMiddlewarePanic(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"security.session.mode": "stateless",
+	})
+	defer ts.Close()
+
+	for _, tc := range []struct {
+		label      string
+		path       string
+		middleware MiddlewareFunc
+	}{
+		{label: "single flight", path: "/singleflight-text.html", middleware: SingleFlightMiddleware},
+		{label: "response cache", path: "/cached-text.html", middleware: CacheMiddleware},
+	} {
+		t.Run(tc.label, func(t *testing.T) {
+			ts.SetMiddlewares(
+				RouteMiddleware,
+				BindMiddleware,
+				AntiCSRFMiddleware,
+				AuthcAuthzMiddleware,
+				tc.middleware,
+				ActionMiddleware,
+			)
+			ts.app.he.onPreReplyFunc = nil
+
+			// leader request panics after the follower joined the flight
+			var cnt int32
+			ts.app.HTTPEngine().OnPreReply(func(e *Event) {
+				if atomic.AddInt32(&cnt, 1) == 1 {
+					time.Sleep(100 * time.Millisecond)
+					panic("leader panic")
+				}
+			})
+
+			var wg sync.WaitGroup
+			statuses := make(chan int, 2)
+			for i := 0; i < 2; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					resp, err := new(http.Client).Get(ts.URL + tc.path)
+					assert.Nil(t, err)
+					statuses <- resp.StatusCode
+				}()
+				time.Sleep(20 * time.Millisecond)
+			}
+
+			done := make(chan struct{})
+			go func() { wg.Wait(); close(done) }()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("follower request is blocked by the panicked flight")
+			}
+			close(statuses)
+			var codes []int
+			for c := range statuses {
+				codes = append(codes, c)
+			}
+			assert.Contains(t, codes, http.StatusInternalServerError)
+			assert.Contains(t, codes, http.StatusOK)
+
+			t.Log("Later request of the key is not joined to the dead flight")
+			ts.Get(tc.path).AssertStatus(http.StatusOK)
+		})
+	}
+}
@@ -6,6 +6,8 @@ package aah
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"html/template"
 	"io"
@@ -51,15 +53,50 @@ func (a *Application) initStatic() error {
 		}
 	}
 
+	// Asset fingerprint manifest, used by template func `assetURL`
+	// static file fingerprint configuration is from `cache.static.fingerprint.*`
+	if a.Config().BoolDefault("cache.static.fingerprint.enable", false) {
+		a.staticMgr.assetURLPrefix = a.Config().StringDefault("cache.static.fingerprint.url_prefix", "/static")
+		dir := a.Config().StringDefault("cache.static.fingerprint.dir", "static")
+		if err := a.staticMgr.buildAssetManifest(dir); err != nil {
+			return err
+		}
+	}
+
+	// Pre-compressed sibling file lookup, for e.g.: aah.css.br, aah.css.gz
+	// static file pre-compressed configuration is from `cache.static.precompressed.*`
+	if a.Config().BoolDefault("cache.static.precompressed.enable", false) {
+		encodings, found := a.Config().StringList("cache.static.precompressed.encodings")
+		if !found {
+			encodings = []string{"br", "gzip"}
+		}
+		for _, enc := range encodings {
+			enc = strings.ToLower(strings.TrimSpace(enc))
+			if _, found := precompressedExts[enc]; !found {
+				return fmt.Errorf("'cache.static.precompressed.encodings' unsupported encoding value: %s", enc)
+			}
+			a.staticMgr.precompressed = append(a.staticMgr.precompressed, enc)
+		}
+	}
+
 	return nil
 }
 
+// precompressedExts holds supported content encoding and its file extension.
+var precompressedExts = map[string]string{
+	"br":   ".br",
+	"gzip": ".gz",
+}
+
 type staticManager struct {
 	a                     *Application
 	defaultCacheHdr       string
 	noCacheHdrValue       string
 	dirListDateTimeFormat string
+	assetURLPrefix        string
 	mimeCacheHdrMap       map[string]string
+	assetManifest         map[string]string
+	precompressed         []string
 }
 
 func (s *staticManager) Serve(ctx *Context) error {
@@ -67,25 +104,63 @@ func (s *staticManager) Serve(ctx *Context) error {
 
 	// Determine route is file or directory as per user defined
 	// static route config (refer to https://docs.aahframework.org/static-files.html#section-static).
-	f, err := s.open(ctx)
+	resource := s.resourcePath(ctx)
+	f, err := s.a.VFS().Open(resource)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if !os.IsNotExist(err) {
+			s.writeError(ctx.Res, ctx.Req, err)
+			return nil
+		}
+		if !ctx.route.IsSPAFallback(ctx.Req.Path) {
 			return errFileNotFound
 		}
-		s.writeError(ctx.Res, ctx.Req, err)
-		return nil
+		f = nil
 	}
-	defer ess.CloseQuietly(f)
 
-	fi, err := f.Stat()
-	if err != nil {
-		s.writeError(ctx.Res, ctx.Req, err)
-		return nil
+	var fi os.FileInfo
+	if f != nil {
+		if fi, err = f.Stat(); err != nil {
+			ess.CloseQuietly(f)
+			s.writeError(ctx.Res, ctx.Req, err)
+			return nil
+		}
+
+		// directory without listing is served with SPA fallback
+		if fi.Mode().IsDir() && !ctx.route.ListDir && ctx.route.IsSPAFallback(ctx.Req.Path) {
+			ess.CloseQuietly(f)
+			f = nil
+		}
 	}
 
+	// single-page application fallback `spa_fallback`, i.e. client-side
+	// routing path
+	spaFallback := f == nil
+	if spaFallback {
+		resource = s.spaFallbackPath(ctx)
+		if f, err = s.a.VFS().Open(resource); err != nil {
+			if os.IsNotExist(err) {
+				return errFileNotFound
+			}
+			s.writeError(ctx.Res, ctx.Req, err)
+			return nil
+		}
+		if fi, err = f.Stat(); err != nil {
+			ess.CloseQuietly(f)
+			s.writeError(ctx.Res, ctx.Req, err)
+			return nil
+		}
+	}
+	defer ess.CloseQuietly(f)
+
 	gf, ok := f.(vfs.Gziper)
 	var fr io.ReadSeeker = f
-	if s.a.settings.GzipEnabled && ctx.Req.IsGzipAccepted {
+	modTime := fi.ModTime()
+	if cf, cfi, enc := s.openPrecompressed(ctx, resource, fi); cf != nil {
+		defer ess.CloseQuietly(cf)
+		ctx.Res.Header().Add(ahttp.HeaderVary, ahttp.HeaderAcceptEncoding)
+		ctx.Res.Header().Add(ahttp.HeaderContentEncoding, enc)
+		fr, modTime = cf, cfi.ModTime()
+	} else if s.a.settings.GzipEnabled && ctx.Req.IsGzipAccepted {
 		if ok && gf.IsGzip() {
 			ctx.Res.Header().Add(ahttp.HeaderVary, ahttp.HeaderAcceptEncoding)
 			ctx.Res.Header().Add(ahttp.HeaderContentEncoding, gzipContentEncoding)
@@ -104,9 +179,15 @@ func (s *staticManager) Serve(ctx *Context) error {
 		if contentType, err := util.DetectFileContentType(fi.Name(), f); err == nil {
 			ctx.Res.Header().Set(ahttp.HeaderContentType, contentType)
 
-			// apply cache header if environment profile is `prod`
-			if s.a.IsEnvProfile("prod") {
-				ctx.Res.Header().Set(ahttp.HeaderCacheControl, s.cacheHeader(contentType))
+			// apply cache header if environment profile is `prod`, static
+			// route `cache.*` takes precedence over `cache.static.*`. SPA
+			// fallback is never cached, it references the fingerprinted assets
+			if s.a.IsEnvProfile("prod") && !spaFallback {
+				cacheHdr := ctx.route.CacheControl
+				if len(cacheHdr) == 0 {
+					cacheHdr = s.cacheHeader(contentType)
+				}
+				ctx.Res.Header().Set(ahttp.HeaderCacheControl, cacheHdr)
 			} else { // for static files hot-reload
 				ctx.Res.Header().Set(ahttp.HeaderExpires, "0")
 				ctx.Res.Header().Set(ahttp.HeaderCacheControl, s.noCacheHdrValue)
@@ -119,7 +200,7 @@ func (s *staticManager) Serve(ctx *Context) error {
 		// 'OnHeaderReply' HTTP event
 		s.a.he.publishOnHeaderReplyEvent(ctx.Res.Header())
 
-		http.ServeContent(ctx.Res, ctx.Req.Unwrap(), path.Base(fi.Name()), fi.ModTime(), fr)
+		http.ServeContent(ctx.Res, ctx.Req.Unwrap(), path.Base(fi.Name()), modTime, fr)
 
 		// 'OnAfterReply' server extension point
 		s.a.he.publishOnPostReplyEvent(ctx)
@@ -153,7 +234,37 @@ func (s *staticManager) Serve(ctx *Context) error {
 	return nil
 }
 
-func (s *staticManager) open(ctx *Context) (vfs.File, error) {
+// openPrecompressed method looks for pre-compressed sibling file of the
+// static resource in the configured encoding order, which is accepted by
+// the client. It returns nil file if sibling not found.
+func (s *staticManager) openPrecompressed(ctx *Context, resource string, fi os.FileInfo) (vfs.File, os.FileInfo, string) {
+	if len(s.precompressed) == 0 || !fi.Mode().IsRegular() {
+		return nil, nil, ""
+	}
+
+	accepted := make(map[string]bool)
+	for _, spec := range ahttp.ParseAcceptEncoding(ctx.Req.Unwrap()) {
+		accepted[strings.ToLower(spec.Value)] = spec.Q > 0
+	}
+
+	for _, enc := range s.precompressed {
+		if !accepted[enc] {
+			continue
+		}
+		cf, err := s.a.VFS().Open(resource + precompressedExts[enc])
+		if err != nil {
+			continue
+		}
+		if cfi, err := cf.Stat(); err == nil && cfi.Mode().IsRegular() {
+			ctx.Log().Tracef("Static resource pre-compressed: %s%s", resource, precompressedExts[enc])
+			return cf, cfi, enc
+		}
+		ess.CloseQuietly(cf)
+	}
+	return nil, nil, ""
+}
+
+func (s *staticManager) resourcePath(ctx *Context) string {
 	var filePath string
 	if ctx.route.IsFile() { // this is configured value from routes.conf
 		filePath = parseCacheBustPart(ctx.route.File, s.a.BuildInfo().Version)
@@ -164,7 +275,53 @@ func (s *staticManager) open(ctx *Context) (vfs.File, error) {
 	resource := filepath.ToSlash(path.Join(s.a.VirtualBaseDir(), ctx.route.Dir, filePath))
 	ctx.Log().Tracef("Static resource: %s", resource)
 
-	return s.a.VFS().Open(resource)
+	return resource
+}
+
+func (s *staticManager) spaFallbackPath(ctx *Context) string {
+	resource := filepath.ToSlash(path.Join(s.a.VirtualBaseDir(), ctx.route.Dir, ctx.route.SPAFallback))
+	ctx.Log().Tracef("Static resource SPA fallback: %s", resource)
+	return resource
+}
+
+// buildAssetManifest method computes content hash for each file under
+// given static directory and keeps it in-memory for URL composing.
+func (s *staticManager) buildAssetManifest(dir string) error {
+	s.assetManifest = make(map[string]string)
+	baseDir := filepath.ToSlash(path.Join(s.a.VirtualBaseDir(), dir))
+	if !s.a.VFS().IsExists(baseDir) {
+		s.a.Log().Warnf("Asset fingerprint directory not exists: %s", dir)
+		return nil
+	}
+
+	return s.a.VFS().Walk(baseDir, func(fpath string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		b, err := s.a.VFS().ReadFile(fpath)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(b)
+		name := strings.TrimPrefix(filepath.ToSlash(fpath), baseDir+"/")
+		s.assetManifest[name] = hex.EncodeToString(sum[:])[:12]
+		return nil
+	})
+}
+
+// assetURL method returns the static asset URL with content hash as query
+// parameter `v`. If the asset is not found in the manifest then it returns
+// URL without hash.
+func (s *staticManager) assetURL(name string) string {
+	name = strings.TrimPrefix(filepath.ToSlash(name), "/")
+	u := path.Join(s.a.Router().BasePath(), s.assetURLPrefix, name)
+	if hash, found := s.assetManifest[name]; found {
+		return u + "?v=" + hash
+	}
+	return u
 }
 
 func (s *staticManager) cacheHeader(contentType string) string {
@@ -15,7 +15,9 @@ import (
 	"testing"
 
 	"aahframe.work/ahttp"
+	"aahframe.work/config"
 	"aahframe.work/internal/util"
+	"aahframe.work/router"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -68,8 +70,18 @@ func TestStaticFilesDelivery(t *testing.T) {
 	t.Log("Static File - /assets/img/notfound/file.txt")
 	resp, err = httpClient.Get(ts.URL + "/assets/img/notfound/file.txt")
 	assert.Nil(t, err)
-	assert.Equal(t, 200, resp.StatusCode)
-	assert.Equal(t, "0", resp.Header.Get(ahttp.HeaderContentLength))
+	assert.Equal(t, 404, resp.StatusCode)
+	assert.Equal(t, "404 - Not Found", responseBody(resp))
+
+	// Static File - missing file replies error as per content negotiation
+	t.Log("Static File - /assets/img/notfound/file.png with Accept JSON")
+	req, err := http.NewRequest(ahttp.MethodGet, ts.URL+"/assets/img/notfound/file.png", nil)
+	assert.Nil(t, err)
+	req.Header.Set(ahttp.HeaderAccept, ahttp.ContentTypeJSON.Mime)
+	resp, err = httpClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+	assert.Equal(t, `{"code":404,"message":"Not Found"}`+"\n", responseBody(resp))
 }
 
 func TestStaticDetectContentType(t *testing.T) {
@@ -165,6 +177,33 @@ func TestStaticCacheHeader(t *testing.T) {
 	assert.Equal(t, "public, max-age=604800, proxy-revalidate", str)
 }
 
+func TestStaticRouteCacheControl(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Static Route Cache-Control]: %s", ts.URL)
+
+	ts.app.settings.EnvProfile = "prod"
+	defer func() { ts.app.settings.EnvProfile = "dev" }()
+
+	t.Log("Static route level cache config")
+	result := ts.Get("/assets/css/aah.css").AssertStatus(http.StatusOK).
+		AssertHeader(ahttp.HeaderCacheControl, "public, max-age=31536000, immutable")
+	lastModified := result.Header.Get(ahttp.HeaderLastModified)
+	assert.NotEqual(t, "", lastModified)
+
+	t.Log("Conditional request with long max-age")
+	req, err := http.NewRequest(ahttp.MethodGet, ts.URL+"/assets/css/aah.css", nil)
+	assert.Nil(t, err)
+	req.Header.Set(ahttp.HeaderIfModifiedSince, lastModified)
+	ts.Do(req).AssertStatus(http.StatusNotModified)
+
+	t.Log("Static route without cache config, falls back to 'cache.static.*'")
+	ts.Get("/robots.txt").AssertStatus(http.StatusOK).
+		AssertHeader(ahttp.HeaderCacheControl, ts.app.staticMgr.cacheHeader("text/plain"))
+}
+
 func TestStaticWriteFileError(t *testing.T) {
 	importPath := filepath.Join(testdataBaseDir(), "webapp1")
 	ts := newTestServer(t, importPath)
@@ -183,3 +222,123 @@ func TestStaticWriteFileError(t *testing.T) {
 	sm.writeError(ahttp.AcquireResponseWriter(w2), ahttp.AcquireRequest(req), nil)
 	assert.Equal(t, "500 Internal Server Error", responseBody(w2.Result()))
 }
+
+func TestStaticAssetURL(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Static Asset URL]: %s", ts.URL)
+
+	sm := ts.app.staticMgr
+	assert.Equal(t, 5, len(sm.assetManifest))
+
+	u := sm.assetURL("css/aah.css")
+	assert.True(t, strings.HasPrefix(u, "/assets/css/aah.css?v="))
+	assert.Equal(t, 12, len(strings.TrimPrefix(u, "/assets/css/aah.css?v=")))
+	assert.Equal(t, u, sm.assetURL("/css/aah.css"))
+	assert.Equal(t, "/assets/css/notexists.css", sm.assetURL("css/notexists.css"))
+
+	resp, err := new(http.Client).Get(ts.URL + u)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.True(t, strings.Contains(responseBody(resp), "Minimal aah framework application template CSS."))
+}
+
+func TestStaticPrecompressed(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"cache.static.precompressed.enable": true,
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Static Pre-compressed]: %s", ts.URL)
+
+	assert.Equal(t, []string{"br", "gzip"}, ts.app.staticMgr.precompressed)
+
+	cssFile := filepath.Join(importPath, "static", "css", "aah.css")
+	assert.Nil(t, ioutil.WriteFile(cssFile+".br", []byte("brotli content"), 0644))
+	assert.Nil(t, ioutil.WriteFile(cssFile+".gz", []byte("gzip content"), 0644))
+	defer func() {
+		_ = os.Remove(cssFile + ".br")
+		_ = os.Remove(cssFile + ".gz")
+	}()
+
+	testcases := []struct {
+		accept, encoding, body string
+	}{
+		{"gzip, deflate, br", "br", "brotli content"},
+		{"gzip", "gzip", "gzip content"},
+		{"br;q=0, gzip", "gzip", "gzip content"},
+		{"identity", "", "Minimal aah framework application template CSS."},
+	}
+	for _, tc := range testcases {
+		req, _ := http.NewRequest(ahttp.MethodGet, ts.URL+"/assets/css/aah.css", nil)
+		req.Header.Set(ahttp.HeaderAcceptEncoding, tc.accept)
+		resp, err := new(http.Client).Do(req)
+		assert.Nil(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, tc.encoding, resp.Header.Get(ahttp.HeaderContentEncoding))
+		assert.True(t, strings.HasPrefix(resp.Header.Get(ahttp.HeaderContentType), "text/css"))
+		body, _ := ioutil.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		assert.True(t, strings.Contains(string(body), tc.body))
+		if tc.encoding != "" {
+			assert.Equal(t, ahttp.HeaderAcceptEncoding, resp.Header.Get(ahttp.HeaderVary))
+		}
+	}
+
+	a := newApp()
+	cfg, err := config.ParseString(`
+cache {
+  static {
+    precompressed {
+      enable = true
+      encodings = ["br", "deflate"]
+    }
+  }
+}`)
+	assert.Nil(t, err)
+	a.cfg = cfg
+	err = a.initStatic()
+	assert.Equal(t, "'cache.static.precompressed.encodings' unsupported encoding value: deflate", err.Error())
+}
+
+func TestStaticSPAFallback(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServerWithConfig(t, importPath, map[string]interface{}{
+		"env.active": "prod",
+	})
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Static SPA Fallback]: %s", ts.URL)
+
+	t.Log("Existing file is served as-is")
+	r := ts.Get("/app/js/app.js").AssertStatus(http.StatusOK)
+	assert.Contains(t, r.BodyString(), "aah SPA app")
+	assert.True(t, strings.HasPrefix(r.Header.Get(ahttp.HeaderContentType), "application/javascript"))
+
+	t.Log("Client-side routing paths are served with fallback")
+	for _, p := range []string{"/app/users/1", "/app/settings/", "/app/", "/app"} {
+		r = ts.Get(p).AssertStatus(http.StatusOK)
+		assert.Contains(t, r.BodyString(), "aah SPA index", p)
+		assert.True(t, strings.HasPrefix(r.Header.Get(ahttp.HeaderContentType), "text/html"), p)
+		assert.Equal(t, "no-cache, no-store, must-revalidate", r.Header.Get(ahttp.HeaderCacheControl), p)
+	}
+
+	t.Log("Missing file and excluded path are not found")
+	ts.Get("/app/js/missing.js").AssertStatus(http.StatusNotFound)
+	req, _ := http.NewRequest(ahttp.MethodGet, ts.URL+"/app/api/users", nil)
+	req.Header.Set(ahttp.HeaderAccept, ahttp.ContentTypeJSON.Mime)
+	r = ts.Do(req).AssertStatus(http.StatusNotFound)
+	assert.Equal(t, `{"code":404,"message":"Not Found"}`+"\n", r.BodyString())
+
+	t.Log("Route")
+	route := &router.Route{SPAFallback: "index.html", SPAExcludes: []string{"/app/api"}}
+	assert.True(t, route.IsSPAFallback("/app/apis"))
+	assert.True(t, route.IsSPAFallback("/app/users.john/profile"))
+	assert.False(t, route.IsSPAFallback("/app/api"))
+	assert.False(t, route.IsSPAFallback("/app/api/users"))
+	assert.False(t, route.IsSPAFallback("/app/logo.png"))
+	assert.False(t, (&router.Route{}).IsSPAFallback("/app/users"))
+}
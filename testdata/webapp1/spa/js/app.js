@@ -0,0 +1 @@
+console.log("aah SPA app");
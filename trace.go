@@ -0,0 +1,90 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"net/http"
+	"strings"
+
+	"aahframe.work/ahttp"
+	"aahframe.work/log"
+)
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Context methods
+//______________________________________________________________________________
+
+// TraceContext method returns the distributed trace correlation headers of
+// the request configured via `request.trace_headers`, for e.g.: `traceparent`,
+// `X-B3-TraceId`. Use it to propagate the trace to the outbound calls.
+//
+//	req, _ := http.NewRequest(http.MethodGet, "http://inventory/items", nil)
+//	ctx.TraceContext().Inject(req.Header)
+func (ctx *Context) TraceContext() *TraceContext {
+	if ctx.trace == nil {
+		ctx.trace = &TraceContext{Headers: make(http.Header)}
+		for _, k := range ctx.a.settings.TraceHeaders {
+			if v := ctx.Req.Header.Get(k); len(v) > 0 {
+				ctx.trace.Headers.Set(k, v)
+				ctx.trace.names = append(ctx.trace.names, k)
+			}
+		}
+	}
+	return ctx.trace
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// TraceContext
+//______________________________________________________________________________
+
+// TraceContext holds the inbound trace correlation header values of the
+// request, only the headers present on the request are held.
+type TraceContext struct {
+	Headers http.Header
+	names   []string
+}
+
+// Get method returns the trace header value for given name otherwise empty
+// string.
+func (tc *TraceContext) Get(name string) string {
+	return tc.Headers.Get(name)
+}
+
+// TraceID method returns the trace ID of the request, it is parsed in the
+// order of W3C `traceparent`, B3 `X-B3-TraceId`, `X-Trace-Id` and then first
+// configured header value. It returns empty string if none available.
+func (tc *TraceContext) TraceID() string {
+	if tp := tc.Headers.Get(ahttp.HeaderTraceparent); len(tp) > 0 {
+		// version-traceid-parentid-flags
+		if parts := strings.Split(tp, "-"); len(parts) >= 4 && len(parts[1]) == 32 {
+			return parts[1]
+		}
+	}
+	for _, k := range []string{ahttp.HeaderXB3TraceID, ahttp.HeaderXTraceID} {
+		if v := tc.Headers.Get(k); len(v) > 0 {
+			return v
+		}
+	}
+	if len(tc.names) > 0 {
+		return tc.Headers.Get(tc.names[0])
+	}
+	return ""
+}
+
+// Inject method sets the trace headers into given header, for e.g.: outbound
+// HTTP request header.
+func (tc *TraceContext) Inject(hdr http.Header) {
+	for k, v := range tc.Headers {
+		hdr[k] = append([]string(nil), v...)
+	}
+}
+
+// addLogFields method adds the trace headers into given log fields, field
+// name is lower case header name, for e.g.: `traceparent`.
+func (tc *TraceContext) addLogFields(fields log.Fields) {
+	for _, k := range tc.names {
+		fields[strings.ToLower(k)] = tc.Headers.Get(k)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"aahframe.work/ahttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextTraceContext(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Trace Context]: %s", ts.URL)
+
+	ts.app.settings.TraceHeaders = []string{ahttp.HeaderTraceparent, ahttp.HeaderXB3TraceID, ahttp.HeaderXTraceID}
+	defer func() { ts.app.settings.TraceHeaders = nil }()
+
+	newTraceCtx := func(hdrs map[string]string) *Context {
+		req := httptest.NewRequest(ahttp.MethodGet, "http://localhost:8080/users", nil)
+		for k, v := range hdrs {
+			req.Header.Set(k, v)
+		}
+		ctx := newContext(nil, req)
+		ctx.a = ts.app
+		return ctx
+	}
+
+	t.Log("W3C traceparent")
+	ctx := newTraceCtx(map[string]string{
+		"traceparent":  "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"X-B3-TraceId": "80f198ee56343ba864fe8b2a57d3eff7",
+		"X-Unknown":    "value",
+	})
+	tc := ctx.TraceContext()
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tc.TraceID())
+	assert.Equal(t, "80f198ee56343ba864fe8b2a57d3eff7", tc.Get("x-b3-traceid"))
+	assert.Equal(t, 2, len(tc.Headers))
+	assert.True(t, tc == ctx.TraceContext())
+
+	out := make(http.Header)
+	tc.Inject(out)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", out.Get("traceparent"))
+	assert.Equal(t, "80f198ee56343ba864fe8b2a57d3eff7", out.Get("X-B3-TraceId"))
+	assert.Equal(t, "", out.Get("X-Unknown"))
+
+	t.Log("B3 and custom trace ID")
+	assert.Equal(t, "80f198ee56343ba864fe8b2a57d3eff7", newTraceCtx(map[string]string{
+		"traceparent":  "invalid",
+		"X-B3-TraceId": "80f198ee56343ba864fe8b2a57d3eff7",
+	}).TraceContext().TraceID())
+	assert.Equal(t, "trace-1234", newTraceCtx(map[string]string{"X-Trace-Id": "trace-1234"}).TraceContext().TraceID())
+	assert.Equal(t, "", newTraceCtx(nil).TraceContext().TraceID())
+
+	ts.app.settings.TraceHeaders = []string{"X-Correlation-Id"}
+	assert.Equal(t, "corr-1", newTraceCtx(map[string]string{"X-Correlation-Id": "corr-1"}).TraceContext().TraceID())
+
+	t.Log("Log fields")
+	ts.app.settings.TraceHeaders = []string{ahttp.HeaderTraceparent, ahttp.HeaderXB3TraceID}
+	lr := ts.CaptureLog()
+	ts.app.HTTPEngine().OnRequest(func(e *Event) {
+		e.Data.(*Context).Log().Error("trace log entry")
+	})
+	defer func() { ts.app.HTTPEngine().onRequestFunc = nil }()
+
+	req, _ := http.NewRequest(ahttp.MethodGet, ts.URL+"/get-text.html", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ts.Do(req).AssertStatus(http.StatusOK)
+	assert.Contains(t, lr.String(), "trace log entry")
+	assert.Contains(t, lr.String(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.NotContains(t, lr.String(), "x-b3-traceid")
+}
@@ -0,0 +1,129 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"context"
+	"net/http"
+
+	"aahframe.work/ahttp"
+)
+
+// TracerProvider interface is used to integrate the distributed tracing,
+// for e.g.: OpenTelemetry, into aah request flow via `TracingMiddleware`.
+// Provider chooses the exporter and propagation format, it continues the
+// trace of inbound trace headers, for e.g.: W3C `traceparent`.
+//
+// OpenTelemetry implementation could be-
+//
+//	func (p *otelProvider) Start(ctx context.Context, name string, hdr http.Header) (context.Context, aah.Span) {
+//	  ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(hdr))
+//	  ctx, span := p.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer))
+//	  return ctx, &otelSpan{span}
+//	}
+type TracerProvider interface {
+	// Start method starts the span with given name as a child of the trace
+	// extracted from given inbound request header, if any. It returns the
+	// context carrying the span.
+	Start(ctx context.Context, name string, hdr http.Header) (context.Context, Span)
+}
+
+// Span interface represents the single operation of the trace, it is
+// created by `TracerProvider`.
+type Span interface {
+	// SetAttribute method records the attribute on the span.
+	SetAttribute(key string, value interface{})
+
+	// End method completes the span.
+	End()
+}
+
+const keyAahTracingSpan = "_aahTracingSpan"
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Package methods
+//______________________________________________________________________________
+
+// TracingMiddleware method starts the span per request via the tracer
+// provider of `Application.SetTracerProvider`. Span is named by route name
+// and records attributes `http.method`, `http.route`, `http.target`,
+// `http.status_code` and `http.latency_ms`.
+//
+// Span context is set on the request context, so downstream calls could
+// continue the trace via `ctx.Context()`. It is no-op if tracer provider is
+// not set. Add it after `aah.RouteMiddleware` in the middleware stack.
+//
+// Span is ended by HTTP engine after the reply is written, so the status
+// code and latency are of the reply on the wire including error reply.
+func TracingMiddleware(ctx *Context, m *Middleware) {
+	tp := ctx.a.tracer
+	if tp == nil {
+		m.Next(ctx)
+		return
+	}
+
+	name, routePath := "HTTP "+ctx.Req.Method, ""
+	if ctx.route != nil {
+		name, routePath = ctx.route.Name, ctx.route.Path
+	}
+
+	sctx, span := tp.Start(ctx.Context(), name, ctx.Req.Header)
+	ctx.Req.SetContext(sctx)
+	ctx.Set(keyAahTracingSpan, &tracingSpan{span: span, route: routePath})
+
+	m.Next(ctx)
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Application methods
+//______________________________________________________________________________
+
+// SetTracerProvider method sets the tracer provider used by
+// `TracingMiddleware`. It must be set prior to aah server start, nil value
+// disables the tracing.
+//
+//	aah.App().SetTracerProvider(newOtelProvider(exporter))
+func (a *Application) SetTracerProvider(tp TracerProvider) {
+	a.tracer = tp
+}
+
+// TracerProvider method returns the tracer provider of application
+// otherwise nil.
+func (a *Application) TracerProvider() TracerProvider {
+	return a.tracer
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Unexported types and methods
+//______________________________________________________________________________
+
+// tracingSpan holds the request span started by `TracingMiddleware` till
+// the reply is written.
+type tracingSpan struct {
+	span  Span
+	route string
+}
+
+// endTracingSpan method records the reply attributes and ends the request
+// span started by `TracingMiddleware`, it is called after the reply is
+// written.
+func endTracingSpan(ctx *Context) {
+	ts, ok := ctx.Get(keyAahTracingSpan).(*tracingSpan)
+	if !ok {
+		return
+	}
+	ctx.Set(keyAahTracingSpan, nil)
+
+	status := ctx.Reply().Code
+	if ahttp.IsCommitted(ctx.Res) {
+		status = ctx.Res.Status()
+	}
+	ts.span.SetAttribute("http.method", ctx.Req.Method)
+	ts.span.SetAttribute("http.route", ts.route)
+	ts.span.SetAttribute("http.target", ctx.Req.URL().RequestURI())
+	ts.span.SetAttribute("http.status_code", status)
+	ts.span.SetAttribute("http.latency_ms", ctx.Elapsed().Milliseconds())
+	ts.span.End()
+}
@@ -0,0 +1,138 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testSpanKey struct{}
+
+type testSpan struct {
+	name   string
+	parent string
+	attrs  map[string]interface{}
+	ended  bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *testSpan) End()                                       { s.ended = true }
+
+type testTracerProvider struct {
+	sync.Mutex
+	spans []*testSpan
+}
+
+func (p *testTracerProvider) Start(ctx context.Context, name string, hdr http.Header) (context.Context, Span) {
+	p.Lock()
+	defer p.Unlock()
+	s := &testSpan{name: name, parent: hdr.Get("traceparent"), attrs: make(map[string]interface{})}
+	p.spans = append(p.spans, s)
+	return context.WithValue(ctx, testSpanKey{}, s), s
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Tracing Middleware]: %s", ts.URL)
+
+	var spanInCtx interface{}
+	ts.SetMiddlewares(
+		RouteMiddleware,
+		TracingMiddleware,
+		func(ctx *Context, m *Middleware) {
+			spanInCtx = ctx.Context().Value(testSpanKey{})
+			m.Next(ctx)
+		},
+		ActionMiddleware,
+	)
+
+	t.Log("Disabled")
+	assert.Nil(t, ts.app.TracerProvider())
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+	assert.Nil(t, spanInCtx)
+
+	t.Log("Enabled")
+	tp := &testTracerProvider{}
+	ts.app.SetTracerProvider(tp)
+	defer ts.app.SetTracerProvider(nil)
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/get-text.html?lang=en", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ts.Do(req).AssertStatus(http.StatusOK)
+
+	tp.Lock()
+	defer tp.Unlock()
+	assert.Equal(t, 1, len(tp.spans))
+	span := tp.spans[0]
+	assert.True(t, span == spanInCtx)
+	assert.True(t, span.ended)
+	assert.Equal(t, "text_get", span.name)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", span.parent)
+	assert.Equal(t, http.MethodGet, span.attrs["http.method"])
+	assert.Equal(t, "/get-text.html", span.attrs["http.route"])
+	assert.Equal(t, "/get-text.html?lang=en", span.attrs["http.target"])
+	assert.Equal(t, http.StatusOK, span.attrs["http.status_code"])
+	_, found := span.attrs["http.latency_ms"]
+	assert.True(t, found)
+}
+
+func TestTracingMiddlewareEndsAfterReply(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Tracing Middleware Reply]: %s", ts.URL)
+
+	tp := &testTracerProvider{}
+	ts.app.SetTracerProvider(tp)
+	defer ts.app.SetTracerProvider(nil)
+
+	fc := NewFakeClock(time.Date(2018, time.October, 10, 10, 10, 10, 0, time.UTC))
+	ts.app.SetClock(fc)
+	defer ts.app.SetClock(nil)
+
+	var endedBeforeReply bool
+	ts.app.HTTPEngine().OnPreReply(func(e *Event) {
+		fc.Advance(120 * time.Millisecond)
+		tp.Lock()
+		defer tp.Unlock()
+		endedBeforeReply = tp.spans[len(tp.spans)-1].ended
+	})
+
+	t.Log("Latency includes reply write")
+	ts.SetMiddlewares(RouteMiddleware, TracingMiddleware, ActionMiddleware)
+	ts.Get("/get-text.html").AssertStatus(http.StatusOK)
+	assert.False(t, endedBeforeReply)
+
+	t.Log("Status of recovery reply")
+	ts.SetMiddlewares(
+		RouteMiddleware,
+		TracingMiddleware,
+		func(ctx *Context, m *Middleware) {
+			panic("tracing panic")
+		},
+		ActionMiddleware,
+	)
+	ts.Get("/get-text.html").AssertStatus(http.StatusInternalServerError)
+
+	tp.Lock()
+	defer tp.Unlock()
+	assert.Equal(t, 2, len(tp.spans))
+	assert.True(t, tp.spans[0].ended)
+	assert.Equal(t, http.StatusOK, tp.spans[0].attrs["http.status_code"])
+	assert.Equal(t, int64(120), tp.spans[0].attrs["http.latency_ms"])
+	assert.True(t, tp.spans[1].ended)
+	assert.Equal(t, http.StatusInternalServerError, tp.spans[1].attrs["http.status_code"])
+}
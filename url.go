@@ -0,0 +1,119 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"strings"
+
+	"aahframe.work/ahttp"
+)
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Application methods
+//______________________________________________________________________________
+
+// AbsURL method returns the absolute URL for given path, it is meant for use
+// outside of request, for e.g.: background job sending emails. URL is composed
+// from config `server.base_url` and `server.base_path`.
+//
+//	aah.App().AbsURL("/reset-password?token=" + token)
+//	// https://www.example.com/myapp/reset-password?token=...
+//
+// If `server.base_url` is not configured then it is derived from the root
+// domain of `routes.conf` and `server.ssl.enable`.
+func (a *Application) AbsURL(path string) string {
+	return a.baseURL() + a.Router().BasePath() + leadingSlash(path)
+}
+
+// AbsURLForRoute method returns the absolute URL for given route name and
+// key-value pairs, refer to `Application.AbsURL`. Host of `server.base_url`
+// takes precedence for its domain routes, subdomain routes gets the scheme of
+// `server.base_url`.
+func (a *Application) AbsURLForRoute(routeName string, args map[string]interface{}) string {
+	baseURL := a.baseURL()
+	idx := strings.Index(baseURL, "://")
+	scheme, authority := baseURL[:idx], baseURL[idx+3:]
+
+	// route URL is scheme relative, for e.g.: //www.example.com:8080/path
+	routeURL := a.Router().CreateRouteURL(authority, routeName, args)
+	rest := strings.TrimPrefix(routeURL, "//")
+	routeAuthority, p := rest, ""
+	if idx = strings.IndexByte(rest, '/'); idx >= 0 {
+		routeAuthority, p = rest[:idx], rest[idx:]
+	}
+	if hostname(routeAuthority) == hostname(authority) {
+		return scheme + "://" + authority + p
+	}
+	return scheme + ":" + routeURL
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Context methods
+//______________________________________________________________________________
+
+// AbsURL method returns the absolute URL for given path using the request
+// scheme and host, `server.base_path` is included.
+//
+//	ctx.AbsURL("/reset-password?token=" + token)
+//	// https://www.example.com/myapp/reset-password?token=...
+//
+// Request scheme honors forwarded protocol headers from trusted proxies only,
+// refer to `request.forwarded_proto.*`.
+func (ctx *Context) AbsURL(path string) string {
+	if ctx.Req == nil {
+		return ctx.a.AbsURL(path)
+	}
+	return ctx.Req.Scheme + "://" + ctx.Req.Host + ctx.a.Router().BasePath() + leadingSlash(path)
+}
+
+// AbsURLForRoute method returns the absolute URL for given route name and
+// key-value pairs using the request scheme and host.
+// See `Context.RouteURLNamedArgs` for more information.
+func (ctx *Context) AbsURLForRoute(routeName string, args map[string]interface{}) string {
+	if ctx.Req == nil {
+		return ctx.a.AbsURLForRoute(routeName, args)
+	}
+	return ctx.Req.Scheme + ":" + ctx.a.Router().CreateRouteURL(ctx.Req.Host, routeName, args)
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// app Unexported methods
+//______________________________________________________________________________
+
+// baseURL method returns the canonical base URL of application without
+// trailing slash, for e.g.: https://www.example.com
+func (a *Application) baseURL() string {
+	if len(a.settings.BaseURL) > 0 {
+		return a.settings.BaseURL
+	}
+
+	scheme := ahttp.SchemeHTTP
+	if a.IsSSLEnabled() {
+		scheme = ahttp.SchemeHTTPS
+	}
+	d := a.Router().RootDomain()
+	if d == nil {
+		return scheme + "://localhost:" + a.HTTPPort()
+	}
+	host := strings.TrimPrefix(d.Host, "*.")
+	if len(d.Port) > 0 {
+		host += ":" + d.Port
+	}
+	return scheme + "://" + host
+}
+
+func leadingSlash(path string) string {
+	if len(path) == 0 || path[0] != '/' {
+		return "/" + path
+	}
+	return path
+}
+
+func hostname(authority string) string {
+	if idx := strings.LastIndexByte(authority, ':'); idx > 0 && !strings.HasSuffix(authority, "]") {
+		return strings.ToLower(authority[:idx])
+	}
+	return strings.ToLower(authority)
+}
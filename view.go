@@ -10,6 +10,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"aahframe.work/ahttp"
 	"aahframe.work/internal/settings"
@@ -35,10 +36,16 @@ func (a *Application) initView() error {
 		return nil
 	}
 
+	var viewEngine view.Enginer
 	engineName := a.Config().StringDefault("view.engine", defaultViewEngineName)
-	viewEngine, found := view.GetEngine(engineName)
-	if !found {
-		return fmt.Errorf("view: named engine not found: %s", engineName)
+	if a.viewMgr != nil && a.viewMgr.userEngine {
+		// user provided view engine via `SetViewEngine` takes precedence
+		viewEngine, engineName = a.viewMgr.engine, a.viewMgr.engineName
+	} else {
+		var found bool
+		if viewEngine, found = view.GetEngine(engineName); !found {
+			return fmt.Errorf("view: named engine not found: %s", engineName)
+		}
 	}
 
 	viewMgr := &viewManager{
@@ -71,6 +78,12 @@ func (a *Application) initView() error {
 		"ispermitted":     viewMgr.tmplIsPermitted,
 		"ispermittedall":  viewMgr.tmplIsPermittedAll,
 		"anticsrftoken":   viewMgr.tmplAntiCSRFToken,
+		"assetURL":        viewMgr.tmplAssetURL,
+		"formatTime":      viewMgr.tmplFormatTime,
+		"formatNumber":    viewMgr.tmplFormatNumber,
+		"formatCurrency":  viewMgr.tmplFormatCurrency,
+		"formatDate":      viewMgr.tmplFormatDate,
+		"feature":         viewMgr.tmplFeature,
 	})
 
 	if err := viewEngine.Init(a.VFS(), a.Config(), viewsDir); err != nil {
@@ -78,8 +91,11 @@ func (a *Application) initView() error {
 	}
 
 	viewMgr.engine = viewEngine
-	if a.viewMgr != nil && a.viewMgr.minifier != nil {
-		viewMgr.minifier = a.viewMgr.minifier
+	if a.viewMgr != nil {
+		viewMgr.userEngine = a.viewMgr.userEngine
+		if a.viewMgr.minifier != nil {
+			viewMgr.minifier = a.viewMgr.minifier
+		}
 	}
 
 	a.viewMgr = viewMgr
@@ -101,10 +117,38 @@ type viewManager struct {
 	defaultTmplLayout     string
 	filenameCaseSensitive bool
 	defaultLayoutEnabled  bool
+	userEngine            bool
 	notFoundTmpl          *template.Template
 	minifier              MinifierFunc
 }
 
+// renderTemplate method renders the given template outside of request cycle
+// and returns the result as string.
+func (vm *viewManager) renderTemplate(layout, name string, data Data) (string, error) {
+	name = strings.TrimLeft(filepath.ToSlash(name), "/")
+	tmplPath := path.Join("pages", path.Dir(name))
+	tmpl, err := vm.engine.Get(layout, tmplPath, path.Base(name))
+	if err != nil {
+		return "", err
+	}
+
+	viewArgs := make(map[string]interface{})
+	for k, v := range data {
+		viewArgs[k] = v
+	}
+	viewArgs["AppName"] = vm.a.Name()
+	viewArgs["EnvProfile"] = vm.a.EnvProfile()
+	viewArgs["AppBuildInfo"] = vm.a.BuildInfo()
+	viewArgs["AahVersion"] = Version
+
+	buf := acquireBuilder()
+	defer releaseBuilder(buf)
+	if err = (&htmlRender{Template: tmpl, Layout: layout, ViewArgs: viewArgs}).Render(buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
 // resolve method resolves the view template based available facts, such as
 // controller name, action and user provided inputs.
 func (vm *viewManager) resolve(ctx *Context) {
@@ -265,6 +309,21 @@ func (vm *viewManager) tmplConfig(key string) interface{} {
 	return ""
 }
 
+//
+// Feature flag view functions
+//
+
+// tmplFeature method returns `Feature.Enabled` of given feature name, with
+// rollout key it returns `Feature.EnabledFor`. For e.g.:
+// `{{ if feature "new_checkout" .UserID }}`.
+func (vm *viewManager) tmplFeature(name string, key ...interface{}) bool {
+	f := vm.a.Feature(name)
+	if len(key) > 0 {
+		return f.EnabledFor(featureKey(key[0]))
+	}
+	return f.Enabled()
+}
+
 //
 // i18n view functions
 //
@@ -307,6 +366,43 @@ func (vm *viewManager) tmplURLm(viewArgs map[string]interface{}, routeName strin
 	return template.URL(vm.a.Router().CreateRouteURL(viewArgs["Host"].(string), routeName, args))
 }
 
+// tmplAssetURL method returns fingerprinted static asset URL for the given
+// asset path. Mapped to Go template func.
+func (vm *viewManager) tmplAssetURL(name string) template.URL {
+	if vm.a.staticMgr == nil {
+		return template.URL(name)
+	}
+	/* #nosec */
+	return template.URL(vm.a.staticMgr.assetURL(name))
+}
+
+// tmplFormatTime method formats the given time as per `format.timezone` and
+// `format.datetime`. Optionally layout can be provided.
+func (vm *viewManager) tmplFormatTime(t time.Time, layout ...string) string {
+	return vm.a.FormatTime(t, layout...)
+}
+
+// tmplFormatNumber method formats the given number as per conventions of
+// request locale. Mapped to Go template func.
+func (vm *viewManager) tmplFormatNumber(viewArgs map[string]interface{}, n interface{}) string {
+	locale, _ := viewArgs[keyLocale].(*ahttp.Locale)
+	return vm.a.FormatNumber(locale, n)
+}
+
+// tmplFormatCurrency method formats the given amount for ISO 4217 currency
+// code as per conventions of request locale. Mapped to Go template func.
+func (vm *viewManager) tmplFormatCurrency(viewArgs map[string]interface{}, amount float64, code string) string {
+	locale, _ := viewArgs[keyLocale].(*ahttp.Locale)
+	return vm.a.FormatCurrency(locale, amount, code)
+}
+
+// tmplFormatDate method formats the date of given time as per conventions
+// of request locale. Mapped to Go template func.
+func (vm *viewManager) tmplFormatDate(viewArgs map[string]interface{}, t time.Time) string {
+	locale, _ := viewArgs[keyLocale].(*ahttp.Locale)
+	return vm.a.FormatDate(locale, t)
+}
+
 //
 // Session and Flash view functions
 //
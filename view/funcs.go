@@ -6,6 +6,7 @@ package view
 
 import (
 	"html/template"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -13,10 +14,27 @@ import (
 )
 
 // tmplSafeHTML method outputs given HTML as-is, use it with care.
+//
+// Funcs `safeHTML`, `safeURL` and `safeJS` bypass the contextual escaping
+// of `html/template`, it is meant for trusted or pre-sanitized content, for
+// e.g.: Markdown output rendered by sanitizer. Passing user input to these
+// funcs leads to XSS vulnerability.
 func (e *GoViewEngine) tmplSafeHTML(str string) template.HTML {
 	return template.HTML(str)
 }
 
+// tmplSafeURL method outputs given URL as-is without sanitizing the scheme,
+// for e.g.: `data:` or `mailto:` URLs. Use it with care.
+func (e *GoViewEngine) tmplSafeURL(str string) template.URL {
+	return template.URL(str)
+}
+
+// tmplSafeJS method outputs given JavaScript expression as-is within
+// `<script>` or event handler attribute. Use it with care.
+func (e *GoViewEngine) tmplSafeJS(str string) template.JS {
+	return template.JS(str)
+}
+
 // tmplInclude method renders given template with View Args and imports into
 // current template.
 func (e *GoViewEngine) tmplInclude(name string, viewArgs map[string]interface{}) template.HTML {
@@ -33,9 +51,32 @@ func (e *GoViewEngine) tmplInclude(name string, viewArgs map[string]interface{})
 		// TODO existing behaviour will be removed in the future release
 		name = "common/" + name
 	}
+	return e.renderTemplate(name, name[0] == '/', viewArgs)
+}
+
+// tmplPartial method renders given partial template from `views/partials`
+// with View Args and imports into current template. Name is resolved
+// relative to partials directory, for e.g.: `form/address.html` resolves to
+// `views/partials/form/address.html`.
+func (e *GoViewEngine) tmplPartial(name string, viewArgs map[string]interface{}) template.HTML {
+	if len(name) == 0 {
+		log.Error("goviewengine: empty template filename suppiled to func 'partial'")
+		return e.tmplSafeHTML("")
+	}
+	name = path.Join(partialsDir, strings.TrimPrefix(filepath.ToSlash(name), "/"))
+	if !strings.HasPrefix(name, partialsDir+"/") {
+		log.Errorf("goviewengine: partial template must be within '%s' directory: %s", partialsDir, name)
+		return e.tmplSafeHTML("")
+	}
+	return e.renderTemplate(name, false, viewArgs)
+}
+
+// renderTemplate method renders the common or partial template of given name,
+// template is parsed on every call in hot-reload mode or if parse is true.
+func (e *GoViewEngine) renderTemplate(name string, parse bool, viewArgs map[string]interface{}) template.HTML {
 	var err error
 	var tmpl *template.Template
-	if e.hotReload || name[0] == '/' {
+	if e.hotReload || parse {
 		if tmpl, err = e.ParseFile(name); err != nil {
 			log.Errorf("goviewengine: %s", err)
 			return e.tmplSafeHTML("")
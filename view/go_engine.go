@@ -15,7 +15,10 @@ import (
 	"aahframe.work/vfs"
 )
 
-const noLayout = "nolayout"
+const (
+	noLayout    = "nolayout"
+	partialsDir = "partials"
+)
 
 var (
 	commonTemplates *Templates
@@ -26,6 +29,17 @@ var (
 //______________________________________________________________________________
 
 // GoViewEngine implements the partial inheritance support with Go templates.
+//
+// Shared fragments are reused via template funcs, they are resolved relative
+// to the views base directory (and VFS when packaged):
+//
+//	{{ include "head_tags.html" . }}           // views/common/head_tags.html
+//	{{ include "/users/form.html" . }}         // views/users/form.html
+//	{{ partial "form/address.html" . }}        // views/partials/form/address.html
+//
+// Files of `common` and `partials` (optional) directories, including sub
+// directories, are parsed at startup. In hot-reload mode they are parsed on
+// every render, so changes reflect without restart.
 type GoViewEngine struct {
 	*EngineBase
 }
@@ -44,8 +58,11 @@ func (e *GoViewEngine) Init(fs *vfs.VFS, appCfg *config.Config, baseDir string)
 	// Add template func
 	AddTemplateFunc(template.FuncMap{
 		"safeHTML": e.tmplSafeHTML,
+		"safeURL":  e.tmplSafeURL,
+		"safeJS":   e.tmplSafeJS,
 		"import":   e.tmplInclude,
 		"include":  e.tmplInclude, // alias for import
+		"partial":  e.tmplPartial,
 	})
 
 	// load common templates
@@ -53,6 +70,13 @@ func (e *GoViewEngine) Init(fs *vfs.VFS, appCfg *config.Config, baseDir string)
 		return err
 	}
 
+	// load partial templates, directory is optional
+	if e.VFS.IsExists(filepath.Join(e.BaseDir, partialsDir)) {
+		if err := e.loadSharedTemplates(partialsDir); err != nil {
+			return err
+		}
+	}
+
 	// collect all layouts
 	layouts, err := e.LayoutFiles()
 	if err != nil {
@@ -83,14 +107,20 @@ func (e *GoViewEngine) Init(fs *vfs.VFS, appCfg *config.Config, baseDir string)
 //______________________________________________________________________________
 
 func (e *GoViewEngine) loadCommonTemplates() error {
-	commons, err := e.FilesPath("common")
+	commonTemplates = &Templates{}
+	return e.loadSharedTemplates("common")
+}
+
+// loadSharedTemplates method parses all the files (including sub
+// directories) of given views sub directory into common templates.
+func (e *GoViewEngine) loadSharedTemplates(subDir string) error {
+	files, err := e.FilesPath(subDir)
 	if err != nil {
 		return err
 	}
 
-	commonTemplates = &Templates{}
 	prefix := path.Dir(e.BaseDir)
-	for _, file := range commons {
+	for _, file := range files {
 		if !strings.HasSuffix(file, e.FileExt) {
 			log.Warnf("goviewengine: not a valid template extension[%s]: %s", e.FileExt, TrimPathPrefix(prefix, file))
 			continue
@@ -127,6 +127,123 @@ func TestViewDelimitersError(t *testing.T) {
 	err := ge.Init(newVFS(), cfg, viewsDir)
 	assert.NotNil(t, err)
 	assert.Equal(t, "goviewengine: config 'view.delimiters' value is invalid", err.Error())
+
+	cfg, _ = config.ParseString(`render {
+		template {
+			delimiters = "[["
+		}
+	}`)
+	err = ge.Init(newVFS(), cfg, viewsDir)
+	assert.NotNil(t, err)
+	assert.Equal(t, "goviewengine: config 'render.template.delimiters' value is invalid", err.Error())
+}
+
+func TestViewCustomDelimiters(t *testing.T) {
+	log.SetWriter(ioutil.Discard)
+	cfg, _ := config.ParseString(`view {
+		delimiters = "[[.]]"
+	}`)
+	ge := loadGoViewEngine(t, cfg, "views", false)
+	assert.Equal(t, "[[", ge.LeftDelim)
+	assert.Equal(t, "]]", ge.RightDelim)
+
+	tmpl, err := ge.NewTemplate("custom").Parse(`<div id="app">{{ message }}</div>[[ .GreetName ]]`)
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	assert.Nil(t, tmpl.Execute(&buf, map[string]interface{}{"GreetName": "aah framework"}))
+	assert.Equal(t, `<div id="app">{{ message }}</div>aah framework`, buf.String())
+
+	// auto inserted fields uses the same delimiters
+	v := ge.AutoFieldInsertion("login.html", `<form action="/login" id="form_auth_login_submit__aah" method="post"></form>`)
+	assert.True(t, strings.Contains(v, `value="[[ anticsrftoken . ]]"`))
+	assert.True(t, strings.Contains(v, `value="[[ qparam . "_rt" ]]"`))
+	assert.False(t, strings.Contains(v, "{{"))
+
+	// alias `render.template.delimiters`, space or dot separated
+	for _, delims := range []string{"[[ ]]", "[[.]]"} {
+		cfg, _ = config.ParseString(`render {
+			template {
+				delimiters = "` + delims + `"
+			}
+		}`)
+		ge = loadGoViewEngine(t, cfg, "views", false)
+		assert.Equal(t, "[[", ge.LeftDelim)
+		assert.Equal(t, "]]", ge.RightDelim)
+	}
+
+	// `view.delimiters` takes precedence over alias
+	cfg, _ = config.ParseString(`view {
+		delimiters = "<%.%>"
+	}
+	render {
+		template {
+			delimiters = "[[ ]]"
+		}
+	}`)
+	ge = loadGoViewEngine(t, cfg, "views", false)
+	assert.Equal(t, "<%", ge.LeftDelim)
+	assert.Equal(t, "%>", ge.RightDelim)
+}
+
+func TestViewPartials(t *testing.T) {
+	// _ = log.SetLevel("trace")
+	log.SetWriter(ioutil.Discard)
+	cfg, _ := config.ParseString(`view { }`)
+	ge := loadGoViewEngine(t, cfg, "views", false)
+
+	data := map[string]interface{}{
+		"GreetName": "aah framework",
+		"PageName":  "partial page",
+	}
+
+	assert.NotNil(t, commonTemplates.Lookup("partials/header.html"))
+	assert.Equal(t, template.HTML("<header>aah framework header</header>\n"), ge.tmplPartial("header.html", data))
+	assert.Equal(t, template.HTML(`<label>partial page</label><input type="text" name="page_name">`+"\n\n"),
+		ge.tmplPartial("/form/field.html", data))
+
+	assert.Equal(t, template.HTML(""), ge.tmplPartial("", data))
+	assert.Equal(t, template.HTML(""), ge.tmplPartial("not_exists.html", data))
+	assert.Equal(t, template.HTML(""), ge.tmplPartial("../common/head_tags.html", data))
+
+	t.Log("Hot-reload mode parses partial on every render")
+	ge.hotReload = true
+	assert.Equal(t, template.HTML("<header>aah framework header</header>\n"), ge.tmplPartial("header.html", data))
+	assert.Equal(t, template.HTML(""), ge.tmplPartial("not_exists.html", data))
+}
+
+func TestViewSafeFuncs(t *testing.T) {
+	log.SetWriter(ioutil.Discard)
+	cfg, _ := config.ParseString(`view { }`)
+	ge := loadGoViewEngine(t, cfg, "views", false)
+
+	data := map[string]interface{}{
+		"HTML": "<b>bold</b>",
+		"URL":  "data:image/png;base64,iVBORw0KGgo=",
+		"JS":   "alert('aah')",
+	}
+
+	testcases := []struct {
+		label, tmpl, expected string
+	}{
+		{"escaped HTML", `{{ .HTML }}`, "&lt;b&gt;bold&lt;/b&gt;"},
+		{"safeHTML", `{{ safeHTML .HTML }}`, "<b>bold</b>"},
+		{"escaped URL", `<img src="{{ .URL }}">`, `<img src="#ZgotmplZ">`},
+		{"safeURL", `<img src="{{ safeURL .URL }}">`, `<img src="data:image/png;base64,iVBORw0KGgo=">`},
+		{"escaped JS", `<script>var v = {{ .JS }};</script>`, `<script>var v = "alert('aah')";</script>`},
+		{"safeJS", `<script>{{ safeJS .JS }};</script>`, `<script>alert('aah');</script>`},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.label, func(t *testing.T) {
+			tmpl, err := ge.NewTemplate(tc.label).Parse(tc.tmpl)
+			assert.Nil(t, err)
+
+			var buf bytes.Buffer
+			assert.Nil(t, tmpl.Execute(&buf, data))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
 }
 
 func TestViewErrors(t *testing.T) {
@@ -166,9 +166,20 @@ func (eb *EngineBase) Init(fs *vfs.VFS, appCfg *config.Config, baseDir, defaultE
 	eb.CaseSensitive = appCfg.BoolDefault("view.case_sensitive", false)
 	eb.IsLayoutEnabled = appCfg.BoolDefault("view.default_layout", true)
 
-	delimiter := strings.Split(appCfg.StringDefault("view.delimiters", DefaultDelimiter), ".")
+	// `render.template.delimiters` is an alias of `view.delimiters`, left and
+	// right delimiter of alias could be separated by space too for e.g.: `[[ ]]`
+	delimKey, delimValue := "view.delimiters", DefaultDelimiter
+	if v, found := appCfg.String("view.delimiters"); found {
+		delimValue = v
+	} else if v, found := appCfg.String("render.template.delimiters"); found {
+		delimKey, delimValue = "render.template.delimiters", v
+	}
+	delimiter := strings.Fields(delimValue)
+	if len(delimiter) != 2 {
+		delimiter = strings.Split(delimValue, ".")
+	}
 	if len(delimiter) != 2 || ess.IsStrEmpty(delimiter[0]) || ess.IsStrEmpty(delimiter[1]) {
-		return fmt.Errorf("%sviewengine: config 'view.delimiters' value is invalid", eb.Name)
+		return fmt.Errorf("%sviewengine: config '%s' value is invalid", eb.Name, delimKey)
 	}
 	eb.LeftDelim, eb.RightDelim = delimiter[0], delimiter[1]
 
@@ -205,7 +216,7 @@ func (eb *EngineBase) AutoFieldInsertion(name, v string) string {
 		for _, m := range matches {
 			ts := v[m[0]:m[1]]
 			v = strings.Replace(v, ts, fmt.Sprintf(`%s
-			<input type="hidden" value="{{ qparam . "_rt" }}" name="_rt">`, ts), 1)
+			<input type="hidden" value="%s qparam . "_rt" %s" name="_rt">`, ts, eb.LeftDelim, eb.RightDelim), 1)
 		}
 	}
 
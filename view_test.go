@@ -5,6 +5,7 @@
 package aah
 
 import (
+	"html/template"
 	"io"
 	"net/http/httptest"
 	"path/filepath"
@@ -132,3 +133,64 @@ func TestViewMinifier(t *testing.T) {
 		return nil
 	})
 }
+
+type testViewEngine struct {
+	*view.GoViewEngine
+	getCnt int
+}
+
+func (e *testViewEngine) Get(layout, path, tmplName string) (*template.Template, error) {
+	e.getCnt++
+	return e.GoViewEngine.Get(layout, path, tmplName)
+}
+
+func TestViewSetViewEngine(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Set View Engine]: %s", ts.URL)
+
+	ts.app.SetViewEngine(nil)
+	assert.False(t, ts.app.viewMgr.userEngine)
+
+	engine := &testViewEngine{GoViewEngine: &view.GoViewEngine{}}
+	ts.app.SetViewEngine(engine)
+	ts.app.SetViewEngine(engine)
+	assert.Nil(t, ts.app.initView())
+	assert.Equal(t, engine, ts.app.ViewEngine())
+	assert.Equal(t, "*aah.testViewEngine", ts.app.viewMgr.engineName)
+
+	ts.Get("/").AssertStatus(200)
+	assert.Equal(t, 1, engine.getCnt)
+
+	// hot-reload retains user provided view engine
+	assert.Nil(t, ts.app.initView())
+	assert.Equal(t, engine, ts.app.ViewEngine())
+}
+
+func TestViewRenderTemplate(t *testing.T) {
+	importPath := filepath.Join(testdataBaseDir(), "webapp1")
+	ts := newTestServer(t, importPath)
+	defer ts.Close()
+
+	t.Logf("Test Server URL [Render Template]: %s", ts.URL)
+
+	ts.app.viewMgr.setHotReload(false)
+
+	result, err := ts.app.RenderTemplate("testsite/index.html", Data{"Message": "Welcome Email"})
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(result, "<!DOCTYPE html>"))
+	assert.True(t, strings.Contains(result, "Welcome Email Yes it works!!!"))
+
+	result, err = ts.app.RenderTemplateWithLayout("master.html", "/app/index.html", nil)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(result, "<!DOCTYPE html>"))
+
+	_, err = ts.app.RenderTemplate("testsite/not-exists.html", nil)
+	assert.NotNil(t, err)
+
+	a := newApp()
+	_, err = a.RenderTemplate("testsite/index.html", nil)
+	assert.Equal(t, "aah: view engine is not initialized", err.Error())
+}
@@ -0,0 +1,244 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"aahframe.work/ahttp"
+	"aahframe.work/internal/util"
+)
+
+// ReadinessCheckFunc type is used to check the readiness of application
+// dependency, for e.g.: database connection, cache warm up. It returns nil
+// when dependency is ready.
+type ReadinessCheckFunc func() error
+
+// AppState type represents the lifecycle state of the aah application, it is
+// the single source of truth for warm up, readiness and shutdown drain.
+//
+//	starting -> ready -> draining -> stopped
+type AppState int32
+
+// Application lifecycle states
+const (
+	AppStateStarting AppState = iota
+	AppStateReady
+	AppStateDraining
+	AppStateStopped
+)
+
+// String method is stringer implementation.
+func (s AppState) String() string {
+	switch s {
+	case AppStateStarting:
+		return "starting"
+	case AppStateReady:
+		return "ready"
+	case AppStateDraining:
+		return "draining"
+	case AppStateStopped:
+		return "stopped"
+	}
+	return "unknown"
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Application methods
+//______________________________________________________________________________
+
+// MarkReady method marks the application ready to serve the requests, it is
+// applicable when `server.warmup.enable` is true. Until then aah server
+// replies `503 Service Unavailable` with `Retry-After` header except for
+// `server.warmup.exclude_paths`, for e.g.: health check routes.
+func (a *Application) MarkReady() {
+	if a.compareAndSetState(AppStateStarting, AppStateReady) {
+		a.Log().Info("aah application is ready to serve the requests")
+	}
+}
+
+// IsReady method returns true if application is ready to serve the requests
+// otherwise false. It is true when warm up is not enabled until the shutdown
+// begins.
+func (a *Application) IsReady() bool {
+	return a.State() == AppStateReady
+}
+
+// State method returns the current lifecycle state of the application.
+// Application is `starting` till it is marked ready, `draining` once the
+// shutdown begins and `stopped` after the shutdown completes.
+func (a *Application) State() AppState {
+	return AppState(atomic.LoadInt32(&a.state))
+}
+
+// AddReadinessCheck method adds the readiness check, application is marked
+// ready once all the registered checks pass. Checks are evaluated in the
+// interval of `server.warmup.check_interval`.
+func (a *Application) AddReadinessCheck(name string, check ReadinessCheckFunc) {
+	if a.warmup == nil || check == nil {
+		return
+	}
+	a.warmup.Lock()
+	defer a.warmup.Unlock()
+	a.warmup.checks = append(a.warmup.checks, &readinessCheck{name: name, check: check})
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// app Unexported methods
+//______________________________________________________________________________
+
+func (a *Application) initWarmup() error {
+	a.initHealth()
+
+	keyPrefix := "server.warmup"
+	if !a.Config().BoolDefault(keyPrefix+".enable", false) {
+		a.warmup = nil
+		a.setState(AppStateReady)
+		return nil
+	}
+
+	intervalStr := a.Config().StringDefault(keyPrefix+".check_interval", "1s")
+	interval, err := time.ParseDuration(intervalStr)
+	if err != nil || !util.IsValidTimeUnit(intervalStr, "ms", "s", "m") {
+		return fmt.Errorf("'%s.check_interval' value is not a valid time unit", keyPrefix)
+	}
+
+	retryAfter := a.Config().StringDefault(keyPrefix+".retry_after", "5")
+	if v, err := strconv.Atoi(retryAfter); err != nil || v < 0 {
+		return fmt.Errorf("'%s.retry_after' unsupported value: %s", keyPrefix, retryAfter)
+	}
+
+	excludes, _ := a.Config().StringList(keyPrefix + ".exclude_paths")
+	a.warmup = &warmup{
+		retryAfter: retryAfter,
+		excludes:   excludes,
+		interval:   interval,
+	}
+	a.setState(AppStateStarting)
+	go a.warmup.runChecks(a)
+
+	return nil
+}
+
+// initHealth method registers the health endpoints `server.health.*` as
+// direct handlers. Liveness always replies `200 OK` with application state,
+// readiness replies `200 OK` only when application is ready otherwise
+// `503 Service Unavailable`, i.e. during warm up and shutdown drain.
+func (a *Application) initHealth() {
+	keyPrefix := "server.health"
+	if !a.Config().BoolDefault(keyPrefix+".enable", false) {
+		return
+	}
+	a.he.DirectHandler(a.Config().StringDefault(keyPrefix+".liveness_path", "/healthz"), a.serveLiveness)
+	a.he.DirectHandler(a.Config().StringDefault(keyPrefix+".readiness_path", "/readyz"), a.serveReadiness)
+}
+
+func (a *Application) serveLiveness(w http.ResponseWriter, r *http.Request) {
+	writeState(w, http.StatusOK, a.State())
+}
+
+func (a *Application) serveReadiness(w http.ResponseWriter, r *http.Request) {
+	state := a.State()
+	if state == AppStateReady {
+		writeState(w, http.StatusOK, state)
+		return
+	}
+	if wu := a.warmup; wu != nil && state == AppStateStarting {
+		w.Header().Set(ahttp.HeaderRetryAfter, wu.retryAfter)
+	}
+	writeState(w, http.StatusServiceUnavailable, state)
+}
+
+func (a *Application) setState(s AppState) {
+	atomic.StoreInt32(&a.state, int32(s))
+}
+
+func (a *Application) compareAndSetState(old, s AppState) bool {
+	return atomic.CompareAndSwapInt32(&a.state, int32(old), int32(s))
+}
+
+func writeState(w http.ResponseWriter, code int, s AppState) {
+	w.Header().Set(ahttp.HeaderContentType, ahttp.ContentTypePlainText.String())
+	w.Header().Set(ahttp.HeaderCacheControl, "no-cache, no-store")
+	w.WriteHeader(code)
+	_, _ = w.Write([]byte(s.String()))
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// warmup and its methods
+//______________________________________________________________________________
+
+type readinessCheck struct {
+	name  string
+	check ReadinessCheckFunc
+}
+
+type warmup struct {
+	sync.Mutex
+	retryAfter string
+	excludes   []string
+	interval   time.Duration
+	checks     []*readinessCheck
+}
+
+// isExcluded method returns true if given request path is served during
+// warm up, path value ends with `*` is prefix match.
+func (w *warmup) isExcluded(p string) bool {
+	for _, e := range w.excludes {
+		if strings.HasSuffix(e, "*") {
+			if strings.HasPrefix(p, e[:len(e)-1]) {
+				return true
+			}
+		} else if p == e {
+			return true
+		}
+	}
+	return false
+}
+
+// runChecks method evaluates the registered readiness checks in the interval
+// till application is marked ready or shutdown begins.
+func (w *warmup) runChecks(a *Application) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if a.State() != AppStateStarting {
+			return
+		}
+		if w.checksPassed(a) {
+			a.MarkReady()
+			return
+		}
+	}
+}
+
+func (w *warmup) checksPassed(a *Application) bool {
+	w.Lock()
+	checks := w.checks
+	w.Unlock()
+	if len(checks) == 0 {
+		return false
+	}
+	for _, c := range checks {
+		if err := c.check(); err != nil {
+			a.Log().Debugf("Readiness check '%s' not passed: %v", c.name, err)
+			return false
+		}
+	}
+	return true
+}
+
+func (w *warmup) writeUnavailable(rw http.ResponseWriter) {
+	rw.Header().Set(ahttp.HeaderRetryAfter, w.retryAfter)
+	rw.Header().Set(ahttp.HeaderContentType, ahttp.ContentTypePlainText.String())
+	rw.WriteHeader(http.StatusServiceUnavailable)
+	_, _ = rw.Write([]byte("503 Service Unavailable"))
+}
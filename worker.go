@@ -0,0 +1,189 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aah
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"aahframe.work/internal/cron"
+)
+
+// WorkerFunc type is used to run the long-running background work, for e.g.:
+// poller, queue consumer. Worker must return when given context is cancelled.
+type WorkerFunc func(ctx context.Context)
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Application methods
+//______________________________________________________________________________
+
+// Go method registers the background worker with aah application lifecycle.
+// Workers registered before the server start are started right after the
+// `OnStart` event, afterwards started immediately.
+//
+// On shutdown worker context is cancelled once in-flight requests are drained
+// and aah waits for workers to return till `server.timeout.workers`, prior
+// to the shutdown hooks. Worker registered after the shutdown has begun is
+// not started.
+//
+//	aah.App().Go("order-consumer", func(ctx context.Context) {
+//	  for {
+//	    select {
+//	    case <-ctx.Done():
+//	      return
+//	    case msg := <-queue:
+//	      process(msg)
+//	    }
+//	  }
+//	})
+func (a *Application) Go(name string, fn WorkerFunc) {
+	if fn == nil {
+		return
+	}
+	a.workers.Lock()
+	defer a.workers.Unlock()
+	if a.workers.stopped {
+		a.Log().Warnf("Background worker '%s' is not started, application is shutting down", name)
+		return
+	}
+	w := &worker{name: name, fn: fn}
+	if a.workers.ctx == nil {
+		a.workers.pending = append(a.workers.pending, w)
+		return
+	}
+	a.workers.run(a, w)
+}
+
+// Schedule method registers the job to be executed periodically as per given
+// spec, job runs in the background worker so it follows the same lifecycle
+// as `Go`. Panic in the job invocation is recovered and logged, it does not
+// stop the schedule. Supported specs are-
+//
+//	@every <duration>        for e.g.: @every 30s, @every 1h30m
+//	@hourly, @daily (@midnight), @weekly, @monthly, @yearly (@annually)
+//	<minute> <hour> <day-of-month> <month> <day-of-week>
+//
+// Job invocations never overlap, next activation time is calculated after
+// the job run completes. So when a job run exceeds its interval, missed
+// activations are skipped instead of queued. For e.g.: `@every 1m` job takes
+// 90s then next run starts 1m after the completion.
+func (a *Application) Schedule(name, spec string, job func()) error {
+	sched, err := cron.Parse(spec)
+	if err != nil {
+		return fmt.Errorf("schedule '%s': %s", name, err)
+	}
+	if job == nil {
+		return nil
+	}
+
+	a.Go(name, func(ctx context.Context) {
+		for {
+			next := sched.Next(a.Clock().Now())
+			if next.IsZero() {
+				a.Log().Warnf("Scheduled job '%s' has no next activation time, stopped", name)
+				return
+			}
+
+			timer := time.NewTimer(next.Sub(a.Clock().Now()))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+				a.runScheduledJob(name, job)
+			}
+		}
+	})
+	return nil
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// app Unexported methods
+//______________________________________________________________________________
+
+// startWorkers method starts the registered background workers.
+func (a *Application) startWorkers() {
+	a.workers.Lock()
+	defer a.workers.Unlock()
+	if a.workers.ctx != nil || a.workers.stopped {
+		return
+	}
+	a.workers.ctx, a.workers.cancel = context.WithCancel(context.Background())
+	for _, w := range a.workers.pending {
+		a.workers.run(a, w)
+	}
+	a.workers.pending = nil
+}
+
+// stopWorkers method cancels the workers context and waits for workers to
+// return within given timeout. It returns false on timeout. Workers are not
+// started afterwards, so the wait group is not added while waiting.
+func (a *Application) stopWorkers(timeout time.Duration) bool {
+	a.workers.Lock()
+	a.workers.stopped = true
+	cancel := a.workers.cancel
+	a.workers.Unlock()
+	if cancel == nil {
+		return true
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		a.workers.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func (a *Application) runScheduledJob(name string, job func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			a.Log().Errorf("Scheduled job '%s' panic: %v", name, r)
+		}
+	}()
+	job()
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// workers and its methods
+//______________________________________________________________________________
+
+type worker struct {
+	name string
+	fn   WorkerFunc
+}
+
+type workers struct {
+	sync.Mutex
+	wg      sync.WaitGroup
+	ctx     context.Context
+	cancel  context.CancelFunc
+	pending []*worker
+	stopped bool
+}
+
+func (ws *workers) run(a *Application, w *worker) {
+	ws.wg.Add(1)
+	go func() {
+		defer ws.wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				a.Log().Errorf("Background worker '%s' panic: %v", w.name, r)
+			}
+		}()
+		a.Log().Debugf("Background worker '%s' started", w.name)
+		w.fn(ws.ctx)
+		a.Log().Debugf("Background worker '%s' stopped", w.name)
+	}()
+}